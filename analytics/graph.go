@@ -0,0 +1,307 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analytics
+
+import (
+	"sort"
+)
+
+// MaxBetweennessSources is the number of source nodes sampled to estimate the
+// betweenness centrality of larger graphs, since the exact computation is quadratic.
+const MaxBetweennessSources = 500
+
+// Node is an asset of the graph along with the metrics computed by Analyze.
+type Node struct {
+	ID   string
+	Kind string
+	// Weight is the number of assets of interest represented by the node, such as the names in scope
+	Weight int
+	// Degree is the number of distinct neighbors of the node
+	Degree int
+	// Betweenness is the fraction of the shortest paths between other nodes that pass through the node
+	Betweenness float64
+	// Articulation is true when the removal of the node disconnects its component
+	Articulation bool
+	// Dependents is the weight of the nodes cut off from the largest remaining part of
+	// the component when the node is removed, which are the assets depending on it
+	Dependents int
+	index      int
+}
+
+// Graph is an undirected graph of assets, such as names, addresses, netblocks and autonomous systems.
+type Graph struct {
+	ids   map[string]int
+	nodes []*Node
+	adj   []map[int]struct{}
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{ids: make(map[string]int)}
+}
+
+// AddNode inserts the node when it is not already in the graph, and returns it. The weight
+// of an existing node is raised to the provided weight, and the kind is set when missing.
+func (g *Graph) AddNode(id, kind string, weight int) *Node {
+	if i, found := g.ids[id]; found {
+		n := g.nodes[i]
+
+		if n.Kind == "" {
+			n.Kind = kind
+		}
+		if weight > n.Weight {
+			n.Weight = weight
+		}
+		return n
+	}
+
+	n := &Node{ID: id, Kind: kind, Weight: weight, index: len(g.nodes)}
+	g.ids[id] = n.index
+	g.nodes = append(g.nodes, n)
+	g.adj = append(g.adj, make(map[int]struct{}))
+	return n
+}
+
+// AddEdge connects the nodes, adding the nodes missing from the graph without a kind.
+func (g *Graph) AddEdge(from, to string) {
+	if from == to {
+		return
+	}
+
+	a := g.AddNode(from, "", 0).index
+	b := g.AddNode(to, "", 0).index
+	g.adj[a][b] = struct{}{}
+	g.adj[b][a] = struct{}{}
+}
+
+// Node returns the node with the ID, or nil when it is not in the graph.
+func (g *Graph) Node(id string) *Node {
+	if i, found := g.ids[id]; found {
+		return g.nodes[i]
+	}
+	return nil
+}
+
+// Len returns the number of nodes in the graph.
+func (g *Graph) Len() int {
+	return len(g.nodes)
+}
+
+// Analyze computes the metrics of all the nodes in the graph.
+func (g *Graph) Analyze() {
+	for i, n := range g.nodes {
+		n.Degree = len(g.adj[i])
+	}
+
+	g.betweenness()
+	g.articulationPoints()
+}
+
+// Ranked returns the nodes of the kinds provided, or all the nodes, ordered by their importance to the
+// other assets: the dependents first, followed by the betweenness centrality and the degree.
+func (g *Graph) Ranked(kinds ...string) []*Node {
+	var results []*Node
+
+	for _, n := range g.nodes {
+		if len(kinds) == 0 || hasKind(n.Kind, kinds) {
+			results = append(results, n)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if a.Dependents != b.Dependents {
+			return a.Dependents > b.Dependents
+		}
+		if a.Betweenness != b.Betweenness {
+			return a.Betweenness > b.Betweenness
+		}
+		if a.Degree != b.Degree {
+			return a.Degree > b.Degree
+		}
+		return a.ID < b.ID
+	})
+	return results
+}
+
+// betweenness implements the algorithm of Brandes, sampling evenly spaced source nodes on larger graphs.
+func (g *Graph) betweenness() {
+	num := len(g.nodes)
+	if num < 3 {
+		return
+	}
+
+	step := 1
+	if num > MaxBetweennessSources {
+		step = (num + MaxBetweennessSources - 1) / MaxBetweennessSources
+	}
+
+	cb := make([]float64, num)
+	sigma := make([]float64, num)
+	dist := make([]int, num)
+	delta := make([]float64, num)
+	preds := make([][]int, num)
+
+	var sources int
+	for s := 0; s < num; s += step {
+		sources++
+
+		for i := 0; i < num; i++ {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		sigma[s] = 1
+		dist[s] = 0
+
+		stack := make([]int, 0, num)
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+
+			for w := range g.adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+
+			for _, v := range preds[w] {
+				delta[v] += (sigma[v] / sigma[w]) * (1 + delta[w])
+			}
+			if w != s {
+				cb[w] += delta[w]
+			}
+		}
+	}
+
+	// The sampled sources are scaled to the whole graph, and the pairs of the undirected graph are counted once
+	scale := float64(num) / float64(sources) / 2
+	pairs := float64((num - 1) * (num - 2) / 2)
+	for i, n := range g.nodes {
+		n.Betweenness = cb[i] * scale / pairs
+	}
+}
+
+// articulationPoints implements the algorithm of Tarjan without recursion, so large graphs cannot exhaust the stack.
+// The weight cut off by each articulation point is computed from the weights of the subtrees of the search.
+func (g *Graph) articulationPoints() {
+	num := len(g.nodes)
+	disc := make([]int, num)
+	low := make([]int, num)
+	parent := make([]int, num)
+	subtree := make([]int, num)
+	// pieces holds the weights of the subtrees cut off from each node by its removal
+	pieces := make([][]int, num)
+	neighbors := make([][]int, num)
+	next := make([]int, num)
+
+	for i := range g.nodes {
+		disc[i] = -1
+		parent[i] = -1
+		for w := range g.adj[i] {
+			neighbors[i] = append(neighbors[i], w)
+		}
+		sort.Ints(neighbors[i])
+	}
+
+	timer := 0
+	for root := 0; root < num; root++ {
+		if disc[root] >= 0 {
+			continue
+		}
+
+		var component []int
+		stack := []int{root}
+		disc[root] = timer
+		low[root] = timer
+		timer++
+
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+
+			if next[v] < len(neighbors[v]) {
+				w := neighbors[v][next[v]]
+				next[v]++
+
+				if disc[w] < 0 {
+					parent[w] = v
+					disc[w] = timer
+					low[w] = timer
+					timer++
+					stack = append(stack, w)
+				} else if w != parent[v] && disc[w] < low[v] {
+					low[v] = disc[w]
+				}
+				continue
+			}
+
+			stack = stack[:len(stack)-1]
+			component = append(component, v)
+			subtree[v] += g.nodes[v].Weight
+
+			if p := parent[v]; p >= 0 {
+				subtree[p] += subtree[v]
+				if low[v] < low[p] {
+					low[p] = low[v]
+				}
+				if low[v] >= disc[p] {
+					pieces[p] = append(pieces[p], subtree[v])
+				}
+			}
+		}
+
+		total := subtree[root]
+		for _, v := range component {
+			parts := pieces[v]
+
+			if v == root {
+				g.nodes[v].Articulation = len(parts) > 1
+			} else {
+				g.nodes[v].Articulation = len(parts) > 0
+
+				var cut int
+				for _, p := range parts {
+					cut += p
+				}
+				// The part of the component holding the parent remains
+				parts = append(parts, total-cut-g.nodes[v].Weight)
+			}
+			if !g.nodes[v].Articulation {
+				continue
+			}
+
+			var sum, largest int
+			for _, p := range parts {
+				sum += p
+				if p > largest {
+					largest = p
+				}
+			}
+			g.nodes[v].Dependents = sum - largest
+		}
+	}
+}
+
+func hasKind(kind string, kinds []string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
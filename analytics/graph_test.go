@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analytics
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestAnalyzeStar(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("ns1.provider.net", "NameServer", 0)
+	for i := 0; i < 4; i++ {
+		name := "host" + strconv.Itoa(i) + ".owasp.org"
+
+		g.AddNode(name, "FQDN", 1)
+		g.AddEdge(name, "ns1.provider.net")
+	}
+	g.Analyze()
+
+	ns := g.Node("ns1.provider.net")
+	if ns.Degree != 4 || !ns.Articulation {
+		t.Errorf("The center of the star has degree %d and articulation %v", ns.Degree, ns.Articulation)
+	}
+	// Three of the four names are cut off from the largest remaining part
+	if ns.Dependents != 3 {
+		t.Errorf("The center of the star has %d dependents, expected 3", ns.Dependents)
+	}
+	if math.Abs(ns.Betweenness-1) > 1e-9 {
+		t.Errorf("The center of the star has a betweenness of %f, expected 1", ns.Betweenness)
+	}
+	if leaf := g.Node("host0.owasp.org"); leaf.Articulation || leaf.Betweenness != 0 {
+		t.Errorf("A leaf of the star was reported as critical: %+v", leaf)
+	}
+}
+
+func TestAnalyzeArticulationPoints(t *testing.T) {
+	g := NewGraph()
+	// Two names share a pair of addresses in the netblock, and a third name depends on a single address
+	for _, name := range []string{"www.owasp.org", "api.owasp.org", "mail.owasp.org"} {
+		g.AddNode(name, "FQDN", 1)
+	}
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		g.AddNode(addr, "IPAddress", 0)
+		g.AddEdge("192.0.2.0/24", addr)
+	}
+	g.AddNode("192.0.2.0/24", "Netblock", 0)
+	g.AddEdge("www.owasp.org", "192.0.2.1")
+	g.AddEdge("www.owasp.org", "192.0.2.2")
+	g.AddEdge("api.owasp.org", "192.0.2.1")
+	g.AddEdge("api.owasp.org", "192.0.2.2")
+	g.AddEdge("mail.owasp.org", "192.0.2.3")
+	g.AddEdge("AS64500", "192.0.2.0/24")
+	g.AddNode("AS64500", "ASN", 0)
+	g.Analyze()
+
+	for _, id := range []string{"192.0.2.1", "192.0.2.2", "www.owasp.org", "AS64500"} {
+		if n := g.Node(id); n.Articulation {
+			t.Errorf("%s was reported as an articulation point", id)
+		}
+	}
+
+	addr := g.Node("192.0.2.3")
+	if !addr.Articulation || addr.Dependents != 1 {
+		t.Errorf("192.0.2.3 has articulation %v and %d dependents", addr.Articulation, addr.Dependents)
+	}
+	netblock := g.Node("192.0.2.0/24")
+	if !netblock.Articulation || netblock.Dependents != 1 {
+		t.Errorf("The netblock has articulation %v and %d dependents", netblock.Articulation, netblock.Dependents)
+	}
+
+	ranked := g.Ranked("IPAddress", "Netblock", "ASN")
+	if len(ranked) != 5 || ranked[0].ID != "192.0.2.0/24" || ranked[len(ranked)-1].ID != "AS64500" {
+		var ids []string
+		for _, n := range ranked {
+			ids = append(ids, n.ID)
+		}
+		t.Errorf("Ranked returned %v", ids)
+	}
+}
+
+func TestAnalyzeSampledBetweenness(t *testing.T) {
+	g := NewGraph()
+	// A path long enough to be sampled, where the middle node lies on the most shortest paths
+	num := 2*MaxBetweennessSources + 1
+	for i := 1; i < num; i++ {
+		g.AddEdge(strconv.Itoa(i-1), strconv.Itoa(i))
+	}
+	g.Analyze()
+
+	mid := g.Node(strconv.Itoa(num / 2))
+	for _, id := range []string{"0", strconv.Itoa(num / 4)} {
+		if n := g.Node(id); n.Betweenness >= mid.Betweenness {
+			t.Errorf("Node %s has a betweenness of %f, not below the middle node %f", id, n.Betweenness, mid.Betweenness)
+		}
+	}
+	if !mid.Articulation {
+		t.Errorf("The middle node of the path was not reported as an articulation point")
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package client is the Go client of the sessions API served by the 'amass webhook' subcommand,
+// so integrators can start enumeration sessions and stream their assets without hand-rolled HTTP calls.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAPI is the OpenAPI 3 specification of the sessions API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// The states of a session.
+const (
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusFailed   = "failed"
+)
+
+// SessionRequest is the body posted to create or resume a session. The sessions without
+// domain names are resumed with the domain names and configuration of their previous request.
+type SessionRequest struct {
+	Session   string   `json:"session,omitempty"`
+	Domains   []string `json:"domains,omitempty"`
+	Blacklist []string `json:"blacklist,omitempty"`
+	// Config holds the YAML configuration of the session, replacing the configuration of the server
+	Config string `json:"config,omitempty"`
+	// Timeout is the number of minutes the enumeration is allowed to run
+	Timeout int `json:"timeout,omitempty"`
+	// Notify is the HTTP(S) URL the Digest of each enumeration is posted to once it finishes
+	Notify string `json:"notify,omitempty"`
+	// Labels annotate the session, such as the customer, engagement and operator, so the sessions can be searched
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Session is the state of a session reported by the server.
+type Session struct {
+	ID       string     `json:"session"`
+	Domains  []string   `json:"domains"`
+	Status   string     `json:"status"`
+	Resumed  bool       `json:"resumed"`
+	Runs     int        `json:"runs"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	// Reason is the completion reason of the enumeration, such as 'converged' or 'timeout'
+	Reason string            `json:"reason,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Artifact is a file kept in the directory of a session.
+type Artifact struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Asset is a name, address, netblock, autonomous system or registered organization in the graph database of a session.
+type Asset struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Neighborhood is an asset in the graph database of a session along with its relations to other assets,
+// such as the addresses of a name or the netblock containing an address.
+type Neighborhood struct {
+	Asset     *Asset      `json:"asset"`
+	Relations []*Relation `json:"relations"`
+	// Truncated is true when the asset has more relations than the server returns
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Relation links two assets in the graph database of a session, such as the 'a_record' of a name.
+type Relation struct {
+	From     *Asset    `json:"from"`
+	Type     string    `json:"type"`
+	To       *Asset    `json:"to"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Digest is posted to the notify URL of a session once an enumeration finishes, holding the names first
+// seen by the enumeration along with their context, so the alerts can be acted on without querying the session.
+type Digest struct {
+	Session  string    `json:"session"`
+	Domains  []string  `json:"domains"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Total is the number of names first seen, which exceeds the assets of the digest when it was truncated
+	Total  int            `json:"total"`
+	Assets []*DigestAsset `json:"assets"`
+	// Stale are the names that resolved previously and went dark during the recent enumerations
+	Stale []*DigestStale `json:"stale,omitempty"`
+}
+
+// DigestStale is a name that did not resolve during the number of consecutive enumerations
+// provided by the 'stale_after' option, after resolving previously.
+type DigestStale struct {
+	Name         string    `json:"name"`
+	LastResolved time.Time `json:"last_resolved"`
+	MissedRuns   int       `json:"missed_runs"`
+}
+
+// DigestAsset is a name first seen by an enumeration, joined with the graph database and the
+// findings of the session when the digest was built.
+type DigestAsset struct {
+	Name      string    `json:"name"`
+	FirstSeen time.Time `json:"first_seen"`
+	Addresses []string  `json:"addresses,omitempty"`
+	// ASNs are the autonomous systems announcing the addresses, along with their descriptions
+	ASNs []*DigestASN `json:"asns,omitempty"`
+	// Technologies are the products and services detected on the ports of the name
+	Technologies []string `json:"technologies,omitempty"`
+	// Sources are the data sources that provided the name
+	Sources []string `json:"sources,omitempty"`
+}
+
+// DigestASN is an autonomous system announcing an address of a DigestAsset.
+type DigestASN struct {
+	Number      int    `json:"asn"`
+	Description string `json:"description,omitempty"`
+}
+
+// Error is the failure reported by the server, such as a session that is not known.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Client sends the requests of the sessions API to a server.
+type Client struct {
+	baseURL string
+	token   string
+	// HTTPClient sends the requests, which is http.DefaultClient unless replaced
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client of the server at the base URL, such as 'http://127.0.0.1:8080',
+// authenticated with the bearer token provided to the server.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// StartSession creates the session, or resumes it when the session already exists.
+func (c *Client) StartSession(ctx context.Context, req *SessionRequest) (*Session, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := c.do(ctx, http.MethodPost, "/sessions", bytes.NewReader(body), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Sessions returns the sessions kept in the directory of the server.
+func (c *Client) Sessions(ctx context.Context) ([]*Session, error) {
+	return c.FindSessions(ctx, nil)
+}
+
+// FindSessions returns the sessions kept in the directory of the server that have all the labels provided.
+// A label with an empty value matches the sessions having the label, whatever its value.
+func (c *Client) FindSessions(ctx context.Context, labels map[string]string) ([]*Session, error) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	q := url.Values{}
+	for _, k := range keys {
+		if v := labels[k]; v != "" {
+			q.Add("label", k+"="+v)
+		} else {
+			q.Add("label", k)
+		}
+	}
+
+	path := "/sessions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var list []*Session
+	if err := c.do(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Session returns the state of the session.
+func (c *Client) Session(ctx context.Context, id string) (*Session, error) {
+	var s Session
+
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+url.PathEscape(id), nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Wait checks the state of the session at the interval provided until the session is no longer running.
+func (c *Client) Wait(ctx context.Context, id string, interval time.Duration) (*Session, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		s, err := c.Session(ctx, id)
+		if err != nil || s.Status != StatusRunning {
+			return s, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// SetInstances changes the numbers of script instances handling the requests of the data sources, keyed by
+// their names, while the session is running. It returns the numbers requested during the current enumeration.
+func (c *Client) SetInstances(ctx context.Context, id string, instances map[string]int) (map[string]int, error) {
+	body, err := json.Marshal(instances)
+	if err != nil {
+		return nil, err
+	}
+
+	var current map[string]int
+	if err := c.do(ctx, http.MethodPut, "/sessions/"+url.PathEscape(id)+"/instances", bytes.NewReader(body), &current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// Artifacts returns the files kept in the directory of the session.
+func (c *Client) Artifacts(ctx context.Context, id string) ([]*Artifact, error) {
+	var list []*Artifact
+
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+url.PathEscape(id)+"/artifacts", nil, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DownloadArtifact writes the file of the session at the path, as listed by Artifacts, to the writer.
+func (c *Client) DownloadArtifact(ctx context.Context, id, path string, w io.Writer) error {
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		parts = append(parts, url.PathEscape(p))
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, "/sessions/"+url.PathEscape(id)+"/artifacts/"+strings.Join(parts, "/"), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// StreamAssets provides the assets in the graph database of the session last seen after the time
+// provided, or every asset for the zero time, to the callback while the server reads them.
+// The stream ends early when the callback returns an error, which is returned by StreamAssets.
+func (c *Client) StreamAssets(ctx context.Context, id string, since time.Time, callback func(*Asset) error) error {
+	return c.SearchAssets(ctx, id, "", since, callback)
+}
+
+// SearchAssets is StreamAssets for the assets whose names contain the query, regardless of case.
+func (c *Client) SearchAssets(ctx context.Context, id, query string, since time.Time, callback func(*Asset) error) error {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	if query != "" {
+		q.Set("q", query)
+	}
+
+	p := "/sessions/" + url.PathEscape(id) + "/assets"
+	if len(q) > 0 {
+		p += "?" + q.Encode()
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var a Asset
+		if err := json.Unmarshal(line, &a); err != nil {
+			return err
+		}
+		if err := callback(&a); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Neighborhood returns the asset of the session graph database, such as a name, an address, a netblock
+// or an ASN like 'AS64496', along with its relations to the other assets.
+func (c *Client) Neighborhood(ctx context.Context, id, asset string) (*Neighborhood, error) {
+	var n Neighborhood
+
+	p := "/sessions/" + url.PathEscape(id) + "/graph?asset=" + url.QueryEscape(asset)
+	if err := c.do(ctx, http.MethodGet, p, nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// send returns the response of a successful request, and the Error reported by the server otherwise.
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	e := &Error{StatusCode: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(e); err != nil || e.Message == "" {
+		e.Message = "the server did not describe the failure"
+	}
+	return nil, e
+}
+
+// IsNotFound returns true when the error reports a session, artifact or asset that is not known.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
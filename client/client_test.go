@@ -0,0 +1,197 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer serves a session named 'nightly' that finishes after its state is requested twice.
+func newTestServer(t *testing.T) *httptest.Server {
+	var checks int
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"a valid bearer token is required"}`)
+			return
+		}
+
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/sessions":
+			var sr SessionRequest
+			if err := json.NewDecoder(req.Body).Decode(&sr); err != nil {
+				t.Errorf("The request body was not decoded: %v", err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(&Session{ID: sr.Session, Domains: sr.Domains, Status: StatusRunning, Runs: 1})
+		case req.Method == http.MethodGet && req.URL.Path == "/sessions":
+			list := []*Session{}
+			if labels := req.URL.Query()["label"]; len(labels) == 0 || strings.Join(labels, ",") == "customer=acme,operator" {
+				list = append(list, &Session{ID: "nightly", Status: StatusFinished, Labels: map[string]string{"customer": "acme", "operator": "jdoe"}})
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case req.URL.Path == "/sessions/nightly":
+			status := StatusRunning
+			if checks++; checks >= 2 {
+				status = StatusFinished
+			}
+			_ = json.NewEncoder(w).Encode(&Session{ID: "nightly", Status: status, Reason: "converged"})
+		case req.URL.Path == "/sessions/nightly/assets":
+			if s := req.URL.Query().Get("since"); s != "" && s != "2023-01-02T03:04:05Z" {
+				t.Errorf("The since parameter was %s", s)
+			}
+			fmt.Fprintln(w, `{"name":"www.owasp.org","type":"FQDN","last_seen":"2023-01-02T03:04:05Z"}`)
+			if req.URL.Query().Get("q") == "" {
+				fmt.Fprintln(w, `{"name":"192.168.1.1","type":"IPAddress","last_seen":"2023-01-02T03:04:05Z"}`)
+			}
+		case req.URL.Path == "/sessions/nightly/graph" && req.URL.Query().Get("asset") == "www.owasp.org":
+			fmt.Fprint(w, `{"asset":{"name":"www.owasp.org","type":"FQDN"},"relations":[`+
+				`{"from":{"name":"www.owasp.org","type":"FQDN"},"type":"a_record","to":{"name":"192.168.1.1","type":"IPAddress"},"last_seen":"2023-01-02T03:04:05Z"}]}`)
+		case req.URL.Path == "/sessions/nightly/artifacts/artifacts/wordlists/bruteforce.txt":
+			fmt.Fprint(w, "www\nmail\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"the session is not known"}`)
+		}
+	}))
+}
+
+func TestSessions(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL+"/", "secret")
+
+	s, err := c.StartSession(ctx, &SessionRequest{Session: "nightly", Domains: []string{"owasp.org"}})
+	if err != nil || s.ID != "nightly" || s.Status != StatusRunning || len(s.Domains) != 1 {
+		t.Fatalf("StartSession returned %+v, %v", s, err)
+	}
+
+	s, err = c.Wait(ctx, "nightly", 10*time.Millisecond)
+	if err != nil || s.Status != StatusFinished || s.Reason != "converged" {
+		t.Errorf("Wait returned %+v, %v", s, err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.DownloadArtifact(ctx, "nightly", "artifacts/wordlists/bruteforce.txt", &buf); err != nil || buf.String() != "www\nmail\n" {
+		t.Errorf("DownloadArtifact wrote %q, %v", buf.String(), err)
+	}
+
+	if _, err := c.Session(ctx, "unknown"); !IsNotFound(err) || !strings.Contains(err.Error(), "the session is not known") {
+		t.Errorf("Session returned %v for the unknown session", err)
+	}
+
+	var e *Error
+	if _, err := NewClient(srv.URL, "wrong").Sessions(ctx); !errors.As(err, &e) || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Sessions returned %v with the wrong token", err)
+	}
+}
+
+func TestFindSessions(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL, "secret")
+
+	list, err := c.FindSessions(ctx, map[string]string{"operator": "", "customer": "acme"})
+	if err != nil || len(list) != 1 || list[0].Labels["customer"] != "acme" {
+		t.Errorf("FindSessions returned %+v, %v", list, err)
+	}
+
+	list, err = c.FindSessions(ctx, map[string]string{"customer": "other"})
+	if err != nil || len(list) != 0 {
+		t.Errorf("FindSessions returned %+v, %v for the labels of no session", list, err)
+	}
+
+	if list, err := c.Sessions(ctx); err != nil || len(list) != 1 {
+		t.Errorf("Sessions returned %+v, %v", list, err)
+	}
+}
+
+func TestStreamAssets(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL, "secret")
+	since := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var assets []*Asset
+	if err := c.StreamAssets(ctx, "nightly", since, func(a *Asset) error {
+		assets = append(assets, a)
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamAssets returned an error: %v", err)
+	}
+	if len(assets) != 2 || assets[0].Name != "www.owasp.org" || assets[1].Type != "IPAddress" || !assets[0].LastSeen.Equal(since) {
+		t.Errorf("StreamAssets provided the wrong assets: %+v", assets)
+	}
+
+	stop := errors.New("stop")
+	var count int
+	if err := c.StreamAssets(ctx, "nightly", time.Time{}, func(a *Asset) error {
+		count++
+		return stop
+	}); err != stop || count != 1 {
+		t.Errorf("StreamAssets did not end with the callback error: %v after %d assets", err, count)
+	}
+}
+
+func TestSearchAssets(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	var assets []*Asset
+	c := NewClient(srv.URL, "secret")
+	if err := c.SearchAssets(context.Background(), "nightly", "OWASP", time.Time{}, func(a *Asset) error {
+		assets = append(assets, a)
+		return nil
+	}); err != nil {
+		t.Fatalf("SearchAssets returned an error: %v", err)
+	}
+	if len(assets) != 1 || assets[0].Name != "www.owasp.org" {
+		t.Errorf("SearchAssets provided the wrong assets: %+v", assets)
+	}
+}
+
+func TestNeighborhood(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL, "secret")
+
+	n, err := c.Neighborhood(ctx, "nightly", "www.owasp.org")
+	if err != nil {
+		t.Fatalf("Neighborhood returned an error: %v", err)
+	}
+	if n.Asset.Name != "www.owasp.org" || len(n.Relations) != 1 || n.Relations[0].Type != "a_record" || n.Relations[0].To.Name != "192.168.1.1" {
+		t.Errorf("Neighborhood provided the wrong relations: %+v", n)
+	}
+
+	if _, err := c.Neighborhood(ctx, "nightly", "mail.owasp.org"); !IsNotFound(err) {
+		t.Errorf("Neighborhood did not report the unknown asset: %v", err)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	for _, p := range []string{"/sessions:", "/sessions/{session}:", "/sessions/{session}/assets:", "/sessions/{session}/graph:", "/sessions/{session}/instances:", "/sessions/{session}/artifacts/{path}:", "/rpc:"} {
+		if !bytes.Contains(OpenAPI, []byte("  "+p+"\n")) {
+			t.Errorf("The OpenAPI specification does not describe %s", strings.TrimSuffix(p, ":"))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/client"
+)
+
+func ExampleClient_StartSession() {
+	ctx := context.Background()
+	c := client.NewClient("http://127.0.0.1:8080", os.Getenv("AMASS_WEBHOOK_TOKEN"))
+
+	s, err := c.StartSession(ctx, &client.SessionRequest{
+		Session: "nightly",
+		Domains: []string{"owasp.org"},
+		Timeout: 120,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err = c.Wait(ctx, s.ID, time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("The session %s is %s: %s\n", s.ID, s.Status, s.Reason)
+}
+
+func ExampleClient_StreamAssets() {
+	ctx := context.Background()
+	c := client.NewClient("http://127.0.0.1:8080", os.Getenv("AMASS_WEBHOOK_TOKEN"))
+
+	// Only the assets seen during the last day are streamed
+	since := time.Now().Add(-24 * time.Hour)
+	if err := c.StreamAssets(ctx, "nightly", since, func(a *client.Asset) error {
+		fmt.Printf("%s %s\n", a.Type, a.Name)
+		return nil
+	}); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,165 @@
+# Copyright © by Jeff Foley 2017-2023. All rights reserved.
+# Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+# SPDX-License-Identifier: Apache-2.0
+
+"""Python client of the sessions API served by the 'amass webhook' subcommand.
+
+The client calls the JSON-RPC 2.0 methods posted to '/rpc', so sessions can be
+started and their assets queried without hand-rolled HTTP calls:
+
+    from amass_client import Client
+
+    c = Client("http://127.0.0.1:8080", token)
+    session = c.start_session(domains=["owasp.org"], session="nightly")
+    session = c.wait("nightly")
+    for asset in c.assets("nightly"):
+        print(asset["type"], asset["name"])
+"""
+
+import itertools
+import json
+import time
+import urllib.error
+import urllib.request
+from datetime import datetime, timezone
+
+__all__ = ["Client", "AmassError", "RUNNING", "FINISHED", "FAILED"]
+__version__ = "1.0.0"
+
+# The states of a session
+RUNNING = "running"
+FINISHED = "finished"
+FAILED = "failed"
+
+
+class AmassError(Exception):
+    """The failure of a method, with the JSON-RPC error code and the HTTP status reported by the server."""
+
+    def __init__(self, code, message, status=None):
+        super().__init__(message)
+        self.code = code
+        self.message = message
+        self.status = status
+
+    def is_not_found(self):
+        """Returns True when the error reports a session that is not known."""
+        return self.status == 404
+
+
+class Client:
+    """Client of the server at the base URL, such as 'http://127.0.0.1:8080', authenticated
+    with the bearer token provided to the server."""
+
+    def __init__(self, base_url, token, timeout=60):
+        self.url = base_url.rstrip("/") + "/rpc"
+        self.token = token
+        self.timeout = timeout
+        self._ids = itertools.count(1)
+
+    def start_session(self, domains=None, session=None, blacklist=None, config=None, timeout=None, notify=None, labels=None):
+        """Creates the session, or resumes it when the session already exists. The sessions resumed
+        without domain names use the domain names and configuration of their previous request.
+        The digest of the names first seen by each enumeration is posted to the notify URL, and
+        the labels, such as {"customer": "acme"}, annotate the session so it can be found."""
+        params = {
+            "session": session,
+            "domains": domains,
+            "blacklist": blacklist,
+            "config": config,
+            "timeout": timeout,
+            "notify": notify,
+            "labels": labels,
+        }
+        return self.call("sessions.start", {k: v for k, v in params.items() if v})
+
+    def sessions(self, labels=None):
+        """Returns the sessions kept in the directory of the server that have the labels provided,
+        where an empty value matches the sessions having the label whatever its value."""
+        if labels:
+            return self.call("sessions.list", {"labels": labels})
+        return self.call("sessions.list")
+
+    def session(self, session):
+        """Returns the state of the session."""
+        return self.call("sessions.get", {"session": session})
+
+    def wait(self, session, interval=30, deadline=None):
+        """Checks the state of the session at the interval, in seconds, until the session is no
+        longer running or the deadline, in seconds, elapses."""
+        start = time.monotonic()
+        while True:
+            s = self.session(session)
+            if s["status"] != RUNNING:
+                return s
+            if deadline is not None and time.monotonic() - start >= deadline:
+                return s
+            time.sleep(interval)
+
+    def set_instances(self, session, instances):
+        """Changes the numbers of script instances handling the requests of the data sources, as a dict
+        keyed by their names, while the session is running. Returns the numbers requested during the run."""
+        return self.call("sessions.instances", {"session": session, "instances": instances})
+
+    def artifacts(self, session):
+        """Returns the files kept in the directory of the session."""
+        return self.call("sessions.artifacts", {"session": session})
+
+    def assets(self, session, since=None, page_size=1000, query=None):
+        """Yields the assets in the graph database of the session last seen after the time provided,
+        as datetime or RFC3339 string, or every asset when no time is provided. The query keeps the
+        assets whose names contain it, regardless of case."""
+        params = {"session": session, "limit": page_size}
+        if query:
+            params["query"] = query
+        if isinstance(since, datetime):
+            if since.tzinfo is None:
+                since = since.replace(tzinfo=timezone.utc)
+            since = since.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+        if since:
+            params["since"] = since
+
+        offset = 0
+        while True:
+            params["offset"] = offset
+            page = self.call("assets.list", params)
+            yield from page["assets"]
+
+            offset = page.get("next", 0)
+            if not offset:
+                return
+
+    def neighborhood(self, session, asset):
+        """Returns the asset of the session graph database, such as a name, an address, a netblock
+        or an ASN like 'AS64496', along with its relations to the other assets."""
+        return self.call("assets.graph", {"session": session, "asset": asset})
+
+    def call(self, method, params=None):
+        """Calls the JSON-RPC method with the parameters and returns its result."""
+        body = {"jsonrpc": "2.0", "id": next(self._ids), "method": method}
+        if params is not None:
+            body["params"] = params
+
+        req = urllib.request.Request(
+            self.url,
+            data=json.dumps(body).encode("utf-8"),
+            headers={
+                "Authorization": "Bearer " + self.token,
+                "Content-Type": "application/json",
+            },
+            method="POST",
+        )
+        try:
+            with urllib.request.urlopen(req, timeout=self.timeout) as resp:
+                reply = json.load(resp)
+        except urllib.error.HTTPError as e:
+            try:
+                message = json.load(e).get("error", e.reason)
+            except ValueError:
+                message = e.reason
+            raise AmassError(None, message, e.code) from None
+
+        err = reply.get("error")
+        if err is not None:
+            data = err.get("data") or {}
+            raise AmassError(err.get("code"), err.get("message"), data.get("status"))
+        return reply.get("result")
@@ -0,0 +1,525 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const (
+	assocUsageMsg = "assoc [options] -asset ASSET"
+	// The number of relations followed from the asset while searching for the scope
+	maxAssocDepth = 6
+)
+
+// relationConfidence is the confidence that a relation of the type links two assets of the same
+// organization. DNS records are verified by resolution, while reverse DNS records are controlled
+// by the owner of the address, and the address space of an AS is often shared with others.
+var relationConfidence = map[string]float64{
+	"node":         1.0,
+	"cname_record": 0.95,
+	"a_record":     0.95,
+	"aaaa_record":  0.95,
+	"ns_record":    0.9,
+	"mx_record":    0.9,
+	"srv_record":   0.9,
+	"contains":     0.8,
+	"ptr_record":   0.6,
+	"announces":    0.5,
+	"managed_by":   0.4,
+}
+
+// sourceConfidence is the confidence of the data sources reporting names that their owners did not
+// publish, such as the names mentioned in code, which applies when no other source reported the asset.
+// The 'source_confidence' option replaces the confidence of the data sources it provides.
+var sourceConfidence = map[string]float64{
+	"Bitbucket": 0.6,
+	"GitHub":    0.6,
+	"GitLab":    0.6,
+}
+
+type assocArgs struct {
+	Assets  *stringset.Set
+	Domains *stringset.Set
+	Options struct {
+		Force   bool
+		Full    bool
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+// assocStep is a relation traversed from the in-scope asset toward the asset being explained.
+type assocStep struct {
+	From       string   `json:"from"`
+	Relation   string   `json:"relation"`
+	To         string   `json:"to"`
+	Confidence float64  `json:"confidence"`
+	RPKI       string   `json:"rpki,omitempty"`
+	Sources    []string `json:"sources,omitempty"`
+}
+
+// assocExplanation is the evidence chain explaining why an asset is associated with the target.
+type assocExplanation struct {
+	Asset      string       `json:"asset"`
+	Type       string       `json:"type"`
+	Found      bool         `json:"found"`
+	Anchor     string       `json:"anchor,omitempty"`
+	ScopeRule  string       `json:"scope_rule,omitempty"`
+	Sources    []string     `json:"sources,omitempty"`
+	Steps      []*assocStep `json:"steps,omitempty"`
+	Confidence float64      `json:"confidence"`
+}
+
+func defineAssocFlags(assocFlags *flag.FlagSet, args *assocArgs) {
+	assocFlags.Var(args.Assets, "asset", "Names, addresses, netblocks, ASNs or services (host:port) to explain separated by commas (can be used multiple times)")
+	assocFlags.Var(args.Domains, "d", "Domain names of the target separated by commas (can be used multiple times)")
+	assocFlags.BoolVar(&args.Options.Force, "force", false, "Execute the graph queries estimated to scan tens of millions of rows")
+	assocFlags.BoolVar(&args.Options.Full, "full", false, "Follow every relation of the high-degree assets instead of their rollups")
+	assocFlags.BoolVar(&args.Options.JSON, "json", false, "Print the explanations to stdout as JSON")
+	assocFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	assocFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	assocFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runAssocCommand(clArgs []string) {
+	args := assocArgs{
+		Assets:  stringset.New(),
+		Domains: stringset.New(),
+	}
+	defer args.Assets.Close()
+	defer args.Domains.Close()
+
+	var help1, help2 bool
+	assocCommand := flag.NewFlagSet("assoc", flag.ContinueOnError)
+
+	assocBuf := new(bytes.Buffer)
+	assocCommand.SetOutput(assocBuf)
+
+	assocCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	assocCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineAssocFlags(assocCommand, &args)
+
+	if err := assocCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(assocUsageMsg, assocCommand, assocBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Assets.Len() == 0 {
+		r.Fprintln(color.Error, "The assets to explain must be provided using the '-asset' flag")
+		os.Exit(1)
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err != nil && args.Filepaths.ConfigFile != "" {
+		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	if args.Domains.Len() > 0 {
+		cfg.AddDomains(args.Domains.Slice()...)
+	}
+	if len(cfg.Domains()) == 0 && len(cfg.Scope.CIDRs) == 0 && len(cfg.Scope.Addresses) == 0 && len(cfg.Scope.ASNs) == 0 {
+		r.Fprintln(color.Error, "The scope of the target must be provided using the '-d' flag or the configuration file")
+		os.Exit(1)
+	}
+
+	// Without the rollups, the search can follow every relation of the graph
+	var rows int64
+	if args.Options.Full {
+		var err error
+
+		rows, err = checkGraphQueryCost(context.Background(), cfg, args.Options.Force)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	g := openGraphDatabase(cfg)
+	if g == nil {
+		r.Fprintf(color.Error, "Failed to open the graph database in %s\n", config.OutputDirectory(cfg.Dir))
+		os.Exit(1)
+	}
+
+	records, err := enum.LoadCoverage(enum.CoveragePath(cfg))
+	if err != nil {
+		fgY.Fprintf(color.Error, "Failed to read the coverage data, so the data sources are not shown: %v\n", err)
+	}
+	sources := make(map[string][]string)
+	for _, rec := range records {
+		sources[rec.Asset] = append(sources[rec.Asset], rec.Source)
+	}
+	for asset, list := range sources {
+		sort.Strings(list)
+		sources[asset] = list
+	}
+
+	// The route origin validation states lower the confidence of the announcements not validated by RPKI
+	origins := make(map[string]*systems.Finding)
+	if fs, err := systems.NewFindingStore(systems.FindingsPath(cfg)); err == nil {
+		for _, f := range fs.Find(time.Time{}, "Netblock") {
+			if f.Properties["rpki"] != "" {
+				origins[f.Value] = f
+			}
+		}
+	}
+
+	// The rollups of the high-degree assets, such as popular name servers, keep the search from following millions of relations
+	var rollups *systems.RollupStore
+	if !args.Options.Full {
+		threshold, sample := systems.RollupSettings(cfg)
+
+		rollups, err = systems.NewRollupStore(systems.RollupPath(cfg), threshold, sample)
+		if err != nil {
+			fgY.Fprintf(color.Error, "Failed to read the relation rollups, so every relation is followed: %v\n", err)
+		}
+	}
+
+	assets := args.Assets.Slice()
+	sort.Strings(assets)
+
+	progress := newQueryProgress("Association search", rows)
+	var results []*assocExplanation
+	for _, asset := range assets {
+		results = append(results, explainAssociation(g, rollups, cfg, sources, origins, progress, asset))
+	}
+	progress.stop()
+	if err := rollups.Save(); err != nil {
+		fgY.Fprintf(color.Error, "Failed to save the relation rollups: %v\n", err)
+	}
+
+	if args.Options.JSON {
+		for _, exp := range results {
+			_ = writeJSONLine(color.Output, exp)
+		}
+		return
+	}
+	for i, exp := range results {
+		if i > 0 {
+			fmt.Fprintln(color.Output)
+		}
+		printAssocExplanation(exp)
+	}
+}
+
+// assocVisit is an asset reached while searching the graph, along with the relation followed.
+type assocVisit struct {
+	asset *types.Asset
+	prev  *assocVisit
+	rel   string
+	// forward is true when the relation points from this asset toward the previous asset
+	forward bool
+}
+
+// explainAssociation searches the graph, starting at the asset, for the shortest chain of relations
+// reaching an asset matched by the scope of the target, and returns the chain as the evidence.
+func explainAssociation(g *netmap.Graph, rollups *systems.RollupStore, cfg *config.Config,
+	sources map[string][]string, origins map[string]*systems.Finding, progress *queryProgress, asset string) *assocExplanation {
+	// The services are associated with the target through the host providing them
+	if host, port, proto, err := systems.ParseService(asset); err == nil {
+		exp := explainAssociation(g, rollups, cfg, sources, origins, progress, host)
+
+		exp.Asset = asset
+		exp.Type = "service"
+		if exp.ScopeRule != "" {
+			exp.Steps = append(exp.Steps, &assocStep{
+				From:       host,
+				Relation:   "port",
+				To:         strconv.Itoa(port) + "/" + proto,
+				Confidence: 1,
+			})
+		}
+		return exp
+	}
+
+	content, atype := parseAssocAsset(asset)
+	exp := &assocExplanation{
+		Asset:   asset,
+		Type:    atype,
+		Sources: sources[asset],
+	}
+
+	found, err := g.DB.FindByContent(content, time.Time{})
+	if err != nil || len(found) == 0 {
+		return exp
+	}
+	exp.Found = true
+
+	seen := map[string]struct{}{found[0].ID: {}}
+	queue := []*assocVisit{{asset: found[0]}}
+	for depth := 0; depth <= maxAssocDepth && len(queue) > 0; depth++ {
+		var next []*assocVisit
+
+		for _, v := range queue {
+			if rule := assetScopeRule(cfg, v.asset.Asset); rule != "" {
+				exp.Anchor = assetLabel(v.asset.Asset)
+				exp.ScopeRule = rule
+				exp.Confidence = 1
+
+				for cur := v; cur.prev != nil; cur = cur.prev {
+					from, to := assetLabel(cur.asset.Asset), assetLabel(cur.prev.asset.Asset)
+					if !cur.forward {
+						from, to = to, from
+					}
+
+					step := &assocStep{
+						From:       from,
+						Relation:   cur.rel,
+						To:         to,
+						Confidence: assocConfidence(cur.rel),
+						Sources:    sources[assetLabel(cur.prev.asset.Asset)],
+					}
+					originConfidence(origins, step)
+					step.Confidence *= reportedConfidence(cfg, step.Sources)
+					exp.Steps = append(exp.Steps, step)
+					exp.Confidence *= step.Confidence
+				}
+				return exp
+			}
+
+			if in, _, err := rollups.Relations(g, v.asset, time.Time{}, true); err == nil {
+				for _, rel := range in {
+					if a := unseenAsset(g, seen, rel.FromAsset.ID); a != nil {
+						next = append(next, &assocVisit{asset: a, prev: v, rel: rel.Type, forward: true})
+					}
+				}
+			}
+			if out, _, err := rollups.Relations(g, v.asset, time.Time{}, false); err == nil {
+				for _, rel := range out {
+					if a := unseenAsset(g, seen, rel.ToAsset.ID); a != nil {
+						next = append(next, &assocVisit{asset: a, prev: v, rel: rel.Type})
+					}
+				}
+			}
+		}
+		progress.add(len(next))
+		queue = next
+	}
+	return exp
+}
+
+func unseenAsset(g *netmap.Graph, seen map[string]struct{}, id string) *types.Asset {
+	if _, found := seen[id]; found {
+		return nil
+	}
+	seen[id] = struct{}{}
+
+	a, err := g.DB.FindById(id, time.Time{})
+	if err != nil {
+		return nil
+	}
+	return a
+}
+
+// parseAssocAsset returns the content used to find the asset in the graph and the type of the asset.
+func parseAssocAsset(asset string) (oam.Asset, string) {
+	if ip, err := netip.ParseAddr(asset); err == nil {
+		t := "IPv4"
+		if ip.Is6() {
+			t = "IPv6"
+		}
+		return &network.IPAddress{Address: ip, Type: t}, "address"
+	}
+	if prefix, err := netip.ParsePrefix(asset); err == nil {
+		t := "IPv4"
+		if prefix.Addr().Is6() {
+			t = "IPv6"
+		}
+		return &network.Netblock{Cidr: prefix, Type: t}, "netblock"
+	}
+	if num, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(asset), "AS")); err == nil {
+		return &network.AutonomousSystem{Number: num}, "asn"
+	}
+	return &domain.FQDN{Name: strings.Trim(strings.ToLower(asset), ".")}, "fqdn"
+}
+
+// assetScopeRule returns the rule of the scope matching the asset, or an empty string when out of scope.
+func assetScopeRule(cfg *config.Config, asset oam.Asset) string {
+	switch v := asset.(type) {
+	case domain.FQDN:
+		if d := cfg.WhichDomain(v.Name); d != "" && !cfg.Blacklisted(v.Name) {
+			if d == v.Name {
+				return "domain " + d
+			}
+			return "subdomain of " + d
+		}
+	case network.IPAddress:
+		ip := net.IP(v.Address.AsSlice())
+
+		for _, addr := range cfg.Scope.Addresses {
+			if addr.Equal(ip) {
+				return "address " + addr.String()
+			}
+		}
+		for _, cidr := range cfg.Scope.CIDRs {
+			if cidr.Contains(ip) {
+				return "address within " + cidr.String()
+			}
+		}
+	case network.Netblock:
+		for _, cidr := range cfg.Scope.CIDRs {
+			if cidr.String() == v.Cidr.String() {
+				return "netblock " + cidr.String()
+			}
+		}
+	case network.AutonomousSystem:
+		for _, asn := range cfg.Scope.ASNs {
+			if asn == v.Number {
+				return "ASN " + strconv.Itoa(asn)
+			}
+		}
+	}
+	return ""
+}
+
+func assetLabel(asset oam.Asset) string {
+	switch v := asset.(type) {
+	case domain.FQDN:
+		return v.Name
+	case network.IPAddress:
+		return v.Address.String()
+	case network.Netblock:
+		return v.Cidr.String()
+	case network.AutonomousSystem:
+		return "AS" + strconv.Itoa(v.Number)
+	case network.RIROrganization:
+		return v.Name
+	}
+	return fmt.Sprintf("%v", asset)
+}
+
+func printAssocExplanation(exp *assocExplanation) {
+	fmt.Fprintf(color.Output, "%s%s %s\n", blue("Asset: "), green(exp.Asset), white("("+exp.Type+")"))
+	if !exp.Found {
+		fgY.Fprintln(color.Output, "The asset was not found in the graph database")
+		return
+	}
+	if exp.ScopeRule == "" {
+		fgY.Fprintf(color.Output, "No chain of relations within %d steps reaches an asset in scope, so the asset is not associated with the target\n", maxAssocDepth)
+		return
+	}
+
+	fmt.Fprintf(color.Output, "%s%s %s\n", blue("Scope: "), green(exp.Anchor), white("matched the rule: "+exp.ScopeRule))
+	if len(exp.Sources) > 0 {
+		fmt.Fprintf(color.Output, "%s%s\n", blue("Sources: "), yellow(strings.Join(exp.Sources, ", ")))
+	}
+
+	if len(exp.Steps) > 0 {
+		fmt.Fprintf(color.Output, "\n%-6s%-32s%-18s%-32s%-15s%s\n", blue("Step"), blue("| From"),
+			blue("| Relation"), blue("| To"), blue("| Confidence"), blue("| Sources"))
+	}
+	for i, step := range exp.Steps {
+		var rpki string
+		if step.RPKI != "" {
+			rpki = " " + yellow("(RPKI "+step.RPKI+")")
+		}
+
+		fmt.Fprintf(color.Output, "%-6s  %-30s  %-16s  %-30s  %-13s  %s%s\n", white(strconv.Itoa(i+1)), green(step.From),
+			magenta(step.Relation), green(step.To), yellow(formatConfidence(step.Confidence)), strings.Join(step.Sources, ", "), rpki)
+	}
+	fmt.Fprintf(color.Output, "\n%s%s\n", blue("Confidence: "), yellow(formatConfidence(exp.Confidence)))
+}
+
+// assocConfidence returns the confidence of the relation, with relations of other types
+// considered as likely as not to link assets of the same organization.
+func assocConfidence(relation string) float64 {
+	if c, found := relationConfidence[relation]; found {
+		return c
+	}
+	return 0.5
+}
+
+// originConfidence lowers the confidence of the announcement when the origination of the
+// netblock was not validated by RPKI, using the confidence kept with the validation state.
+func originConfidence(origins map[string]*systems.Finding, step *assocStep) {
+	if step.Relation != "announces" {
+		return
+	}
+
+	f, found := origins[step.To]
+	if !found {
+		f, found = origins[step.From]
+	}
+	if !found {
+		return
+	}
+
+	step.RPKI = f.Properties["rpki"]
+	if c, err := strconv.ParseFloat(f.Properties["confidence"], 64); err == nil {
+		step.Confidence *= c
+	}
+}
+
+// reportedConfidence returns the highest confidence of the data sources that reported the asset.
+func reportedConfidence(cfg *config.Config, sources []string) float64 {
+	if len(sources) == 0 {
+		return 1
+	}
+
+	var highest float64
+	for _, src := range sources {
+		c, found := configuredConfidence(cfg, src)
+		if !found {
+			c, found = sourceConfidence[src]
+		}
+		if !found {
+			return 1
+		}
+		if c > highest {
+			highest = c
+		}
+	}
+	return highest
+}
+
+// configuredConfidence returns the confidence of the data source provided by the 'source_confidence' option,
+// either a confidence for all the data sources, or a table of data source names and confidences, where the
+// 'default' entry applies to the others.
+func configuredConfidence(cfg *config.Config, source string) (float64, bool) {
+	c := options.FloatValue(options.Source(cfg, "source_confidence", source))
+	if c <= 0 || c > 1 {
+		return 0, false
+	}
+	return c, true
+}
+
+func formatConfidence(c float64) string {
+	return strconv.Itoa(int(c*100+0.5)) + "%"
+}
@@ -0,0 +1,387 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const compareUsageMsg = "compare [options] -d1 DOMAIN -d2 DOMAIN"
+
+type compareArgs struct {
+	Domains1 *stringset.Set
+	Domains2 *stringset.Set
+	Options  struct {
+		Force   bool
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory1 string
+		Directory2 string
+	}
+}
+
+// scopeInfra is the infrastructure discovered for the domain names of a scope.
+type scopeInfra struct {
+	Domains      []string `json:"domains"`
+	Names        int      `json:"names"`
+	Addresses    []string `json:"addresses"`
+	Netblocks    []string `json:"netblocks"`
+	ASNs         []string `json:"asns"`
+	NameServers  []string `json:"name_servers"`
+	Certificates []string `json:"certificates"`
+}
+
+type compareReport struct {
+	Scope1       *scopeInfra `json:"scope1"`
+	Scope2       *scopeInfra `json:"scope2"`
+	Addresses    []string    `json:"shared_addresses"`
+	Netblocks    []string    `json:"shared_netblocks"`
+	ASNs         []string    `json:"shared_asns"`
+	NameServers  []string    `json:"shared_name_servers"`
+	Certificates []string    `json:"shared_certificates"`
+}
+
+func defineCompareFlags(compareFlags *flag.FlagSet, args *compareArgs) {
+	compareFlags.Var(args.Domains1, "d1", "Domain names of the first scope separated by commas (can be used multiple times)")
+	compareFlags.Var(args.Domains2, "d2", "Domain names of the second scope separated by commas (can be used multiple times)")
+	compareFlags.BoolVar(&args.Options.Force, "force", false, "Execute the graph queries estimated to scan tens of millions of rows")
+	compareFlags.BoolVar(&args.Options.JSON, "json", false, "Print the report to stdout as JSON")
+	compareFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	compareFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	compareFlags.StringVar(&args.Filepaths.Directory1, "dir1", "", "Path to the directory containing the output files of the first scope")
+	compareFlags.StringVar(&args.Filepaths.Directory2, "dir2", "", "Path to the directory containing the output files of the second scope")
+}
+
+func runCompareCommand(clArgs []string) {
+	args := compareArgs{
+		Domains1: stringset.New(),
+		Domains2: stringset.New(),
+	}
+	defer args.Domains1.Close()
+	defer args.Domains2.Close()
+
+	var help1, help2 bool
+	compareCommand := flag.NewFlagSet("compare", flag.ContinueOnError)
+
+	compareBuf := new(bytes.Buffer)
+	compareCommand.SetOutput(compareBuf)
+
+	compareCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	compareCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCompareFlags(compareCommand, &args)
+
+	if err := compareCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(compareUsageMsg, compareCommand, compareBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Domains1.Len() == 0 || args.Domains2.Len() == 0 {
+		r.Fprintln(color.Error, "The domain names of both scopes must be provided using the '-d1' and '-d2' flags")
+		os.Exit(1)
+	}
+	// The second scope is read from the same output directory unless another one is provided
+	if args.Filepaths.Directory2 == "" {
+		args.Filepaths.Directory2 = args.Filepaths.Directory1
+	}
+
+	ctx := context.Background()
+	scope1, err := readScopeInfra(ctx, args.Filepaths.Directory1, args.Filepaths.ConfigFile, args.Domains1.Slice(), args.Options.Force)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	scope2, err := readScopeInfra(ctx, args.Filepaths.Directory2, args.Filepaths.ConfigFile, args.Domains2.Slice(), args.Options.Force)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	report := &compareReport{
+		Scope1:       scope1,
+		Scope2:       scope2,
+		Addresses:    sharedValues(scope1.Addresses, scope2.Addresses),
+		Netblocks:    sharedValues(scope1.Netblocks, scope2.Netblocks),
+		ASNs:         sharedValues(scope1.ASNs, scope2.ASNs),
+		NameServers:  sharedValues(scope1.NameServers, scope2.NameServers),
+		Certificates: sharedValues(scope1.Certificates, scope2.Certificates),
+	}
+	if args.Options.JSON {
+		_ = writeJSONLine(color.Output, report)
+		return
+	}
+	printCompareReport(report)
+}
+
+// readScopeInfra collects the infrastructure of the domain names from the graph database in the directory.
+func readScopeInfra(ctx context.Context, dir, file string, domains []string, force bool) (*scopeInfra, error) {
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(dir, file, cfg); err != nil && file != "" {
+		return nil, fmt.Errorf("failed to load the configuration file: %v", err)
+	}
+	if dir != "" {
+		cfg.Dir = dir
+	}
+
+	rows, err := checkGraphQueryCost(ctx, cfg, force)
+	if err != nil {
+		return nil, err
+	}
+
+	g := openGraphDatabase(cfg)
+	if g == nil {
+		return nil, fmt.Errorf("failed to open the graph database in %s", config.OutputDirectory(cfg.Dir))
+	}
+
+	progress := newQueryProgress("Infrastructure of "+strings.Join(domains, ", "), rows)
+	defer progress.stop()
+
+	sort.Strings(domains)
+	info := &scopeInfra{
+		Domains:      domains,
+		Certificates: readScopeCertificates(systems.FindingsPath(cfg), domains),
+	}
+
+	var fqdns []oam.Asset
+	for _, d := range domains {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+
+	assets, err := g.DB.FindByScope(fqdns, time.Time{})
+	if err != nil {
+		return info, nil
+	}
+
+	var names []string
+	nameservers := stringset.New()
+	defer nameservers.Close()
+	for _, a := range assets {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok {
+			continue
+		}
+
+		progress.add(1)
+		names = append(names, fqdn.Name)
+		if rels, err := g.DB.OutgoingRelations(a, time.Time{}, "ns_record"); err == nil {
+			for _, rel := range rels {
+				if ns, err := g.DB.FindById(rel.ToAsset.ID, time.Time{}); err == nil {
+					if n, ok := ns.Asset.(domain.FQDN); ok {
+						nameservers.Insert(n.Name)
+					}
+				}
+			}
+		}
+	}
+	info.Names = len(names)
+	info.NameServers = sortedSlice(nameservers)
+
+	addrs := stringset.New()
+	defer addrs.Close()
+	netblocks := stringset.New()
+	defer netblocks.Close()
+	asns := stringset.New()
+	defer asns.Close()
+
+	pairs := make(chan *netmap.NameAddrPair, systems.DefaultNameBatchSize)
+	go func() { _ = systems.NamesToAddrsStream(ctx, g, time.Time{}, 0, pairs, names...) }()
+
+	for p := range pairs {
+		progress.add(1)
+		if p.Addr == nil || addrs.Has(p.Addr.Address.String()) {
+			continue
+		}
+
+		addrs.Insert(p.Addr.Address.String())
+		readAddrInfra(g, p.Addr, netblocks, asns)
+	}
+	info.Addresses = sortedSlice(addrs)
+	info.Netblocks = sortedSlice(netblocks)
+	info.ASNs = sortedSlice(asns)
+	return info, nil
+}
+
+// readScopeCertificates returns the certificates kept in the findings file for the domain names, or
+// listing names within them, identified by their serial number and issuer as "serial (issuer)".
+func readScopeCertificates(path string, domains []string) []string {
+	finds, err := systems.NewFindingStore(path)
+	if err != nil {
+		return nil
+	}
+
+	// The stringset would lower the case of the issuers
+	seen := make(map[string]struct{})
+
+	var certs []string
+	for _, f := range finds.Find(time.Time{}, "Certificate") {
+		inscope := withinDomains(f.Domain, domains)
+		for _, name := range strings.Split(f.Properties["names"], ",") {
+			if inscope {
+				break
+			}
+			inscope = withinDomains(strings.TrimPrefix(strings.TrimSpace(name), "*."), domains)
+		}
+		if !inscope {
+			continue
+		}
+
+		cert := strings.ToLower(f.Value) + " (" + canonicalIssuer(f.Properties["issuer"]) + ")"
+		if _, found := seen[cert]; !found {
+			seen[cert] = struct{}{}
+			certs = append(certs, cert)
+		}
+	}
+	sort.Strings(certs)
+	return certs
+}
+
+// canonicalIssuer orders the attributes of the distinguished name, since the data sources
+// do not provide the issuer of the same certificate in the same order.
+func canonicalIssuer(dn string) string {
+	var attrs []string
+
+	for _, attr := range strings.Split(dn, ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	sort.Strings(attrs)
+	return strings.Join(attrs, ", ")
+}
+
+func withinDomains(name string, domains []string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return false
+	}
+
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// readAddrInfra follows the graph from the address to the netblock containing it and the announcing AS.
+func readAddrInfra(g *netmap.Graph, addr *network.IPAddress, netblocks, asns *stringset.Set) {
+	found, err := g.DB.FindByContent(addr, time.Time{})
+	if err != nil || len(found) == 0 {
+		return
+	}
+
+	for _, nb := range incomingAssets(g, found[0], "contains") {
+		cidr, ok := nb.Asset.(network.Netblock)
+		if !ok {
+			continue
+		}
+
+		netblocks.Insert(cidr.Cidr.String())
+		for _, as := range incomingAssets(g, nb, "announces") {
+			if a, ok := as.Asset.(network.AutonomousSystem); ok {
+				asns.Insert(strconv.Itoa(a.Number))
+			}
+		}
+	}
+}
+
+func incomingAssets(g *netmap.Graph, a *types.Asset, relation string) []*types.Asset {
+	var results []*types.Asset
+
+	if rels, err := g.DB.IncomingRelations(a, time.Time{}, relation); err == nil {
+		for _, rel := range rels {
+			if from, err := g.DB.FindById(rel.FromAsset.ID, time.Time{}); err == nil {
+				results = append(results, from)
+			}
+		}
+	}
+	return results
+}
+
+func sortedSlice(set *stringset.Set) []string {
+	list := set.Slice()
+
+	sort.Strings(list)
+	return list
+}
+
+func sharedValues(a, b []string) []string {
+	set := stringset.New(a...)
+	defer set.Close()
+
+	var shared []string
+	for _, v := range b {
+		if set.Has(v) {
+			shared = append(shared, v)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+func printCompareReport(report *compareReport) {
+	fmt.Fprintf(color.Output, "%-20s%-40s%s\n", blue(""), blue("| "+strings.Join(report.Scope1.Domains, ",")),
+		blue("| "+strings.Join(report.Scope2.Domains, ",")))
+
+	rows := []struct {
+		label  string
+		a, b   int
+		shared []string
+	}{
+		{"Names", report.Scope1.Names, report.Scope2.Names, nil},
+		{"Addresses", len(report.Scope1.Addresses), len(report.Scope2.Addresses), report.Addresses},
+		{"Netblocks", len(report.Scope1.Netblocks), len(report.Scope2.Netblocks), report.Netblocks},
+		{"ASNs", len(report.Scope1.ASNs), len(report.Scope2.ASNs), report.ASNs},
+		{"Name Servers", len(report.Scope1.NameServers), len(report.Scope2.NameServers), report.NameServers},
+		{"Certificates", len(report.Scope1.Certificates), len(report.Scope2.Certificates), report.Certificates},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(color.Output, "%-20s  %-40s  %s\n", green(row.label),
+			yellow(strconv.Itoa(row.a)), yellow(strconv.Itoa(row.b)))
+	}
+
+	var shared bool
+	for _, row := range rows[1:] {
+		if len(row.shared) == 0 {
+			continue
+		}
+
+		shared = true
+		fmt.Fprintf(color.Output, "\n%s%s\n", blue("Shared "+row.label+": "), yellow(strconv.Itoa(len(row.shared))))
+		for _, v := range row.shared {
+			fmt.Fprintf(color.Output, "\t%s\n", white(v))
+		}
+	}
+	if !shared {
+		fmt.Fprintf(color.Output, "\n%s\n", blue("The scopes do not share any infrastructure"))
+	}
+}
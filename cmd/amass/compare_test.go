@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+func TestSharedCertificates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), systems.FindingsFile)
+	finds, err := systems.NewFindingStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+
+	for _, f := range []*systems.Finding{
+		// The same certificate reported for both brands, with the issuer attributes in different orders
+		{
+			Type:       "Certificate",
+			Value:      "0A1B2C",
+			Domain:     "owasp.org",
+			Source:     "CTStream",
+			Properties: map[string]string{"issuer": "CN=R3,O=Let's Encrypt,C=US", "names": "www.owasp.org,www.example.com"},
+		},
+		{
+			Type:       "Certificate",
+			Value:      "0a1b2c",
+			Domain:     "example.com",
+			Source:     "Crtsh",
+			Properties: map[string]string{"issuer": "C=US, O=Let's Encrypt, CN=R3", "names": "www.example.com"},
+		},
+		{
+			Type:       "Certificate",
+			Value:      "ffee",
+			Domain:     "owasp.org",
+			Source:     "CTStream",
+			Properties: map[string]string{"issuer": "CN=Other CA", "names": "api.owasp.org"},
+		},
+		// A certificate only listing names of the second brand under the domain of the first
+		{
+			Type:       "Certificate",
+			Value:      "beef",
+			Domain:     "owasp.org",
+			Source:     "CTStream",
+			Properties: map[string]string{"issuer": "CN=Shared CA", "names": "*.example.com"},
+		},
+		{Type: "Email", Value: "admin@owasp.org", Domain: "owasp.org", Source: "Hunter"},
+	} {
+		finds.Add(f)
+	}
+	if err := finds.Save(); err != nil {
+		t.Fatalf("Failed to save the findings: %v", err)
+	}
+
+	scope1 := readScopeCertificates(path, []string{"owasp.org"})
+	scope2 := readScopeCertificates(path, []string{"example.com"})
+	if len(scope1) != 3 || len(scope2) != 2 {
+		t.Errorf("Unexpected certificates of the scopes: %v and %v", scope1, scope2)
+	}
+
+	expected := []string{
+		"0a1b2c (C=US, CN=R3, O=Let's Encrypt)",
+		"beef (CN=Shared CA)",
+	}
+	if shared := sharedValues(scope1, scope2); !reflect.DeepEqual(shared, expected) {
+		t.Errorf("Expected the shared certificates %v, got %v", expected, shared)
+	}
+}
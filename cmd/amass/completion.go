@@ -0,0 +1,298 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"golang.org/x/net/publicsuffix"
+)
+
+const completionUsageMsg = "completion bash|zsh|fish"
+
+const bashCompletionScript = `# bash completion for amass
+_amass_completion() {
+	local IFS=$'\n'
+	COMPREPLY=($(amass completion -complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _amass_completion amass
+`
+
+const zshCompletionScript = `#compdef amass
+_amass() {
+	local -a candidates
+	candidates=("${(@f)$(amass completion -complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -- "${candidates[@]}"
+	else
+		_files
+	fi
+}
+compdef _amass amass
+`
+
+const fishCompletionScript = `# fish completion for amass
+function __amass_complete
+	set -l words (commandline -opc) (commandline -ct)
+	amass completion -complete $words[2..-1] 2>/dev/null
+end
+complete -c amass -a '(__amass_complete)'
+`
+
+var completionSubcommands = []string{"assoc", "compare", "completion", "coverage", "diff", "dlq", "enum", "help", "intel", "maltego", "quarantine", "scope", "webhook"}
+
+func runCompletionCommand(clArgs []string) {
+	var help1, help2 bool
+	completionCommand := flag.NewFlagSet("completion", flag.ContinueOnError)
+
+	completionBuf := new(bytes.Buffer)
+	completionCommand.SetOutput(completionBuf)
+
+	completionCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	completionCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+
+	if len(clArgs) < 1 {
+		commandUsage(completionUsageMsg, completionCommand, completionBuf)
+		return
+	}
+	// The words following this argument are completed, so they must not be parsed as flags
+	if clArgs[0] == "-complete" {
+		for _, c := range completeWords(clArgs[1:]) {
+			fmt.Fprintln(color.Output, c)
+		}
+		return
+	}
+	if err := completionCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(completionUsageMsg, completionCommand, completionBuf)
+		return
+	}
+
+	switch completionCommand.Arg(0) {
+	case "bash":
+		fmt.Fprint(color.Output, bashCompletionScript)
+	case "zsh":
+		fmt.Fprint(color.Output, zshCompletionScript)
+	case "fish":
+		fmt.Fprint(color.Output, fishCompletionScript)
+	default:
+		commandUsage(completionUsageMsg, completionCommand, completionBuf)
+		os.Exit(1)
+	}
+}
+
+// completeWords returns the candidates for the last of the provided command-line words.
+// An empty slice is returned when the shell should fall back to completing file paths.
+func completeWords(words []string) []string {
+	if len(words) == 0 {
+		return completionSubcommands
+	}
+
+	cur := words[len(words)-1]
+	if len(words) == 1 {
+		return filterByPrefix(completionSubcommands, cur)
+	}
+
+	sub := words[0]
+	switch sub {
+	case "help":
+		if len(words) == 2 {
+			return filterByPrefix(completionSubcommands, cur)
+		}
+		return []string{}
+	case "completion":
+		if len(words) == 2 {
+			return filterByPrefix([]string{"bash", "fish", "zsh"}, cur)
+		}
+		return []string{}
+	case "scope":
+		if len(words) == 2 {
+			return filterByPrefix([]string{"init"}, cur)
+		}
+	case "dlq", "quarantine":
+		if len(words) == 2 {
+			return filterByPrefix([]string{"list", "purge"}, cur)
+		}
+	}
+
+	fs := completionFlagSet(sub, words)
+	if fs == nil {
+		return []string{}
+	}
+
+	prev := words[len(words)-2]
+	if f := fs.Lookup(strings.TrimLeft(prev, "-")); f != nil && strings.HasPrefix(prev, "-") && !isBoolFlag(f) {
+		if f.Name == "d" || f.Name == "d1" || f.Name == "d2" {
+			return filterByPrefix(completionDomains(words), cur)
+		}
+		return []string{}
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var flags []string
+
+		fs.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, "-"+f.Name)
+		})
+		sort.Strings(flags)
+		return filterByPrefix(flags, cur)
+	}
+	return []string{}
+}
+
+func completionFlagSet(sub string, words []string) *flag.FlagSet {
+	fs := flag.NewFlagSet(sub, flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+
+	var help bool
+	fs.BoolVar(&help, "h", false, "Show the program usage message")
+	fs.BoolVar(&help, "help", false, "Show the program usage message")
+
+	switch sub {
+	case "enum":
+		args := enumArgs{
+			AltWordList:       stringset.New(),
+			AltWordListMask:   stringset.New(),
+			BruteWordList:     stringset.New(),
+			BruteWordListMask: stringset.New(),
+			Blacklist:         stringset.New(),
+			Domains:           stringset.New(),
+			Excluded:          stringset.New(),
+			Included:          stringset.New(),
+			Names:             stringset.New(),
+			Resolvers:         stringset.New(),
+			Trusted:           stringset.New(),
+		}
+		defineEnumArgumentFlags(fs, &args)
+		defineEnumOptionFlags(fs, &args)
+		defineEnumFilepathFlags(fs, &args)
+	case "intel":
+		args := intelArgs{
+			Domains:   stringset.New(),
+			Excluded:  stringset.New(),
+			Included:  stringset.New(),
+			Resolvers: stringset.New(),
+		}
+		defineIntelArgumentFlags(fs, &args)
+		defineIntelOptionFlags(fs, &args)
+		defineIntelFilepathFlags(fs, &args)
+	case "coverage":
+		defineCoverageFlags(fs, &coverageArgs{Domains: stringset.New()})
+	case "compare":
+		defineCompareFlags(fs, &compareArgs{
+			Domains1: stringset.New(),
+			Domains2: stringset.New(),
+		})
+	case "diff":
+		defineDiffFlags(fs, &diffArgs{})
+	case "assoc":
+		defineAssocFlags(fs, &assocArgs{
+			Assets:  stringset.New(),
+			Domains: stringset.New(),
+		})
+	case "dlq":
+		defineDLQFlags(fs, &dlqArgs{
+			IDs:     stringset.New(),
+			Sources: stringset.New(),
+		})
+	case "quarantine":
+		defineQuarantineFlags(fs, &quarantineArgs{
+			Domains: stringset.New(),
+			Names:   stringset.New(),
+			Sources: stringset.New(),
+		})
+	case "maltego":
+		defineMaltegoFlags(fs, &maltegoArgs{})
+	case "webhook":
+		defineWebhookFlags(fs, &webhookArgs{Labels: stringset.New()})
+	case "scope":
+		if len(words) > 1 && words[1] == "init" {
+			defineScopeInitFlags(fs, &scopeInitArgs{Domains: stringset.New()})
+			break
+		}
+		return nil
+	default:
+		return nil
+	}
+	return fs
+}
+
+// completionDomains returns the registered domain names found in the graph database
+// selected by the -dir and -config flags on the command line.
+func completionDomains(words []string) []string {
+	var dir, file string
+
+	for i := 0; i < len(words)-1; i++ {
+		switch words[i] {
+		case "-dir", "--dir":
+			dir = words[i+1]
+		case "-config", "--config":
+			file = words[i+1]
+		}
+	}
+
+	cfg := config.NewConfig()
+	_ = config.AcquireConfig(dir, file, cfg)
+	if dir != "" {
+		cfg.Dir = dir
+	}
+
+	g := openGraphDatabase(cfg)
+	if g == nil {
+		return []string{}
+	}
+
+	assets, err := g.DB.FindByType(oam.FQDN, time.Time{})
+	if err != nil {
+		return []string{}
+	}
+
+	domains := stringset.New()
+	defer domains.Close()
+
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			if d, err := publicsuffix.EffectiveTLDPlusOne(fqdn.Name); err == nil {
+				domains.Insert(d)
+			}
+		}
+	}
+
+	list := domains.Slice()
+	sort.Strings(list)
+	return list
+}
+
+func filterByPrefix(candidates []string, prefix string) []string {
+	var matches []string
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+		return b.IsBoolFlag()
+	}
+	return false
+}
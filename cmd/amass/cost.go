@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jackc/pgx/v5"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// The estimated rows beyond which the graph queries are only executed with the '-force' flag
+	forceQueryRows = 10000000
+	// The estimated rows beyond which the progress of the graph queries is reported
+	progressQueryRows = 1000000
+	// The average size of the asset and relation rows in the local database file, along with their indexes
+	localRowBytes    = 256
+	progressInterval = 5 * time.Second
+)
+
+// estimateGraphRows returns the estimated number of asset and relation rows in the primary graph database, without scanning
+// the tables. PostgreSQL provides the estimate from its planner statistics, and the local database from the size of the file.
+func estimateGraphRows(ctx context.Context, cfg *config.Config) (int64, error) {
+	system, dsn := graphDatabaseDSN(cfg)
+
+	switch system {
+	case "":
+		return 0, nil
+	case "local":
+		info, err := os.Stat(dsn)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size() / localRowBytes, nil
+	}
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close(ctx)
+
+	var rows int64
+	err = conn.QueryRow(ctx, `SELECT COALESCE(SUM(GREATEST(reltuples, 0)), 0)::bigint
+		FROM pg_class WHERE relname IN ('assets', 'relations')`).Scan(&rows)
+	return rows, err
+}
+
+// checkGraphQueryCost estimates the cost of the graph queries and refuses the queries predicted
+// to scan tens of millions of rows, unless they are forced. The estimated rows are returned.
+func checkGraphQueryCost(ctx context.Context, cfg *config.Config, force bool) (int64, error) {
+	rows, err := estimateGraphRows(ctx, cfg)
+	if err != nil {
+		fgY.Fprintf(color.Error, "Failed to estimate the cost of the graph queries: %v\n", err)
+		return 0, nil
+	}
+
+	if rows >= forceQueryRows && !force {
+		return rows, fmt.Errorf("the graph queries are estimated to scan %d rows, which requires the '-force' flag", rows)
+	}
+	return rows, nil
+}
+
+// queryProgress reports the number of assets processed by the graph queries
+// estimated to scan millions of rows, so the long executions show their progress.
+type queryProgress struct {
+	label string
+	count int64
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newQueryProgress(label string, rows int64) *queryProgress {
+	p := &queryProgress{
+		label: label,
+		done:  make(chan struct{}),
+	}
+
+	if rows >= progressQueryRows {
+		fgY.Fprintf(color.Error, "The graph queries are estimated to scan %d rows\n", rows)
+		go p.report()
+	}
+	return p
+}
+
+func (p *queryProgress) add(n int) {
+	atomic.AddInt64(&p.count, int64(n))
+}
+
+func (p *queryProgress) stop() {
+	p.once.Do(func() { close(p.done) })
+}
+
+func (p *queryProgress) report() {
+	t := time.NewTicker(progressInterval)
+	defer t.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+			fgY.Fprintf(color.Error, "%s: %d assets processed in %s\n",
+				p.label, atomic.LoadInt64(&p.count), time.Since(start).Truncate(time.Second))
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/config/config"
+)
+
+const coverageUsageMsg = "coverage [options]"
+
+type coverageArgs struct {
+	Domains *stringset.Set
+	Top     int
+	Options struct {
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+// coverageEntry is the contribution made by a data source or technique.
+type coverageEntry struct {
+	Name      string `json:"name"`
+	Technique string `json:"technique,omitempty"`
+	Assets    int    `json:"assets"`
+	Unique    int    `json:"unique"`
+}
+
+type coverageReport struct {
+	Assets     int                       `json:"assets"`
+	Sources    []*coverageEntry          `json:"sources"`
+	Techniques []*coverageEntry          `json:"techniques"`
+	Overlap    map[string]map[string]int `json:"overlap"`
+}
+
+func defineCoverageFlags(coverageFlags *flag.FlagSet, args *coverageArgs) {
+	coverageFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	coverageFlags.IntVar(&args.Top, "top", 10, "Number of data sources shown in the overlap matrix")
+	coverageFlags.BoolVar(&args.Options.JSON, "json", false, "Print the report to stdout as JSON")
+	coverageFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	coverageFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	coverageFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runCoverageCommand(clArgs []string) {
+	args := coverageArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+
+	var help1, help2 bool
+	coverageCommand := flag.NewFlagSet("coverage", flag.ContinueOnError)
+
+	coverageBuf := new(bytes.Buffer)
+	coverageCommand.SetOutput(coverageBuf)
+
+	coverageCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	coverageCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCoverageFlags(coverageCommand, &args)
+
+	if err := coverageCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(coverageUsageMsg, coverageCommand, coverageBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err != nil && args.Filepaths.ConfigFile != "" {
+		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	records, err := enum.LoadCoverage(enum.CoveragePath(cfg))
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to read the coverage data: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := buildCoverageReport(records, args.Domains)
+	if args.Options.JSON {
+		_ = writeJSONLine(color.Output, report)
+		return
+	}
+	if report.Assets == 0 {
+		fgY.Fprintln(color.Error, "No coverage data was found. Coverage is recorded by enumerations")
+		return
+	}
+	printCoverageReport(report, args.Top)
+}
+
+func buildCoverageReport(records []*enum.CoverageRecord, domains *stringset.Set) *coverageReport {
+	assetSources := make(map[string]map[string]struct{})
+	assetTechniques := make(map[string]map[string]struct{})
+	srcTechnique := make(map[string]string)
+
+	for _, rec := range records {
+		if domains.Len() > 0 && !domains.Has(rec.Domain) {
+			continue
+		}
+
+		key := rec.Type + "|" + rec.Asset
+		if _, found := assetSources[key]; !found {
+			assetSources[key] = make(map[string]struct{})
+			assetTechniques[key] = make(map[string]struct{})
+		}
+		assetSources[key][rec.Source] = struct{}{}
+		assetTechniques[key][rec.Technique] = struct{}{}
+		srcTechnique[rec.Source] = rec.Technique
+	}
+
+	report := &coverageReport{
+		Assets:  len(assetSources),
+		Overlap: make(map[string]map[string]int),
+	}
+	sources := make(map[string]*coverageEntry)
+	techniques := make(map[string]*coverageEntry)
+
+	for key, srcs := range assetSources {
+		for src := range srcs {
+			entry, found := sources[src]
+			if !found {
+				entry = &coverageEntry{Name: src, Technique: srcTechnique[src]}
+				sources[src] = entry
+			}
+			entry.Assets++
+			if len(srcs) == 1 {
+				entry.Unique++
+			}
+
+			if _, found := report.Overlap[src]; !found {
+				report.Overlap[src] = make(map[string]int)
+			}
+			for other := range srcs {
+				report.Overlap[src][other]++
+			}
+		}
+
+		techs := assetTechniques[key]
+		for tech := range techs {
+			entry, found := techniques[tech]
+			if !found {
+				entry = &coverageEntry{Name: tech}
+				techniques[tech] = entry
+			}
+			entry.Assets++
+			if len(techs) == 1 {
+				entry.Unique++
+			}
+		}
+	}
+
+	report.Sources = sortedCoverageEntries(sources)
+	report.Techniques = sortedCoverageEntries(techniques)
+	return report
+}
+
+func sortedCoverageEntries(entries map[string]*coverageEntry) []*coverageEntry {
+	var list []*coverageEntry
+
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Unique != list[j].Unique {
+			return list[i].Unique > list[j].Unique
+		}
+		if list[i].Assets != list[j].Assets {
+			return list[i].Assets > list[j].Assets
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func printCoverageReport(report *coverageReport, top int) {
+	fmt.Fprintf(color.Output, "%s%s\n\n", blue("Unique assets: "), yellow(strconv.Itoa(report.Assets)))
+
+	fmt.Fprintf(color.Output, "%-35s%-20s%-15s%s\n", blue("Source"), blue("| Technique"), blue("| Assets"), blue("| Only Found By Source"))
+	for _, e := range report.Sources {
+		fmt.Fprintf(color.Output, "%-35s  %-20s  %-15s  %s\n", green(e.Name), white(e.Technique),
+			yellow(strconv.Itoa(e.Assets)), yellow(strconv.Itoa(e.Unique)))
+	}
+
+	fmt.Fprintf(color.Output, "\n%-35s%-15s%s\n", blue("Technique"), blue("| Assets"), blue("| Only Found By Technique"))
+	for _, e := range report.Techniques {
+		fmt.Fprintf(color.Output, "%-35s  %-15s  %s\n", green(e.Name),
+			yellow(strconv.Itoa(e.Assets)), yellow(strconv.Itoa(e.Unique)))
+	}
+
+	var names []string
+	for i, e := range report.Sources {
+		if top > 0 && i >= top {
+			break
+		}
+		names = append(names, e.Name)
+	}
+	if len(names) < 2 {
+		return
+	}
+	// The matrix shows the number of assets found by both the row and the column source
+	fmt.Fprintf(color.Output, "\n%s\n%-20s", blue("Overlap"), "")
+	for i := range names {
+		fmt.Fprintf(color.Output, "%8s", blue("["+strconv.Itoa(i+1)+"]"))
+	}
+	fmt.Fprintln(color.Output)
+	for i, row := range names {
+		label := row
+		if len(label) > 14 {
+			label = label[:14]
+		}
+
+		fmt.Fprintf(color.Output, "%-20s", green(fmt.Sprintf("[%d] %s", i+1, label)))
+		for _, col := range names {
+			fmt.Fprintf(color.Output, "%8s", yellow(strconv.Itoa(report.Overlap[row][col])))
+		}
+		fmt.Fprintln(color.Output)
+	}
+}
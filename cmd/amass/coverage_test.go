@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/enum"
+)
+
+func TestBuildCoverageReport(t *testing.T) {
+	records := []*enum.CoverageRecord{
+		{Asset: "www.owasp.org", Type: "fqdn", Domain: "owasp.org", Source: "crtsh", Technique: "cert"},
+		{Asset: "www.owasp.org", Type: "fqdn", Domain: "owasp.org", Source: "DNSDumpster", Technique: "scrape"},
+		{Asset: "api.owasp.org", Type: "fqdn", Domain: "owasp.org", Source: "crtsh", Technique: "cert"},
+		{Asset: "192.0.2.1", Type: "address", Domain: "owasp.org", Source: "DNSDumpster", Technique: "scrape"},
+		{Asset: "www.example.com", Type: "fqdn", Domain: "example.com", Source: "crtsh", Technique: "cert"},
+	}
+
+	domains := stringset.New("owasp.org")
+	defer domains.Close()
+
+	report := buildCoverageReport(records, domains)
+	if report.Assets != 3 {
+		t.Errorf("Expected 3 assets within the domain, got %d", report.Assets)
+	}
+
+	expected := map[string][2]int{
+		"crtsh":       {2, 1},
+		"DNSDumpster": {2, 1},
+	}
+	if len(report.Sources) != len(expected) {
+		t.Fatalf("Expected %d data sources, got %d", len(expected), len(report.Sources))
+	}
+	for _, entry := range report.Sources {
+		if e := expected[entry.Name]; entry.Assets != e[0] || entry.Unique != e[1] {
+			t.Errorf("%s: expected %d assets and %d unique, got %d and %d",
+				entry.Name, e[0], e[1], entry.Assets, entry.Unique)
+		}
+	}
+	if n := report.Overlap["crtsh"]["DNSDumpster"]; n != 1 {
+		t.Errorf("Expected the data sources to share 1 asset, got %d", n)
+	}
+	if n := report.Overlap["crtsh"]["crtsh"]; n != 2 {
+		t.Errorf("Expected the diagonal to hold the assets of the data source, got %d", n)
+	}
+	if len(report.Techniques) != 2 {
+		t.Errorf("Expected 2 techniques, got %d", len(report.Techniques))
+	}
+
+	all := stringset.New()
+	defer all.Close()
+	if report := buildCoverageReport(records, all); report.Assets != 4 {
+		t.Errorf("Expected 4 assets without a domain filter, got %d", report.Assets)
+	}
+}
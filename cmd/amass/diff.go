@@ -0,0 +1,321 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const diffUsageMsg = "diff [options] -old PATH -new PATH"
+
+type diffArgs struct {
+	Options struct {
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		Old  string
+		New  string
+		HTML string
+	}
+}
+
+// relationChange is an asset whose relations of a type differ between the reports, such as a name resolving to new addresses.
+type relationChange struct {
+	Asset    AssetSummary   `json:"asset"`
+	Relation string         `json:"relation"`
+	Before   []AssetSummary `json:"before"`
+	After    []AssetSummary `json:"after"`
+}
+
+type diffReport struct {
+	Old     string            `json:"old"`
+	New     string            `json:"new"`
+	Added   []*RelationOutput `json:"added"`
+	Removed []*RelationOutput `json:"removed"`
+	Changed []*relationChange `json:"changed"`
+}
+
+func defineDiffFlags(diffFlags *flag.FlagSet, args *diffArgs) {
+	diffFlags.BoolVar(&args.Options.JSON, "json", false, "Print the differences to stdout as JSON")
+	diffFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	diffFlags.StringVar(&args.Filepaths.Old, "old", "", "Path to the JSON lines report of the earlier enumeration")
+	diffFlags.StringVar(&args.Filepaths.New, "new", "", "Path to the JSON lines report of the later enumeration")
+	diffFlags.StringVar(&args.Filepaths.HTML, "html", "", "Path to the standalone HTML file rendering the differences")
+}
+
+func runDiffCommand(clArgs []string) {
+	var args diffArgs
+	var help1, help2 bool
+	diffCommand := flag.NewFlagSet("diff", flag.ContinueOnError)
+
+	diffBuf := new(bytes.Buffer)
+	diffCommand.SetOutput(diffBuf)
+
+	diffCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	diffCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineDiffFlags(diffCommand, &args)
+
+	if err := diffCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(diffUsageMsg, diffCommand, diffBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Filepaths.Old == "" || args.Filepaths.New == "" {
+		r.Fprintln(color.Error, "The reports must be provided using the '-old' and '-new' flags")
+		os.Exit(1)
+	}
+
+	before, err := readRelationReport(args.Filepaths.Old)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	after, err := readRelationReport(args.Filepaths.New)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	report := diffRelations(before, after)
+	report.Old = args.Filepaths.Old
+	report.New = args.Filepaths.New
+	if args.Filepaths.HTML != "" {
+		if err := writeDiffHTML(args.Filepaths.HTML, report); err != nil {
+			r.Fprintf(color.Error, "Failed to write the HTML report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.Options.JSON {
+		_ = writeJSONLine(color.Output, report)
+		return
+	}
+	printDiffReport(report)
+}
+
+// readRelationReport reads the relations of a report written by 'amass enum -json', skipping the other lines.
+func readRelationReport(path string) ([]*RelationOutput, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the report %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var rels []*RelationOutput
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rel RelationOutput
+
+		if err := json.Unmarshal(scanner.Bytes(), &rel); err != nil || rel.From.Name == "" || rel.Relation == "" {
+			continue
+		}
+		rels = append(rels, &rel)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the report %s: %v", path, err)
+	}
+	return rels, nil
+}
+
+// diffRelations compares the relations of the reports for each asset and relation type. The relations
+// of an asset are changed when the asset had relations of the type in both reports, and otherwise added or removed.
+func diffRelations(before, after []*RelationOutput) *diffReport {
+	old := groupRelations(before)
+	cur := groupRelations(after)
+	report := new(diffReport)
+
+	for key, rels := range cur {
+		prev, found := old[key]
+		if !found {
+			report.Added = append(report.Added, rels...)
+			continue
+		}
+
+		prevTo := relationTargets(prev)
+		curTo := relationTargets(rels)
+		if !sameTargets(prevTo, curTo) {
+			report.Changed = append(report.Changed, &relationChange{
+				Asset:    rels[0].From,
+				Relation: rels[0].Relation,
+				Before:   prevTo,
+				After:    curTo,
+			})
+		}
+	}
+	for key, rels := range old {
+		if _, found := cur[key]; !found {
+			report.Removed = append(report.Removed, rels...)
+		}
+	}
+
+	sortRelations(report.Added)
+	sortRelations(report.Removed)
+	sort.Slice(report.Changed, func(i, j int) bool {
+		a, b := report.Changed[i], report.Changed[j]
+		if a.Asset.Name != b.Asset.Name {
+			return a.Asset.Name < b.Asset.Name
+		}
+		return a.Relation < b.Relation
+	})
+	return report
+}
+
+func groupRelations(rels []*RelationOutput) map[string][]*RelationOutput {
+	groups := make(map[string][]*RelationOutput)
+
+	for _, rel := range rels {
+		key := rel.From.Type + "|" + rel.From.Name + "|" + rel.Relation
+		groups[key] = append(groups[key], rel)
+	}
+	return groups
+}
+
+func relationTargets(rels []*RelationOutput) []AssetSummary {
+	seen := make(map[AssetSummary]struct{})
+
+	var targets []AssetSummary
+	for _, rel := range rels {
+		if _, found := seen[rel.To]; !found {
+			seen[rel.To] = struct{}{}
+			targets = append(targets, rel.To)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Name != targets[j].Name {
+			return targets[i].Name < targets[j].Name
+		}
+		return targets[i].Type < targets[j].Type
+	})
+	return targets
+}
+
+func sameTargets(a, b []AssetSummary) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortRelations(rels []*RelationOutput) {
+	sort.Slice(rels, func(i, j int) bool {
+		a, b := rels[i], rels[j]
+		if a.From.Name != b.From.Name {
+			return a.From.Name < b.From.Name
+		}
+		if a.Relation != b.Relation {
+			return a.Relation < b.Relation
+		}
+		return a.To.Name < b.To.Name
+	})
+}
+
+func printDiffReport(report *diffReport) {
+	for _, rel := range report.Added {
+		fmt.Fprintf(color.Output, "%s %s\n", green("[Added]"), rel.String())
+	}
+	for _, rel := range report.Removed {
+		fmt.Fprintf(color.Output, "%s %s\n", r.Sprint("[Removed]"), rel.String())
+	}
+	for _, c := range report.Changed {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", yellow("[Changed]"), c.Asset.String(), magenta(c.Relation),
+			white(targetNames(c.Before)+" -> "+targetNames(c.After)))
+	}
+
+	fmt.Fprintf(color.Output, "\n%s%s %s%s %s%s\n", blue("Added: "), green(strconv.Itoa(len(report.Added))),
+		blue("Removed: "), r.Sprint(strconv.Itoa(len(report.Removed))), blue("Changed: "), yellow(strconv.Itoa(len(report.Changed))))
+}
+
+func targetNames(targets []AssetSummary) string {
+	var names []string
+
+	for _, t := range targets {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+var diffHTMLTemplate = template.Must(template.New("diff").Funcs(template.FuncMap{"names": targetNames}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Amass Report Differences</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #d0d7de; font-family: monospace; }
+th { font-family: inherit; background: #f6f8fa; }
+.summary span { display: inline-block; margin-right: 1.5em; font-weight: bold; }
+.added { background: #dafbe1; }
+.removed { background: #ffebe9; }
+.changed { background: #fff8c5; }
+.type { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>Amass Report Differences</h1>
+<p>{{.Old}} &rarr; {{.New}}, generated {{.Generated}}</p>
+<p class="summary"><span class="added">Added: {{len .Added}}</span><span class="removed">Removed: {{len .Removed}}</span><span class="changed">Changed: {{len .Changed}}</span></p>
+{{if .Added}}<h2>Added</h2>
+<table>
+<tr><th>Asset</th><th>Relation</th><th>Related Asset</th></tr>
+{{range .Added}}<tr class="added"><td>{{.From.Name}} <span class="type">({{.From.Type}})</span></td><td>{{.Relation}}</td><td>{{.To.Name}} <span class="type">({{.To.Type}})</span></td></tr>
+{{end}}</table>
+{{end}}{{if .Removed}}<h2>Removed</h2>
+<table>
+<tr><th>Asset</th><th>Relation</th><th>Related Asset</th></tr>
+{{range .Removed}}<tr class="removed"><td>{{.From.Name}} <span class="type">({{.From.Type}})</span></td><td>{{.Relation}}</td><td>{{.To.Name}} <span class="type">({{.To.Type}})</span></td></tr>
+{{end}}</table>
+{{end}}{{if .Changed}}<h2>Changed</h2>
+<table>
+<tr><th>Asset</th><th>Relation</th><th>Before</th><th>After</th></tr>
+{{range .Changed}}<tr class="changed"><td>{{.Asset.Name}} <span class="type">({{.Asset.Type}})</span></td><td>{{.Relation}}</td><td>{{names .Before}}</td><td>{{names .After}}</td></tr>
+{{end}}</table>
+{{end}}{{if not (or .Added .Removed .Changed)}}<p>The reports do not differ.</p>
+{{end}}</body>
+</html>
+`))
+
+// writeDiffHTML renders the differences as a standalone HTML file, without external resources, so it can be shared as is.
+func writeDiffHTML(path string, report *diffReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return diffHTMLTemplate.Execute(f, struct {
+		*diffReport
+		Generated string
+	}{
+		diffReport: report,
+		Generated:  time.Now().Format(time.RFC1123),
+	})
+}
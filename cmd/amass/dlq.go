@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const dlqUsageMsg = "dlq list|purge [options]"
+
+type dlqArgs struct {
+	IDs     *stringset.Set
+	Sources *stringset.Set
+	Options struct {
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineDLQFlags(dlqFlags *flag.FlagSet, args *dlqArgs) {
+	dlqFlags.Var(args.IDs, "id", "Dead letter IDs separated by commas (can be used multiple times)")
+	dlqFlags.Var(args.Sources, "src", "Data source names separated by commas (can be used multiple times)")
+	dlqFlags.BoolVar(&args.Options.JSON, "json", false, "Print the dead letters to stdout as JSON lines")
+	dlqFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	dlqFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	dlqFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runDLQCommand(clArgs []string) {
+	args := dlqArgs{
+		IDs:     stringset.New(),
+		Sources: stringset.New(),
+	}
+	defer args.IDs.Close()
+	defer args.Sources.Close()
+
+	var help1, help2 bool
+	dlqCommand := flag.NewFlagSet("dlq", flag.ContinueOnError)
+
+	dlqBuf := new(bytes.Buffer)
+	dlqCommand.SetOutput(dlqBuf)
+
+	dlqCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	dlqCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineDLQFlags(dlqCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(dlqUsageMsg, dlqCommand, dlqBuf)
+		return
+	}
+
+	action := clArgs[0]
+	if action != "list" && action != "purge" {
+		commandUsage(dlqUsageMsg, dlqCommand, dlqBuf)
+		if action != "-help" && action != "-h" {
+			os.Exit(1)
+		}
+		return
+	}
+	if err := dlqCommand.Parse(clArgs[1:]); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(dlqUsageMsg, dlqCommand, dlqBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err != nil && args.Filepaths.ConfigFile != "" {
+		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	dlq := systems.NewDeadLetterQueue(systems.DeadLetterPath(cfg))
+	letters, err := dlq.List()
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var selected []*systems.DeadLetter
+	for _, d := range letters {
+		if args.IDs.Len() > 0 && !args.IDs.Has(d.ID) {
+			continue
+		}
+		if args.Sources.Len() > 0 && !args.Sources.Has(d.Source) {
+			continue
+		}
+		selected = append(selected, d)
+	}
+
+	switch action {
+	case "list":
+		printDeadLetters(selected, args.Options.JSON)
+	case "purge":
+		var ids []string
+		for _, d := range selected {
+			ids = append(ids, d.ID)
+		}
+		if len(ids) > 0 {
+			if err := dlq.Remove(ids...); err != nil {
+				r.Fprintf(color.Error, "Failed to purge the dead-letter queue: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		g.Fprintf(color.Error, "%d dead letters were removed from the queue\n", len(ids))
+	}
+}
+
+func printDeadLetters(letters []*systems.DeadLetter, jsonOut bool) {
+	if jsonOut {
+		for _, d := range letters {
+			_ = writeJSONLine(color.Output, d)
+		}
+		return
+	}
+	if len(letters) == 0 {
+		g.Fprintln(color.Error, "The dead-letter queue is empty")
+		return
+	}
+
+	for _, d := range letters {
+		fmt.Fprintf(color.Output, "%s %s %s %s %s\n", yellow(d.ID), green(d.Source), blue(d.Kind),
+			white(d.Target()), magenta(d.Failed.Local().Format(time.RFC3339)))
+		fmt.Fprintf(color.Output, "\t%s%s\n", blue("Attempts: "), yellow(strconv.Itoa(d.Attempts)))
+		if d.Error != "" {
+			fmt.Fprintf(color.Output, "\t%s%s\n", blue("Error: "), d.Error)
+		}
+	}
+}
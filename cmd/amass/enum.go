@@ -17,6 +17,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -28,6 +30,9 @@ import (
 	"github.com/owasp-amass/amass/v4/datasrcs"
 	"github.com/owasp-amass/amass/v4/enum"
 	"github.com/owasp-amass/amass/v4/format"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/provenance"
 	"github.com/owasp-amass/amass/v4/resources"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
@@ -35,6 +40,13 @@ import (
 
 const enumUsageMsg = "enum [options] -d DOMAIN"
 
+// Settings used by the quick mode when they are not provided by the configuration
+const (
+	quickTimeout          = 5
+	quickMaxSourceResults = 250
+	quickMaxPages         = 2
+)
+
 type enumArgs struct {
 	Addresses         format.ParseIPs
 	ASNs              format.ParseInts
@@ -47,7 +59,9 @@ type enumArgs struct {
 	Domains           *stringset.Set
 	Excluded          *stringset.Set
 	Included          *stringset.Set
+	EventBudget       int
 	Interface         string
+	MaxBandwidth      int
 	MaxDNSQueries     int
 	ResolverQPS       int
 	TrustedQPS        int
@@ -56,6 +70,10 @@ type enumArgs struct {
 	Names             *stringset.Set
 	Ports             format.ParseInts
 	Resolvers         *stringset.Set
+	SOCKSProxy        string
+	SourceAddrs       format.ParseStrings
+	SourceRotation    string
+	TunnelInterface   string
 	Trusted           *stringset.Set
 	Timeout           int
 	Options           struct {
@@ -63,11 +81,15 @@ type enumArgs struct {
 		Alterations  bool
 		BruteForcing bool
 		DemoMode     bool
+		JSON         bool
 		ListSources  bool
 		NoAlts       bool
 		NoColor      bool
 		NoRecursive  bool
+		Offline      bool
 		Passive      bool
+		Quick        bool
+		ReplayFailed bool
 		Silent       bool
 		Verbose      bool
 	}
@@ -77,11 +99,11 @@ type enumArgs struct {
 		Blacklist        string
 		BruteWordlist    format.ParseStrings
 		ConfigFile       string
+		Datasets         format.ParseStrings
 		Directory        string
 		Domains          format.ParseStrings
 		ExcludedSrcs     string
 		IncludedSrcs     string
-		JSONOutput       string
 		LogFile          string
 		Names            format.ParseStrings
 		Resolvers        format.ParseStrings
@@ -101,9 +123,15 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
 	enumFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
 	enumFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
+	enumFlags.IntVar(&args.EventBudget, "event-budget", 0, "Seconds an event can spend in the enumeration before it is reported as slow")
 	enumFlags.StringVar(&args.Interface, "iface", "", "Provide the network interface to send traffic through")
+	enumFlags.IntVar(&args.MaxBandwidth, "max-bandwidth", 0, "Maximum number of bytes per second for HTTP and DNS traffic")
 	enumFlags.IntVar(&args.MaxDNSQueries, "max-dns-queries", 0, "Deprecated flag to be replaced by dns-qps in version 4.0")
 	enumFlags.IntVar(&args.MaxDNSQueries, "dns-qps", 0, "Maximum number of DNS queries per second across all resolvers")
+	enumFlags.StringVar(&args.SOCKSProxy, "socks", "", "SOCKS5 proxy URL, such as an SSH dynamic forward, to send the DNS and HTTP traffic through")
+	enumFlags.Var(&args.SourceAddrs, "source-addr", "Local IPs or interfaces separated by commas to spread the outbound connections across")
+	enumFlags.StringVar(&args.SourceRotation, "source-rotation", "", "Rotation of the source addresses: round_robin or affinity")
+	enumFlags.StringVar(&args.TunnelInterface, "tunnel-iface", "", "Tunnel interface, such as WireGuard, to send the DNS and HTTP traffic through")
 	enumFlags.IntVar(&args.ResolverQPS, "rqps", 0, "Maximum number of DNS queries per second for each untrusted resolver")
 	enumFlags.IntVar(&args.TrustedQPS, "trqps", 0, "Maximum number of DNS queries per second for each trusted resolver")
 	enumFlags.IntVar(&args.MaxDepth, "max-depth", 0, "Maximum number of subdomain labels for brute forcing")
@@ -118,11 +146,15 @@ func defineEnumOptionFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.BoolVar(&args.Options.Active, "active", false, "Attempt zone transfers and certificate name grabs")
 	enumFlags.BoolVar(&args.Options.BruteForcing, "brute", false, "Execute brute forcing after searches")
 	enumFlags.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
+	enumFlags.BoolVar(&args.Options.JSON, "json", false, "Print the results to stdout as JSON lines")
 	enumFlags.BoolVar(&args.Options.ListSources, "list", false, "Print the names of all available data sources")
 	enumFlags.BoolVar(&args.Options.Alterations, "alts", false, "Enable generation of altered names")
 	enumFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
 	enumFlags.BoolVar(&args.Options.NoRecursive, "norecursive", false, "Turn off recursive brute forcing")
+	enumFlags.BoolVar(&args.Options.Offline, "offline", false, "Operate only on local datasets with all network egress disabled")
 	enumFlags.BoolVar(&args.Options.Passive, "passive", false, "Deprecated since passive is the default setting")
+	enumFlags.BoolVar(&args.Options.Quick, "quick", false, "Quick reconnaissance with bounded results and a five minute timeout")
+	enumFlags.BoolVar(&args.Options.ReplayFailed, "replay-failed", false, "Replay the data source requests in the dead-letter queue")
 	enumFlags.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
 	enumFlags.BoolVar(&args.Options.Verbose, "v", false, "Output status / debug / troubleshooting info")
 }
@@ -133,6 +165,7 @@ func defineEnumFilepathFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.StringVar(&args.Filepaths.Blacklist, "blf", "", "Path to a file providing blacklisted subdomains")
 	enumFlags.Var(&args.Filepaths.BruteWordlist, "w", "Path to a different wordlist file for brute forcing")
 	enumFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	enumFlags.Var(&args.Filepaths.Datasets, "dataset", "Path to a zone file or passive DNS dump used in offline mode")
 	enumFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
 	enumFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
 	enumFlags.StringVar(&args.Filepaths.ExcludedSrcs, "ef", "", "Path to a file providing data sources to exclude")
@@ -175,6 +208,9 @@ func runEnumCommand(clArgs []string) {
 		r.Fprintf(color.Error, "%v\n", err)
 		os.Exit(1)
 	}
+	if options.Bool(cfg, "update_check") && !systems.Offline(cfg) {
+		printUpdateNotice()
+	}
 
 	// Setup the new enumeration
 	e := enum.NewEnumeration(cfg, sys, sys.GraphDatabases()[0])
@@ -184,21 +220,19 @@ func runEnumCommand(clArgs []string) {
 	}
 
 	var wg sync.WaitGroup
-	var outChans []chan string
+	var outChans []chan *RelationOutput
 	// This channel sends the signal for goroutines to terminate
 	done := make(chan struct{})
-	// Print output only if JSONOutput is not meant for STDOUT
-	if args.Filepaths.JSONOutput != "-" {
-		wg.Add(1)
-		// This goroutine will handle printing the output
-		printOutChan := make(chan string, 10)
-		go printOutput(e, args, printOutChan, &wg)
-		outChans = append(outChans, printOutChan)
-	}
+
+	wg.Add(1)
+	// This goroutine will handle printing the output
+	printOutChan := make(chan *RelationOutput, 10)
+	go printOutput(e, args, printOutChan, &wg)
+	outChans = append(outChans, printOutChan)
 
 	wg.Add(1)
 	// This goroutine will handle saving the output to the text file
-	txtOutChan := make(chan string, 10)
+	txtOutChan := make(chan *RelationOutput, 10)
 	go saveTextOutput(e, args, txtOutChan, &wg)
 	outChans = append(outChans, txtOutChan)
 
@@ -228,13 +262,376 @@ func runEnumCommand(clArgs []string) {
 	}(done, ctx, cancel)
 	// Start the enumeration process
 	if err := e.Start(ctx); err != nil {
-		r.Println(err)
+		r.Fprintln(color.Error, err)
 		os.Exit(1)
 	}
 	// Let all the output goroutines know that the enumeration has finished
 	close(done)
 	wg.Wait()
 	fmt.Fprintf(color.Error, "\n%s\n", green("The enumeration has finished"))
+	printCompletion(e)
+	printExposureStats(e)
+	printDualStackStats(e)
+	printHijackFindings(e)
+	printRegistrationAlerts(e)
+	printLookalikeFindings(e)
+	printIssuanceAlerts(e)
+	printOriginationAlerts(e)
+	printCriticalInfrastructure(e)
+	printIaCDrift(e)
+	printPrefixChanges(e)
+	printStaleNames(e)
+	printWordlistStats(e)
+	printBlockedEgress(e)
+	printSourceErrors()
+	if args.Options.Verbose {
+		printBandwidthStats()
+		printEventBudgetStats(e)
+	}
+}
+
+// printCompletion shows why the enumeration ended, so the enumerations that gave up are not mistaken for finished ones.
+func printCompletion(e *enum.Enumeration) {
+	c := e.Completion()
+	if c == nil || c.Reason == "" {
+		return
+	}
+
+	status := green(string(c.Reason))
+	if !c.Finished {
+		status = yellow(string(c.Reason))
+	}
+	fmt.Fprintf(color.Error, "%s %s %s\n", blue("Completion:"), status, white("("+c.Detail+")"))
+}
+
+// printUpdateNotice shows the release of the engine newer than the running version, when the check is enabled
+// by the 'update_check' option, so the deployments enforcing provenance learn of the releases to review.
+func printUpdateNotice() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rel, err := provenance.LatestRelease(ctx, provenance.ReleaseURL)
+	if err != nil {
+		fgY.Fprintf(color.Error, "Failed to check for a newer release: %v\n", err)
+		return
+	}
+	if provenance.NewerVersion(format.Version, rel.Version) {
+		fmt.Fprintf(color.Error, "%s %s %s\n", yellow("A newer release is available:"), green(rel.Version), white(rel.URL))
+	}
+}
+
+// printBlockedEgress shows the connections refused in offline mode, which confirms that nothing left the host,
+// and the connections refused outside the egress allowlist.
+func printBlockedEgress(e *enum.Enumeration) {
+	offline := systems.Offline(e.Config)
+	if !offline && !amassnet.EgressRestricted() {
+		return
+	}
+
+	blocked := amassnet.BlockedEgress()
+	if len(blocked) == 0 {
+		if offline {
+			fmt.Fprintf(color.Error, "%s\n", green("Offline mode: no network connections were attempted"))
+		}
+		return
+	}
+
+	mode := "Offline mode"
+	if !offline {
+		mode = "Egress allowlist"
+	}
+
+	var addrs []string
+	for addr := range blocked {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	fmt.Fprintf(color.Error, "%s\n", yellow(mode+": the following network connections were refused"))
+	for _, addr := range addrs {
+		fmt.Fprintf(color.Error, "%s %s %s\n", r.Sprint("[Blocked]"), white(addr), blue(strconv.Itoa(blocked[addr])+" attempts"))
+	}
+}
+
+// printSourceErrors shows the failures of the data sources, so the sources without results due to rejected credentials,
+// exhausted quotas and unexpected responses are known.
+func printSourceErrors() {
+	stats := amassnet.SourceErrorStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, c := range stats[name] {
+			fmt.Fprintf(color.Error, "%s %s %s\n", yellow("[Source Error]"), white(c.Last),
+				blue(fmt.Sprintf("(%s, %d times)", c.Kind, c.Count)))
+		}
+	}
+}
+
+func printBandwidthStats() {
+	stats := amassnet.BandwidthStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(color.Error, "\n%-35s%-20s%s\n", blue("Source"), blue("| Sent"), blue("| Received"))
+	for _, name := range names {
+		u := stats[name]
+
+		fmt.Fprintf(color.Error, "%-35s  %-20s  %s\n", green(name),
+			yellow(strconv.FormatUint(u.Sent, 10)), yellow(strconv.FormatUint(u.Received, 10)))
+	}
+}
+
+func printEventBudgetStats(e *enum.Enumeration) {
+	stats := e.EventBudgetStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(color.Error, "\n%-35s%s\n", blue("Bottleneck"), blue("| Events Over Budget"))
+	for _, name := range names {
+		fmt.Fprintf(color.Error, "%-35s  %s\n", green(name), yellow(strconv.Itoa(stats[name])))
+	}
+}
+
+// printExposureStats shows the number of email addresses for each domain name that
+// appear in known breaches, as reported by the data sources enriching the addresses.
+func printExposureStats(e *enum.Enumeration) {
+	exposed := make(map[string]int)
+	checked := make(map[string]int)
+
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "EmailAddress") {
+		v, found := f.Properties["breaches"]
+		if !found {
+			continue
+		}
+
+		checked[f.Domain]++
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			exposed[f.Domain]++
+		}
+	}
+	if len(checked) == 0 {
+		return
+	}
+
+	var domains []string
+	for d := range checked {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	fmt.Fprintf(color.Error, "\n%-35s%-20s%s\n", blue("Domain"), blue("| Emails Checked"), blue("| Found In Breaches"))
+	for _, d := range domains {
+		fmt.Fprintf(color.Error, "%-35s  %-20s  %s\n", green(d),
+			yellow(strconv.Itoa(checked[d])), r.Sprint(strconv.Itoa(exposed[d])))
+	}
+}
+
+// printDualStackStats shows the names and services that the enumeration found available
+// over only one of the IPv4 and IPv6 stacks, starting with the IPv6-only exposure.
+func printDualStackStats(e *enum.Enumeration) {
+	findings := e.Sys.Findings().Find(e.Config.CollectionStartTime, "FQDN", "Service")
+
+	var v4only, v6only []*systems.Finding
+	for _, f := range findings {
+		if f.Source != enum.DualStackSource {
+			continue
+		}
+
+		switch f.Relation {
+		case enum.RelationIPv4Only:
+			v4only = append(v4only, f)
+		case enum.RelationIPv6Only:
+			v6only = append(v6only, f)
+		}
+	}
+	if len(v4only) == 0 && len(v6only) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Error, "\n%-35s%-20s%s\n", blue("Dual-Stack Disparity"), blue("| Names"), blue("| Services"))
+	for _, row := range []struct {
+		label string
+		list  []*systems.Finding
+	}{
+		{"IPv6 Only", v6only},
+		{"IPv4 Only", v4only},
+	} {
+		var names, services int
+		for _, f := range row.list {
+			if f.Type == "Service" {
+				services++
+			} else {
+				names++
+			}
+		}
+		fmt.Fprintf(color.Error, "%-35s  %-20s  %s\n", green(row.label),
+			yellow(strconv.Itoa(names)), yellow(strconv.Itoa(services)))
+	}
+	for _, f := range v6only {
+		fmt.Fprintf(color.Error, "%s %s\n", r.Sprint("[IPv6 Only]"), white(f.Value))
+	}
+}
+
+// printHijackFindings shows the names whose addresses or name servers moved
+// to unrelated infrastructure since the previous enumerations.
+func printHijackFindings(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "FQDN", "Domain") {
+		if f.Source != enum.HijackSource {
+			continue
+		}
+
+		label := "[Addresses Moved]"
+		if f.Relation == enum.RelationNSMoved {
+			label = "[Name Servers Moved]"
+		}
+		fmt.Fprintf(color.Error, "%s %s %s %s %s\n", r.Sprint(label), white(f.Value),
+			yellow(f.Properties["previous"]), blue("->"), yellow(f.Properties["current"]))
+	}
+}
+
+// printRegistrationAlerts shows the registered domains in scope that are about to expire or lack a transfer lock.
+func printRegistrationAlerts(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "DomainRecord") {
+		if f.Properties["expiring"] == "true" {
+			fmt.Fprintf(color.Error, "%s %s %s\n", r.Sprint("[Expiring]"), white(f.Value),
+				yellow(f.Properties["days_to_expiration"]+" days"))
+		}
+		if f.Properties["transfer_unlocked"] == "true" {
+			fmt.Fprintf(color.Error, "%s %s\n", r.Sprint("[Transfer Unlocked]"), white(f.Value))
+		}
+	}
+}
+
+// printLookalikeFindings shows the newly registered domains impersonating the brands in scope.
+func printLookalikeFindings(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "Domain") {
+		if f.Source != enum.LookalikeSource {
+			continue
+		}
+
+		fmt.Fprintf(color.Error, "%s %s %s %s\n", r.Sprint("[Lookalike]"), white(f.Value),
+			yellow(f.Properties["token"]), blue("("+f.Properties["techniques"]+")"))
+	}
+}
+
+// printIssuanceAlerts shows the recent certificates issued by an unfamiliar authority or for names not in DNS.
+func printIssuanceAlerts(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "Certificate") {
+		if f.Properties["new_ca"] == "true" {
+			fmt.Fprintf(color.Error, "%s %s %s %s\n", r.Sprint("[New CA]"), white(f.Domain),
+				yellow(f.Properties["issuer"]), blue(f.Value))
+		}
+		if names := f.Properties["undeployed_names"]; names != "" {
+			fmt.Fprintf(color.Error, "%s %s %s\n", r.Sprint("[Not In DNS]"), white(names), blue(f.Value))
+		}
+	}
+}
+
+// printOriginationAlerts shows the netblocks announced by the ASNs in scope that are invalid or not found in RPKI.
+func printOriginationAlerts(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "Netblock") {
+		switch f.Properties["rpki"] {
+		case amassnet.RPKIInvalid:
+			fmt.Fprintf(color.Error, "%s %s AS%s %s\n", r.Sprint("[RPKI Invalid]"), white(f.Value),
+				f.Properties["asn"], yellow(f.Properties["reason"]))
+		case amassnet.RPKINotFound:
+			fmt.Fprintf(color.Error, "%s %s AS%s\n", yellow("[RPKI Not Found]"), white(f.Value), f.Properties["asn"])
+		}
+	}
+}
+
+// printCriticalInfrastructure shows the infrastructure most names in scope depend on, in the order of the ranking.
+func printCriticalInfrastructure(e *enum.Enumeration) {
+	findings := e.Sys.Findings().Find(e.Config.CollectionStartTime, "Infrastructure")
+	if len(findings) == 0 {
+		return
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, _ := strconv.Atoi(findings[i].Properties["rank"])
+		b, _ := strconv.Atoi(findings[j].Properties["rank"])
+		return a < b
+	})
+
+	fmt.Fprintf(color.Error, "\n%s\n", green("Critical infrastructure:"))
+	for _, f := range findings {
+		var cut string
+		if f.Properties["articulation"] == "true" && f.Properties["dependents"] != "0" {
+			cut = ", single point of failure"
+		}
+
+		fmt.Fprintf(color.Error, "%s %-12s %s %s\n", yellow("[Critical]"), blue(f.Properties["kind"]), green(f.Value),
+			white(fmt.Sprintf("(%s dependent names, degree %s, betweenness %s%s)", f.Properties["dependents"],
+				f.Properties["degree"], f.Properties["betweenness"], cut)))
+	}
+}
+
+// printIaCDrift shows the assets discovered but not declared by the infrastructure as code, and those declared but not discovered.
+func printIaCDrift(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "IaCDrift") {
+		switch f.Properties["drift"] {
+		case "shadow":
+			fmt.Fprintf(color.Error, "%s %-9s %s %s\n", r.Sprint("[Shadow]"), blue(f.Properties["kind"]),
+				green(f.Value), white("(not declared by the IaC baselines)"))
+		case "missing":
+			fmt.Fprintf(color.Error, "%s %-9s %s %s\n", yellow("[Missing]"), blue(f.Properties["kind"]),
+				green(f.Value), white(fmt.Sprintf("(declared by %s, not discovered)", f.Properties["resource"])))
+		}
+	}
+}
+
+// printPrefixChanges shows the prefixes announced or withdrawn by the ASNs in scope since the previous enumerations.
+func printPrefixChanges(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "PrefixChange") {
+		switch f.Properties["change"] {
+		case "announced":
+			fmt.Fprintf(color.Error, "%s %s %s\n", g.Sprint("[BGP Announced]"), green(f.Value),
+				white(fmt.Sprintf("(AS%s, not announced by the previous enumerations)", f.Properties["asn"])))
+		case "withdrawn":
+			fmt.Fprintf(color.Error, "%s %s %s\n", yellow("[BGP Withdrawn]"), green(f.Value),
+				white(fmt.Sprintf("(AS%s, not announced during this enumeration)", f.Properties["asn"])))
+		}
+	}
+}
+
+// printStaleNames shows the names that resolved previously and did not resolve during the recent enumerations.
+func printStaleNames(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "Stale") {
+		fmt.Fprintf(color.Error, "%s %s %s\n", yellow("[Gone Dark]"), green(f.Value), white(fmt.Sprintf("(missed %s enumerations, last resolved %s)",
+			f.Properties["missed_runs"], f.Properties["last_resolved"])))
+	}
+}
+
+// printWordlistStats shows the number of words of each managed wordlist that found names during the enumeration.
+func printWordlistStats(e *enum.Enumeration) {
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "Wordlist") {
+		rate, _ := strconv.ParseFloat(f.Properties["hit_rate"], 64)
+
+		fmt.Fprintf(color.Error, "%s %s %s\n", blue("[Wordlist]"), green(f.Value), white(fmt.Sprintf("(%s of %s words found names, %.2f%%)",
+			f.Properties["hits"], f.Properties["words"], rate*100)))
+	}
 }
 
 func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
@@ -327,8 +724,15 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 		r.Fprintf(color.Error, "Configuration error: %v\n", err)
 		os.Exit(1)
 	}
+	applyQuickMode(cfg, &args)
 	// Check if the user has requested the data source names
 	if args.Options.ListSources {
+		if args.Options.JSON {
+			for _, entry := range GetAllSourceEntries(cfg) {
+				_ = writeJSONLine(color.Output, entry)
+			}
+			return nil, &args
+		}
 		for _, line := range GetAllSourceInfo(cfg) {
 			fmt.Fprintln(color.Output, line)
 		}
@@ -346,22 +750,47 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 	return cfg, &args
 }
 
-func printOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg *sync.WaitGroup) {
+// applyQuickMode bounds the enumeration for triage when the 'quick' option is enabled. Brute forcing,
+// name alterations and name guessing are skipped, the results and pages requested from each data source
+// are limited unless configured otherwise, and the enumeration stops after five minutes when no timeout was provided.
+func applyQuickMode(cfg *config.Config, args *enumArgs) {
+	if !options.Bool(cfg, "quick") {
+		return
+	}
+
+	cfg.BruteForcing = false
+	cfg.Alterations = false
+	if _, found := cfg.Options["max_source_results"]; !found {
+		cfg.Options["max_source_results"] = quickMaxSourceResults
+	}
+	if _, found := cfg.Options["max_pages"]; !found {
+		cfg.Options["max_pages"] = quickMaxPages
+	}
+	if args.Timeout == 0 {
+		args.Timeout = quickTimeout
+	}
+}
+
+func printOutput(e *enum.Enumeration, args *enumArgs, output chan *RelationOutput, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	var total int
 	// Print all the output returned by the enumeration
 	for out := range output {
-		fmt.Fprintf(color.Output, "%s\n", out)
+		if args.Options.JSON {
+			_ = writeJSONLine(color.Output, out)
+		} else {
+			fmt.Fprintf(color.Output, "%s\n", out)
+		}
 		total++
 	}
 
 	if total == 0 {
-		r.Println("No assets were discovered")
+		r.Fprintln(color.Error, "No assets were discovered")
 	}
 }
 
-func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg *sync.WaitGroup) {
+func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan *RelationOutput, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	dir := config.OutputDirectory(e.Config.Dir)
@@ -395,7 +824,7 @@ func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg
 	}
 }
 
-func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, outputs []chan string, done chan struct{}, wg *sync.WaitGroup) {
+func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, outputs []chan *RelationOutput, done chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer func() {
 		// Signal all the other output goroutines to terminate
@@ -583,11 +1012,44 @@ func (e enumArgs) OverrideConfig(conf *config.Config) error {
 	if len(e.Ports) > 0 {
 		conf.Scope.Ports = e.Ports
 	}
+	if e.MaxBandwidth > 0 {
+		conf.Options["bandwidth_limit"] = e.MaxBandwidth
+	}
+	if e.EventBudget > 0 {
+		conf.Options["event_budget"] = e.EventBudget
+	}
+	if e.Options.ReplayFailed {
+		conf.Options["replay_dead_letters"] = true
+	}
+	if e.Options.Quick {
+		conf.Options["quick"] = true
+	}
+	if e.Options.Offline {
+		conf.Options["offline"] = true
+	}
+	if e.SOCKSProxy != "" {
+		conf.Options["socks_proxy"] = e.SOCKSProxy
+	}
+	if e.TunnelInterface != "" {
+		conf.Options["tunnel_interface"] = e.TunnelInterface
+	}
+	if len(e.SourceAddrs) > 0 {
+		conf.Options["source_addresses"] = strings.Join(e.SourceAddrs, ",")
+	}
+	if e.SourceRotation != "" {
+		conf.Options["source_rotation"] = e.SourceRotation
+	}
+	if len(e.Filepaths.Datasets) > 0 {
+		conf.Options["offline_datasets"] = append(options.Strings(conf, "offline_datasets"), e.Filepaths.Datasets...)
+	}
 	if e.Filepaths.Directory != "" {
 		conf.Dir = e.Filepaths.Directory
 	}
 	if e.Filepaths.ScriptsDirectory != "" {
 		conf.ScriptsDirectory = e.Filepaths.ScriptsDirectory
+	} else if dir := options.String(conf, "scripts_directory"); dir != "" {
+		// The configuration provides the directory when the sessions are started without the flag, such as by the webhook
+		conf.ScriptsDirectory = dir
 	}
 	if e.Names.Len() > 0 {
 		conf.ProvidedNames = e.Names.Slice()
@@ -604,6 +1066,9 @@ func (e enumArgs) OverrideConfig(conf *config.Config) error {
 	if e.Options.Alterations {
 		conf.Alterations = true
 	}
+	// The flags override the recursion settings of the bruteforce section
+	bruteForceSettings(conf)
+	alterationSettings(conf)
 	if e.Options.NoRecursive {
 		conf.Recursive = false
 	}
@@ -643,6 +1108,49 @@ func (e enumArgs) OverrideConfig(conf *config.Config) error {
 	return nil
 }
 
+// bruteForceSettings applies the 'recursive', 'minimum_for_recursive' and 'max_depth' settings of the bruteforce section.
+func bruteForceSettings(conf *config.Config) {
+	bf := options.Table(conf, "bruteforce")
+	if bf == nil {
+		return
+	}
+
+	if v, ok := bf["recursive"].(bool); ok {
+		conf.Recursive = v
+	}
+	if v, ok := bf["minimum_for_recursive"].(int); ok && v >= 0 {
+		conf.MinForRecursive = v
+	}
+	if v, ok := bf["max_depth"].(int); ok && v >= 0 {
+		conf.MaxDepth = v
+	}
+}
+
+// alterationSettings applies the 'flip_words', 'flip_numbers', 'add_words', 'add_numbers' and 'edit_distance'
+// settings of the alterations section.
+func alterationSettings(conf *config.Config) {
+	alt := options.Table(conf, "alterations")
+	if alt == nil {
+		return
+	}
+
+	if v, ok := alt["flip_words"].(bool); ok {
+		conf.FlipWords = v
+	}
+	if v, ok := alt["flip_numbers"].(bool); ok {
+		conf.FlipNumbers = v
+	}
+	if v, ok := alt["add_words"].(bool); ok {
+		conf.AddWords = v
+	}
+	if v, ok := alt["add_numbers"].(bool); ok {
+		conf.AddNumbers = v
+	}
+	if v, ok := alt["edit_distance"].(int); ok && v >= 0 {
+		conf.EditDistance = v
+	}
+}
+
 func getWordList(reader io.Reader) ([]string, error) {
 	var words []string
 
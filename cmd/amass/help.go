@@ -23,6 +23,26 @@ func runHelpCommand(clArgs []string) {
 		runEnumCommand(help)
 	case "intel":
 		runIntelCommand(help)
+	case "scope":
+		runScopeCommand(help)
+	case "coverage":
+		runCoverageCommand(help)
+	case "compare":
+		runCompareCommand(help)
+	case "diff":
+		runDiffCommand(help)
+	case "assoc":
+		runAssocCommand(help)
+	case "dlq":
+		runDLQCommand(help)
+	case "quarantine":
+		runQuarantineCommand(help)
+	case "maltego":
+		runMaltegoCommand(help)
+	case "webhook":
+		runWebhookCommand(help)
+	case "completion":
+		runCompletionCommand(help)
 	default:
 		commandUsage(mainUsageMsg, helpCommand, helpBuf)
 		return
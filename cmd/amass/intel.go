@@ -15,6 +15,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -49,6 +50,7 @@ type intelArgs struct {
 		IPs          bool
 		IPv4         bool
 		IPv6         bool
+		JSON         bool
 		ListSources  bool
 		ReverseWhois bool
 		Verbose      bool
@@ -85,6 +87,7 @@ func defineIntelOptionFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 	intelFlags.BoolVar(&args.Options.IPs, "ip", false, "Show the IP addresses for discovered names")
 	intelFlags.BoolVar(&args.Options.IPv4, "ipv4", false, "Show the IPv4 addresses for discovered names")
 	intelFlags.BoolVar(&args.Options.IPv6, "ipv6", false, "Show the IPv6 addresses for discovered names")
+	intelFlags.BoolVar(&args.Options.JSON, "json", false, "Print the results to stdout as JSON lines")
 	intelFlags.BoolVar(&args.Options.ListSources, "list", false, "Print additional information")
 	intelFlags.BoolVar(&args.Options.ReverseWhois, "whois", false, "All provided domains are run through reverse whois")
 	intelFlags.BoolVar(&args.Options.Verbose, "v", false, "Output status / debug / troubleshooting info")
@@ -173,6 +176,12 @@ func runIntelCommand(clArgs []string) {
 
 	// Check if the user requested data source information
 	if args.Options.ListSources && len(args.ASNs) == 0 {
+		if args.Options.JSON {
+			for _, entry := range GetAllSourceEntries(cfg) {
+				_ = writeJSONLine(color.Output, entry)
+			}
+			return
+		}
 		for _, info := range GetAllSourceInfo(cfg) {
 			g.Println(info)
 		}
@@ -204,13 +213,13 @@ func runIntelCommand(clArgs []string) {
 			asns = append(asns, entry.ASN)
 		}
 		if len(asns) > 0 {
-			printNetblocks(asns, cfg, sys)
+			printNetblocks(asns, cfg, sys, args.Options.JSON)
 		}
 		return
 	}
 	// Check if the user requested additional ASN & netblock information
 	if args.Options.ListSources && len(args.ASNs) > 0 {
-		printNetblocks(args.ASNs, cfg, sys)
+		printNetblocks(args.ASNs, cfg, sys, args.Options.JSON)
 		return
 	}
 
@@ -259,7 +268,18 @@ func runIntelCommand(clArgs []string) {
 	}
 }
 
-func printNetblocks(asns []int, cfg *config.Config, sys systems.System) {
+type netblocksEntry struct {
+	ASN         int      `json:"asn"`
+	Description string   `json:"description"`
+	Netblocks   []string `json:"netblocks"`
+}
+
+type intelEntry struct {
+	Domain    string   `json:"domain"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+func printNetblocks(asns []int, cfg *config.Config, sys systems.System, jsonOut bool) {
 	for _, asn := range asns {
 		systems.PopulateCache(context.Background(), asn, sys)
 
@@ -268,6 +288,15 @@ func printNetblocks(asns []int, cfg *config.Config, sys systems.System) {
 			continue
 		}
 
+		if jsonOut {
+			_ = writeJSONLine(color.Output, &netblocksEntry{
+				ASN:         asn,
+				Description: d.Description,
+				Netblocks:   d.Netblocks,
+			})
+			continue
+		}
+
 		fmt.Printf("%s%s %s %s\n", blue("ASN: "), yellow(strconv.Itoa(asn)), green("-"), green(d.Description))
 		for _, cidr := range d.Netblocks {
 			fmt.Printf("%s\n", yellow(fmt.Sprintf("\t%s", cidr)))
@@ -304,11 +333,21 @@ func processIntelOutput(ic *intel.Collection, args *intelArgs) bool {
 	for out := range ic.Output {
 		_, ips := format.OutputLineParts(out, args.Options.IPs || args.Options.IPv4 || args.Options.IPv6, args.Options.DemoMode)
 
+		if args.Options.JSON {
+			entry := &intelEntry{Domain: out.Domain}
+			if ips != "" {
+				entry.Addresses = strings.Split(ips, ",")
+			}
+			_ = writeJSONLine(color.Output, entry)
+		}
+
 		if ips != "" {
 			ips = " " + ips
 		}
 
-		fmt.Fprintf(color.Output, "%s%s\n", green(out.Domain), yellow(ips))
+		if !args.Options.JSON {
+			fmt.Fprintf(color.Output, "%s%s\n", green(out.Domain), yellow(ips))
+		}
 		// Handle writing the line to a specified output file
 		if outptr != nil {
 			fmt.Fprintf(outptr, "%s%s\n", out.Domain, ips)
@@ -6,7 +6,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/caffix/stringset"
 	"github.com/owasp-amass/amass/v4/enum"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/asset-db/types"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
@@ -22,8 +25,37 @@ import (
 	"golang.org/x/net/publicsuffix"
 )
 
-func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, since time.Time) []string {
-	var output []string
+// AssetSummary is the name and type of an asset included in the program output.
+type AssetSummary struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// RelationOutput represents a relationship between two assets discovered by the enumeration.
+type RelationOutput struct {
+	From     AssetSummary `json:"from"`
+	Relation string       `json:"relation"`
+	To       AssetSummary `json:"to"`
+}
+
+// String returns the colorized line printed to the terminal for the relationship.
+func (r *RelationOutput) String() string {
+	arrow := white("-->")
+
+	return fmt.Sprintf("%s %s %s %s %s", r.From.String(), arrow, magenta(r.Relation), arrow, r.To.String())
+}
+
+// String returns the colorized name and type of the asset.
+func (a AssetSummary) String() string {
+	if a.Name == "" {
+		return ""
+	}
+	return green(a.Name) + blue(" ("+a.Type+")")
+}
+
+// NewOutput returns the relationships discovered by the enumeration since the provided time.
+func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, since time.Time) []*RelationOutput {
+	var output []*RelationOutput
 
 	// Make sure a filter has been created
 	if filter == nil {
@@ -38,10 +70,9 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 		}
 	}
 
-	arrow := white("-->")
 	start := e.Config.CollectionStartTime.UTC()
 	for _, from := range assets {
-		fromstr := extractAssetName(from)
+		fromsum := extractAssetSummary(from)
 
 		if rels, err := g.DB.OutgoingRelations(from, start); err == nil {
 			for _, rel := range rels {
@@ -50,47 +81,68 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 					continue
 				}
 				if to, err := g.DB.FindById(rel.ToAsset.ID, start); err == nil {
-					tostr := extractAssetName(to)
-
-					output = append(output, fmt.Sprintf("%s %s %s %s %s", fromstr, arrow, magenta(rel.Type), arrow, tostr))
+					output = append(output, &RelationOutput{
+						From:     fromsum,
+						Relation: rel.Type,
+						To:       extractAssetSummary(to),
+					})
 					filter.Insert(lineid)
 				}
 			}
 		}
 	}
+	// Include the findings that are not kept in the graph database, such as email addresses
+	for _, f := range e.Sys.Findings().Find(since) {
+		lineid := "finding|" + f.Type + "|" + f.Value + "|" + f.Domain
+		if f.Domain == "" || filter.Has(lineid) {
+			continue
+		}
+
+		output = append(output, &RelationOutput{
+			From:     AssetSummary{Name: f.Domain, Type: "FQDN"},
+			Relation: f.Relation,
+			To:       AssetSummary{Name: f.Value, Type: f.Type},
+		})
+		filter.Insert(lineid)
+	}
 
 	return output
 }
 
-func extractAssetName(a *types.Asset) string {
-	var result string
+func extractAssetSummary(a *types.Asset) AssetSummary {
+	var result AssetSummary
 
 	switch a.Asset.AssetType() {
 	case oam.FQDN:
 		if fqdn, ok := a.Asset.(domain.FQDN); ok {
-			result = green(fqdn.Name) + blue(" (FQDN)")
+			result = AssetSummary{Name: fqdn.Name, Type: "FQDN"}
 		}
 	case oam.IPAddress:
 		if ip, ok := a.Asset.(network.IPAddress); ok {
-			result = green(ip.Address.String()) + blue(" (IPAddress)")
+			result = AssetSummary{Name: ip.Address.String(), Type: "IPAddress"}
 		}
 	case oam.ASN:
 		if asn, ok := a.Asset.(network.AutonomousSystem); ok {
-			result = green(strconv.Itoa(asn.Number)) + blue(" (ASN)")
+			result = AssetSummary{Name: strconv.Itoa(asn.Number), Type: "ASN"}
 		}
 	case oam.RIROrg:
 		if rir, ok := a.Asset.(network.RIROrganization); ok {
-			result = green(rir.RIRId+rir.Name) + blue(" (RIROrganization)")
+			result = AssetSummary{Name: rir.RIRId + rir.Name, Type: "RIROrganization"}
 		}
 	case oam.Netblock:
 		if nb, ok := a.Asset.(network.Netblock); ok {
-			result = green(nb.Cidr.String()) + blue(" (Netblock)")
+			result = AssetSummary{Name: nb.Cidr.String(), Type: "Netblock"}
 		}
 	}
 
 	return result
 }
 
+// writeJSONLine writes the JSON encoding of v to the writer followed by a newline.
+func writeJSONLine(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
 // ExtractOutput is a convenience method for obtaining new discoveries made by the enumeration process.
 func ExtractOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, asinfo bool) []*requests.Output {
 	return EventOutput(ctx, g, e.Config.Domains(), e.Config.CollectionStartTime, filter, asinfo, e.Sys.Cache())
@@ -149,16 +201,24 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 		lookup[n] = o
 	}
 	// Build the lookup map used to create the final result set
-	if pairs, err := g.NamesToAddrs(ctx, qtime, names...); err == nil {
-		for _, p := range pairs {
-			addr := p.Addr.Address.String()
+	records := make(chan *systems.NameAddrRecord, systems.DefaultNameBatchSize)
+	go func() { _ = systems.NamesToAddrRecordsStream(ctx, g, qtime, 0, records, names...) }()
 
-			if p.FQDN.Name == "" || addr == "" {
-				continue
-			}
-			if o, found := lookup[p.FQDN.Name]; found {
-				o.Addresses = append(o.Addresses, requests.AddressInfo{Address: net.ParseIP(addr)})
-			}
+	for r := range records {
+		addr := r.Addr.Address.String()
+
+		if r.FQDN.Name == "" || addr == "" {
+			continue
+		}
+		if o, found := lookup[r.FQDN.Name]; found {
+			first, last := r.FirstSeen, r.LastSeen
+			o.Addresses = append(o.Addresses, requests.AddressInfo{
+				Address:    net.ParseIP(addr),
+				RecordType: r.RecordType,
+				Via:        r.Via,
+				FirstSeen:  &first,
+				LastSeen:   &last,
+			})
 		}
 	}
 
@@ -199,6 +259,10 @@ func addInfrastructureInfo(lookup outLookup, filter *stringset.Set, cache *reque
 				CIDRStr:     i.Prefix,
 				Netblock:    netblock,
 				Description: i.Description,
+				RecordType:  a.RecordType,
+				Via:         a.Via,
+				FirstSeen:   a.FirstSeen,
+				LastSeen:    a.LastSeen,
 			})
 		}
 
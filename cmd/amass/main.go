@@ -29,7 +29,9 @@ import (
 	"net"
 	"os"
 	"path"
+	"path/filepath"
 
+	"github.com/caffix/netmap"
 	"github.com/caffix/service"
 	"github.com/fatih/color"
 	"github.com/owasp-amass/amass/v4/datasrcs"
@@ -40,7 +42,7 @@ import (
 )
 
 const (
-	mainUsageMsg         = "intel|enum [options]"
+	mainUsageMsg         = "intel|enum|scope|coverage|compare|diff|assoc|dlq|quarantine|maltego|webhook|completion [options]"
 	exampleConfigFileURL = "https://github.com/owasp-amass/amass/blob/master/examples/config.yaml"
 	userGuideURL         = "https://github.com/owasp-amass/amass/blob/master/doc/user_guide.md"
 	tutorialURL          = "https://github.com/owasp-amass/amass/blob/master/doc/tutorial.md"
@@ -69,6 +71,16 @@ func commandUsage(msg string, cmdFlagSet *flag.FlagSet, errBuf *bytes.Buffer) {
 		g.Fprintf(color.Error, "\nSubcommands: \n\n")
 		g.Fprintf(color.Error, "\t%-11s - Discover targets for enumerations\n", "amass intel")
 		g.Fprintf(color.Error, "\t%-11s - Perform enumerations and network mapping\n", "amass enum")
+		g.Fprintf(color.Error, "\t%-11s - Build the scope of an enumeration\n", "amass scope")
+		g.Fprintf(color.Error, "\t%-11s - Report the assets contributed by each data source\n", "amass coverage")
+		g.Fprintf(color.Error, "\t%-11s - Analyze the infrastructure shared by two scopes\n", "amass compare")
+		g.Fprintf(color.Error, "\t%-11s - Render the differences between two enumeration reports\n", "amass diff")
+		g.Fprintf(color.Error, "\t%-11s - Explain why assets are associated with the target\n", "amass assoc")
+		g.Fprintf(color.Error, "\t%-11s - Inspect the data source requests that failed\n", "amass dlq")
+		g.Fprintf(color.Error, "\t%-11s - Review the names quarantined as junk\n", "amass quarantine")
+		g.Fprintf(color.Error, "\t%-11s - Execute the Maltego local transforms on the graph database\n", "amass maltego")
+		g.Fprintf(color.Error, "\t%-11s - Start the enumerations requested by other systems\n", "amass webhook")
+		g.Fprintf(color.Error, "\t%-11s - Generate shell completion scripts\n", "amass completion")
 	}
 
 	g.Fprintln(color.Error)
@@ -110,6 +122,26 @@ func main() {
 		runEnumCommand(os.Args[2:])
 	case "intel":
 		runIntelCommand(os.Args[2:])
+	case "scope":
+		runScopeCommand(os.Args[2:])
+	case "coverage":
+		runCoverageCommand(os.Args[2:])
+	case "compare":
+		runCompareCommand(os.Args[2:])
+	case "diff":
+		runDiffCommand(os.Args[2:])
+	case "assoc":
+		runAssocCommand(os.Args[2:])
+	case "dlq":
+		runDLQCommand(os.Args[2:])
+	case "quarantine":
+		runQuarantineCommand(os.Args[2:])
+	case "maltego":
+		runMaltegoCommand(os.Args[2:])
+	case "webhook":
+		runWebhookCommand(os.Args[2:])
+	case "completion":
+		runCompletionCommand(os.Args[2:])
 	case "help":
 		runHelpCommand(os.Args[2:])
 	default:
@@ -118,27 +150,66 @@ func main() {
 	}
 }
 
+type dataSourceEntry struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Available bool   `json:"available"`
+}
+
 // GetAllSourceInfo returns the output for the 'list' flag.
 func GetAllSourceInfo(cfg *config.Config) []string {
+	return formatSourceEntries(GetAllSourceEntries(cfg))
+}
+
+// GetAllSourceEntries returns the data source details used by the 'list' flag.
+func GetAllSourceEntries(cfg *config.Config) []dataSourceEntry {
 	if cfg == nil {
 		cfg = config.NewConfig()
 	}
 
 	sys, err := systems.NewLocalSystem(cfg)
 	if err != nil {
-		return []string{}
+		return []dataSourceEntry{}
 	}
 	defer func() { _ = sys.Shutdown() }()
 
 	srcs := datasrcs.SelectedDataSources(cfg, datasrcs.GetAllSources(sys))
 	if err := sys.SetDataSources(srcs); err != nil {
-		return []string{}
+		return []dataSourceEntry{}
 	}
-	return DataSourceInfo(srcs, sys)
+	return sourceEntries(srcs, sys)
 }
 
 // DataSourceInfo acquires the information for data sources used by the provided System.
 func DataSourceInfo(all []service.Service, sys systems.System) []string {
+	return formatSourceEntries(sourceEntries(all, sys))
+}
+
+func sourceEntries(all []service.Service, sys systems.System) []dataSourceEntry {
+	var entries []dataSourceEntry
+
+	available := sys.DataSources()
+	for _, src := range all {
+		var avail bool
+
+		for _, a := range available {
+			if src.String() == a.String() {
+				avail = true
+				break
+			}
+		}
+
+		entries = append(entries, dataSourceEntry{
+			Name:      src.String(),
+			Type:      src.Description(),
+			Available: avail,
+		})
+	}
+
+	return entries
+}
+
+func formatSourceEntries(entries []dataSourceEntry) []string {
 	var names []string
 
 	names = append(names, fmt.Sprintf("%-35s%-35s%s", blue("Data Source"), blue("| Type"), blue("| Available")))
@@ -148,24 +219,62 @@ func DataSourceInfo(all []service.Service, sys systems.System) []string {
 	}
 	names = append(names, line)
 
-	available := sys.DataSources()
-	for _, src := range all {
+	for _, entry := range entries {
 		var avail string
 
-		for _, a := range available {
-			if src.String() == a.String() {
-				avail = "*"
-				break
-			}
+		if entry.Available {
+			avail = "*"
 		}
 
 		names = append(names, fmt.Sprintf("%-35s  %-35s  %s",
-			green(src.String()), yellow(src.Description()), yellow(avail)))
+			green(entry.Name), yellow(entry.Type), yellow(avail)))
 	}
 
 	return names
 }
 
+// openGraphDatabase returns the primary graph database identified by the configuration.
+// The local database is only opened when it already exists in the output directory.
+func openGraphDatabase(cfg *config.Config) *netmap.Graph {
+	db := primaryGraphDatabase(cfg)
+	if db == nil {
+		return nil
+	}
+
+	system, dsn := graphDatabaseDSN(cfg)
+	if system == "local" {
+		if _, err := os.Stat(dsn); err != nil {
+			return nil
+		}
+	}
+	return netmap.NewGraph(system, dsn, db.Options)
+}
+
+func primaryGraphDatabase(cfg *config.Config) *config.Database {
+	dbs := append([]*config.Database{}, cfg.GraphDBs...)
+	dbs = append(dbs, cfg.LocalDatabaseSettings(cfg.GraphDBs))
+
+	for _, db := range dbs {
+		if db.Primary {
+			return db
+		}
+	}
+	return nil
+}
+
+// graphDatabaseDSN returns the system of the primary graph database, along with the path of the local database
+// file or the connection string of the database server. The system is empty without a primary database.
+func graphDatabaseDSN(cfg *config.Config) (string, string) {
+	db := primaryGraphDatabase(cfg)
+	if db == nil {
+		return "", ""
+	}
+	if db.System == "local" {
+		return db.System, filepath.Join(config.OutputDirectory(cfg.Dir), "amass.sqlite")
+	}
+	return db.System, fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
+}
+
 func createOutputDirectory(cfg *config.Config) {
 	// Prepare output file paths
 	dir := config.OutputDirectory(cfg.Dir)
@@ -0,0 +1,235 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+const (
+	maltegoUsageMsg = "maltego [options] -transform subdomains|names|asns ENTITY [PROPERTIES]"
+	// maltegoTimeout is the time allowed to query the graph database before Maltego gives up on the transform
+	maltegoTimeout = 2 * time.Minute
+)
+
+// maltegoTransforms are the local transforms provided by the bridge, along with the Maltego entity type they return.
+var maltegoTransforms = map[string]string{
+	"subdomains": "maltego.DNSName",
+	"names":      "maltego.DNSName",
+	"asns":       "maltego.AS",
+}
+
+type maltegoArgs struct {
+	Transform string
+	Options   struct {
+		Max int
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+// maltegoMessage is written to stdout for Maltego, holding either the entities returned by the transform or its exceptions.
+type maltegoMessage struct {
+	XMLName   xml.Name          `xml:"MaltegoMessage"`
+	Response  *maltegoResponse  `xml:"MaltegoTransformResponseMessage,omitempty"`
+	Exception *maltegoException `xml:"MaltegoTransformExceptionMessage,omitempty"`
+}
+
+type maltegoResponse struct {
+	Entities []*maltegoEntity    `xml:"Entities>Entity"`
+	Messages []*maltegoUIMessage `xml:"UIMessages>UIMessage"`
+}
+
+type maltegoEntity struct {
+	Type   string          `xml:"Type,attr"`
+	Value  string          `xml:"Value"`
+	Weight int             `xml:"Weight"`
+	Fields []*maltegoField `xml:"AdditionalFields>Field,omitempty"`
+}
+
+type maltegoField struct {
+	Name        string `xml:"Name,attr"`
+	DisplayName string `xml:"DisplayName,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type maltegoUIMessage struct {
+	Type string `xml:"MessageType,attr"`
+	Text string `xml:",chardata"`
+}
+
+type maltegoException struct {
+	Exceptions []string `xml:"Exceptions>Exception"`
+}
+
+func defineMaltegoFlags(maltegoFlags *flag.FlagSet, args *maltegoArgs) {
+	maltegoFlags.StringVar(&args.Transform, "transform", "", "Transform executed on the entity: subdomains, names or asns")
+	maltegoFlags.IntVar(&args.Options.Max, "max", 0, "Maximum number of entities returned by the transform (0 returns all of them)")
+	maltegoFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	maltegoFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+// runMaltegoCommand executes a local transform of Maltego on the graph database. Maltego appends the value
+// of the entity and its properties to the parameters of the transform, and reads the results from stdout.
+func runMaltegoCommand(clArgs []string) {
+	var args maltegoArgs
+	var help1, help2 bool
+	maltegoCommand := flag.NewFlagSet("maltego", flag.ContinueOnError)
+
+	maltegoBuf := new(bytes.Buffer)
+	maltegoCommand.SetOutput(maltegoBuf)
+
+	maltegoCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	maltegoCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineMaltegoFlags(maltegoCommand, &args)
+
+	if err := maltegoCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(maltegoUsageMsg, maltegoCommand, maltegoBuf)
+		return
+	}
+	// Maltego shows the exceptions of the transform to the analyst, so the failures are written to stdout
+	if _, found := maltegoTransforms[args.Transform]; !found {
+		writeMaltegoException(os.Stdout, "The '-transform' flag must be subdomains, names or asns")
+		return
+	}
+	if maltegoCommand.NArg() < 1 || strings.TrimSpace(maltegoCommand.Arg(0)) == "" {
+		writeMaltegoException(os.Stdout, "The value of the entity was not provided by Maltego")
+		return
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err != nil && args.Filepaths.ConfigFile != "" {
+		writeMaltegoException(os.Stdout, fmt.Sprintf("Failed to load the configuration file: %v", err))
+		return
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	g := openGraphDatabase(cfg)
+	if g == nil {
+		writeMaltegoException(os.Stdout, fmt.Sprintf("Failed to open the graph database in %s", config.OutputDirectory(cfg.Dir)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maltegoTimeout)
+	defer cancel()
+
+	entities, err := maltegoTransform(ctx, g, args.Transform, strings.TrimSpace(maltegoCommand.Arg(0)))
+	if err != nil {
+		writeMaltegoException(os.Stdout, err.Error())
+		return
+	}
+
+	resp := &maltegoResponse{Entities: entities}
+	if args.Options.Max > 0 && len(entities) > args.Options.Max {
+		resp.Entities = entities[:args.Options.Max]
+		resp.Messages = append(resp.Messages, &maltegoUIMessage{
+			Type: "PartialError",
+			Text: fmt.Sprintf("Returned %d of the %d entities found in the graph database", args.Options.Max, len(entities)),
+		})
+	}
+	if err := writeMaltegoMessage(os.Stdout, &maltegoMessage{Response: resp}); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// maltegoTransform returns the entities related to the value in the graph database: the subdomains of a domain name,
+// the names resolving to an IP address, or the autonomous systems managed by an organization.
+func maltegoTransform(ctx context.Context, g *netmap.Graph, transform, value string) ([]*maltegoEntity, error) {
+	var entities []*maltegoEntity
+
+	switch transform {
+	case "subdomains":
+		for _, name := range readSubdomains(g, strings.ToLower(strings.TrimSuffix(value, "."))) {
+			entities = append(entities, &maltegoEntity{Type: maltegoTransforms[transform], Value: name, Weight: 100})
+		}
+	case "names":
+		names, err := systems.ReadAddrNames(ctx, g, value, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			entities = append(entities, &maltegoEntity{Type: maltegoTransforms[transform], Value: name, Weight: 100})
+		}
+	case "asns":
+		asns, err := systems.ReadOrgASNs(ctx, g, value, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, asn := range asns {
+			ent := &maltegoEntity{Type: maltegoTransforms[transform], Value: strconv.Itoa(asn), Weight: 100}
+			if desc := g.ReadASDescription(ctx, asn, time.Time{}); desc != "" {
+				ent.Fields = append(ent.Fields, &maltegoField{Name: "description", DisplayName: "Description", Value: desc})
+			}
+			entities = append(entities, ent)
+		}
+	}
+	return entities, ctx.Err()
+}
+
+// readSubdomains returns the names stored in the graph database within the domain, excluding the domain itself.
+func readSubdomains(g *netmap.Graph, d string) []string {
+	assets, err := g.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]struct{})
+	for _, a := range assets {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok || !strings.HasSuffix(fqdn.Name, "."+d) {
+			continue
+		}
+		if _, found := seen[fqdn.Name]; !found {
+			seen[fqdn.Name] = struct{}{}
+			names = append(names, fqdn.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func writeMaltegoException(w io.Writer, msg string) {
+	_ = writeMaltegoMessage(w, &maltegoMessage{
+		Exception: &maltegoException{Exceptions: []string{msg}},
+	})
+}
+
+func writeMaltegoMessage(w io.Writer, msg *maltegoMessage) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(msg); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
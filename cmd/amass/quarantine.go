@@ -0,0 +1,156 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const quarantineUsageMsg = "quarantine list|purge [options]"
+
+type quarantineArgs struct {
+	Domains *stringset.Set
+	Names   *stringset.Set
+	Sources *stringset.Set
+	Options struct {
+		JSON    bool
+		NoColor bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineQuarantineFlags(qFlags *flag.FlagSet, args *quarantineArgs) {
+	qFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	qFlags.Var(args.Names, "name", "Quarantined names separated by commas (can be used multiple times)")
+	qFlags.Var(args.Sources, "src", "Data source names separated by commas (can be used multiple times)")
+	qFlags.BoolVar(&args.Options.JSON, "json", false, "Print the quarantined names to stdout as JSON lines")
+	qFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	qFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file")
+	qFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runQuarantineCommand(clArgs []string) {
+	args := quarantineArgs{
+		Domains: stringset.New(),
+		Names:   stringset.New(),
+		Sources: stringset.New(),
+	}
+	defer args.Domains.Close()
+	defer args.Names.Close()
+	defer args.Sources.Close()
+
+	var help1, help2 bool
+	qCommand := flag.NewFlagSet("quarantine", flag.ContinueOnError)
+
+	qBuf := new(bytes.Buffer)
+	qCommand.SetOutput(qBuf)
+
+	qCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	qCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineQuarantineFlags(qCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(quarantineUsageMsg, qCommand, qBuf)
+		return
+	}
+
+	action := clArgs[0]
+	if action != "list" && action != "purge" {
+		commandUsage(quarantineUsageMsg, qCommand, qBuf)
+		if action != "-help" && action != "-h" {
+			os.Exit(1)
+		}
+		return
+	}
+	if err := qCommand.Parse(clArgs[1:]); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(quarantineUsageMsg, qCommand, qBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err != nil && args.Filepaths.ConfigFile != "" {
+		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	q := systems.NewQuarantine(systems.QuarantinePath(cfg))
+	entries, err := q.List()
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var selected []*systems.QuarantinedName
+	for _, n := range entries {
+		if args.Domains.Len() > 0 && !args.Domains.Has(n.Domain) {
+			continue
+		}
+		if args.Names.Len() > 0 && !args.Names.Has(n.Name) {
+			continue
+		}
+		if args.Sources.Len() > 0 && !args.Sources.Has(n.Source) {
+			continue
+		}
+		selected = append(selected, n)
+	}
+
+	switch action {
+	case "list":
+		printQuarantinedNames(selected, args.Options.JSON)
+	case "purge":
+		var names []string
+		for _, n := range selected {
+			names = append(names, n.Name)
+		}
+		if len(names) > 0 {
+			if err := q.Remove(names...); err != nil {
+				r.Fprintf(color.Error, "Failed to purge the quarantine: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		g.Fprintf(color.Error, "%d names were removed from the quarantine\n", len(names))
+	}
+}
+
+func printQuarantinedNames(entries []*systems.QuarantinedName, jsonOut bool) {
+	if jsonOut {
+		for _, n := range entries {
+			_ = writeJSONLine(color.Output, n)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		g.Fprintln(color.Error, "The quarantine is empty")
+		return
+	}
+
+	for _, n := range entries {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", white(n.Name), green(n.Source),
+			yellow(strings.Join(n.Reasons, ",")), magenta(n.Quarantined.Local().Format(time.RFC3339)))
+	}
+}
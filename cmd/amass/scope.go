@@ -0,0 +1,254 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	scopeUsageMsg     = "scope init [options]"
+	scopeInitUsageMsg = "scope init [options] -org NAME"
+	rdapAutnumURL     = "https://rdap.org/autnum/"
+)
+
+type scopeInitArgs struct {
+	Domains          *stringset.Set
+	OrganizationName string
+	Options          struct {
+		NoColor bool
+		Yes     bool
+	}
+	Filepaths struct {
+		Directory string
+		Output    string
+	}
+}
+
+func defineScopeInitFlags(scopeFlags *flag.FlagSet, args *scopeInitArgs) {
+	scopeFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	scopeFlags.StringVar(&args.OrganizationName, "org", "", "Search string provided against AS description information")
+	scopeFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	scopeFlags.BoolVar(&args.Options.Yes, "y", false, "Accept every ASN found without prompting")
+	scopeFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	scopeFlags.StringVar(&args.Filepaths.Output, "o", "", "Path to the configuration file that will be written")
+}
+
+func runScopeCommand(clArgs []string) {
+	scopeBuf := new(bytes.Buffer)
+	scopeCommand := flag.NewFlagSet("scope", flag.ContinueOnError)
+	scopeCommand.SetOutput(scopeBuf)
+
+	if len(clArgs) < 1 || clArgs[0] != "init" {
+		commandUsage(scopeUsageMsg, scopeCommand, scopeBuf)
+		if len(clArgs) > 0 && clArgs[0] != "-help" && clArgs[0] != "-h" {
+			os.Exit(1)
+		}
+		return
+	}
+	runScopeInitCommand(clArgs[1:])
+}
+
+func runScopeInitCommand(clArgs []string) {
+	args := scopeInitArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+
+	var help1, help2 bool
+	scopeCommand := flag.NewFlagSet("scope init", flag.ContinueOnError)
+
+	scopeBuf := new(bytes.Buffer)
+	scopeCommand.SetOutput(scopeBuf)
+
+	scopeCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	scopeCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineScopeInitFlags(scopeCommand, &args)
+
+	if err := scopeCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(scopeInitUsageMsg, scopeCommand, scopeBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if args.OrganizationName == "" {
+		args.OrganizationName = prompt(in, "Organization name: ")
+	}
+	if args.Domains.Len() == 0 {
+		_ = args.Domains.Set(prompt(in, "Root domain names (comma separated): "))
+	}
+	if args.OrganizationName == "" && args.Domains.Len() == 0 {
+		r.Fprintln(color.Error, "An organization name or root domain name must be provided")
+		os.Exit(1)
+	}
+
+	var asns []*requests.ASNRequest
+	if args.OrganizationName != "" {
+		asns = selectScopeASNs(in, args.OrganizationName, args.Options.Yes)
+	}
+
+	path := args.Filepaths.Output
+	if path == "" {
+		path = filepath.Join(config.OutputDirectory(args.Filepaths.Directory), "config.yaml")
+	}
+	if _, err := os.Stat(path); err == nil && !args.Options.Yes {
+		if !confirm(in, fmt.Sprintf("Overwrite the existing file %s? [y/N]: ", path), false) {
+			return
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.Fprintf(color.Error, "Failed to create the directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	domains := args.Domains.Slice()
+	sort.Strings(domains)
+	writeScopeConfig(f, domains, asns)
+	g.Fprintf(color.Error, "The configuration file was written to %s\n", path)
+}
+
+func selectScopeASNs(in *bufio.Reader, org string, yes bool) []*requests.ASNRequest {
+	cache := requests.NewASNCache()
+	if err := systems.LoadCacheData(cache); err != nil {
+		r.Fprintf(color.Error, "Failed to load the ASN data: %v\n", err)
+		return nil
+	}
+
+	matches := make(map[int]*requests.ASNRequest)
+	for _, s := range []string{org, strings.ToUpper(org)} {
+		for _, entry := range cache.DescriptionSearch(s) {
+			matches[entry.ASN] = entry
+		}
+	}
+	if len(matches) == 0 {
+		fgY.Fprintf(color.Error, "No autonomous systems were found matching %s\n", org)
+		return nil
+	}
+
+	var keys []int
+	for asn := range matches {
+		keys = append(keys, asn)
+	}
+	sort.Ints(keys)
+
+	var selected []*requests.ASNRequest
+	for _, asn := range keys {
+		entry := matches[asn]
+
+		fmt.Fprintf(color.Output, "%s%s %s %s\n", blue("ASN: "), yellow(strconv.Itoa(asn)), green("-"), green(entry.Description))
+		if name := rdapAutnumName(asn); name != "" {
+			fmt.Fprintf(color.Output, "\t%s%s\n", blue("RDAP: "), white(name))
+		}
+		fmt.Fprintf(color.Output, "\t%s%s\n", blue("Netblocks: "), yellow(strconv.Itoa(len(entry.Netblocks))))
+
+		if yes || confirm(in, "Include this ASN in the scope? [Y/n]: ", true) {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+// rdapAutnumName returns the registered name for the ASN, or an empty string when RDAP is unavailable.
+func rdapAutnumName(asn int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	resp, err := http.RequestWebPage(ctx, &http.Request{URL: rdapAutnumURL + strconv.Itoa(asn)})
+	if err != nil || resp.StatusCode != 200 {
+		return ""
+	}
+
+	var autnum struct {
+		Handle string `json:"handle"`
+		Name   string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &autnum); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(autnum.Handle + " " + autnum.Name)
+}
+
+func writeScopeConfig(w io.Writer, domains []string, asns []*requests.ASNRequest) {
+	fmt.Fprintln(w, "scope:")
+	if len(domains) > 0 {
+		fmt.Fprintln(w, "  domains: # domain names to be in scope")
+		for _, d := range domains {
+			fmt.Fprintf(w, "    - %s\n", d)
+		}
+	}
+	if len(asns) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "  asns: # ASNs that are to be in scope")
+	for _, a := range asns {
+		fmt.Fprintf(w, "    - %d # %s\n", a.ASN, a.Description)
+	}
+
+	cidrs := stringset.New()
+	defer cidrs.Close()
+
+	for _, a := range asns {
+		cidrs.InsertMany(a.Netblocks...)
+	}
+	if cidrs.Len() == 0 {
+		return
+	}
+
+	list := cidrs.Slice()
+	sort.Strings(list)
+	fmt.Fprintln(w, "  cidrs: # CIDR ranges that are to be in scope")
+	for _, cidr := range list {
+		fmt.Fprintf(w, "    - %s\n", cidr)
+	}
+}
+
+func prompt(in *bufio.Reader, msg string) string {
+	fmt.Fprint(color.Output, white(msg))
+
+	line, _ := in.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func confirm(in *bufio.Reader, msg string, def bool) bool {
+	switch strings.ToLower(prompt(in, msg)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
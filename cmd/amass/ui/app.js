@@ -0,0 +1,515 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// The web UI of 'amass webhook -ui', which calls the sessions API with the token entered by the user.
+// The values shown were collected from untrusted sources, so they are only written as text content.
+"use strict";
+
+const tokenKey = "amass-webhook-token";
+// The assets shown by a search at most, while the download provides every asset found
+const maxAssetRows = 500;
+// The relations drawn around an asset at most, while the table lists every relation returned
+const maxGraphNodes = 60;
+// The assets the graph database can find by name, so they can become the center of the graph
+const browsable = new Set(["FQDN", "IPAddress", "Netblock", "ASN"]);
+const svgNS = "http://www.w3.org/2000/svg";
+
+const $ = (id) => document.getElementById(id);
+
+let config = { write: false };
+
+function token() {
+  return sessionStorage.getItem(tokenKey) || "";
+}
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function api(path, options = {}) {
+  const headers = Object.assign({ Authorization: "Bearer " + token() }, options.headers || {});
+  const resp = await fetch(path, Object.assign({}, options, { headers }));
+  if (resp.ok) {
+    return resp;
+  }
+
+  let msg = resp.statusText;
+  try {
+    msg = (await resp.json()).error || msg;
+  } catch (e) {
+    // The body did not hold the error of the API
+  }
+  if (resp.status === 401) {
+    sessionStorage.removeItem(tokenKey);
+  }
+  throw new APIError(resp.status, msg);
+}
+
+async function apiJSON(path, options) {
+  return (await api(path, options)).json();
+}
+
+function sessionPath(id) {
+  return "/sessions/" + encodeURIComponent(id);
+}
+
+function showError(err) {
+  if (err instanceof APIError && err.status === 401) {
+    showLogin();
+  }
+  $("error").textContent = err.message || String(err);
+  $("error").hidden = false;
+}
+
+function clearError() {
+  $("error").hidden = true;
+}
+
+function el(tag, text, className) {
+  const e = document.createElement(tag);
+  if (text !== undefined && text !== null) {
+    e.textContent = text;
+  }
+  if (className) {
+    e.className = className;
+  }
+  return e;
+}
+
+function link(text, onclick) {
+  const a = el("a", text, "link");
+  a.href = "#";
+  a.addEventListener("click", (ev) => {
+    ev.preventDefault();
+    onclick();
+  });
+  return a;
+}
+
+function row(cells) {
+  const tr = document.createElement("tr");
+  for (const c of cells) {
+    const td = document.createElement("td");
+    if (c instanceof Node) {
+      td.appendChild(c);
+    } else {
+      td.textContent = c === undefined || c === null ? "" : String(c);
+    }
+    tr.appendChild(td);
+  }
+  return tr;
+}
+
+function clear(node) {
+  while (node.firstChild) {
+    node.removeChild(node.firstChild);
+  }
+}
+
+function when(t) {
+  return t ? new Date(t).toLocaleString() : "";
+}
+
+function labelText(labels) {
+  return Object.keys(labels || {}).sort().map((k) => (labels[k] ? k + "=" + labels[k] : k)).join(" ");
+}
+
+function parseLabels(text) {
+  const labels = {};
+  for (const l of text.split(/\s+/).filter(Boolean)) {
+    const i = l.indexOf("=");
+    if (i < 0) {
+      labels[l] = "";
+    } else {
+      labels[l.slice(0, i)] = l.slice(i + 1);
+    }
+  }
+  return labels;
+}
+
+function saveBlob(blob, filename) {
+  const url = URL.createObjectURL(blob);
+  const a = document.createElement("a");
+  a.href = url;
+  a.download = filename;
+  document.body.appendChild(a);
+  a.click();
+  a.remove();
+  setTimeout(() => URL.revokeObjectURL(url), 1000);
+}
+
+function showOnly(section) {
+  for (const id of ["login", "sessions", "session"]) {
+    $(id).hidden = id !== section;
+  }
+  $("logout").hidden = section === "login";
+}
+
+function showLogin() {
+  showOnly("login");
+  $("token").focus();
+}
+
+// route shows the view selected by the fragment of the location, such as '#session=nightly&asset=www.example.com'.
+function route() {
+  clearError();
+  if (!token()) {
+    showLogin();
+    return;
+  }
+
+  const params = new URLSearchParams(location.hash.slice(1));
+  const id = params.get("session");
+  if (id) {
+    showSession(id, params.get("asset")).catch(showError);
+  } else {
+    showSessions().catch(showError);
+  }
+}
+
+function navigate(params) {
+  const hash = "#" + new URLSearchParams(params).toString();
+  if ((location.hash || "#") === hash) {
+    route();
+  } else {
+    location.hash = hash;
+  }
+}
+
+async function showSessions() {
+  showOnly("sessions");
+  $("start-form").hidden = !config.write;
+
+  const q = new URLSearchParams();
+  for (const l of $("label-filter").value.split(/\s+/).filter(Boolean)) {
+    q.append("label", l);
+  }
+  const path = "/sessions" + (q.toString() ? "?" + q.toString() : "");
+  const list = await apiJSON(path);
+
+  const rows = $("session-rows");
+  clear(rows);
+  list.sort((a, b) => (b.started || "").localeCompare(a.started || ""));
+  for (const s of list) {
+    rows.appendChild(row([
+      link(s.session, () => navigate({ session: s.session })),
+      (s.domains || []).join(", "),
+      s.reason ? s.status + " (" + s.reason + ")" : s.status,
+      s.runs,
+      when(s.started),
+      when(s.finished),
+      labelText(s.labels),
+    ]));
+  }
+  if (list.length === 0) {
+    rows.appendChild(row(["No sessions were found"]));
+  }
+}
+
+let current = "";
+
+async function showSession(id, asset) {
+  showOnly("session");
+  $("session-title").textContent = id;
+
+  if (current !== id) {
+    current = id;
+    $("search").value = "";
+    clear($("asset-rows"));
+    $("search-status").textContent = "";
+    $("graph").hidden = true;
+  }
+
+  const s = await apiJSON(sessionPath(id));
+  const dl = $("session-state");
+  clear(dl);
+  const state = [
+    ["Domains", (s.domains || []).join(", ")],
+    ["Status", s.reason ? s.status + " (" + s.reason + ")" : s.status],
+    ["Runs", s.runs],
+    ["Started", when(s.started)],
+    ["Finished", when(s.finished)],
+    ["Labels", labelText(s.labels)],
+    ["Error", s.error],
+  ];
+  for (const [k, v] of state) {
+    if (v === undefined || v === "") {
+      continue;
+    }
+    dl.appendChild(el("dt", k));
+    dl.appendChild(el("dd", v));
+  }
+
+  await Promise.all([showArtifacts(id), asset ? showGraph(id, asset) : Promise.resolve()]);
+  if (!asset) {
+    $("graph").hidden = true;
+  }
+}
+
+async function showArtifacts(id) {
+  const list = await apiJSON(sessionPath(id) + "/artifacts");
+  const rows = $("artifact-rows");
+  clear(rows);
+
+  for (const a of list) {
+    rows.appendChild(row([
+      link(a.path, () => downloadArtifact(id, a.path).catch(showError)),
+      a.size,
+      when(a.modified),
+    ]));
+  }
+  if (list.length === 0) {
+    rows.appendChild(row(["The session has no artifacts"]));
+  }
+}
+
+async function downloadArtifact(id, path) {
+  const p = path.split("/").map(encodeURIComponent).join("/");
+  const resp = await api(sessionPath(id) + "/artifacts/" + p);
+  saveBlob(await resp.blob(), path.split("/").pop());
+}
+
+function assetsPath(id) {
+  const q = $("search").value.trim();
+  return sessionPath(id) + "/assets" + (q ? "?q=" + encodeURIComponent(q) : "");
+}
+
+// searchAssets reads the assets streamed by the server, stopping once the rows shown are full.
+async function searchAssets(id) {
+  const rows = $("asset-rows");
+  clear(rows);
+  $("search-status").textContent = "Searching...";
+
+  const resp = await api(assetsPath(id));
+  const reader = resp.body.getReader();
+  const decoder = new TextDecoder();
+
+  let buf = "";
+  let count = 0;
+  let full = false;
+  while (!full) {
+    const { done, value } = await reader.read();
+    if (done) {
+      break;
+    }
+
+    buf += decoder.decode(value, { stream: true });
+    const lines = buf.split("\n");
+    buf = lines.pop();
+    for (const line of lines) {
+      if (!line.trim()) {
+        continue;
+      }
+      if (count === maxAssetRows) {
+        full = true;
+        break;
+      }
+
+      const a = JSON.parse(line);
+      const name = browsable.has(a.type) ? link(a.name, () => navigate({ session: id, asset: a.name })) : a.name;
+      rows.appendChild(row([name, a.type, when(a.last_seen)]));
+      count++;
+    }
+  }
+  if (full) {
+    await reader.cancel();
+    $("search-status").textContent = "Showing the first " + maxAssetRows + " assets, download the results to obtain all of them";
+  } else {
+    $("search-status").textContent = count + " assets found";
+  }
+}
+
+async function exportAssets(id) {
+  const resp = await api(assetsPath(id));
+  const q = $("search").value.trim().replace(/[^A-Za-z0-9_.-]/g, "_");
+  saveBlob(await resp.blob(), id + (q ? "-" + q : "") + "-assets.ndjson");
+}
+
+async function showGraph(id, asset) {
+  $("graph").hidden = false;
+  $("graph-title").textContent = asset;
+  $("graph-status").textContent = "Loading...";
+
+  const n = await apiJSON(sessionPath(id) + "/graph?asset=" + encodeURIComponent(asset));
+  const rows = $("relation-rows");
+  clear(rows);
+
+  const center = n.asset.name;
+  for (const r of n.relations) {
+    rows.appendChild(row([
+      graphLink(id, r.from, center),
+      r.type,
+      graphLink(id, r.to, center),
+      when(r.last_seen),
+    ]));
+  }
+
+  let status = n.relations.length + " relations";
+  if (n.truncated) {
+    status = "Showing the first " + n.relations.length + " relations of the asset";
+  }
+  $("graph-status").textContent = status;
+  drawGraph(id, n);
+}
+
+function graphLink(id, a, center) {
+  if (a.name === center || !browsable.has(a.type)) {
+    return a.name + " (" + a.type + ")";
+  }
+  return link(a.name + " (" + a.type + ")", () => navigate({ session: id, asset: a.name }));
+}
+
+function svg(tag, attrs, text) {
+  const e = document.createElementNS(svgNS, tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    e.setAttribute(k, v);
+  }
+  if (text !== undefined) {
+    e.textContent = text;
+  }
+  return e;
+}
+
+// drawGraph places the asset at the center and the assets it is related to around it.
+function drawGraph(id, n) {
+  const view = $("graph-view");
+  clear(view);
+
+  const cx = 450;
+  const cy = 300;
+  const radius = 220;
+  const center = n.asset.name;
+  const neighbors = new Map();
+  for (const r of n.relations) {
+    const incoming = r.to.name === center && r.from.name !== center;
+    const other = incoming ? r.from : r.to;
+    const key = other.type + "/" + other.name;
+    if (!neighbors.has(key)) {
+      if (neighbors.size === maxGraphNodes) {
+        continue;
+      }
+      neighbors.set(key, { asset: other, relations: [] });
+    }
+    neighbors.get(key).relations.push(incoming ? "← " + r.type : r.type + " →");
+  }
+
+  const nodes = Array.from(neighbors.values());
+  nodes.forEach((node, i) => {
+    const angle = (2 * Math.PI * i) / Math.max(nodes.length, 1) - Math.PI / 2;
+    node.x = cx + radius * Math.cos(angle);
+    node.y = cy + radius * 0.9 * Math.sin(angle);
+
+    view.appendChild(svg("line", { x1: cx, y1: cy, x2: node.x, y2: node.y }));
+    view.appendChild(svg("text", {
+      class: "relation",
+      x: (cx + node.x) / 2,
+      y: (cy + node.y) / 2,
+      "text-anchor": "middle",
+    }, node.relations.join(", ")));
+  });
+
+  for (const node of nodes) {
+    const fixed = !browsable.has(node.asset.type);
+    const g = svg("g", { class: fixed ? "node fixed" : "node" });
+    g.appendChild(svg("title", {}, node.asset.name + " (" + node.asset.type + ")"));
+    g.appendChild(svg("circle", { cx: node.x, cy: node.y, r: 8 }));
+    g.appendChild(svg("text", {
+      x: node.x,
+      y: node.y + (node.y < cy ? -12 : 20),
+      "text-anchor": "middle",
+    }, node.asset.name));
+    if (!fixed) {
+      g.addEventListener("click", () => navigate({ session: id, asset: node.asset.name }));
+    }
+    view.appendChild(g);
+  }
+
+  const c = svg("g", { class: "center" });
+  c.appendChild(svg("circle", { cx: cx, cy: cy, r: 12 }));
+  c.appendChild(svg("text", { x: cx, y: cy + 28, "text-anchor": "middle" }, center + " (" + n.asset.type + ")"));
+  view.appendChild(c);
+}
+
+async function startSession() {
+  const body = {
+    domains: $("start-domains").value.split(/[\s,]+/).filter(Boolean),
+  };
+  const id = $("start-session").value.trim();
+  if (id) {
+    body.session = id;
+  }
+  const timeout = parseInt($("start-timeout").value, 10);
+  if (timeout > 0) {
+    body.timeout = timeout;
+  }
+  const labels = parseLabels($("start-labels").value);
+  if (Object.keys(labels).length > 0) {
+    body.labels = labels;
+  }
+
+  const s = await apiJSON("/sessions", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  });
+  navigate({ session: s.session });
+}
+
+async function init() {
+  try {
+    const resp = await fetch("config.json");
+    if (resp.ok) {
+      config = await resp.json();
+    }
+  } catch (e) {
+    // The UI stays read-only
+  }
+  $("mode").textContent = config.write ? "Sessions can be started from this page" : "Read-only";
+
+  $("login-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    sessionStorage.setItem(tokenKey, $("token").value);
+    $("token").value = "";
+    route();
+  });
+  $("logout").addEventListener("click", () => {
+    sessionStorage.removeItem(tokenKey);
+    showLogin();
+  });
+  $("filter-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    clearError();
+    showSessions().catch(showError);
+  });
+  $("refresh").addEventListener("click", () => {
+    clearError();
+    showSessions().catch(showError);
+  });
+  $("start-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    clearError();
+    startSession().catch(showError);
+  });
+  $("back").addEventListener("click", (ev) => {
+    ev.preventDefault();
+    navigate({});
+  });
+  $("search-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    clearError();
+    searchAssets(current).catch(showError);
+  });
+  $("export").addEventListener("click", () => {
+    clearError();
+    exportAssets(current).catch(showError);
+  });
+
+  window.addEventListener("hashchange", route);
+  route();
+}
+
+init();
@@ -0,0 +1,1380 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/client"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"golang.org/x/net/publicsuffix"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	webhookUsageMsg = "webhook [options]"
+	// webhookTokenEnv provides the token when the -token flag is not used, so it does not appear in the process list
+	webhookTokenEnv = "AMASS_WEBHOOK_TOKEN"
+	// webhookUITokenEnv provides the token of the web UI when the -ui-token flag is not used
+	webhookUITokenEnv = "AMASS_WEBHOOK_UI_TOKEN"
+	// The files kept in the output directory of each session
+	webhookSessionFile = "webhook_session.json"
+	webhookStateFile   = "webhook_state.json"
+	webhookConfigFile  = "webhook_config.yaml"
+	webhookLogFile     = "webhook.log"
+	webhookMaxBody     = 1 << 20
+	// webhookArtifactsDir holds the evidence of each session, such as the wordlists used, screenshots and exports
+	webhookArtifactsDir = "artifacts"
+	// webhookCleanupInterval is the time between the removals of the sessions beyond the retention period
+	webhookCleanupInterval = time.Hour
+	// The labels of a session at most, and the length of their values
+	webhookMaxLabels     = 32
+	webhookMaxLabelValue = 256
+	// webhookMaxRelations is the number of relations returned by the neighborhood of an asset at most
+	webhookMaxRelations = 500
+	// webhookMaxInstances is the number of script instances the clients can request for a data source at most
+	webhookMaxInstances = 64
+)
+
+var (
+	webhookSessionRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	webhookLabelRE   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+)
+
+// webhookReadOnly is the key of the request context value set for the tokens that only read the sessions.
+type webhookReadOnly struct{}
+
+// errWebhookReadOnly is returned to the tokens that only read the sessions when they start or change a session.
+var errWebhookReadOnly = &webhookError{http.StatusForbidden, "the token only allows reading the sessions"}
+
+// readOnlyRequest returns true when the request was authorized by a token that only reads the sessions.
+func readOnlyRequest(ctx context.Context) bool {
+	ro, _ := ctx.Value(webhookReadOnly{}).(bool)
+	return ro
+}
+
+// webhookRestrictedOptions execute programs or scripts, or write outside the session directory, so the
+// configurations posted by the clients cannot set them unless the server is started with -allow-plugins.
+var webhookRestrictedOptions = []string{"hashcat_path", "markers_directory", "plugins", "scripts_directory"}
+
+type webhookArgs struct {
+	Listen      string
+	Token       string
+	UIToken     string
+	MaxSessions int
+	Retention   int
+	Labels      *stringset.Set
+	Options     struct {
+		List         bool
+		NoColor      bool
+		UI           bool
+		UIWrite      bool
+		AllowPlugins bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		TLSCert    string
+		TLSKey     string
+	}
+}
+
+// The bodies of the requests and responses are the types of the client package, so the
+// server and the client published for the integrators cannot drift apart.
+type (
+	webhookRequest  = client.SessionRequest
+	webhookSession  = client.Session
+	webhookArtifact = client.Artifact
+)
+
+// webhookServer creates and resumes the enumeration sessions requested by CI pipelines and inventory systems.
+// Each session is an output directory within the directory of the server, so the graph database and findings
+// of a resumed session are shared with its previous enumerations.
+type webhookServer struct {
+	sync.Mutex
+	token    string
+	dir      string
+	config   string
+	max      int
+	running  int
+	sessions map[string]*webhookSession
+	ctx      context.Context
+	wg       sync.WaitGroup
+	// run executes the enum subcommand with the arguments, writing its output to the log
+	run func(ctx context.Context, args []string, log *os.File) error
+	// retention is the time the sessions are kept after their last enumeration, or zero to keep them
+	retention time.Duration
+	// ui serves the web UI at '/ui/', and the uiToken of its users only starts and resumes sessions when uiWrite is true
+	ui      bool
+	uiToken string
+	uiWrite bool
+	// allowPlugins lets the configurations posted by the clients set the webhookRestrictedOptions
+	allowPlugins bool
+	// graphs keeps the graph database opened for each session, since netmap provides no way to close them
+	graphsLock sync.Mutex
+	graphs     map[string]*webhookGraph
+}
+
+// webhookGraph is the graph database of a session, along with the connection string it was opened with.
+type webhookGraph struct {
+	dsn   string
+	graph *netmap.Graph
+}
+
+func defineWebhookFlags(webhookFlags *flag.FlagSet, args *webhookArgs) {
+	webhookFlags.StringVar(&args.Listen, "listen", "127.0.0.1:8080", "Address and port the webhook listens on")
+	webhookFlags.StringVar(&args.Token, "token", "", "Bearer token required from the clients (default $"+webhookTokenEnv+")")
+	webhookFlags.IntVar(&args.MaxSessions, "max-sessions", 1, "Maximum number of sessions running at the same time")
+	webhookFlags.IntVar(&args.Retention, "retention", 0, "Number of days the sessions are kept after their last enumeration")
+	webhookFlags.Var(args.Labels, "label", "Labels (key=value or key) the listed sessions must have (can be used multiple times)")
+	webhookFlags.BoolVar(&args.Options.List, "list", false, "Print the sessions kept in the directory and exit")
+	webhookFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	webhookFlags.BoolVar(&args.Options.UI, "ui", false, "Serve the web UI for browsing the sessions at /ui/")
+	webhookFlags.BoolVar(&args.Options.UIWrite, "ui-write", false, "Allow the web UI to start and resume sessions (implies -ui)")
+	webhookFlags.StringVar(&args.UIToken, "ui-token", "", "Bearer token of the web UI users, which only reads the sessions without -ui-write (default $"+webhookUITokenEnv+")")
+	webhookFlags.BoolVar(&args.Options.AllowPlugins, "allow-plugins", false, "Allow the posted configurations to set plugins, script directories and executables")
+	webhookFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file used by the sessions")
+	webhookFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the session directories")
+	webhookFlags.StringVar(&args.Filepaths.TLSCert, "tls-cert", "", "Path to the certificate used to serve HTTPS")
+	webhookFlags.StringVar(&args.Filepaths.TLSKey, "tls-key", "", "Path to the private key used to serve HTTPS")
+}
+
+func runWebhookCommand(clArgs []string) {
+	args := webhookArgs{Labels: stringset.New()}
+	defer args.Labels.Close()
+	var help1, help2 bool
+	webhookCommand := flag.NewFlagSet("webhook", flag.ContinueOnError)
+
+	webhookBuf := new(bytes.Buffer)
+	webhookCommand.SetOutput(webhookBuf)
+
+	webhookCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	webhookCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineWebhookFlags(webhookCommand, &args)
+
+	if err := webhookCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(webhookUsageMsg, webhookCommand, webhookBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.List {
+		listWebhookSessions(args.Filepaths.Directory, args.Labels.Slice())
+		return
+	}
+	if args.Token == "" {
+		args.Token = os.Getenv(webhookTokenEnv)
+	}
+	if args.Token == "" {
+		r.Fprintf(color.Error, "A token must be provided by the -token flag or the %s variable\n", webhookTokenEnv)
+		os.Exit(1)
+	}
+	if args.UIToken == "" {
+		args.UIToken = os.Getenv(webhookUITokenEnv)
+	}
+	if (args.Options.UI || args.Options.UIWrite) && (args.UIToken == "" || args.UIToken == args.Token) {
+		r.Fprintf(color.Error, "The web UI requires a token other than the API token, provided by the -ui-token flag or the %s variable\n", webhookUITokenEnv)
+		os.Exit(1)
+	}
+	if (args.Filepaths.TLSCert == "") != (args.Filepaths.TLSKey == "") {
+		r.Fprintln(color.Error, "Both the -tls-cert and -tls-key flags must be provided to serve HTTPS")
+		os.Exit(1)
+	}
+
+	dir := args.Filepaths.Directory
+	if dir == "" {
+		dir = "amass_sessions"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		r.Fprintf(color.Error, "Failed to create the sessions directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ws := newWebhookServer(ctx, args.Token, dir, args.Filepaths.ConfigFile, args.MaxSessions)
+	ws.ui = args.Options.UI || args.Options.UIWrite
+	ws.uiToken = args.UIToken
+	ws.uiWrite = args.Options.UIWrite
+	ws.allowPlugins = args.Options.AllowPlugins
+	if args.Retention > 0 {
+		ws.retention = time.Duration(args.Retention) * 24 * time.Hour
+		go ws.manageRetention()
+	}
+	srv := &http.Server{
+		Addr:              args.Listen,
+		Handler:           ws,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		<-quit
+		sctx, scancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer scancel()
+		_ = srv.Shutdown(sctx)
+	}()
+
+	g.Fprintf(color.Error, "The webhook is listening on %s for the sessions in %s\n", args.Listen, dir)
+	if ws.ui {
+		g.Fprintf(color.Error, "The web UI is served at /ui/ on %s\n", args.Listen)
+	}
+	var err error
+	if args.Filepaths.TLSCert != "" {
+		err = srv.ListenAndServeTLS(args.Filepaths.TLSCert, args.Filepaths.TLSKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	// The running sessions are interrupted, so their enumerations save the results collected
+	cancel()
+	ws.wg.Wait()
+}
+
+func newWebhookServer(ctx context.Context, token, dir, config string, max int) *webhookServer {
+	if max <= 0 {
+		max = 1
+	}
+
+	ws := &webhookServer{
+		token:    token,
+		dir:      dir,
+		config:   config,
+		max:      max,
+		sessions: make(map[string]*webhookSession),
+		graphs:   make(map[string]*webhookGraph),
+		ctx:      ctx,
+		run:      runEnumProcess,
+	}
+	ws.loadSessions()
+	return ws
+}
+
+// ServeHTTP handles 'POST /sessions' to create or resume a session, 'GET /sessions' to list
+// the sessions, filtered by their labels, and 'GET /sessions/{id}' to obtain the state of a session. The files of a session
+// are listed by 'GET /sessions/{id}/artifacts' and downloaded by 'GET /sessions/{id}/artifacts/{path}',
+// while 'GET /sessions/{id}/assets' streams the assets of its graph database and 'GET /sessions/{id}/graph'
+// returns the relations of one asset. The data source instances of a running session are changed by
+// 'PUT /sessions/{id}/instances'. The same methods are provided as JSON-RPC 2.0 by 'POST /rpc', and the
+// OpenAPI specification of these paths is served by 'GET /openapi.yaml'. The files of the web UI hold no data,
+// so they are served without the token, which the UI requests before calling the other paths. The token of
+// the UI only reads the sessions, unless the UI is allowed to start them.
+func (ws *webhookServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if ws.ui && req.Method == http.MethodGet && (req.URL.Path == "/" || req.URL.Path == "/ui" || strings.HasPrefix(req.URL.Path, "/ui/")) {
+		ws.serveUI(w, req)
+		return
+	}
+
+	auth := []byte(req.Header.Get("Authorization"))
+	if subtle.ConstantTimeCompare(auth, []byte("Bearer "+ws.token)) != 1 {
+		if !ws.ui || ws.uiToken == "" || subtle.ConstantTimeCompare(auth, []byte("Bearer "+ws.uiToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeWebhookError(w, http.StatusUnauthorized, "a valid bearer token is required")
+			return
+		}
+		// The token of the web UI only reads the sessions, unless the UI is allowed to start them
+		if !ws.uiWrite {
+			req = req.WithContext(context.WithValue(req.Context(), webhookReadOnly{}, true))
+		}
+	}
+
+	path := strings.Trim(req.URL.Path, "/")
+	switch {
+	case path == "rpc" && req.Method == http.MethodPost:
+		ws.serveRPC(w, req)
+	case path == "openapi.yaml" && req.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(client.OpenAPI)
+	case path == "sessions" && req.Method == http.MethodPost:
+		ws.startSession(w, req)
+	case path == "sessions" && req.Method == http.MethodGet:
+		if filter, err := parseLabelFilter(req.URL.Query()["label"]); err != nil {
+			writeWebhookError(w, http.StatusBadRequest, err.Error())
+		} else {
+			writeWebhookJSON(w, http.StatusOK, ws.listSessions(filter))
+		}
+	case strings.HasPrefix(path, "sessions/") && strings.HasSuffix(path, "/instances") && req.Method == http.MethodPut:
+		ws.putInstances(w, req, strings.TrimSuffix(strings.TrimPrefix(path, "sessions/"), "/instances"))
+	case strings.HasPrefix(path, "sessions/") && strings.Contains(strings.TrimPrefix(path, "sessions/"), "/"):
+		if req.Method != http.MethodGet {
+			writeWebhookError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+			return
+		}
+
+		id, rest, _ := strings.Cut(strings.TrimPrefix(path, "sessions/"), "/")
+		if rest == "assets" {
+			ws.streamAssets(w, req, id)
+		} else if rest == "graph" {
+			if n, err := ws.neighborhood(req.Context(), id, req.URL.Query().Get("asset")); err != nil {
+				writeWebhookFailure(w, err)
+			} else {
+				writeWebhookJSON(w, http.StatusOK, n)
+			}
+		} else if rest == "artifacts" {
+			if list, err := ws.artifacts(id); err != nil {
+				writeWebhookFailure(w, err)
+			} else {
+				writeWebhookJSON(w, http.StatusOK, list)
+			}
+		} else if strings.HasPrefix(rest, "artifacts/") {
+			ws.serveArtifact(w, req, id, strings.TrimPrefix(rest, "artifacts/"))
+		} else {
+			writeWebhookError(w, http.StatusNotFound, "the path is not known")
+		}
+	case strings.HasPrefix(path, "sessions/") && req.Method == http.MethodGet:
+		if s, err := ws.session(strings.TrimPrefix(path, "sessions/")); err != nil {
+			writeWebhookFailure(w, err)
+		} else {
+			writeWebhookJSON(w, http.StatusOK, s)
+		}
+	case path == "sessions" || strings.HasPrefix(path, "sessions/"):
+		writeWebhookError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+	default:
+		writeWebhookError(w, http.StatusNotFound, "the path is not known")
+	}
+}
+
+func (ws *webhookServer) startSession(w http.ResponseWriter, req *http.Request) {
+	if readOnlyRequest(req.Context()) {
+		writeWebhookFailure(w, errWebhookReadOnly)
+		return
+	}
+
+	var wr webhookRequest
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, webhookMaxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&wr); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, "the request is not valid: "+err.Error())
+		return
+	}
+
+	s, err := ws.createSession(&wr)
+	if err != nil {
+		writeWebhookFailure(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusAccepted, s)
+}
+
+// createSession starts the enumeration of the session requested in the background and returns a copy of its state.
+func (ws *webhookServer) createSession(wr *webhookRequest) (*webhookSession, error) {
+	if wr.Session == "" {
+		wr.Session = newWebhookSessionID()
+	} else if !webhookSessionRE.MatchString(wr.Session) {
+		return nil, &webhookError{http.StatusBadRequest, "the session must be letters, digits, hyphens and underscores"}
+	}
+
+	dir := filepath.Join(ws.dir, wr.Session)
+	previous, resumed := loadWebhookRequest(dir)
+	if len(wr.Domains) == 0 && resumed {
+		wr.Domains = previous.Domains
+		if wr.Config == "" && len(wr.Blacklist) == 0 {
+			wr.Config = previous.Config
+			wr.Blacklist = previous.Blacklist
+		}
+		if wr.Timeout == 0 {
+			wr.Timeout = previous.Timeout
+		}
+		if wr.Notify == "" {
+			wr.Notify = previous.Notify
+		}
+	}
+	// The labels are kept when a session is resumed without labels, and replaced otherwise
+	if wr.Labels == nil && resumed {
+		wr.Labels = previous.Labels
+	}
+	if err := checkWebhookLabels(wr.Labels); err != nil {
+		return nil, &webhookError{http.StatusBadRequest, err.Error()}
+	}
+	if wr.Config != "" {
+		if err := checkWebhookConfig(dir, wr.Config, ws.allowPlugins); err != nil {
+			return nil, &webhookError{http.StatusBadRequest, err.Error()}
+		}
+	}
+	if wr.Notify != "" && !validNotifyURL(wr.Notify) {
+		return nil, &webhookError{http.StatusBadRequest, "the notify URL must be an absolute HTTP or HTTPS URL"}
+	}
+
+	domains, err := webhookDomains(wr.Domains)
+	if err != nil {
+		return nil, &webhookError{http.StatusBadRequest, err.Error()}
+	}
+	wr.Domains = domains
+
+	ws.Lock()
+	if s, found := ws.sessions[wr.Session]; found && s.Status == client.StatusRunning {
+		ws.Unlock()
+		return nil, &webhookError{http.StatusConflict, "the session is already running"}
+	}
+	if ws.running >= ws.max {
+		ws.Unlock()
+		return nil, &webhookError{http.StatusTooManyRequests, "the maximum number of sessions are running"}
+	}
+
+	var runs int
+	if s, found := ws.sessions[wr.Session]; found {
+		runs = s.Runs
+	}
+	s := &webhookSession{
+		ID:      wr.Session,
+		Domains: wr.Domains,
+		Status:  client.StatusRunning,
+		Resumed: resumed,
+		Runs:    runs + 1,
+		Started: time.Now(),
+		Labels:  wr.Labels,
+	}
+	ws.sessions[s.ID] = s
+	ws.running++
+	c := *s
+	ws.Unlock()
+
+	args, log, err := ws.prepareSession(dir, wr)
+	if err != nil {
+		ws.finishSession(s.ID, err)
+		return nil, &webhookError{http.StatusInternalServerError, "failed to prepare the session: " + err.Error()}
+	}
+	saveWebhookState(dir, &c)
+
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+		defer log.Close()
+
+		err := ws.run(ws.ctx, args, log)
+		ws.finishSession(s.ID, err)
+		if err == nil && wr.Notify != "" {
+			ws.notifySession(s.ID, wr.Domains, c.Started, wr.Notify, log)
+		}
+	}()
+	return &c, nil
+}
+
+// listSessions returns copies of the sessions kept in the directory that have the labels of the filter.
+func (ws *webhookServer) listSessions(filter map[string]string) []*webhookSession {
+	ws.Lock()
+	list := make([]*webhookSession, 0, len(ws.sessions))
+	for _, s := range ws.sessions {
+		if !matchLabels(s.Labels, filter) {
+			continue
+		}
+
+		c := *s
+		list = append(list, &c)
+	}
+	ws.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// session returns a copy of the state of the session.
+func (ws *webhookServer) session(id string) (*webhookSession, error) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	s, found := ws.sessions[id]
+	if !found {
+		return nil, &webhookError{http.StatusNotFound, "the session is not known"}
+	}
+	c := *s
+	return &c, nil
+}
+
+// prepareSession saves the request in the output directory of the session and returns the arguments of the enumeration.
+func (ws *webhookServer) prepareSession(dir string, wr *webhookRequest) ([]string, *os.File, error) {
+	if err := os.MkdirAll(filepath.Join(dir, webhookArtifactsDir), 0755); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(wr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, webhookSessionFile), data, 0600); err != nil {
+		return nil, nil, err
+	}
+	// The instances requested during a previous run are not applied to the next one
+	_ = os.Remove(filepath.Join(dir, enum.InstancesFile))
+
+	args := []string{"enum", "-nocolor", "-dir", dir, "-d", strings.Join(wr.Domains, ",")}
+	if wr.Config != "" {
+		cfgfile := filepath.Join(dir, webhookConfigFile)
+
+		if err := os.WriteFile(cfgfile, []byte(wr.Config), 0600); err != nil {
+			return nil, nil, err
+		}
+		args = append(args, "-config", cfgfile)
+	} else if ws.config != "" {
+		args = append(args, "-config", ws.config)
+	}
+	if len(wr.Blacklist) > 0 {
+		args = append(args, "-bl", strings.Join(wr.Blacklist, ","))
+	}
+	if err := saveWebhookWordlists(dir, args); err != nil {
+		return nil, nil, err
+	}
+	if wr.Timeout > 0 {
+		args = append(args, "-timeout", strconv.Itoa(wr.Timeout))
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, webhookLogFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, log, nil
+}
+
+func (ws *webhookServer) finishSession(id string, err error) {
+	ws.Lock()
+	s, found := ws.sessions[id]
+	if !found {
+		ws.Unlock()
+		return
+	}
+
+	now := time.Now()
+	s.Finished = &now
+	s.Status = client.StatusFinished
+	s.Reason = ""
+	s.Error = ""
+	if err != nil {
+		s.Status = client.StatusFailed
+		s.Error = err.Error()
+	} else if c, err := enum.LoadCompletion(filepath.Join(ws.dir, id, enum.CompletionFile)); err == nil && c != nil {
+		// The completion file of a previous run is not reported for the run that failed to replace it
+		if !c.Started.Before(s.Started) {
+			s.Reason = string(c.Reason)
+		}
+	}
+	ws.running--
+	c := *s
+	ws.Unlock()
+
+	saveWebhookState(filepath.Join(ws.dir, id), &c)
+}
+
+// saveWebhookWordlists keeps the wordlists of the configuration used by the enumeration as artifacts of the
+// session, since the files referenced by the configuration can change before the results are reviewed.
+func saveWebhookWordlists(dir string, args []string) error {
+	var cfgfile string
+	for i, arg := range args {
+		if arg == "-config" && i+1 < len(args) {
+			cfgfile = args[i+1]
+		}
+	}
+	if cfgfile == "" {
+		return nil
+	}
+
+	cfg := config.NewConfig()
+	if err := config.AcquireConfig("", cfgfile, cfg); err != nil {
+		return err
+	}
+
+	lists := map[string][]string{"bruteforce.txt": cfg.Wordlist, "alterations.txt": cfg.AltWordlist}
+	for name, words := range lists {
+		if len(words) == 0 {
+			continue
+		}
+
+		wdir := filepath.Join(dir, webhookArtifactsDir, "wordlists")
+		if err := os.MkdirAll(wdir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(wdir, name), []byte(strings.Join(words, "\n")+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionDir returns the directory of a session started by the webhook, which is known
+// from the current process or from the request kept in the directory.
+func (ws *webhookServer) sessionDir(id string) (string, bool) {
+	if !webhookSessionRE.MatchString(id) {
+		return "", false
+	}
+
+	dir := filepath.Join(ws.dir, id)
+	ws.Lock()
+	_, found := ws.sessions[id]
+	ws.Unlock()
+	if !found {
+		_, found = loadWebhookRequest(dir)
+	}
+	return dir, found
+}
+
+// artifacts returns the files kept in the directory of the session.
+func (ws *webhookServer) artifacts(id string) ([]*webhookArtifact, error) {
+	dir, found := ws.sessionDir(id)
+	if !found {
+		return nil, &webhookError{http.StatusNotFound, "the session is not known"}
+	}
+
+	artifacts := []*webhookArtifact{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// The symbolic links are not followed, so the artifacts remain within the session
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, &webhookArtifact{
+			Path:     filepath.ToSlash(rel),
+			Size:     info.Size(),
+			Modified: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, &webhookError{http.StatusInternalServerError, "failed to list the artifacts: " + err.Error()}
+	}
+	return artifacts, nil
+}
+
+func (ws *webhookServer) serveArtifact(w http.ResponseWriter, req *http.Request, id, name string) {
+	dir, found := ws.sessionDir(id)
+	if !found {
+		writeWebhookError(w, http.StatusNotFound, "the session is not known")
+		return
+	}
+
+	rel := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if rel == "" {
+		writeWebhookError(w, http.StatusNotFound, "the artifact is not known")
+		return
+	}
+
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	info, err := os.Lstat(p)
+	if err != nil || !info.Mode().IsRegular() || !withinDir(dir, p) {
+		writeWebhookError(w, http.StatusNotFound, "the artifact is not known")
+		return
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		writeWebhookError(w, http.StatusInternalServerError, "failed to open the artifact: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(p)))
+	http.ServeContent(w, req, filepath.Base(p), info.ModTime(), f)
+}
+
+func (ws *webhookServer) putInstances(w http.ResponseWriter, req *http.Request, id string) {
+	var instances map[string]int
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, req.Body, webhookMaxBody))
+	if err := dec.Decode(&instances); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, "the request must be a JSON object of data source names and numbers")
+		return
+	}
+
+	if current, err := ws.setInstances(req.Context(), id, instances); err != nil {
+		writeWebhookFailure(w, err)
+	} else {
+		writeWebhookJSON(w, http.StatusAccepted, current)
+	}
+}
+
+// setInstances writes the numbers of script instances requested for the data sources of the running session to its
+// enum.InstancesFile, which the enumeration applies within seconds, and returns all the numbers requested during the run.
+func (ws *webhookServer) setInstances(ctx context.Context, id string, instances map[string]int) (map[string]int, error) {
+	if readOnlyRequest(ctx) {
+		return nil, errWebhookReadOnly
+	}
+	if len(instances) == 0 {
+		return nil, &webhookError{http.StatusBadRequest, "the instances of at least one data source are required"}
+	}
+	for name, num := range instances {
+		if strings.TrimSpace(name) == "" || num < 1 || num > webhookMaxInstances {
+			return nil, &webhookError{http.StatusBadRequest,
+				fmt.Sprintf("the data sources must be named and have between 1 and %d instances", webhookMaxInstances)}
+		}
+	}
+
+	// The lock also keeps the concurrent requests from losing the numbers of each other
+	ws.Lock()
+	defer ws.Unlock()
+
+	s, found := ws.sessions[id]
+	if !found {
+		return nil, &webhookError{http.StatusNotFound, "the session is not known"}
+	} else if s.Status != client.StatusRunning {
+		return nil, &webhookError{http.StatusConflict, "the session is not running"}
+	}
+
+	path := filepath.Join(ws.dir, id, enum.InstancesFile)
+	current := make(map[string]int)
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &current)
+	}
+	for name, num := range instances {
+		current[name] = num
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, &webhookError{http.StatusInternalServerError, "failed to encode the instances: " + err.Error()}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return nil, &webhookError{http.StatusInternalServerError, "failed to save the instances: " + err.Error()}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, &webhookError{http.StatusInternalServerError, "failed to save the instances: " + err.Error()}
+	}
+	return current, nil
+}
+
+// streamAssets writes the assets of the session graph database last seen after the 'since' parameter,
+// and containing the 'q' parameter in their names when provided, as JSON lines, flushing each line
+// so the clients can process the assets while they are read.
+func (ws *webhookServer) streamAssets(w http.ResponseWriter, req *http.Request, id string) {
+	var since time.Time
+	if v := req.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeWebhookError(w, http.StatusBadRequest, "the since parameter must be an RFC3339 time")
+			return
+		}
+		since = t
+	}
+
+	g, err := ws.sessionGraph(id)
+	if err != nil {
+		writeWebhookFailure(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	query := req.URL.Query().Get("q")
+	_ = readWebhookAssets(req.Context(), g, since, func(a *client.Asset) error {
+		if !matchAssetQuery(a, query) {
+			return nil
+		}
+		if err := writeJSONLine(w, a); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// neighborhood returns the asset of the session graph database, such as a name, an address, a netblock or an ASN,
+// along with its incoming and outgoing relations, so the clients can explore the graph one asset at a time.
+func (ws *webhookServer) neighborhood(ctx context.Context, id, asset string) (*client.Neighborhood, error) {
+	asset = strings.TrimSpace(asset)
+	if asset == "" {
+		return nil, &webhookError{http.StatusBadRequest, "the asset parameter is required"}
+	}
+
+	g, err := ws.sessionGraph(id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, _ := parseAssocAsset(asset)
+	found, err := g.DB.FindByContent(content, time.Time{})
+	if err != nil || len(found) == 0 {
+		return nil, &webhookError{http.StatusNotFound, "the asset is not known"}
+	}
+
+	a := found[0]
+	n := &client.Neighborhood{Asset: webhookAsset(a), Relations: []*client.Relation{}}
+	if n.Asset == nil {
+		return nil, &webhookError{http.StatusNotFound, "the asset is not known"}
+	}
+
+	for _, incoming := range []bool{true, false} {
+		var rels []*types.Relation
+		if incoming {
+			rels, err = g.DB.IncomingRelations(a, time.Time{})
+		} else {
+			rels, err = g.DB.OutgoingRelations(a, time.Time{})
+		}
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range rels {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if len(n.Relations) == webhookMaxRelations {
+				n.Truncated = true
+				return n, nil
+			}
+
+			otherID := rel.ToAsset.ID
+			if incoming {
+				otherID = rel.FromAsset.ID
+			}
+			other, err := g.DB.FindById(otherID, time.Time{})
+			if err != nil {
+				continue
+			}
+			oa := webhookAsset(other)
+			if oa == nil {
+				continue
+			}
+
+			r := &client.Relation{From: n.Asset, Type: rel.Type, To: oa, LastSeen: rel.LastSeen.UTC()}
+			if incoming {
+				r.From, r.To = oa, n.Asset
+			}
+			n.Relations = append(n.Relations, r)
+		}
+	}
+	return n, nil
+}
+
+// sessionGraph returns the graph database of the session, using the configuration the session was started with.
+// The graph is opened once and shared by the requests, unless a resumed session uses another database.
+func (ws *webhookServer) sessionGraph(id string) (*netmap.Graph, error) {
+	dir, found := ws.sessionDir(id)
+	if !found {
+		return nil, &webhookError{http.StatusNotFound, "the session is not known"}
+	}
+
+	cfg := config.NewConfig()
+	cfgfile := filepath.Join(dir, webhookConfigFile)
+	if _, err := os.Stat(cfgfile); err != nil {
+		cfgfile = ws.config
+	}
+	if cfgfile != "" {
+		if err := config.AcquireConfig("", cfgfile, cfg); err != nil {
+			return nil, &webhookError{http.StatusInternalServerError, "failed to load the configuration: " + err.Error()}
+		}
+	}
+	cfg.Dir = dir
+
+	system, dsn := graphDatabaseDSN(cfg)
+	dsn = system + ":" + dsn
+
+	ws.graphsLock.Lock()
+	defer ws.graphsLock.Unlock()
+
+	if wg, found := ws.graphs[id]; found && wg.dsn == dsn {
+		return wg.graph, nil
+	}
+
+	g := openGraphDatabase(cfg)
+	if g == nil {
+		return nil, &webhookError{http.StatusNotFound, "the session has no graph database"}
+	}
+	ws.graphs[id] = &webhookGraph{dsn: dsn, graph: g}
+	return g, nil
+}
+
+// readWebhookAssets provides the names, addresses, netblocks and autonomous systems of the
+// graph database last seen after the time provided to the callback, until it returns an error.
+func readWebhookAssets(ctx context.Context, g *netmap.Graph, since time.Time, callback func(*client.Asset) error) error {
+	for _, atype := range []oam.AssetType{oam.FQDN, oam.IPAddress, oam.Netblock, oam.ASN} {
+		assets, err := g.DB.FindByType(atype, since)
+		if err != nil {
+			continue
+		}
+
+		for _, a := range assets {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			ca := webhookAsset(a)
+			if ca == nil {
+				continue
+			}
+			if err := callback(ca); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// webhookAsset returns the asset of the graph database as provided to the clients, or nil for the types not summarized.
+func webhookAsset(a *types.Asset) *client.Asset {
+	sum := extractAssetSummary(a)
+	if sum.Name == "" {
+		return nil
+	}
+	return &client.Asset{
+		Name:     sum.Name,
+		Type:     sum.Type,
+		LastSeen: a.LastSeen.UTC(),
+	}
+}
+
+// matchAssetQuery returns true when the name of the asset contains the query regardless of case, or the query is empty.
+func matchAssetQuery(a *client.Asset, query string) bool {
+	return query == "" || strings.Contains(strings.ToLower(a.Name), strings.ToLower(query))
+}
+
+// withinDir returns true when the file remains within the directory once the symbolic links of its parents are followed.
+func withinDir(dir, file string) bool {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(file))
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, parent)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// manageRetention removes the sessions that were not used within the retention period, until the server stops.
+func (ws *webhookServer) manageRetention() {
+	t := time.NewTicker(webhookCleanupInterval)
+	defer t.Stop()
+
+	for {
+		ws.removeExpiredSessions(time.Now())
+
+		select {
+		case <-ws.ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// removeExpiredSessions removes the directories of the sessions whose files were last modified before the retention
+// period. The directories without the request of a webhook session are not removed, nor are the running sessions.
+func (ws *webhookServer) removeExpiredSessions(now time.Time) {
+	entries, err := os.ReadDir(ws.dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		id := entry.Name()
+		if !entry.IsDir() || !webhookSessionRE.MatchString(id) {
+			continue
+		}
+
+		dir := filepath.Join(ws.dir, id)
+		if _, found := loadWebhookRequest(dir); !found {
+			continue
+		}
+		last := lastModified(dir)
+		if last.IsZero() || now.Sub(last) < ws.retention {
+			continue
+		}
+
+		ws.Lock()
+		if s, found := ws.sessions[id]; found && s.Status == client.StatusRunning {
+			ws.Unlock()
+			continue
+		}
+		delete(ws.sessions, id)
+		ws.Unlock()
+
+		ws.graphsLock.Lock()
+		delete(ws.graphs, id)
+		ws.graphsLock.Unlock()
+
+		if err := os.RemoveAll(dir); err != nil {
+			r.Fprintf(color.Error, "Failed to remove the expired session %s: %v\n", id, err)
+			continue
+		}
+		fmt.Fprintf(color.Error, "Removed the session %s, last used on %s\n", id, last.Format(time.RFC3339))
+	}
+}
+
+// lastModified returns the latest modification time of the files within the directory.
+func lastModified(dir string) time.Time {
+	var last time.Time
+
+	_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+		return nil
+	})
+	return last
+}
+
+// runEnumProcess executes the enum subcommand of this binary, which is interrupted when the context is done.
+func runEnumProcess(ctx context.Context, args []string, log *os.File) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = cmd.Process.Signal(os.Interrupt)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
+
+// loadWebhookRequest returns the previous request of the session kept in its output directory.
+func loadWebhookRequest(dir string) (*webhookRequest, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, webhookSessionFile))
+	if err != nil {
+		return nil, false
+	}
+
+	var wr webhookRequest
+	if err := json.Unmarshal(data, &wr); err != nil {
+		return nil, false
+	}
+	return &wr, true
+}
+
+// saveWebhookState keeps the state of the session in its output directory, so the
+// sessions are listed and searched by their labels after the server is restarted.
+func saveWebhookState(dir string, s *webhookSession) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+
+	tmp := filepath.Join(dir, webhookStateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err == nil {
+		_ = os.Rename(tmp, filepath.Join(dir, webhookStateFile))
+	}
+}
+
+// loadWebhookStates returns the states of the sessions kept in the directory. The sessions still running when
+// the state was saved were interrupted by a failure of the server, so they are reported as failed.
+func loadWebhookStates(dir string) []*webhookSession {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var sessions []*webhookSession
+	for _, entry := range entries {
+		if !entry.IsDir() || !webhookSessionRE.MatchString(entry.Name()) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), webhookStateFile))
+		if err != nil {
+			continue
+		}
+
+		var s webhookSession
+		if err := json.Unmarshal(data, &s); err != nil || s.ID != entry.Name() {
+			continue
+		}
+		if s.Status == client.StatusRunning {
+			s.Status = client.StatusFailed
+			s.Error = "the server stopped before the enumeration finished"
+		}
+		sessions = append(sessions, &s)
+	}
+	return sessions
+}
+
+// loadSessions restores the sessions kept in the directory by the previous executions of the server.
+func (ws *webhookServer) loadSessions() {
+	ws.Lock()
+	defer ws.Unlock()
+
+	for _, s := range loadWebhookStates(ws.dir) {
+		ws.sessions[s.ID] = s
+	}
+}
+
+// listWebhookSessions prints the sessions kept in the directory that have the labels provided.
+func listWebhookSessions(dir string, labels []string) {
+	if dir == "" {
+		dir = "amass_sessions"
+	}
+
+	filter, err := parseLabelFilter(labels)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var list []*webhookSession
+	for _, s := range loadWebhookStates(dir) {
+		if matchLabels(s.Labels, filter) {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	for _, s := range list {
+		keys := make([]string, 0, len(s.Labels))
+		for k := range s.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var labels []string
+		for _, k := range keys {
+			labels = append(labels, k+"="+s.Labels[k])
+		}
+
+		status := green
+		if s.Status == client.StatusFailed {
+			status = r.Sprint
+		} else if s.Status == client.StatusRunning {
+			status = yellow
+		}
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", blue(s.ID), status(s.Status),
+			strings.Join(s.Domains, ","), white(strings.Join(labels, " ")))
+	}
+}
+
+// checkWebhookLabels checks the number of labels, the characters of their keys and the length of their values.
+func checkWebhookLabels(labels map[string]string) error {
+	if len(labels) > webhookMaxLabels {
+		return fmt.Errorf("a session has at most %d labels", webhookMaxLabels)
+	}
+
+	for k, v := range labels {
+		if !webhookLabelRE.MatchString(k) {
+			return fmt.Errorf("the label %q must be letters, digits, periods, hyphens and underscores", k)
+		}
+		if v == "" || len(v) > webhookMaxLabelValue {
+			return fmt.Errorf("the value of the label %s must have between 1 and %d characters", k, webhookMaxLabelValue)
+		}
+	}
+	return nil
+}
+
+// checkWebhookConfig refuses the configuration posted by a client when it sets one of the webhookRestrictedOptions,
+// unless plugins are allowed, or when it reads a file outside the directory of the session, since the files
+// read, such as the wordlists, are kept as artifacts that the clients download.
+func checkWebhookConfig(dir, data string, allowPlugins bool) error {
+	var c struct {
+		Options map[string]interface{} `yaml:"options"`
+	}
+
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		return fmt.Errorf("the configuration is not valid: %v", err)
+	}
+	if !allowPlugins {
+		for _, key := range webhookRestrictedOptions {
+			if _, found := c.Options[key]; found {
+				return fmt.Errorf("the configuration cannot set the %s option unless the webhook allows plugins", key)
+			}
+		}
+	}
+	for _, p := range webhookConfigPaths(c.Options) {
+		if !withinSession(dir, p) {
+			return fmt.Errorf("the configuration cannot read the file %s outside the session directory", p)
+		}
+	}
+	return nil
+}
+
+// webhookConfigPaths returns the paths of the files read by the enumeration from the options of the configuration.
+func webhookConfigPaths(opts map[string]interface{}) []string {
+	paths := options.StringsValue(opts["wordlist"])
+	paths = append(paths, options.StringsValue(opts["datasources"])...)
+	paths = append(paths, options.StringsValue(opts["offline_datasets"])...)
+	for _, key := range []string{"bruteforce", "alterations"} {
+		if m, ok := opts[key].(map[string]interface{}); ok {
+			paths = append(paths, options.StringsValue(m["wordlists"])...)
+		}
+	}
+	// The resolvers and feeds are also provided as addresses and URLs
+	for _, r := range options.StringsValue(opts["resolvers"]) {
+		if net.ParseIP(r) == nil {
+			paths = append(paths, r)
+		}
+	}
+	for _, f := range options.StringsValue(opts["nrd_feeds"]) {
+		if !strings.HasPrefix(f, "https://") && !strings.HasPrefix(f, "http://") {
+			paths = append(paths, f)
+		}
+	}
+	return paths
+}
+
+// withinSession returns true when the relative path, read from the session directory, remains within it.
+func withinSession(dir, p string) bool {
+	if filepath.IsAbs(p) {
+		return false
+	}
+
+	file := filepath.Join(dir, p)
+	if rel, err := filepath.Rel(dir, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	// The files already in the session directory could be symbolic links
+	if _, err := os.Lstat(file); err == nil {
+		target, err := filepath.EvalSymlinks(file)
+		return err == nil && withinDir(dir, target)
+	}
+	return true
+}
+
+// parseLabelFilter returns the labels of the 'key=value' parameters, where a parameter
+// without a value is stored with an empty value, matching the sessions having the label.
+func parseLabelFilter(params []string) (map[string]string, error) {
+	filter := make(map[string]string, len(params))
+
+	for _, p := range params {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if !webhookLabelRE.MatchString(k) {
+			return nil, fmt.Errorf("the label filter %q is not valid", p)
+		}
+		filter[k] = v
+	}
+	return filter, nil
+}
+
+// matchLabels returns true when the labels hold every label of the filter, regardless of case.
+func matchLabels(labels, filter map[string]string) bool {
+	for k, v := range filter {
+		var found bool
+
+		for key, value := range labels {
+			if strings.EqualFold(key, k) && (v == "" || strings.EqualFold(value, v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDomains checks that the domain names are registered domains or their subdomains.
+func webhookDomains(domains []string) ([]string, error) {
+	var results []string
+
+	seen := make(map[string]struct{})
+	for _, d := range domains {
+		d = strings.ToLower(strings.Trim(strings.TrimSpace(d), "."))
+		if d == "" {
+			continue
+		}
+		if strings.ContainsAny(d, ", /\\") || strings.HasPrefix(d, "-") {
+			return nil, fmt.Errorf("the domain name %s is not valid", d)
+		}
+		if _, err := publicsuffix.EffectiveTLDPlusOne(d); err != nil {
+			return nil, fmt.Errorf("the domain name %s is not valid: %v", d, err)
+		}
+
+		if _, found := seen[d]; !found {
+			seen[d] = struct{}{}
+			results = append(results, d)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, errors.New("no domain names were provided")
+	}
+	return results, nil
+}
+
+func newWebhookSessionID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+func writeWebhookJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeWebhookError(w http.ResponseWriter, status int, msg string) {
+	writeWebhookJSON(w, status, map[string]string{"error": msg})
+}
+
+// webhookError is a failure of a request with the HTTP status reporting it.
+type webhookError struct {
+	status int
+	msg    string
+}
+
+func (e *webhookError) Error() string {
+	return e.msg
+}
+
+// writeWebhookFailure reports the error with its status, or as an internal error when it has none.
+func writeWebhookFailure(w http.ResponseWriter, err error) {
+	var we *webhookError
+	if errors.As(err, &we) {
+		writeWebhookError(w, we.status, we.msg)
+		return
+	}
+	writeWebhookError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,278 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/client"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const (
+	// The names held by a digest at most, so the notifications of the first enumerations remain small
+	webhookDigestMax = 1000
+	// webhookNotifyTimeout is the time allowed to build the digest and post it to the notify URL
+	webhookNotifyTimeout = 5 * time.Minute
+)
+
+// validNotifyURL returns true when the notify URL of a session request is an absolute HTTP or HTTPS URL.
+func validNotifyURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
+// notifySession posts the digest of the names first seen by the enumeration of the session to the notify URL.
+// The failures are written to the log of the session, since the enumeration itself succeeded.
+func (ws *webhookServer) notifySession(id string, domains []string, started time.Time, notify string, log *os.File) {
+	ctx, cancel := context.WithTimeout(ws.ctx, webhookNotifyTimeout)
+	defer cancel()
+
+	if err := ws.postDigest(ctx, id, domains, started, notify); err != nil {
+		fmt.Fprintf(log, "Failed to notify %s of the session digest: %v\n", notify, err)
+	}
+}
+
+func (ws *webhookServer) postDigest(ctx context.Context, id string, domains []string, started time.Time, notify string) error {
+	g, err := ws.sessionGraph(id)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(ws.dir, id)
+	digest := buildSessionDigest(ctx, g, dir, domains, started)
+	digest.Session = id
+	if len(digest.Assets) == 0 && len(digest.Stale) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notify, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the notify URL returned with status: %s", resp.Status)
+	}
+	return nil
+}
+
+// buildSessionDigest returns the names of the domains first stored in the graph database after the
+// enumeration started. Each name is joined with its addresses and the autonomous systems announcing them,
+// the technologies detected on its ports by the data sources, and the data sources that provided it.
+func buildSessionDigest(ctx context.Context, g *netmap.Graph, dir string, domains []string, started time.Time) *client.Digest {
+	digest := &client.Digest{
+		Domains:  domains,
+		Started:  started.UTC(),
+		Finished: time.Now().UTC(),
+		Assets:   []*client.DigestAsset{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.AddDomains(domains...)
+	digest.Stale = digestStale(filepath.Join(dir, systems.FindingsFile), started)
+
+	assets, err := g.DB.FindByType(oam.FQDN, started)
+	if err != nil {
+		return digest
+	}
+
+	var names []*client.DigestAsset
+	for _, a := range assets {
+		sum := extractAssetSummary(a)
+		if sum.Name == "" || a.CreatedAt.Before(started) || cfg.WhichDomain(sum.Name) == "" {
+			continue
+		}
+		names = append(names, &client.DigestAsset{
+			Name:      sum.Name,
+			FirstSeen: a.CreatedAt.UTC(),
+		})
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].Name < names[j].Name })
+
+	digest.Total = len(names)
+	if len(names) > webhookDigestMax {
+		names = names[:webhookDigestMax]
+	}
+
+	sources := digestSources(filepath.Join(dir, enum.CoverageFile))
+	technologies := digestTechnologies(filepath.Join(dir, systems.FindingsFile))
+	infra := make(map[string][]*client.DigestASN)
+	for _, n := range names {
+		if ctx.Err() != nil {
+			break
+		}
+
+		n.Sources = sources[n.Name]
+		n.Technologies = technologies[n.Name]
+		if records, err := systems.NamesToAddrRecords(ctx, g, time.Time{}, n.Name); err == nil {
+			for _, rec := range records {
+				if rec.Addr == nil {
+					continue
+				}
+
+				addr := rec.Addr.Address.String()
+				if _, found := infra[addr]; !found {
+					infra[addr] = digestASNs(ctx, g, rec.Addr)
+				}
+				n.Addresses = append(n.Addresses, addr)
+				n.ASNs = appendDigestASNs(n.ASNs, infra[addr])
+			}
+		}
+		digest.Assets = append(digest.Assets, n)
+	}
+	return digest
+}
+
+// digestASNs returns the autonomous systems announcing the netblocks that contain the address.
+func digestASNs(ctx context.Context, g *netmap.Graph, addr *network.IPAddress) []*client.DigestASN {
+	netblocks := stringset.New()
+	defer netblocks.Close()
+	asns := stringset.New()
+	defer asns.Close()
+
+	readAddrInfra(g, addr, netblocks, asns)
+
+	var results []*client.DigestASN
+	for _, v := range sortedSlice(asns) {
+		if asn, err := strconv.Atoi(v); err == nil {
+			results = append(results, &client.DigestASN{
+				Number:      asn,
+				Description: g.ReadASDescription(ctx, asn, time.Time{}),
+			})
+		}
+	}
+	return results
+}
+
+func appendDigestASNs(list []*client.DigestASN, asns []*client.DigestASN) []*client.DigestASN {
+	for _, asn := range asns {
+		var found bool
+		for _, a := range list {
+			if a.Number == asn.Number {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, asn)
+		}
+	}
+	return list
+}
+
+// digestSources returns the data sources that provided each name, according to the coverage file of the session.
+func digestSources(path string) map[string][]string {
+	sources := make(map[string][]string)
+
+	records, err := enum.LoadCoverage(path)
+	if err != nil {
+		return sources
+	}
+
+	for _, rec := range records {
+		if rec.Type == "fqdn" {
+			sources[rec.Asset] = append(sources[rec.Asset], rec.Source)
+		}
+	}
+	for name, list := range sources {
+		sort.Strings(list)
+		sources[name] = list
+	}
+	return sources
+}
+
+// digestStale returns the names that went dark according to the 'Stale' findings kept by the enumeration.
+func digestStale(path string, started time.Time) []*client.DigestStale {
+	fs, err := systems.NewFindingStore(path)
+	if err != nil {
+		return nil
+	}
+
+	var stale []*client.DigestStale
+	for _, f := range fs.Find(started, "Stale") {
+		last, _ := time.Parse(time.RFC3339, f.Properties["last_resolved"])
+		missed, _ := strconv.Atoi(f.Properties["missed_runs"])
+
+		stale = append(stale, &client.DigestStale{
+			Name:         f.Value,
+			LastResolved: last,
+			MissedRuns:   missed,
+		})
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Name < stale[j].Name })
+	return stale
+}
+
+// digestTechnologies returns the products and services detected on the ports of each name,
+// according to the 'Service' findings of the session, such as 'nginx 1.18.0' for 'www.owasp.org:443'.
+func digestTechnologies(path string) map[string][]string {
+	technologies := make(map[string][]string)
+
+	fs, err := systems.NewFindingStore(path)
+	if err != nil {
+		return technologies
+	}
+
+	for _, f := range fs.Find(time.Time{}, "Service") {
+		host, _, found := strings.Cut(f.Value, ":")
+		if !found {
+			continue
+		}
+
+		tech := strings.TrimSpace(f.Properties["product"] + " " + f.Properties["version"])
+		if tech == "" {
+			tech = f.Properties["service"]
+		}
+		if tech == "" {
+			continue
+		}
+
+		host = strings.ToLower(host)
+		var dup bool
+		for _, t := range technologies[host] {
+			if t == tech {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			technologies[host] = append(technologies[host], tech)
+		}
+	}
+	return technologies
+}
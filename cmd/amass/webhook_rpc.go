@@ -0,0 +1,265 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/client"
+)
+
+const (
+	// The assets returned by each 'assets.list' call when the limit is not provided, and at most
+	webhookAssetLimit    = 1000
+	webhookMaxAssetLimit = 10000
+)
+
+// The JSON-RPC 2.0 error codes, where the failures of the sessions API keep the HTTP status in the error data.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+var errRPCPageFull = errors.New("the page of assets is full")
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    *rpcErrorData `json:"data,omitempty"`
+}
+
+type rpcErrorData struct {
+	Status int `json:"status"`
+}
+
+// rpcParams holds the parameters of the methods other than 'sessions.start'.
+type rpcParams struct {
+	Session string `json:"session"`
+	Since   string `json:"since,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+	// Query keeps the assets listed whose names contain it, and Asset is the asset of 'assets.graph'
+	Query string `json:"query,omitempty"`
+	Asset string `json:"asset,omitempty"`
+	// Labels filter the sessions listed, where an empty value matches the sessions having the label
+	Labels map[string]string `json:"labels,omitempty"`
+	// Instances are the numbers of script instances of 'sessions.instances' keyed by data source name
+	Instances map[string]int `json:"instances,omitempty"`
+}
+
+// rpcAssetPage is the result of 'assets.list', where Next is the offset of the following page, or zero after the last page.
+type rpcAssetPage struct {
+	Assets []*client.Asset `json:"assets"`
+	Next   int             `json:"next,omitempty"`
+}
+
+// serveRPC handles the JSON-RPC 2.0 requests posted to '/rpc', including batches, which provide the
+// methods of the sessions API to the clients written in other languages, such as the Python client.
+func (ws *webhookServer) serveRPC(w http.ResponseWriter, req *http.Request) {
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(http.MaxBytesReader(w, req.Body, webhookMaxBody)); err != nil {
+		writeWebhookJSON(w, http.StatusOK, rpcFailure(nil, rpcParseError, "the request could not be read: "+err.Error()))
+		return
+	}
+
+	data := bytes.TrimSpace(body.Bytes())
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			writeWebhookJSON(w, http.StatusOK, rpcFailure(nil, rpcParseError, "the request is not valid JSON"))
+			return
+		} else if len(batch) == 0 {
+			writeWebhookJSON(w, http.StatusOK, rpcFailure(nil, rpcInvalidRequest, "the batch is empty"))
+			return
+		}
+
+		var results []*rpcResponse
+		for _, raw := range batch {
+			if resp := ws.callRPC(req.Context(), raw); resp != nil {
+				results = append(results, resp)
+			}
+		}
+		if len(results) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeWebhookJSON(w, http.StatusOK, results)
+		return
+	}
+
+	if resp := ws.callRPC(req.Context(), data); resp != nil {
+		writeWebhookJSON(w, http.StatusOK, resp)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// callRPC executes the method of the request and returns its response, or nil for the notifications.
+func (ws *webhookServer) callRPC(ctx context.Context, raw json.RawMessage) *rpcResponse {
+	var r rpcRequest
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return rpcFailure(nil, rpcParseError, "the request is not valid JSON")
+	}
+	if r.JSONRPC != "2.0" || r.Method == "" {
+		return rpcFailure(r.ID, rpcInvalidRequest, "the request must provide the 2.0 version and a method")
+	}
+
+	result, err := ws.dispatchRPC(ctx, r.Method, r.Params)
+	if r.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var re *rpcError
+		var we *webhookError
+		if errors.As(err, &re) {
+			return &rpcResponse{JSONRPC: "2.0", ID: r.ID, Error: re}
+		} else if errors.As(err, &we) {
+			return &rpcResponse{JSONRPC: "2.0", ID: r.ID, Error: &rpcError{
+				Code:    rpcServerError,
+				Message: we.msg,
+				Data:    &rpcErrorData{Status: we.status},
+			}}
+		}
+		return rpcFailure(r.ID, rpcServerError, err.Error())
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpcFailure(r.ID, rpcServerError, "failed to encode the result: "+err.Error())
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: r.ID, Result: data}
+}
+
+func (ws *webhookServer) dispatchRPC(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	if method == "sessions.start" {
+		if readOnlyRequest(ctx) {
+			return nil, errWebhookReadOnly
+		}
+
+		var wr webhookRequest
+
+		dec := json.NewDecoder(bytes.NewReader(params))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&wr); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "the parameters are not valid: " + err.Error()}
+		}
+		return ws.createSession(&wr)
+	}
+
+	var p rpcParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "the parameters are not valid: " + err.Error()}
+		}
+	}
+
+	switch method {
+	case "sessions.list":
+		return ws.listSessions(p.Labels), nil
+	case "sessions.get", "sessions.artifacts", "sessions.instances", "assets.list", "assets.graph":
+		if p.Session == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "the session parameter is required"}
+		}
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "the method " + method + " is not known"}
+	}
+
+	switch method {
+	case "sessions.get":
+		return ws.session(p.Session)
+	case "sessions.artifacts":
+		return ws.artifacts(p.Session)
+	case "sessions.instances":
+		return ws.setInstances(ctx, p.Session, p.Instances)
+	case "assets.graph":
+		return ws.neighborhood(ctx, p.Session, p.Asset)
+	}
+	return ws.assetPage(ctx, &p)
+}
+
+// assetPage returns the assets of the session graph database matching the query within the offset and limit of the parameters.
+func (ws *webhookServer) assetPage(ctx context.Context, p *rpcParams) (*rpcAssetPage, error) {
+	var since time.Time
+	if p.Since != "" {
+		t, err := time.Parse(time.RFC3339, p.Since)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "the since parameter must be an RFC3339 time"}
+		}
+		since = t
+	}
+
+	limit := p.Limit
+	if limit <= 0 {
+		limit = webhookAssetLimit
+	} else if limit > webhookMaxAssetLimit {
+		limit = webhookMaxAssetLimit
+	}
+	offset := p.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	g, err := ws.sessionGraph(p.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	var index int
+	page := &rpcAssetPage{Assets: []*client.Asset{}}
+	err = readWebhookAssets(ctx, g, since, func(a *client.Asset) error {
+		if !matchAssetQuery(a, p.Query) {
+			return nil
+		}
+		defer func() { index++ }()
+
+		if index < offset {
+			return nil
+		}
+		if len(page.Assets) == limit {
+			page.Next = offset + limit
+			return errRPCPageFull
+		}
+		page.Assets = append(page.Assets, a)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRPCPageFull) {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func rpcFailure(id json.RawMessage, code int, msg string) *rpcResponse {
+	return &rpcResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &rpcError{Code: code, Message: msg},
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/client"
+	"github.com/owasp-amass/amass/v4/enum"
+)
+
+func TestCheckWebhookConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		valid  bool
+	}{
+		{
+			name:   "Options without executables",
+			config: "options:\n  dedup_ttl: 1h\n  resolvers:\n    - 8.8.8.8\n",
+			valid:  true,
+		},
+		{
+			name:   "Plugin executable",
+			config: "options:\n  plugins:\n    - name: Example\n      path: /bin/sh\n",
+		},
+		{
+			name:   "Scripts directory",
+			config: "options:\n  scripts_directory: /tmp/scripts\n",
+		},
+		{
+			name:   "Hashcat binary",
+			config: "options:\n  hashcat_path: /tmp/hashcat\n",
+		},
+		{
+			name:   "Invalid YAML",
+			config: "options: [",
+		},
+		{
+			name:   "Wordlist within the session",
+			config: "options:\n  bruteforce:\n    wordlists:\n      - ./wordlists/names.txt\n",
+			valid:  true,
+		},
+		{
+			name:   "Wordlist outside the session",
+			config: "options:\n  wordlist:\n    - /etc/passwd\n",
+		},
+		{
+			name:   "Alterations wordlist above the session",
+			config: "options:\n  alterations:\n    wordlists:\n      - ../../etc/passwd\n",
+		},
+		{
+			name:   "Resolvers file outside the session",
+			config: "options:\n  resolvers:\n    - 8.8.8.8\n    - /etc/hosts\n",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkWebhookConfig(dir, test.config, false); (err == nil) != test.valid {
+				t.Errorf("checkWebhookConfig returned %v, expected the configuration to be valid: %t", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestWebhookConfigOutsideSession(t *testing.T) {
+	ws := newWebhookServer(context.Background(), "api-token", t.TempDir(), "", 1)
+	ws.allowPlugins = true
+	ws.run = func(ctx context.Context, args []string, log *os.File) error { return nil }
+
+	body := `{"session":"files","domains":["owasp.org"],"config":"options:\n  wordlist:\n    - /etc/passwd\n"}`
+	req := httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer api-token")
+	rec := httptest.NewRecorder()
+	ws.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected the wordlist outside the session directory to be refused, got status %d", rec.Code)
+	}
+	if _, err := os.Stat(filepath.Join(ws.dir, "files", webhookArtifactsDir)); err == nil {
+		t.Errorf("Expected the session not to be prepared")
+	}
+}
+
+func TestWebhookReadOnlyUIToken(t *testing.T) {
+	ws := newWebhookServer(context.Background(), "api-token", t.TempDir(), "", 1)
+	ws.ui = true
+	ws.uiToken = "ui-token"
+	ws.run = func(ctx context.Context, args []string, log *os.File) error { return nil }
+
+	call := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		ws.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	start := `{"session":"ro","domains":["owasp.org"]}`
+	if code := call(http.MethodPost, "/sessions", "ui-token", start); code != http.StatusForbidden {
+		t.Errorf("Expected the UI token to be refused starting a session, got status %d", code)
+	}
+	if code := call(http.MethodGet, "/sessions", "ui-token", ""); code != http.StatusOK {
+		t.Errorf("Expected the UI token to list the sessions, got status %d", code)
+	}
+
+	rpc := `{"jsonrpc":"2.0","id":1,"method":"sessions.start","params":` + start + `}`
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(rpc))
+	req.Header.Set("Authorization", "Bearer ui-token")
+	rec := httptest.NewRecorder()
+	ws.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"status":403`) {
+		t.Errorf("Expected the UI token to be refused starting a session by JSON-RPC, got %s", rec.Body.String())
+	}
+
+	ws.uiWrite = true
+	if code := call(http.MethodPost, "/sessions", "ui-token", start); code != http.StatusAccepted {
+		t.Errorf("Expected the UI token to start a session with -ui-write, got status %d", code)
+	}
+	if code := call(http.MethodGet, "/sessions", "other-token", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown token to be refused, got status %d", code)
+	}
+	ws.wg.Wait()
+}
+
+func TestWebhookSessionGraph(t *testing.T) {
+	ws := newWebhookServer(context.Background(), "api-token", t.TempDir(), "", 1)
+	ws.sessions["graph"] = &webhookSession{ID: "graph", Status: client.StatusFinished}
+
+	if _, err := ws.sessionGraph("graph"); err == nil {
+		t.Errorf("Expected an error for the session without a graph database")
+	}
+
+	db := filepath.Join(ws.dir, "graph", "amass.sqlite")
+	if err := os.MkdirAll(filepath.Dir(db), 0755); err != nil {
+		t.Fatalf("Failed to create the session directory: %v", err)
+	}
+	g := netmap.NewGraph("local", db, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+
+	first, err := ws.sessionGraph("graph")
+	if err != nil {
+		t.Fatalf("Failed to open the session graph: %v", err)
+	}
+	second, err := ws.sessionGraph("graph")
+	if err != nil {
+		t.Fatalf("Failed to open the session graph again: %v", err)
+	}
+	if first != second {
+		t.Errorf("Expected the requests to share the graph database of the session")
+	}
+}
+
+func TestWebhookNeighborhoodGraph(t *testing.T) {
+	ws := newWebhookServer(context.Background(), "api-token", t.TempDir(), "", 1)
+	ws.ui = true
+	ws.uiToken = "ui-token"
+	ws.sessions["graph"] = &webhookSession{ID: "graph", Status: client.StatusFinished}
+
+	db := filepath.Join(ws.dir, "graph", "amass.sqlite")
+	if err := os.MkdirAll(filepath.Dir(db), 0755); err != nil {
+		t.Fatalf("Failed to create the session directory: %v", err)
+	}
+	g := netmap.NewGraph("local", db, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	if _, err := g.UpsertFQDN(context.Background(), "www.owasp.org"); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/sessions/graph/graph?asset=www.owasp.org", nil)
+		req.Header.Set("Authorization", "Bearer ui-token")
+		rec := httptest.NewRecorder()
+		ws.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "www.owasp.org") {
+			t.Errorf("Unexpected response for the neighborhood: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	if len(ws.graphs) != 1 {
+		t.Errorf("Expected the neighborhood requests to share one graph database, got %d", len(ws.graphs))
+	}
+}
+
+func TestWebhookSetInstances(t *testing.T) {
+	ws := newWebhookServer(context.Background(), "api-token", t.TempDir(), "", 1)
+	ws.ui = true
+	ws.uiToken = "ui-token"
+	ws.sessions["live"] = &webhookSession{ID: "live", Status: client.StatusRunning}
+	ws.sessions["done"] = &webhookSession{ID: "done", Status: client.StatusFinished}
+	if err := os.MkdirAll(filepath.Join(ws.dir, "live"), 0755); err != nil {
+		t.Fatalf("Failed to create the session directory: %v", err)
+	}
+
+	call := func(path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		ws.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, test := range []struct {
+		path, token, body string
+		status            int
+	}{
+		{"/sessions/live/instances", "api-token", `{"Crtsh":4}`, http.StatusAccepted},
+		{"/sessions/live/instances", "api-token", `{"CommonCrawl":2}`, http.StatusAccepted},
+		{"/sessions/live/instances", "api-token", `{"Crtsh":0}`, http.StatusBadRequest},
+		{"/sessions/live/instances", "api-token", `{}`, http.StatusBadRequest},
+		{"/sessions/live/instances", "ui-token", `{"Crtsh":2}`, http.StatusForbidden},
+		{"/sessions/done/instances", "api-token", `{"Crtsh":2}`, http.StatusConflict},
+		{"/sessions/unknown/instances", "api-token", `{"Crtsh":2}`, http.StatusNotFound},
+	} {
+		if rec := call(test.path, test.token, test.body); rec.Code != test.status {
+			t.Errorf("Expected status %d for %s with %s, got %d: %s", test.status, test.path, test.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(ws.dir, "live", enum.InstancesFile))
+	if err != nil {
+		t.Fatalf("Failed to read the instances of the session: %v", err)
+	}
+	if string(data) != `{"CommonCrawl":2,"Crtsh":4}` {
+		t.Errorf("Unexpected instances requested for the session: %s", data)
+	}
+
+	rpc := `{"jsonrpc":"2.0","id":1,"method":"sessions.instances","params":{"session":"live","instances":{"Crtsh":1}}}`
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(rpc))
+	req.Header.Set("Authorization", "Bearer api-token")
+	rec := httptest.NewRecorder()
+	ws.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"result":{"CommonCrawl":2,"Crtsh":1}`) {
+		t.Errorf("Unexpected response to the JSON-RPC method: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webhookUIFiles holds the web UI, which browses the sessions, searches their assets, shows the relations
+// around an asset and downloads the artifacts by calling the sessions API with the token entered by the user.
+//
+//go:embed ui
+var webhookUIFiles embed.FS
+
+// webhookUIConfig is served at '/ui/config.json', so the UI only offers to start sessions when allowed.
+type webhookUIConfig struct {
+	Write bool `json:"write"`
+}
+
+// serveUI serves the files of the web UI, redirecting the root of the server to the UI.
+func (ws *webhookServer) serveUI(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/", "/ui":
+		http.Redirect(w, req, "/ui/", http.StatusFound)
+		return
+	case "/ui/config.json":
+		writeWebhookJSON(w, http.StatusOK, &webhookUIConfig{Write: ws.uiWrite})
+		return
+	}
+
+	files, err := fs.Sub(webhookUIFiles, "ui")
+	if err != nil {
+		writeWebhookError(w, http.StatusInternalServerError, "the web UI is not available")
+		return
+	}
+	// The names and other values shown by the UI were collected from untrusted sources
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.StripPrefix("/ui/", http.FileServer(http.FS(files))).ServeHTTP(w, req)
+}
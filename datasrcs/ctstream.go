@@ -0,0 +1,256 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/service"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	// defaultCTPollInterval is the number of seconds between the checks for new log entries.
+	defaultCTPollInterval = 30
+	// maxCTEntries is the number of entries requested at once, while the logs often return fewer.
+	maxCTEntries = 256
+	// maxCTBacklog limits the entries read after each check, so a busy log cannot stall the stream.
+	maxCTBacklog = 4096
+)
+
+// The entry types of the Merkle tree leaves in RFC 6962.
+const (
+	ctX509Entry    = 0
+	ctPrecertEntry = 1
+)
+
+// CTStream is the Service that follows certificate transparency logs while the enumeration runs,
+// providing the names in scope from the certificates issued after the enumeration started.
+type CTStream struct {
+	service.BaseService
+	sys      systems.System
+	logs     []string
+	interval time.Duration
+}
+
+// NewCTStream returns the Service following the RFC 6962 logs in the 'ct_logs' option, or nil when there are none.
+// The 'ct_poll_interval' option sets the number of seconds between the checks for new log entries.
+func NewCTStream(sys systems.System) *CTStream {
+	var logs []string
+	for _, l := range options.Strings(sys.Config(), "ct_logs") {
+		logs = append(logs, strings.TrimSuffix(l, "/"))
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	interval := defaultCTPollInterval
+	if n := options.Int(sys.Config(), "ct_poll_interval"); n > 0 {
+		interval = n
+	}
+
+	c := &CTStream{
+		sys:      sys,
+		logs:     logs,
+		interval: time.Duration(interval) * time.Second,
+	}
+	c.BaseService = *service.NewBaseService(c, "CTStream")
+	return c
+}
+
+// Description implements the Service interface.
+func (c *CTStream) Description() string {
+	return "certificate transparency stream"
+}
+
+// OnStart implements the Service interface.
+func (c *CTStream) OnStart() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.Done()
+		cancel()
+	}()
+
+	for _, l := range c.logs {
+		go c.follow(ctx, l)
+	}
+	return nil
+}
+
+// HandlesReq implements the Service interface. The stream provides names without being asked.
+func (c *CTStream) HandlesReq(req interface{}) bool {
+	return false
+}
+
+// follow reads the entries added to the log since the previous check, starting with the size of the
+// tree when the stream begins, so only the certificates issued during the enumeration are provided.
+func (c *CTStream) follow(ctx context.Context, log string) {
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+
+	next := int64(-1)
+	for {
+		if size, err := c.treeSize(ctx, log); err != nil {
+			c.sys.Config().Log.Printf("%s: %s: %v", c.String(), log, err)
+		} else if next < 0 {
+			next = size
+		} else {
+			if size-next > maxCTBacklog {
+				next = size - maxCTBacklog
+			}
+			next = c.readEntries(ctx, log, next, size)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (c *CTStream) treeSize(ctx context.Context, log string) (int64, error) {
+	resp, err := amasshttp.RequestWebPage(ctx, &amasshttp.Request{URL: log + "/ct/v1/get-sth"})
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("the log returned status %d", resp.StatusCode)
+	}
+
+	var sth struct {
+		TreeSize int64 `json:"tree_size"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &sth); err != nil {
+		return 0, err
+	}
+	return sth.TreeSize, nil
+}
+
+// readEntries returns the index of the first entry not read.
+func (c *CTStream) readEntries(ctx context.Context, log string, start, size int64) int64 {
+	for start < size {
+		end := start + maxCTEntries - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		u := fmt.Sprintf("%s/ct/v1/get-entries?start=%d&end=%d", log, start, end)
+		resp, err := amasshttp.RequestWebPage(ctx, &amasshttp.Request{URL: u})
+		if err != nil || resp.StatusCode != 200 {
+			break
+		}
+
+		var entries struct {
+			Entries []struct {
+				LeafInput []byte `json:"leaf_input"`
+				ExtraData []byte `json:"extra_data"`
+			} `json:"entries"`
+		}
+		if err := json.Unmarshal([]byte(resp.Body), &entries); err != nil || len(entries.Entries) == 0 {
+			break
+		}
+
+		for _, e := range entries.Entries {
+			if cert, err := parseCTEntry(e.LeafInput, e.ExtraData); err == nil {
+				c.certificate(cert)
+			}
+		}
+		start += int64(len(entries.Entries))
+	}
+	return start
+}
+
+// certificate provides the names in scope covered by the certificate and keeps it as a Certificate finding for each domain.
+func (c *CTStream) certificate(cert *x509.Certificate) {
+	cfg := c.sys.Config()
+
+	byDomain := make(map[string][]string)
+	for _, name := range amasshttp.NamesFromCert(cert) {
+		name = strings.ToLower(name)
+
+		if d := cfg.WhichDomain(name); d != "" && !cfg.Blacklisted(name) {
+			byDomain[d] = append(byDomain[d], name)
+		}
+	}
+
+	for d, names := range byDomain {
+		sort.Strings(names)
+
+		for _, name := range names {
+			c.send(&requests.DNSRequest{
+				Name:   name,
+				Domain: d,
+			})
+		}
+		c.sys.Findings().Add(&systems.Finding{
+			Type:     "Certificate",
+			Value:    hex.EncodeToString(cert.SerialNumber.Bytes()),
+			Domain:   d,
+			Relation: "issued_for",
+			Source:   c.String(),
+			Properties: map[string]string{
+				"issuer":     cert.Issuer.String(),
+				"names":      strings.Join(names, ","),
+				"not_before": cert.NotBefore.UTC().Format(time.RFC3339),
+				"not_after":  cert.NotAfter.UTC().Format(time.RFC3339),
+			},
+		})
+	}
+}
+
+func (c *CTStream) send(req interface{}) {
+	select {
+	case <-c.Done():
+	case c.Output() <- req:
+	}
+}
+
+// parseCTEntry returns the certificate or precertificate of the log entry. The leaf of a precertificate
+// only holds the TBSCertificate, so the precertificate is read from the chain in the extra data.
+func parseCTEntry(leaf, extra []byte) (*x509.Certificate, error) {
+	// The MerkleTreeLeaf has the version, leaf type, timestamp and entry type before the entry
+	if len(leaf) < 12 || leaf[0] != 0 || leaf[1] != 0 {
+		return nil, errors.New("the log entry is not a timestamped entry")
+	}
+
+	var der []byte
+	switch binary.BigEndian.Uint16(leaf[10:12]) {
+	case ctX509Entry:
+		der = readCTCert(leaf[12:])
+	case ctPrecertEntry:
+		der = readCTCert(extra)
+	}
+	if der == nil {
+		return nil, errors.New("the log entry has no certificate")
+	}
+	// The poison extension of precertificates is only recorded as an unhandled critical extension
+	return x509.ParseCertificate(der)
+}
+
+// readCTCert returns the certificate following the 24-bit length at the start of the data.
+func readCTCert(data []byte) []byte {
+	if len(data) < 3 {
+		return nil
+	}
+
+	n := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	if n == 0 || len(data) < 3+n {
+		return nil
+	}
+	return data[3 : 3+n]
+}
@@ -0,0 +1,37 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"net/url"
+	"regexp"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+var scriptURLRE = regexp.MustCompile(`https?://[A-Za-z0-9.\-]+`)
+
+// allowSourceEgress adds the hosts of the data sources to the egress allowlist: the hosts of the
+// URLs found in the scripts, and the certificate transparency logs followed by the engine.
+func allowSourceEgress(cfg *config.Config, scripts []string) {
+	if !amassnet.EgressRestricted() {
+		return
+	}
+
+	urls := options.Strings(cfg, "ct_logs")
+	for _, script := range scripts {
+		urls = append(urls, scriptURLRE.FindAllString(script, -1)...)
+	}
+
+	var hosts []string
+	for _, u := range urls {
+		if parsed, err := url.Parse(u); err == nil && parsed.Hostname() != "" {
+			hosts = append(hosts, parsed.Hostname())
+		}
+	}
+	amassnet.AllowEgress(hosts...)
+}
@@ -0,0 +1,418 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/caffix/service"
+	"github.com/caffix/stringset"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/provenance"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"gopkg.in/yaml.v3"
+)
+
+var descriptorFieldRE = regexp.MustCompile(`{{\s*([a-z]+)\s*}}`)
+
+// descriptor declares a data source queried by the Generic service, so the simple REST APIs
+// can be added without writing a script. The descriptors are YAML files such as the following:
+//
+//	name: ExampleAPI
+//	url: "https://api.example.com/v1/domains/{{domain}}/subdomains"
+//	headers:
+//	  Authorization: "Bearer {{apikey}}"
+//	extract:
+//	  json: "data.subdomains[*].hostname"
+//	rate_limit: 2
+type descriptor struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Extract struct {
+		// JSON is the path of the values within the JSON response, where '[*]' selects each element of an array
+		JSON string `yaml:"json"`
+		// Regex extracts the names from the values, using its first submatch when it has one
+		Regex string `yaml:"regex"`
+	} `yaml:"extract"`
+	// RateLimit is the number of seconds between the requests
+	RateLimit int `yaml:"rate_limit"`
+}
+
+// Generic is the Service querying the REST API declared by a descriptor for the subdomains of each domain in scope.
+type Generic struct {
+	service.BaseService
+	sys   systems.System
+	desc  *descriptor
+	creds *config.Credentials
+	re    *regexp.Regexp
+	ctx   context.Context
+}
+
+// NewGenericSources returns a Generic service for each descriptor kept in the directories of the external scripts.
+// The descriptors are parsed from the YAML files, and must be signed like the scripts when the 'script_public_keys'
+// option is provided. The descriptors that are not valid, lack the credentials referenced by their templates, or have
+// the name of one of the other data sources provided are logged.
+func NewGenericSources(sys systems.System, descs []string, others []service.Service) []service.Service {
+	var srvs []service.Service
+
+	for _, data := range descs {
+		d, err := parseDescriptor([]byte(data))
+		if err != nil {
+			sys.Config().Log.Printf("Refused the descriptor: %v", err)
+			continue
+		}
+		if sourceNamed(others, d.Name) || sourceNamed(srvs, d.Name) {
+			sys.Config().Log.Printf("%s: the descriptor has the name of another data source", d.Name)
+			continue
+		}
+
+		if g, err := newGeneric(sys, d); err != nil {
+			sys.Config().Log.Printf("%s: %v", d.Name, err)
+		} else {
+			srvs = append(srvs, g)
+		}
+	}
+	return srvs
+}
+
+func newGeneric(sys systems.System, d *descriptor) (*Generic, error) {
+	g := &Generic{
+		sys:   sys,
+		desc:  d,
+		creds: &config.Credentials{},
+	}
+
+	if d.Extract.Regex != "" {
+		re, err := regexp.Compile(d.Extract.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("the extract regex is not valid: %v", err)
+		}
+		g.re = re
+	}
+	if dsc := sys.Config().GetDataSourceConfig(d.Name); dsc != nil {
+		for _, creds := range dsc.Creds {
+			g.creds = creds
+			break
+		}
+	}
+
+	templates := []string{d.URL}
+	for _, v := range d.Headers {
+		templates = append(templates, v)
+	}
+	for _, t := range templates {
+		for _, m := range descriptorFieldRE.FindAllStringSubmatch(t, -1) {
+			if m[1] != "domain" && g.field(m[1]) == "" {
+				return nil, fmt.Errorf("the %s credential is not configured", m[1])
+			}
+		}
+	}
+
+	g.BaseService = *service.NewBaseService(g, d.Name)
+	if d.RateLimit > 0 {
+		g.SetRateLimit(1)
+	}
+	return g, nil
+}
+
+// parseDescriptor returns the descriptor held by the YAML data, after checking the fields required to query the API.
+func parseDescriptor(data []byte) (*descriptor, error) {
+	var d descriptor
+
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if d.Name = strings.TrimSpace(d.Name); d.Name == "" {
+		return nil, errors.New("the descriptor has no name")
+	}
+	if !strings.HasPrefix(d.URL, "https://") && !strings.HasPrefix(d.URL, "http://") {
+		return nil, fmt.Errorf("%s: the url must be an HTTP or HTTPS URL", d.Name)
+	}
+
+	fields := descriptorFieldRE.FindAllStringSubmatch(d.URL, -1)
+	if !hasDomainField(fields) {
+		return nil, fmt.Errorf("%s: the url must contain the {{domain}} field", d.Name)
+	}
+	for _, v := range d.Headers {
+		fields = append(fields, descriptorFieldRE.FindAllStringSubmatch(v, -1)...)
+	}
+	for _, m := range fields {
+		switch m[1] {
+		case "domain", "apikey", "username", "password", "secret":
+		default:
+			return nil, fmt.Errorf("%s: the {{%s}} field is not known", d.Name, m[1])
+		}
+	}
+	return &d, nil
+}
+
+// Description implements the Service interface.
+func (g *Generic) Description() string {
+	return "api"
+}
+
+// OnStart implements the Service interface.
+func (g *Generic) OnStart() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.ctx = ctx
+	go func() {
+		<-g.Done()
+		cancel()
+	}()
+
+	go g.requests()
+	return nil
+}
+
+// HandlesReq implements the Service interface.
+func (g *Generic) HandlesReq(req interface{}) bool {
+	r, ok := req.(*requests.DNSRequest)
+	return ok && r != nil && r.Domain != ""
+}
+
+func (g *Generic) requests() {
+	for {
+		select {
+		case <-g.Done():
+			return
+		case in := <-g.Input():
+			if req, ok := in.(*requests.DNSRequest); ok && req.Domain != "" {
+				for i := 0; i < g.desc.RateLimit; i++ {
+					g.CheckRateLimit()
+				}
+				g.query(req.Domain)
+			}
+		}
+	}
+}
+
+func (g *Generic) query(domain string) {
+	g.sys.Config().Log.Printf("Querying %s for %s subdomains", g.String(), domain)
+
+	hdr := make(amasshttp.Header, len(g.desc.Headers))
+	for k, v := range g.desc.Headers {
+		hdr[k] = g.expand(v, domain)
+	}
+
+	resp, err := amasshttp.RequestWebPage(g.ctx, &amasshttp.Request{
+		URL:    g.expand(g.desc.URL, domain),
+		Header: hdr,
+	})
+	if err != nil {
+		g.sys.Config().Log.Printf("%s: %s: %v", g.String(), domain, err)
+		return
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		g.sys.Config().Log.Printf("%s: %s: the request returned with status: %s", g.String(), domain, resp.Status)
+		return
+	}
+
+	for _, name := range g.extract(resp.Body, domain) {
+		select {
+		case <-g.Done():
+			return
+		case g.Output() <- &requests.DNSRequest{
+			Name:   name,
+			Domain: domain,
+		}:
+		}
+	}
+}
+
+// extract returns the names in scope found in the values of the response selected by the descriptor.
+func (g *Generic) extract(body, domain string) []string {
+	values := []string{body}
+	if g.desc.Extract.JSON != "" {
+		var v interface{}
+
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			g.sys.Config().Log.Printf("%s: failed to decode the JSON response: %v", g.String(), err)
+			return nil
+		}
+		values = jsonPathValues(v, splitJSONPath(g.desc.Extract.JSON))
+	}
+
+	re := g.re
+	if re == nil {
+		re = amassdns.SubdomainRegex(domain)
+	}
+
+	names := stringset.New()
+	defer names.Close()
+
+	cfg := g.sys.Config()
+	for _, value := range values {
+		for _, m := range re.FindAllStringSubmatch(value, -1) {
+			match := m[0]
+			if g.re != nil && len(m) > 1 {
+				match = m[1]
+			}
+
+			name := amasshttp.CleanName(match)
+			if name != "" && cfg.WhichDomain(name) == domain && !cfg.Blacklisted(name) {
+				names.Insert(name)
+			}
+		}
+	}
+	return names.Slice()
+}
+
+// expand replaces the fields of the template with the domain name and the credentials of the data source.
+func (g *Generic) expand(tmpl, domain string) string {
+	return descriptorFieldRE.ReplaceAllStringFunc(tmpl, func(m string) string {
+		field := descriptorFieldRE.FindStringSubmatch(m)[1]
+		if field == "domain" {
+			return domain
+		}
+		return g.field(field)
+	})
+}
+
+func (g *Generic) field(name string) string {
+	switch name {
+	case "apikey":
+		return g.creds.Apikey
+	case "username":
+		return g.creds.Username
+	case "password":
+		return g.creds.Password
+	case "secret":
+		return g.creds.Secret
+	}
+	return ""
+}
+
+// splitJSONPath returns the segments of a path such as '$.data.subdomains[*].hostname'.
+func splitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".[")
+
+	var segments []string
+	for _, s := range strings.Split(path, ".") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// jsonPathValues returns the strings and numbers selected by the path segments. The keys of the
+// segments are applied to each element of the arrays, so the '[*]' segments are only required for
+// readability, while an index such as '[0]' selects a single element.
+func jsonPathValues(v interface{}, segments []string) []string {
+	if len(segments) == 0 {
+		switch t := v.(type) {
+		case string:
+			return []string{t}
+		case float64:
+			return []string{strconv.FormatFloat(t, 'f', -1, 64)}
+		case []interface{}:
+			var results []string
+			for _, e := range t {
+				results = append(results, jsonPathValues(e, nil)...)
+			}
+			return results
+		}
+		return nil
+	}
+
+	seg := segments[0]
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if strings.HasPrefix(seg, "[") {
+			return nil
+		}
+		return jsonPathValues(t[seg], segments[1:])
+	case []interface{}:
+		if seg == "[*]" || seg == "[]" {
+			return jsonPathValues(t, segments[1:])
+		}
+		if strings.HasPrefix(seg, "[") {
+			i, err := strconv.Atoi(strings.Trim(seg, "[]"))
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+			return jsonPathValues(t[i], segments[1:])
+		}
+
+		var results []string
+		for _, e := range t {
+			results = append(results, jsonPathValues(e, segments)...)
+		}
+		return results
+	}
+	return nil
+}
+
+// acquireDescriptors returns the YAML descriptors kept in the directories of the external scripts.
+// When the 'script_public_keys' option is provided, the descriptors are only returned when signed by one of the keys.
+func acquireDescriptors(cfg *config.Config) []string {
+	var keys []*provenance.PublicKey
+	if values := options.Strings(cfg, "script_public_keys"); len(values) > 0 {
+		k, err := provenance.LoadPublicKeys(values)
+		if err != nil {
+			cfg.Log.Printf("Refused the descriptors: %v", err)
+			return nil
+		}
+		keys = k
+	}
+
+	var descs []string
+	for _, dir := range scriptDirectories(cfg) {
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Is this file not a descriptor?
+			if ext := filepath.Ext(info.Name()); info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				return nil
+			}
+
+			var data []byte
+			if keys != nil {
+				data, err = provenance.VerifyFile(keys, path)
+			} else {
+				data, err = os.ReadFile(path)
+			}
+			if err != nil {
+				cfg.Log.Printf("Refused the descriptor %s: %v", path, err)
+				return nil
+			}
+			descs = append(descs, string(data))
+			return nil
+		})
+	}
+	return descs
+}
+
+func sourceNamed(srvs []service.Service, name string) bool {
+	for _, srv := range srvs {
+		if strings.EqualFold(srv.String(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDomainField(fields [][]string) bool {
+	for _, m := range fields {
+		if m[1] == "domain" {
+			return true
+		}
+	}
+	return false
+}
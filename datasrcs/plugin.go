@@ -0,0 +1,417 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/caffix/service"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/provenance"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// maxPluginRestarts is the number of times a plugin process is restarted after exiting during the enumeration
+	maxPluginRestarts = 5
+	// pluginRestartDelay is the time waited before the first restart, doubled after each restart
+	pluginRestartDelay = time.Second
+	// maxPluginLine is the length of the lines read from the plugin processes
+	maxPluginLine = 4 * 1024 * 1024
+)
+
+// pluginConfig is an external plugin declared in the 'plugins' option of the configuration.
+type pluginConfig struct {
+	Name string
+	Path string
+	Args []string
+}
+
+// pluginEvent is written to the standard input of a plugin process as a JSON line.
+type pluginEvent struct {
+	// Event is 'config' when the process starts, then 'vertical', 'resolved' or 'address'
+	Event       string                         `json:"event"`
+	Domain      string                         `json:"domain,omitempty"`
+	Name        string                         `json:"name,omitempty"`
+	Address     string                         `json:"address,omitempty"`
+	Domains     []string                       `json:"domains,omitempty"`
+	Credentials map[string]*config.Credentials `json:"credentials,omitempty"`
+}
+
+// pluginMessage is read from the standard output of a plugin process as a JSON line.
+type pluginMessage struct {
+	// Type is 'name', 'address', 'finding' or 'log'
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Kind, Value, Relation and Properties describe the finding linked to the name
+	Kind       string            `json:"kind"`
+	Value      string            `json:"value"`
+	Relation   string            `json:"relation"`
+	Properties map[string]string `json:"properties"`
+	Message    string            `json:"message"`
+}
+
+// Plugin is the Service forwarding the requests of the enumeration to a plugin executed as a separate process,
+// which can be written in any language. The events are JSON lines written to the standard input of the process,
+// and the names, addresses and findings are JSON lines read from its standard output, so the failures of the
+// plugin cannot disrupt the enumeration, and the process is restarted when it exits.
+type Plugin struct {
+	service.BaseService
+	sys  systems.System
+	conf *pluginConfig
+	keys []*provenance.PublicKey
+	ctx  context.Context
+}
+
+// NewPlugins returns a Plugin service for each plugin in the 'plugins' option, a list of tables such as the following:
+//
+//	plugins:
+//	  - name: Example
+//	    path: /usr/local/bin/amass-example
+//	    args: ["-verbose"]
+//
+// When the 'script_public_keys' option is provided, the plugin executables must be signed like the scripts.
+func NewPlugins(sys systems.System, others []service.Service) []service.Service {
+	confs, err := parsePlugins(sys.Config())
+	if err != nil {
+		sys.Config().Log.Printf("Plugins: %v", err)
+	}
+
+	var keys []*provenance.PublicKey
+	if values := options.Strings(sys.Config(), "script_public_keys"); len(values) > 0 && len(confs) > 0 {
+		// The plugins are refused when the keys cannot be read, rather than executed without verification
+		k, err := provenance.LoadPublicKeys(values)
+		if err != nil {
+			sys.Config().Log.Printf("Refused the plugins: %v", err)
+			return nil
+		}
+		keys = k
+	}
+
+	var srvs []service.Service
+	for _, c := range confs {
+		if sourceNamed(others, c.Name) || sourceNamed(srvs, c.Name) {
+			sys.Config().Log.Printf("%s: the plugin has the name of another data source", c.Name)
+			continue
+		}
+		path, err := exec.LookPath(c.Path)
+		if err != nil {
+			sys.Config().Log.Printf("%s: %v", c.Name, err)
+			continue
+		}
+		c.Path = path
+
+		p := &Plugin{
+			sys:  sys,
+			conf: c,
+			keys: keys,
+		}
+		if err := p.verify(); err != nil {
+			sys.Config().Log.Printf("%s: refused the plugin %s: %v", c.Name, path, err)
+			continue
+		}
+		p.BaseService = *service.NewBaseService(p, c.Name)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		p.ctx = ctx
+		go func() {
+			<-p.Done()
+			cancel()
+		}()
+		srvs = append(srvs, p)
+	}
+	return srvs
+}
+
+func parsePlugins(cfg *config.Config) ([]*pluginConfig, error) {
+	list, ok := cfg.Options["plugins"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var plugins []*pluginConfig
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return plugins, fmt.Errorf("plugin %d is not a table", i+1)
+		}
+
+		p := &pluginConfig{
+			Name: strings.TrimSpace(fmt.Sprint(m["name"])),
+			Path: strings.TrimSpace(fmt.Sprint(m["path"])),
+		}
+		if m["name"] == nil || p.Name == "" {
+			return plugins, fmt.Errorf("plugin %d has no name", i+1)
+		}
+		if m["path"] == nil || p.Path == "" {
+			return plugins, fmt.Errorf("plugin %d has no path", i+1)
+		}
+		if args, ok := m["args"].([]interface{}); ok {
+			for _, a := range args {
+				p.Args = append(p.Args, fmt.Sprint(a))
+			}
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Description implements the Service interface.
+func (p *Plugin) Description() string {
+	return "plugin"
+}
+
+// OnStart implements the Service interface.
+func (p *Plugin) OnStart() error {
+	go p.run()
+	return nil
+}
+
+// HandlesReq implements the Service interface.
+func (p *Plugin) HandlesReq(req interface{}) bool {
+	switch v := req.(type) {
+	case *requests.DNSRequest:
+		return v != nil && v.Domain != ""
+	case *requests.ResolvedRequest:
+		return v != nil && v.Name != ""
+	case *requests.AddrRequest:
+		return v != nil && v.Address != ""
+	}
+	return false
+}
+
+// run executes the plugin process and forwards the requests to it, restarting the process when it exits.
+func (p *Plugin) run() {
+	delay := pluginRestartDelay
+
+	for restarts := 0; ; restarts++ {
+		err := p.execute()
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		p.sys.Config().Log.Printf("%s: the plugin process exited: %v", p.String(), err)
+		if restarts >= maxPluginRestarts {
+			p.sys.Config().Log.Printf("%s: the plugin was restarted %d times and is disabled", p.String(), restarts)
+			p.discard(nil)
+			return
+		}
+
+		t := time.NewTimer(delay)
+		p.discard(t.C)
+		t.Stop()
+		delay *= 2
+	}
+}
+
+// discard drops the requests while the plugin process is not running, so the enumeration is not blocked
+// by the plugin, until the stop channel is closed or the service stops.
+func (p *Plugin) discard(stop <-chan time.Time) {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-p.Input():
+		}
+	}
+}
+
+// verify checks the signature of the plugin executable when the trusted keys are provided.
+func (p *Plugin) verify() error {
+	if p.keys == nil {
+		return nil
+	}
+
+	_, err := provenance.VerifyFile(p.keys, p.conf.Path)
+	return err
+}
+
+// execute starts the plugin process and writes the requests to it until the process exits or the service stops.
+// The executable is verified before each start, since it can be replaced while the enumeration runs.
+func (p *Plugin) execute() error {
+	if err := p.verify(); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(p.ctx, p.conf.Path, p.conf.Args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		p.logLines(stderr)
+		exited <- p.readMessages(stdout)
+	}()
+
+	enc := json.NewEncoder(stdin)
+	err = enc.Encode(p.configEvent())
+	for err == nil {
+		select {
+		case <-p.ctx.Done():
+			_ = stdin.Close()
+			_ = cmd.Wait()
+			return nil
+		case err = <-exited:
+		case in := <-p.Input():
+			if ev := pluginRequestEvent(in); ev != nil {
+				err = enc.Encode(ev)
+			}
+		}
+	}
+
+	_ = stdin.Close()
+	if werr := cmd.Wait(); werr != nil {
+		err = werr
+	}
+	return err
+}
+
+// configEvent provides the domain names in scope and the credentials configured for the plugin.
+func (p *Plugin) configEvent() *pluginEvent {
+	ev := &pluginEvent{
+		Event:   "config",
+		Domains: p.sys.Config().Domains(),
+	}
+
+	if dsc := p.sys.Config().GetDataSourceConfig(p.String()); dsc != nil && len(dsc.Creds) > 0 {
+		ev.Credentials = dsc.Creds
+	}
+	return ev
+}
+
+func pluginRequestEvent(in interface{}) *pluginEvent {
+	switch req := in.(type) {
+	case *requests.DNSRequest:
+		if req.Domain != "" {
+			return &pluginEvent{Event: "vertical", Domain: req.Domain}
+		}
+	case *requests.ResolvedRequest:
+		if req.Name != "" {
+			return &pluginEvent{Event: "resolved", Name: req.Name, Domain: req.Domain}
+		}
+	case *requests.AddrRequest:
+		if req.Address != "" {
+			return &pluginEvent{Event: "address", Address: req.Address, Domain: req.Domain}
+		}
+	}
+	return nil
+}
+
+// logLines writes the standard error of the plugin process to the log in the background.
+func (p *Plugin) logLines(r io.Reader) {
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			p.sys.Config().Log.Printf("%s: %s", p.String(), scanner.Text())
+		}
+	}()
+}
+
+// readMessages handles the JSON lines written by the plugin process until its standard output is closed.
+func (p *Plugin) readMessages(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxPluginLine)
+
+	for scanner.Scan() {
+		var m pluginMessage
+
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			p.sys.Config().Log.Printf("%s: the plugin wrote a line that is not JSON: %v", p.String(), err)
+			continue
+		}
+		p.handleMessage(&m)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (p *Plugin) handleMessage(m *pluginMessage) {
+	cfg := p.sys.Config()
+	name := strings.ToLower(strings.TrimSpace(m.Name))
+
+	switch m.Type {
+	case "log":
+		cfg.Log.Printf("%s: %s", p.String(), m.Message)
+	case "name":
+		if d := cfg.WhichDomain(name); d != "" && !cfg.Blacklisted(name) {
+			p.send(&requests.DNSRequest{
+				Name:   name,
+				Domain: d,
+			})
+		}
+	case "address":
+		ip := net.ParseIP(m.Address)
+		if ip == nil {
+			return
+		}
+		if reserved, _ := amassnet.IsReservedAddress(ip.String()); reserved {
+			return
+		}
+		if d := cfg.WhichDomain(name); d != "" {
+			p.send(&requests.AddrRequest{
+				Address: ip.String(),
+				Domain:  d,
+			})
+		}
+	case "finding":
+		d := cfg.WhichDomain(name)
+		if d == "" || m.Kind == "" || m.Value == "" {
+			return
+		}
+
+		f := &systems.Finding{
+			Type:       m.Kind,
+			Value:      m.Value,
+			Domain:     d,
+			Relation:   m.Relation,
+			Source:     p.String(),
+			Properties: m.Properties,
+		}
+		if f.Relation == "" {
+			f.Relation = "associated_with"
+		}
+		if (f.Type == "Service" || f.Type == "Port") && !systems.AssetInScope(cfg, f.Type, f.Value) {
+			return
+		}
+		p.sys.Findings().Add(f)
+	}
+}
+
+func (p *Plugin) send(req interface{}) {
+	select {
+	case <-p.Done():
+	case p.Output() <- req:
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/provenance"
+	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/config/config"
+)
+
+// acquireScripts returns the scripts of the data sources. When the 'script_public_keys' option provides
+// the trusted minisign public keys, the external scripts are only returned when signed by one of the keys,
+// while the scripts embedded in the binary are trusted along with the binary.
+func acquireScripts(cfg *config.Config) ([]string, error) {
+	values := options.Strings(cfg, "script_public_keys")
+	if len(values) == 0 {
+		return cfg.AcquireScripts()
+	}
+
+	scripts, err := resources.GetDefaultScripts()
+	if err != nil {
+		return scripts, err
+	}
+	// The external scripts are refused when the keys cannot be read, rather than executed without verification
+	keys, err := provenance.LoadPublicKeys(values)
+	if err != nil {
+		cfg.Log.Printf("Refused the external scripts: %v", err)
+		return scripts, nil
+	}
+
+	for _, dir := range scriptDirectories(cfg) {
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Is this file not a script?
+			if info.IsDir() || filepath.Ext(info.Name()) != ".ads" {
+				return nil
+			}
+
+			data, err := provenance.VerifyFile(keys, path)
+			if err != nil {
+				cfg.Log.Printf("Refused the script %s: %v", path, err)
+				return nil
+			}
+			scripts = append(scripts, string(data))
+			return nil
+		})
+	}
+	return scripts, nil
+}
+
+// scriptDirectories returns the directories holding the external data source scripts: the
+// 'scripts' directory of the output directory and the directory provided by the '-scripts' flag.
+func scriptDirectories(cfg *config.Config) []string {
+	var dirs []string
+
+	if dir := config.OutputDirectory(cfg.Dir); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "scripts"))
+	}
+	if cfg.ScriptsDirectory != "" {
+		dirs = append(dirs, cfg.ScriptsDirectory)
+	}
+	return dirs
+}
@@ -0,0 +1,221 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// rule is a derivation declared in the 'rules' option of the configuration.
+type rule struct {
+	Type     string
+	Match    *regexp.Regexp
+	Netblock *net.IPNet
+	Emit     []string
+	Tags     map[string]string
+}
+
+// Rules is the Service that applies the user-defined rules to the names and addresses discovered.
+type Rules struct {
+	service.BaseService
+	sys   systems.System
+	rules []*rule
+}
+
+// NewRules returns the Service for the rules declared in the configuration, or nil when there are none.
+func NewRules(sys systems.System) *Rules {
+	rules, err := parseRules(sys.Config())
+	if err != nil {
+		sys.Config().Log.Printf("Rules: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	r := &Rules{
+		sys:   sys,
+		rules: rules,
+	}
+	r.BaseService = *service.NewBaseService(r, "Rules")
+	return r
+}
+
+// parseRules reads the 'rules' option, a list of tables such as the following:
+//
+//	rules:
+//	  - type: fqdn
+//	    match: "^(.+)\\.dev\\.example\\.com$"
+//	    emit: "$1.staging.example.com"
+//	  - type: address
+//	    netblock: 192.0.2.0/24
+//	    tags:
+//	      region: eu
+func parseRules(cfg *config.Config) ([]*rule, error) {
+	list, ok := cfg.Options["rules"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var rules []*rule
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return rules, fmt.Errorf("rule %d is not a table", i+1)
+		}
+
+		r := &rule{Type: strings.ToLower(fmt.Sprint(m["type"]))}
+		if r.Type != "fqdn" && r.Type != "address" {
+			return rules, fmt.Errorf("rule %d has an unknown type: %s", i+1, r.Type)
+		}
+		if v, found := m["match"]; found {
+			re, err := regexp.Compile(fmt.Sprint(v))
+			if err != nil {
+				return rules, fmt.Errorf("rule %d has an invalid match expression: %v", i+1, err)
+			}
+			r.Match = re
+		}
+		if v, found := m["netblock"]; found {
+			_, ipnet, err := net.ParseCIDR(fmt.Sprint(v))
+			if err != nil {
+				return rules, fmt.Errorf("rule %d has an invalid netblock: %v", i+1, err)
+			}
+			r.Netblock = ipnet
+		}
+		switch v := m["emit"].(type) {
+		case string:
+			r.Emit = []string{v}
+		case []interface{}:
+			for _, e := range v {
+				r.Emit = append(r.Emit, fmt.Sprint(e))
+			}
+		}
+		if tags, ok := m["tags"].(map[string]interface{}); ok {
+			r.Tags = make(map[string]string, len(tags))
+			for k, v := range tags {
+				r.Tags[k] = fmt.Sprint(v)
+			}
+		}
+
+		if r.Match == nil && r.Netblock == nil {
+			return rules, fmt.Errorf("rule %d requires a match expression or netblock", i+1)
+		}
+		if len(r.Emit) == 0 && len(r.Tags) == 0 {
+			return rules, fmt.Errorf("rule %d requires names to emit or tags", i+1)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Description implements the Service interface.
+func (r *Rules) Description() string {
+	return "rules"
+}
+
+// OnStart implements the Service interface.
+func (r *Rules) OnStart() error {
+	go r.requests()
+	return nil
+}
+
+// HandlesReq implements the Service interface.
+func (r *Rules) HandlesReq(req interface{}) bool {
+	switch v := req.(type) {
+	case *requests.ResolvedRequest:
+		return v != nil && v.Name != ""
+	}
+	return false
+}
+
+func (r *Rules) requests() {
+	for {
+		select {
+		case <-r.Done():
+			return
+		case in := <-r.Input():
+			if req, ok := in.(*requests.ResolvedRequest); ok {
+				r.applyToName(req.Name, req.Domain)
+			}
+		}
+	}
+}
+
+func (r *Rules) applyToName(name, domain string) {
+	for _, rl := range r.rules {
+		if rl.Type != "fqdn" || rl.Match == nil {
+			continue
+		}
+
+		match := rl.Match.FindStringSubmatchIndex(name)
+		if match == nil {
+			continue
+		}
+
+		for _, tmpl := range rl.Emit {
+			derived := strings.ToLower(string(rl.Match.ExpandString(nil, tmpl, name, match)))
+
+			if d := r.sys.Config().WhichDomain(derived); d != "" && !strings.EqualFold(derived, name) {
+				r.send(&requests.DNSRequest{
+					Name:   derived,
+					Domain: d,
+				})
+			}
+		}
+		r.tag("FQDN", name, domain, rl.Tags)
+	}
+}
+
+// ApplyToAddr tags the address with the address rules that match it. The enumeration calls it as the
+// addresses in scope are stored, since the addresses are not sent to the data sources.
+func (r *Rules) ApplyToAddr(addr, domain string) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return
+	}
+
+	for _, rl := range r.rules {
+		if rl.Type != "address" {
+			continue
+		}
+		if rl.Netblock != nil && !rl.Netblock.Contains(ip) {
+			continue
+		}
+		if rl.Match != nil && !rl.Match.MatchString(addr) {
+			continue
+		}
+		r.tag("IPAddress", addr, domain, rl.Tags)
+	}
+}
+
+// tag stores the tags of the asset as the properties of a finding.
+func (r *Rules) tag(atype, value, domain string, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	r.sys.Findings().Add(&systems.Finding{
+		Type:       atype,
+		Value:      value,
+		Domain:     domain,
+		Relation:   "tagged",
+		Source:     r.String(),
+		Properties: tags,
+	})
+}
+
+func (r *Rules) send(req interface{}) {
+	select {
+	case <-r.Done():
+	case r.Output() <- req:
+	}
+}
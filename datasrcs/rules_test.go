@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestNameRules(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Log = log.New(io.Discard, "", 0)
+	cfg.AddDomain("example.com")
+	cfg.Options["rules"] = []interface{}{
+		map[string]interface{}{
+			"type":  "fqdn",
+			"match": "^(.+)\\.dev\\.example\\.com$",
+			"emit":  []interface{}{"$1.staging.example.com", "$1.dev.example.com", "$1.example.org"},
+			"tags":  map[string]interface{}{"env": "dev"},
+		},
+	}
+
+	finds, err := systems.NewFindingStore("")
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys := &systems.SimpleSystem{
+		Cfg:   cfg,
+		Finds: finds,
+	}
+
+	r := NewRules(sys)
+	if r == nil {
+		t.Fatal("Failed to create the rules service")
+	}
+	if err := r.Start(); err != nil {
+		t.Fatalf("Failed to start the rules service: %v", err)
+	}
+	defer func() { _ = r.Stop() }()
+
+	for _, name := range []string{"www.example.com", "API.dev.example.com"} {
+		req := &requests.ResolvedRequest{Name: name, Domain: "example.com"}
+		if !r.HandlesReq(req) {
+			t.Fatalf("Expected the rules service to handle the name %s", name)
+		}
+		r.Input() <- req
+	}
+
+	// Only the derived name within scope and different from the matched name is emitted
+	select {
+	case out := <-r.Output():
+		req, ok := out.(*requests.DNSRequest)
+		if !ok || req.Name != "api.staging.example.com" || req.Domain != "example.com" {
+			t.Errorf("Unexpected request emitted by the rules: %+v", out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The rules did not emit the derived name")
+	}
+	select {
+	case out := <-r.Output():
+		t.Errorf("Unexpected additional request emitted by the rules: %+v", out)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if f := finds.Get("FQDN", "API.dev.example.com", "example.com"); f == nil || f.Relation != "tagged" || f.Properties["env"] != "dev" {
+		t.Errorf("Expected the matched name to be tagged, got %+v", f)
+	}
+	if f := finds.Get("FQDN", "www.example.com", "example.com"); f != nil {
+		t.Errorf("Expected the name not matching the rule to remain untagged: %+v", f)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/systems"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxArchiveEntrySize is the largest file within an application package that is searched.
+const maxArchiveEntrySize = 64 * 1024 * 1024
+
+// cdxTimeFormat is the layout of the capture timestamps provided by the CDX servers of web archives.
+const cdxTimeFormat = "20060102150405"
+
+var endpointRegex = regexp.MustCompile(`https?://[a-zA-Z0-9._\-]+(?::[0-9]+)?(?:/[a-zA-Z0-9._~%!$&'()*+,;=:@/\-]*)?`)
+
+// archivedURLLock serializes the merging of the capture times provided by the archives for the same URL.
+var archivedURLLock sync.Mutex
+
+// Wrapper so that scripts can send the names and API endpoints embedded in a mobile application
+// package, such as an APK or IPA file, to Amass. The number of names found is returned.
+func (s *Script) sendAppNames(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	num, err := s.internalSendAppNames(ctx, L.CheckString(2))
+	L.Push(lua.LNumber(num))
+	if err != nil {
+		L.Push(lua.LString(err.Error()))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 2
+}
+
+func (s *Script) internalSendAppNames(ctx context.Context, path string) (int, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var count int
+	for _, f := range r.File {
+		if contextExpired(ctx) {
+			break
+		}
+		if f.FileInfo().IsDir() || f.UncompressedSize64 > maxArchiveEntrySize {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxArchiveEntrySize))
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		// Strings in the binary XML and resource files are encoded as UTF-16
+		content := string(bytes.ReplaceAll(data, []byte{0}, nil))
+
+		count += s.internalSendNames(ctx, content)
+		s.sendEndpoints(content, path)
+	}
+	return count, nil
+}
+
+// sendEndpoints stores the URLs for in-scope hosts found in the content as API endpoint findings.
+func (s *Script) sendEndpoints(content, origin string) {
+	for _, match := range endpointRegex.FindAllString(content, -1) {
+		u, err := url.Parse(match)
+		if err != nil {
+			continue
+		}
+
+		host := http.CleanName(u.Hostname())
+		domain := s.sys.Config().WhichDomain(host)
+		if domain == "" || strings.Trim(u.Path, "/") == "" {
+			continue
+		}
+
+		s.sys.Findings().Add(&systems.Finding{
+			Type:       "URL",
+			Value:      u.Scheme + "://" + u.Host + u.Path,
+			Domain:     domain,
+			Relation:   "api_endpoint",
+			Source:     s.String(),
+			Properties: map[string]string{"origin": origin},
+		})
+	}
+}
+
+// Wrapper so that scripts can send a URL captured by a web archive to Amass, along with the times of the
+// first and last captures, as CDX timestamps or RFC3339 times. The names found in the URL are sent, and the
+// URLs of in-scope hosts are kept as findings holding the earliest and latest captures of all the archives.
+func (s *Script) newArchivedURL(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	raw := strings.TrimSpace(L.CheckString(2))
+	if raw == "" {
+		return 0
+	}
+	s.internalSendNames(ctx, raw)
+
+	first := parseCaptureTime(L.OptString(3, ""))
+	last := parseCaptureTime(L.OptString(4, ""))
+	if last.IsZero() || last.Before(first) {
+		last = first
+	}
+	s.keepArchivedURL(raw, first, last)
+	return 0
+}
+
+func (s *Script) keepArchivedURL(raw string, first, last time.Time) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+
+	cfg := s.sys.Config()
+	host := http.CleanName(u.Hostname())
+	domain := cfg.WhichDomain(host)
+	value := u.Scheme + "://" + strings.ToLower(u.Host) + u.Path
+	if domain == "" || !systems.AssetInScope(cfg, "URL", value) {
+		return
+	}
+
+	archivedURLLock.Lock()
+	defer archivedURLLock.Unlock()
+
+	props := make(map[string]string)
+	if cur := s.sys.Findings().Get("URL", value, domain); cur != nil {
+		if t := parseCaptureTime(cur.Properties["first_seen"]); !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+		if t := parseCaptureTime(cur.Properties["last_seen"]); t.After(last) {
+			last = t
+		}
+	}
+	if !first.IsZero() {
+		props["first_seen"] = first.UTC().Format(time.RFC3339)
+		props["last_seen"] = last.UTC().Format(time.RFC3339)
+	}
+	if c := s.confidence(); c != "" {
+		props["confidence"] = c
+	}
+
+	s.sys.Findings().Add(&systems.Finding{
+		Type:       "URL",
+		Value:      value,
+		Domain:     domain,
+		Relation:   "archived",
+		Source:     s.String(),
+		Properties: props,
+	})
+}
+
+func parseCaptureTime(ts string) time.Time {
+	ts = strings.TrimSpace(ts)
+
+	if t, err := time.Parse(time.RFC3339, ts); err == nil {
+		return t
+	}
+	// The CDX timestamps are truncated to the precision of the capture
+	if len(ts) >= 4 && len(ts) <= len(cdxTimeFormat) && len(ts)%2 == 0 {
+		if t, err := time.Parse(cdxTimeFormat[:len(ts)], ts); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,148 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+func TestSendAppNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.apk")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create the application package: %v", err)
+	}
+	w := zip.NewWriter(f)
+	dex, _ := w.Create("classes.dex")
+	_, _ = dex.Write([]byte("\x00\x01https://api.owasp.org/v1/users\x00\x02cdn.example.com\x00"))
+	// Binary XML stores the strings as UTF-16
+	manifest, _ := w.Create("AndroidManifest.xml")
+	for _, c := range "auth.owasp.org" {
+		_, _ = manifest.Write([]byte{byte(c), 0})
+	}
+	_ = w.Close()
+	_ = f.Close()
+
+	script, sys := setupMockScriptEnv(fmt.Sprintf(`
+		name="apps"
+		type="testing"
+
+		function vertical(ctx, domain)
+			send_app_names(ctx, %q)
+		end
+	`, path))
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(dir, systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	expected := map[string]struct{}{"api.owasp.org": {}, "auth.owasp.org": {}}
+	for i := 0; i < 2; i++ {
+		req := <-sys.DataSources()[0].Output()
+
+		if d, ok := req.(*requests.DNSRequest); !ok {
+			t.Errorf("Name %d: %v was not a DNS request", i+1, req)
+		} else if _, found := expected[d.Name]; !found {
+			t.Errorf("Name %d: %v was not found in the list of expected names", i+1, d.Name)
+		}
+	}
+
+	// The endpoints of the first file were stored before the names of the second file were sent
+	if found := findings.Find(time.Time{}, "URL"); len(found) != 1 || found[0].Value != "https://api.owasp.org/v1/users" {
+		t.Errorf("The API endpoint was not stored as expected: %v", found)
+	}
+}
+
+func TestNewArchivedURL(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="archive"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_archived_url(ctx, "https://www.owasp.org/login?next=/", "20190102030405", "20200102030405")
+			new_archived_url(ctx, "https://www.owasp.org/login", "2018-05-06T07:08:09Z", "")
+			new_archived_url(ctx, "http://www.example.com/owasp.org", "20210102030405", "")
+			new_archived_url(ctx, "ftp://files.owasp.org/", "20210102030405", "")
+			new_name(ctx, "zzdone.owasp.org")
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(t.TempDir(), systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	names := make(map[string]struct{})
+	for done := false; !done; {
+		req := <-sys.DataSources()[0].Output()
+
+		if d, ok := req.(*requests.DNSRequest); ok {
+			names[d.Name] = struct{}{}
+			done = d.Name == "zzdone.owasp.org"
+		}
+	}
+	if _, found := names["www.owasp.org"]; !found {
+		t.Errorf("The name of the archived URL was not sent: %v", names)
+	}
+	if _, found := names["files.owasp.org"]; !found {
+		t.Errorf("The name of the URL that was not kept was not sent: %v", names)
+	}
+
+	found := findings.Find(time.Time{}, "URL")
+	if len(found) != 1 || found[0].Value != "https://www.owasp.org/login" || found[0].Relation != "archived" {
+		t.Fatalf("The archived URL was not stored as expected: %v", found)
+	}
+	if p := found[0].Properties; p["first_seen"] != "2018-05-06T07:08:09Z" || p["last_seen"] != "2020-01-02T03:04:05Z" {
+		t.Errorf("The captures were not merged into the history of the URL: %v", p)
+	}
+}
+
+func TestParseCaptureTime(t *testing.T) {
+	tests := []struct {
+		ts       string
+		expected time.Time
+	}{
+		{"20230102030405", time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"202301", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2023-01-02T03:04:05Z", time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"20230", time.Time{}},
+		{"-", time.Time{}},
+	}
+
+	for _, test := range tests {
+		if got := parseCaptureTime(test.ts); !got.Equal(test.expected) {
+			t.Errorf("%q was parsed as %v, expected %v", test.ts, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// awsCredentials are provided by the 'aws' table of the request parameters.
+type awsCredentials struct {
+	Key     string
+	Secret  string
+	Token   string
+	Region  string
+	Service string
+}
+
+func awsCredentialsField(L *lua.LState, opt *lua.LTable) *awsCredentials {
+	tbl, ok := L.GetField(opt, "aws").(*lua.LTable)
+	if !ok {
+		return nil
+	}
+
+	var creds awsCredentials
+	creds.Key, _ = getStringField(L, tbl, "key")
+	creds.Secret, _ = getStringField(L, tbl, "secret")
+	creds.Token, _ = getStringField(L, tbl, "token")
+	creds.Region, _ = getStringField(L, tbl, "region")
+	creds.Service, _ = getStringField(L, tbl, "service")
+	if creds.Region == "" {
+		creds.Region = "us-east-1"
+	}
+	return &creds
+}
+
+// signAWS adds the headers authenticating the request with AWS Signature Version 4.
+func signAWS(hdr http.Header, method, rawURL, body string, creds *awsCredentials, t time.Time) error {
+	if creds.Key == "" || creds.Secret == "" || creds.Service == "" {
+		return errors.New("the AWS credentials require the key, secret and service")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	amzdate := t.UTC().Format("20060102T150405Z")
+	date := amzdate[:8]
+
+	headers := map[string]string{
+		"host":       u.Host,
+		"x-amz-date": amzdate,
+	}
+	if creds.Token != "" {
+		headers["x-amz-security-token"] = creds.Token
+	}
+
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	request := strings.Join([]string{
+		method,
+		path,
+		awsCanonicalQuery(u.Query()),
+		canonical.String(),
+		signed,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + creds.Region + "/" + creds.Service + "/aws4_request"
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzdate, scope, sha256Hex(request)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.Secret), date)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, creds.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	hdr["X-Amz-Date"] = amzdate
+	if creds.Token != "" {
+		hdr["X-Amz-Security-Token"] = creds.Token
+	}
+	hdr["Authorization"] = "AWS4-HMAC-SHA256 Credential=" + creds.Key + "/" + scope +
+		", SignedHeaders=" + signed + ", Signature=" + signature
+	return nil
+}
+
+// awsCanonicalQuery sorts the parameters and encodes the spaces as '%20', as expected by the signature.
+func awsCanonicalQuery(values url.Values) string {
+	var params []string
+
+	for key, vals := range values {
+		for _, val := range vals {
+			params = append(params, awsEscape(key)+"="+awsEscape(val))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
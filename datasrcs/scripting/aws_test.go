@@ -0,0 +1,41 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+)
+
+func TestSignAWS(t *testing.T) {
+	// The 'get-vanilla' case of the AWS Signature Version 4 test suite
+	creds := &awsCredentials{
+		Key:     "AKIDEXAMPLE",
+		Secret:  "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:  "us-east-1",
+		Service: "service",
+	}
+	now := time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC)
+
+	hdr := make(http.Header)
+	if err := signAWS(hdr, "GET", "https://example.amazonaws.com/", "", creds, now); err != nil {
+		t.Fatalf("Failed to sign the request: %v", err)
+	}
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := hdr["Authorization"]; got != expected {
+		t.Errorf("Expected the authorization header %s, got %s", expected, got)
+	}
+	if got := hdr["X-Amz-Date"]; got != "20150830T123600Z" {
+		t.Errorf("Expected the date header 20150830T123600Z, got %s", got)
+	}
+
+	if err := signAWS(make(http.Header), "GET", "https://example.amazonaws.com/", "", &awsCredentials{Key: "key"}, now); err == nil {
+		t.Errorf("The request was signed without the secret")
+	}
+}
@@ -5,12 +5,24 @@
 package scripting
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/options"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
 )
 
+// defaultReverseWhoisLimit is the number of domains sharing a registrant beyond which the
+// registrant is considered too common, such as a privacy service, to pivot on.
+const defaultReverseWhoisLimit = 50
+
+// defaultGuessedNames is the number of names guessed after each batch of names learned, when the 'names' setting is not provided.
+const defaultGuessedNames = 100
+
 // Wrapper so that scripts can obtain the configuration for the current enumeration.
 func (s *Script) config(L *lua.LState) int {
 	cfg := s.sys.Config()
@@ -27,9 +39,29 @@ func (s *Script) config(L *lua.LState) int {
 	}
 
 	r.RawSetString("max_dns_queries", lua.LNumber(cfg.MaxDNSQueries))
+	r.RawSetString("quick", lua.LBool(options.Bool(cfg, "quick")))
+	r.RawSetString("mobile_apps", lua.LBool(options.Bool(cfg, "mobile_apps")))
+
+	limit := defaultReverseWhoisLimit
+	if n := options.Int(cfg, "reverse_whois_limit"); n > 0 {
+		limit = n
+	}
+	r.RawSetString("reverse_whois_limit", lua.LNumber(limit))
+
+	crtsh := "http"
+	if mode := options.String(cfg, "crtsh_mode"); mode != "" {
+		crtsh = strings.ToLower(mode)
+	}
+	r.RawSetString("crtsh_mode", lua.LString(crtsh))
 
-	scope := L.NewTable()
 	tb := L.NewTable()
+	for _, path := range options.Strings(cfg, "app_files") {
+		tb.Append(lua.LString(path))
+	}
+	r.RawSetString("app_files", tb)
+
+	scope := L.NewTable()
+	tb = L.NewTable()
 	for _, domain := range cfg.Domains() {
 		tb.Append(lua.LString(domain))
 	}
@@ -82,6 +114,12 @@ func (s *Script) config(L *lua.LState) int {
 		tb.Append(lua.LNumber(port))
 	}
 	scope.RawSetString("ports", tb)
+
+	tb = L.NewTable()
+	for _, org := range options.Strings(cfg, "organizations") {
+		tb.Append(lua.LString(org))
+	}
+	scope.RawSetString("organizations", tb)
 	r.RawSetString("scope", scope)
 
 	tb = L.NewTable()
@@ -98,12 +136,146 @@ func (s *Script) config(L *lua.LState) int {
 	tb.RawSetString("add_words", lua.LBool(cfg.AddWords))
 	tb.RawSetString("add_numbers", lua.LBool(cfg.AddNumbers))
 	tb.RawSetString("edit_distance", lua.LNumber(cfg.EditDistance))
+	tb.RawSetString("flip_separators", lua.LBool(alterationBool(cfg, "flip_separators", true)))
+	keywords := L.NewTable()
+	for _, group := range swapKeywords(cfg) {
+		words := L.NewTable()
+		for _, w := range group {
+			words.Append(lua.LString(w))
+		}
+		keywords.Append(words)
+	}
+	tb.RawSetString("swap_keywords", keywords)
 	r.RawSetString("alterations", tb)
 
+	tb = L.NewTable()
+	// Like brute forcing and alterations, the names are not guessed in quick mode
+	tb.RawSetString("active", lua.LBool(guessingBool(cfg, "enabled") && !options.Bool(cfg, "quick")))
+	names := defaultGuessedNames
+	if _, found := guessingSection(cfg)["names"]; found {
+		names = guessingNumber(cfg, "names")
+	}
+	tb.RawSetString("names", lua.LNumber(names))
+	r.RawSetString("guessing", tb)
+
 	L.Push(r)
 	return 1
 }
 
+// Wrapper so that scripts can bound the number of result pages they request.
+// The provided number of pages is lowered to the 'max_pages' option when it is set.
+func (s *Script) pageLimit(L *lua.LState) int {
+	pages := L.CheckInt(1)
+
+	if max := options.Int(s.sys.Config(), "max_pages"); max > 0 && max < pages {
+		pages = max
+	}
+
+	L.Push(lua.LNumber(pages))
+	return 1
+}
+
+// candidateQPS returns the 'qps' setting of the bruteforce section for the brute forcing scripts, the alterations
+// section for the alteration scripts, or the guessing section for the name guessing scripts, the candidate names
+// sent for resolution per second.
+func candidateQPS(cfg *config.Config, stype string) float64 {
+	var section string
+	switch stype {
+	case "brute":
+		section = "bruteforce"
+	case "alt":
+		section = "alterations"
+	case "guess":
+		section = "guessing"
+	default:
+		return 0
+	}
+
+	return options.FloatValue(options.Table(cfg, section)["qps"])
+}
+
+// alterationBool returns the setting of the alterations section, or the default value when it is not provided.
+func alterationBool(cfg *config.Config, key string, def bool) bool {
+	if m := options.Table(cfg, "alterations"); m != nil {
+		if v, ok := m[key].(bool); ok {
+			return v
+		}
+	}
+	return def
+}
+
+// guessingSection returns the settings of the guessing section, which are empty when it is not provided.
+func guessingSection(cfg *config.Config) map[string]interface{} {
+	if m := options.Table(cfg, "guessing"); m != nil {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+func guessingBool(cfg *config.Config, key string) bool {
+	return options.BoolValue(guessingSection(cfg)[key])
+}
+
+func guessingNumber(cfg *config.Config, key string) int {
+	return options.IntValue(guessingSection(cfg)[key])
+}
+
+// swapKeywords returns the groups of the 'swap_keywords' setting of the alterations section, where each keyword
+// found in a name is swapped for the other keywords of its group. A list of words is a single group.
+func swapKeywords(cfg *config.Config) [][]string {
+	list, ok := options.Table(cfg, "alterations")["swap_keywords"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var groups [][]string
+	var single []string
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			single = appendKeyword(single, v)
+		case []interface{}:
+			var group []string
+			for _, w := range v {
+				if word, ok := w.(string); ok {
+					group = appendKeyword(group, word)
+				}
+			}
+			if len(group) > 1 {
+				groups = append(groups, group)
+			}
+		}
+	}
+	if len(single) > 1 {
+		groups = append(groups, single)
+	}
+	return groups
+}
+
+func appendKeyword(group []string, word string) []string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return group
+	}
+
+	for _, w := range group {
+		if w == word {
+			return group
+		}
+	}
+	return append(group, word)
+}
+
+// confidence returns the confidence of the data source provided by the 'source_confidence' option,
+// or an empty string when the option does not provide one.
+func (s *Script) confidence() string {
+	c := options.FloatValue(options.Source(s.sys.Config(), "source_confidence", s.String()))
+	if c <= 0 || c > 1 {
+		return ""
+	}
+	return strconv.FormatFloat(c, 'f', -1, 64)
+}
+
 func (s *Script) dataSourceConfig(L *lua.LState) int {
 	dsc := s.sys.Config().DataSrcConfigs
 	if dsc == nil {
@@ -122,30 +294,53 @@ func (s *Script) dataSourceConfig(L *lua.LState) int {
 	if cfg.TTL != 0 {
 		tb.RawSetString("ttl", lua.LNumber(cfg.TTL))
 	}
+	if c, err := strconv.ParseFloat(s.confidence(), 64); err == nil {
+		tb.RawSetString("confidence", lua.LNumber(c))
+	}
 
 	if creds := dsc.GetCredentials(cfg.Name); creds != nil {
-		c := L.NewTable()
-
-		c.RawSetString("name", lua.LString(creds.Name))
-		if creds.Username != "" {
-			c.RawSetString("username", lua.LString(creds.Username))
-		}
-		if creds.Password != "" {
-			c.RawSetString("password", lua.LString(creds.Password))
-		}
-		if creds.Apikey != "" {
-			c.RawSetString("key", lua.LString(creds.Apikey))
+		tb.RawSetString("credentials", credentialsTable(L, creds))
+	}
+	// The scripts rotating through multiple accounts obtain the credentials of each, ordered by the account names
+	if len(cfg.Creds) > 0 {
+		var accounts []string
+		for account := range cfg.Creds {
+			accounts = append(accounts, account)
 		}
-		if creds.Secret != "" {
-			c.RawSetString("secret", lua.LString(creds.Secret))
+		sort.Strings(accounts)
+
+		all := L.NewTable()
+		for _, account := range accounts {
+			if creds := cfg.Creds[account]; creds != nil {
+				all.Append(credentialsTable(L, creds))
+			}
 		}
-		tb.RawSetString("credentials", c)
+		tb.RawSetString("all_credentials", all)
 	}
 
 	L.Push(tb)
 	return 1
 }
 
+func credentialsTable(L *lua.LState, creds *config.Credentials) *lua.LTable {
+	c := L.NewTable()
+
+	c.RawSetString("name", lua.LString(creds.Name))
+	if creds.Username != "" {
+		c.RawSetString("username", lua.LString(creds.Username))
+	}
+	if creds.Password != "" {
+		c.RawSetString("password", lua.LString(creds.Password))
+	}
+	if creds.Apikey != "" {
+		c.RawSetString("key", lua.LString(creds.Apikey))
+	}
+	if creds.Secret != "" {
+		c.RawSetString("secret", lua.LString(creds.Secret))
+	}
+	return c
+}
+
 // Wrapper so that scripts can check if a subdomain name is in scope.
 func (s *Script) inScope(L *lua.LState) int {
 	result := lua.LFalse
@@ -0,0 +1,254 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// crtshDSN is the public PostgreSQL interface of crt.sh, used when the 'crtsh_dsn' option is not provided
+	crtshDSN = "postgres://guest@crt.sh:5432/certwatch?sslmode=disable"
+	// crtshFetchSize is the number of identities fetched from the cursor at a time
+	crtshFetchSize = 1000
+	// crtshTimeout bounds the query, since the HTTP endpoint is only bypassed for the large domains
+	crtshTimeout = 10 * time.Minute
+)
+
+// crtshQuery selects the DNS names of the certificates matching the domain name, and their certificates.
+const crtshQuery = `DECLARE crtsh_identities NO SCROLL CURSOR FOR
+SELECT cai.NAME_VALUE, x509_commonName(cai.CERTIFICATE), encode(x509_serialNumber(cai.CERTIFICATE), 'hex'),
+	ca.NAME, x509_notBefore(cai.CERTIFICATE), x509_notAfter(cai.CERTIFICATE)
+FROM certificate_and_identities cai
+LEFT JOIN ca ON ca.ID = cai.ISSUER_CA_ID
+WHERE plainto_tsquery('certwatch', $1) @@ identities(cai.CERTIFICATE)
+	AND cai.NAME_TYPE IN ('2.5.4.3', 'san:dNSName')
+	AND (lower(cai.NAME_VALUE) = $1 OR lower(cai.NAME_VALUE) LIKE ('%.' || $1))`
+
+// certIdentity is a DNS name of a certificate, as provided by a row of the crt.sh database.
+type certIdentity struct {
+	Name       string
+	CommonName string
+	Serial     string
+	Issuer     string
+	NotBefore  time.Time
+	NotAfter   time.Time
+}
+
+// certIdentities deduplicates the DNS names of the certificates, which are repeated by the precertificates
+// and renewals, and collects the names of each certificate.
+type certIdentities struct {
+	names map[string]struct{}
+	certs map[string]*certIdentity
+	sans  map[string]map[string]struct{}
+}
+
+func newCertIdentities() *certIdentities {
+	return &certIdentities{
+		names: make(map[string]struct{}),
+		certs: make(map[string]*certIdentity),
+		sans:  make(map[string]map[string]struct{}),
+	}
+}
+
+// add returns the names of the identity not seen before.
+func (c *certIdentities) add(id *certIdentity) []string {
+	var names []string
+
+	for _, n := range []string{id.Name, id.CommonName} {
+		n = strings.ToLower(strings.TrimSpace(dns.RemoveAsteriskLabel(n)))
+		if n == "" {
+			continue
+		}
+
+		if _, found := c.names[n]; !found {
+			c.names[n] = struct{}{}
+			names = append(names, n)
+		}
+		if id.Serial != "" {
+			if _, found := c.sans[id.Serial]; !found {
+				c.sans[id.Serial] = make(map[string]struct{})
+			}
+			c.sans[id.Serial][n] = struct{}{}
+		}
+	}
+
+	if _, found := c.certs[id.Serial]; id.Serial != "" && !found {
+		c.certs[id.Serial] = id
+	}
+	return names
+}
+
+// certNames returns the sorted names of the certificate.
+func (c *certIdentities) certNames(serial string) []string {
+	var names []string
+
+	for n := range c.sans[serial] {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wrapper so that scripts can query the crt.sh PostgreSQL interface for the certificates of a domain name,
+// as the HTTP endpoint times out for large domains. The identities are read through a cursor and
+// deduplicated before the names are sent, and each certificate is kept as a finding.
+// The number of names found is returned, along with an error message when the query failed.
+func (s *Script) crtshQuery(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	num, err := s.internalCrtshQuery(ctx, strings.ToLower(strings.TrimSpace(L.CheckString(2))))
+	L.Push(lua.LNumber(num))
+	if err != nil {
+		L.Push(lua.LString(err.Error()))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 2
+}
+
+func (s *Script) internalCrtshQuery(ctx context.Context, domain string) (int, error) {
+	cfg := s.sys.Config()
+
+	dsn := crtshDSN
+	if v := options.String(cfg, "crtsh_dsn"); v != "" {
+		dsn = v
+	}
+
+	pgcfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return 0, err
+	}
+	// The connections follow the egress settings, and the public interface does not accept prepared statements
+	pgcfg.DialFunc = amassnet.DialContext
+	pgcfg.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+
+	ctx, cancel := context.WithTimeout(ctx, crtshTimeout)
+	defer cancel()
+
+	conn, err := pgx.ConnectConfig(ctx, pgcfg)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	if _, err := tx.Exec(ctx, crtshQuery, domain); err != nil {
+		return 0, err
+	}
+
+	var count int
+	ids := newCertIdentities()
+	for {
+		n, err := fetchIdentities(ctx, tx, func(id *certIdentity) {
+			for _, name := range ids.add(id) {
+				s.newNameWithContext(ctx, name)
+				count++
+			}
+		})
+		if err != nil {
+			return count, err
+		}
+		if n < crtshFetchSize {
+			break
+		}
+	}
+
+	s.addCertFindings(domain, ids)
+	return count, nil
+}
+
+// fetchIdentities provides the next rows of the cursor to the callback, and returns the number of rows.
+func fetchIdentities(ctx context.Context, tx pgx.Tx, callback func(*certIdentity)) (int, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("FETCH %d FROM crtsh_identities", crtshFetchSize))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		var name, cn, serial, issuer *string
+		var notBefore, notAfter *time.Time
+
+		if err := rows.Scan(&name, &cn, &serial, &issuer, &notBefore, &notAfter); err != nil {
+			return n, err
+		}
+		n++
+
+		id := &certIdentity{
+			Name:       stringOrEmpty(name),
+			CommonName: stringOrEmpty(cn),
+			Serial:     stringOrEmpty(serial),
+			Issuer:     stringOrEmpty(issuer),
+		}
+		if notBefore != nil {
+			id.NotBefore = *notBefore
+		}
+		if notAfter != nil {
+			id.NotAfter = *notAfter
+		}
+		callback(id)
+	}
+	return n, rows.Err()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// addCertFindings keeps the certificates as findings, like the certificates obtained from the HTTP endpoint.
+func (s *Script) addCertFindings(domain string, ids *certIdentities) {
+	d := s.sys.Config().WhichDomain(domain)
+	if d == "" {
+		return
+	}
+
+	for serial, id := range ids.certs {
+		props := map[string]string{
+			"issuer": id.Issuer,
+			"names":  strings.Join(ids.certNames(serial), ","),
+		}
+		if !id.NotBefore.IsZero() {
+			props["not_before"] = id.NotBefore.UTC().Format("2006-01-02T15:04:05")
+		}
+		if !id.NotAfter.IsZero() {
+			props["not_after"] = id.NotAfter.UTC().Format("2006-01-02T15:04:05")
+		}
+
+		s.sys.Findings().Add(&systems.Finding{
+			Type:       "Certificate",
+			Value:      serial,
+			Domain:     d,
+			Relation:   "issued_for",
+			Source:     s.String(),
+			Properties: props,
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCertIdentities(t *testing.T) {
+	ids := newCertIdentities()
+
+	names := ids.add(&certIdentity{Name: "*.owasp.org", CommonName: "owasp.org", Serial: "01"})
+	if expected := []string{"owasp.org"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("The first identity provided %v, expected %v", names, expected)
+	}
+	// The precertificate repeats the names of the certificate
+	if names := ids.add(&certIdentity{Name: "OWASP.org", CommonName: "owasp.org", Serial: "01"}); len(names) != 0 {
+		t.Errorf("The repeated identity provided %v", names)
+	}
+
+	names = ids.add(&certIdentity{Name: "www.owasp.org", CommonName: "owasp.org", Serial: "02"})
+	if expected := []string{"www.owasp.org"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("The renewal provided %v, expected %v", names, expected)
+	}
+	ids.add(&certIdentity{Name: "api.owasp.org", Serial: "02"})
+
+	if len(ids.certs) != 2 {
+		t.Errorf("%d certificates were kept, expected 2", len(ids.certs))
+	}
+	if expected := []string{"api.owasp.org", "owasp.org", "www.owasp.org"}; !reflect.DeepEqual(ids.certNames("02"), expected) {
+		t.Errorf("The certificate names were %v, expected %v", ids.certNames("02"), expected)
+	}
+}
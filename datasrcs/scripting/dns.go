@@ -75,7 +75,7 @@ func (s *Script) resolve(L *lua.LState) int {
 	}
 
 	tb := L.NewTable()
-	if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
+	if ans := amassdns.ExtractRecords(resp); len(ans) > 0 {
 		if records := resolve.AnswersByType(ans, qtype); len(records) > 0 {
 			for _, rr := range records {
 				entry := L.NewTable()
@@ -116,10 +116,18 @@ func (s *Script) dnsQuery(ctx context.Context, msg *dns.Msg, r *resolve.Resolver
 		default:
 		}
 
+		if err := amassnet.ThrottleBandwidth(ctx, msg.Len()); err != nil {
+			return nil, err
+		}
+
 		resp, err := r.QueryBlocking(ctx, msg)
 		if err != nil {
+			amassnet.RecordBandwidth(s.String(), msg.Len(), 0)
 			continue
 		}
+		amassnet.RecordBandwidth(s.String(), msg.Len(), resp.Len())
+		_ = amassnet.ThrottleBandwidth(ctx, resp.Len())
+
 		if resp.Rcode == dns.RcodeNameError {
 			return nil, errors.New("name does not exist")
 		}
@@ -155,6 +163,14 @@ func convertType(qtype string) uint16 {
 		t = dns.TypeSOA
 	case "srv":
 		t = dns.TypeSRV
+	case "caa":
+		t = dns.TypeCAA
+	case "naptr":
+		t = dns.TypeNAPTR
+	case "https":
+		t = dns.TypeHTTPS
+	case "svcb":
+		t = dns.TypeSVCB
 	}
 	return t
 }
@@ -270,20 +286,36 @@ func (s *Script) zoneWalk(L *lua.LState) int {
 		return 1
 	}
 
+	// The resolvers send their queries without the dialer, so the egress is checked here
+	if err := amassnet.CheckEgress(server); err != nil {
+		L.Push(lua.LString(err.Error()))
+		return 1
+	}
+
 	r := resolve.NewResolvers()
 	r.SetLogger(s.sys.Config().Log)
 	_ = r.AddResolvers(15, server)
 	defer r.Stop()
 
-	names, err := r.NsecTraversal(ctx, name)
+	var names []string
+	records, err := r.NsecTraversal(ctx, name)
+	// The zones signed with NSEC3 records only provide hashed names, which are cracked when a backend is configured
+	if backend := nsec3Backend(s.sys.Config().Options); err != nil && backend != "" {
+		var chain *nsec3Chain
+
+		if chain, err = s.nsec3Walk(ctx, r, name); err == nil {
+			names, err = s.nsec3Crack(ctx, chain, backend)
+		}
+	}
 	if err != nil {
 		L.Push(lua.LString(fmt.Sprintf("Zone Walk failed: %s: %v", name, err)))
 		return 1
 	}
 
-	for _, nsec := range names {
-		name := resolve.RemoveLastDot(nsec.NextDomain)
-
+	for _, nsec := range records {
+		names = append(names, resolve.RemoveLastDot(nsec.NextDomain))
+	}
+	for _, name := range names {
 		if domain := s.sys.Config().WhichDomain(name); domain != "" {
 			s.Output() <- &requests.DNSRequest{
 				Name:   name,
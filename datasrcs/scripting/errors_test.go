@@ -0,0 +1,145 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSourceErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/denied":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/quota":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte("not json"))
+		}
+	}))
+	defer srv.Close()
+
+	script, sys := setupMockScriptEnv(`
+		name="source_errors"
+		type="testing"
+
+		function vertical(ctx, domain)
+			request(ctx, {['url']="` + srv.URL + `/denied"})
+			request(ctx, {['url']="` + srv.URL + `/quota"})
+			request(ctx, {['url']="` + srv.URL + `/quota"})
+			request(ctx, {['url']="` + srv.URL + `/ok"})
+			report_error(ctx, "parse_error", "failed to decode the JSON response")
+			new_name(ctx, "done." .. domain)
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{
+		Name:   domain,
+		Domain: domain,
+	}
+
+	select {
+	case <-sys.DataSources()[0].Output():
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not finish the requests")
+	}
+
+	counts := make(map[amassnet.ErrorKind]int)
+	for _, c := range amassnet.SourceErrorStats()["source_errors"] {
+		counts[c.Kind] = c.Count
+	}
+	if len(counts) != 3 || counts[amassnet.ErrAuth] != 1 || counts[amassnet.ErrQuota] != 2 || counts[amassnet.ErrParse] != 1 {
+		t.Errorf("The errors of the script were not recorded as expected: %v", counts)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+
+	sys := newMockSystem(config.NewConfig())
+	sys.(*systems.SimpleSystem).DLQ = systems.NewDeadLetterQueue(filepath.Join(t.TempDir(), systems.DeadLetterFile))
+	defer func() { _ = sys.Shutdown() }()
+
+	script := NewScript(`
+		name="dead_letters"
+		type="testing"
+
+		function vertical(ctx, domain)
+			if (domain == "broken.org") then
+				error("a bug in the script")
+			elseif (domain == "unavailable.org") then
+				request(ctx, {['url']="`+srv.URL+`/unavailable"})
+				return
+			end
+
+			request(ctx, {['url']="`+srv.URL+`/ok"})
+			if (domain == "parse.org") then
+				report_error(ctx, "parse_error", "failed to decode the JSON response")
+			end
+		end
+
+		function address(ctx, addr)
+			new_name(ctx, "done.owasp.org")
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	for _, domain := range []string{"broken.org", "unavailable.org", "parse.org", "owasp.org"} {
+		sys.Config().AddDomain(domain)
+		script.Input() <- &requests.DNSRequest{Name: domain, Domain: domain}
+	}
+	// The requests are handled in order, so the last one signals that the others are done
+	script.Input() <- &requests.AddrRequest{Address: "192.0.2.1", Domain: "owasp.org"}
+
+	select {
+	case <-script.Output():
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not finish the requests")
+	}
+
+	letters, err := sys.DeadLetters().List()
+	if err != nil {
+		t.Fatalf("Failed to list the dead letters: %v", err)
+	}
+
+	targets := make(map[string]bool)
+	for _, d := range letters {
+		targets[d.Target()] = true
+	}
+	if len(targets) != 2 || !targets["unavailable.org"] || !targets["parse.org"] {
+		t.Errorf("Expected the failed requests to be entered into the queue, got %v", targets)
+	}
+	// The errors raised by the script are not retried
+	if num := atomic.LoadInt32(&hits); num != 3 {
+		t.Errorf("Expected each callback to run once, got %d requests", num)
+	}
+}
@@ -6,12 +6,16 @@ package scripting
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
 
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -48,12 +52,25 @@ func (s *Script) request(L *lua.LState) int {
 		}
 	}
 
+	method := "GET"
 	var body string
-	if method, ok := getStringField(L, opt, "method"); ok && strings.ToLower(method) == "post" {
+	if m, ok := getStringField(L, opt, "method"); ok && strings.ToLower(m) == "post" {
+		method = "POST"
 		if d, ok := getStringField(L, opt, "body"); ok {
 			body = d
 		}
 	}
+	// The requests to the AWS APIs are signed with the credentials of the 'aws' table
+	if creds := awsCredentialsField(L, opt); creds != nil {
+		if hdr == nil {
+			hdr = make(http.Header)
+		}
+		if err := signAWS(hdr, method, url, body, creds, time.Now()); err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+	}
 
 	id, _ := getStringField(L, opt, "id")
 	pass, _ := getStringField(L, opt, "pass")
@@ -62,11 +79,12 @@ func (s *Script) request(L *lua.LState) int {
 		Password: pass,
 	})
 
+	failRequest(L.CheckUserData(1), transientFailure(resp, err))
 	if err != nil || resp == nil {
 		L.Push(lua.LNil)
 		estr := "no HTTP response"
 		if err != nil {
-			estr = err.Error()
+			estr = errorString(err)
 		}
 		L.Push(lua.LString(estr))
 	} else {
@@ -160,35 +178,76 @@ func (s *Script) scrape(L *lua.LState) int {
 		}
 	}
 
+	method := "GET"
 	var body string
-	if method, ok := getStringField(L, opt, "method"); ok && strings.ToLower(method) == "post" {
+	if m, ok := getStringField(L, opt, "method"); ok && strings.ToLower(m) == "post" {
+		method = "POST"
 		if d, ok := getStringField(L, opt, "body"); ok {
 			body = d
 		}
 	}
+	// The requests to the AWS APIs are signed with the credentials of the 'aws' table
+	if creds := awsCredentialsField(L, opt); creds != nil {
+		if hdr == nil {
+			hdr = make(http.Header)
+		}
+		if err := signAWS(hdr, method, url, body, creds, time.Now()); err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+	}
 
 	id, _ := getStringField(L, opt, "id")
 	pass, _ := getStringField(L, opt, "pass")
 
 	sucess := lua.LFalse
-	if resp, err := s.req(ctx, url, body, hdr, &http.BasicAuth{
+	resp, err := s.req(ctx, url, body, hdr, &http.BasicAuth{
 		Username: id,
 		Password: pass,
-	}); err == nil {
+	})
+	failRequest(L.CheckUserData(1), transientFailure(resp, err))
+	if err == nil {
 		if resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 400 {
 			if num := s.internalSendNames(ctx, resp.Body); num > 0 {
 				sucess = lua.LTrue
 			}
 		}
 	} else {
-		s.sys.Config().Log.Print(s.String() + ": scrape: " + err.Error())
+		s.sys.Config().Log.Print(s.String() + ": scrape: " + errorString(err))
 	}
 
 	L.Push(sucess)
 	return 1
 }
 
+// transientFailure returns the failure of a request that could succeed when sent again later,
+// so the request of the callback is entered into the dead-letter queue.
+func transientFailure(resp *http.Response, err error) error {
+	var se *amassnet.SourceError
+
+	switch {
+	case errors.As(err, &se) && (se.Kind == amassnet.ErrOutOfScope || se.Kind == amassnet.ErrParse):
+		return nil
+	case err != nil:
+		return err
+	case resp == nil:
+		return errors.New("no HTTP response")
+	case resp.StatusCode == 429 || resp.StatusCode >= 500:
+		return fmt.Errorf("the request returned with status: %s", resp.Status)
+	}
+	return nil
+}
+
+// req returns a SourceError when the request fails, which is also added to the error statistics
+// of the script along with the responses showing that the credentials or quota were rejected.
 func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, auth *http.BasicAuth) (*http.Response, error) {
+	if !s.urls.AllowedString(url) {
+		se := amassnet.NewSourceError(s.String(), amassnet.ErrOutOfScope, fmt.Errorf("the URL %s is outside the URL scope", url))
+		amassnet.RecordSourceError(se)
+		return nil, se
+	}
+
 	method := "GET"
 	if data != "" {
 		method = "POST"
@@ -198,21 +257,81 @@ func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, aut
 	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
+	cfg := s.sys.Config()
 	resp, err := http.RequestWebPage(ctx, &http.Request{
-		URL:    url,
-		Method: method,
-		Header: hdr,
-		Body:   data,
-		Auth:   auth,
+		URL:          url,
+		Method:       method,
+		Header:       hdr,
+		Body:         data,
+		Auth:         auth,
+		MaxBodySize:  options.SizeValue(options.Source(cfg, "http_max_body_size", s.String())),
+		ContentTypes: options.StringsValue(options.Source(cfg, "http_content_types", s.String())),
 	})
+	s.recordHTTPBandwidth(url, data, hdr, resp)
 	if err != nil {
-		cfg := s.sys.Config()
-
 		if cfg.Verbose {
 			cfg.Log.Printf("%s: %s: %v", s.String(), url, err)
 		}
 	}
-	return resp, err
+
+	var status int
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	authenticated := (auth != nil && auth.Username != "") || s.hasCredentials()
+	if kind, failed := amassnet.ErrorKindOf(status, err, authenticated); failed {
+		// The responses refused by the limits cannot be parsed safely
+		if errors.Is(err, http.ErrBodyTooLarge) || errors.Is(err, http.ErrContentType) {
+			kind = amassnet.ErrParse
+		}
+
+		cause := err
+		if cause == nil {
+			cause = errors.New(resp.Status)
+		}
+
+		se := amassnet.NewSourceError(s.String(), kind, cause)
+		amassnet.RecordSourceError(se)
+		if err != nil {
+			return resp, se
+		}
+	}
+	return resp, nil
+}
+
+// hasCredentials returns true when the configuration provides credentials for the data source.
+func (s *Script) hasCredentials() bool {
+	dsc := s.sys.Config().DataSrcConfigs
+	if dsc == nil {
+		return false
+	}
+	return dsc.GetCredentials(s.String()) != nil
+}
+
+// errorString returns the cause of the SourceError returned by the helpers, since the scripts add their own name.
+func errorString(err error) string {
+	var se *amassnet.SourceError
+	if errors.As(err, &se) && se.Err != nil {
+		return se.Err.Error()
+	}
+	return err.Error()
+}
+
+// recordHTTPBandwidth adds the approximate size of the request and response to the script statistics.
+func (s *Script) recordHTTPBandwidth(url, data string, hdr http.Header, resp *http.Response) {
+	sent := len(url) + len(data)
+	for k, v := range hdr {
+		sent += len(k) + len(v)
+	}
+
+	var received int
+	if resp != nil {
+		received = len(resp.Body)
+		for k, v := range resp.Header {
+			received += len(k) + len(v)
+		}
+	}
+	amassnet.RecordBandwidth(s.String(), sent, received)
 }
 
 // Wrapper so that scripts can crawl for subdomain names in scope.
@@ -232,7 +351,7 @@ func (s *Script) crawl(L *lua.LState) int {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
-	err = http.Crawl(ctx, u, cfg.Domains(), max, func(req *http.Request, resp *http.Response) {
+	err = http.Crawl(ctx, u, cfg.Domains(), s.urls, max, func(req *http.Request, resp *http.Response) {
 		if u, err := url.Parse(req.URL); err == nil {
 			s.newNameWithContext(ctx, http.CleanName(u.Hostname()))
 		}
@@ -7,14 +7,18 @@ package scripting
 import (
 	"context"
 	"net"
+	"net/mail"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/caffix/stringset"
 	"github.com/miekg/dns"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/resolve"
 	bf "github.com/tylertreat/BoomFilters"
 	lua "github.com/yuin/gopher-lua"
@@ -23,6 +27,9 @@ import (
 
 func (s *Script) newNameWithContext(ctx context.Context, name string) {
 	if domain := s.sys.Config().WhichDomain(name); domain != "" {
+		if s.names != nil && s.names.Wait(ctx) != nil {
+			return
+		}
 		select {
 		case <-ctx.Done():
 		case <-s.Done():
@@ -46,6 +53,66 @@ func (s *Script) newName(L *lua.LState) int {
 	return 0
 }
 
+// The number of names sent by new_names before the output of the script is allowed to drain
+const nameBatchSize = 250
+
+// Wrapper so that scripts can send the FQDNs of a bulk dataset to Amass. The names are sent
+// in batches, and the output of the script drains between the batches, so the other data
+// sources are not held back by a dataset of several thousand names.
+func (s *Script) newNames(L *lua.LState) int {
+	var num int
+
+	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
+		var names []string
+		L.CheckTable(2).ForEach(func(_, v lua.LValue) {
+			if n, ok := v.(lua.LString); ok {
+				names = append(names, string(n))
+			}
+		})
+		num = s.sendNameBatches(ctx, names)
+	}
+
+	L.Push(lua.LNumber(num))
+	return 1
+}
+
+func (s *Script) sendNameBatches(ctx context.Context, names []string) int {
+	filter := stringset.New()
+	defer filter.Close()
+
+	var count int
+	for _, n := range names {
+		name := http.CleanName(s.subre.FindString(n))
+		if name == "" || filter.Has(name) || s.sys.Config().WhichDomain(name) == "" {
+			continue
+		}
+		filter.Insert(name)
+
+		s.newNameWithContext(ctx, name)
+		if count++; count%nameBatchSize == 0 && !s.drainOutput(ctx) {
+			break
+		}
+	}
+	return count
+}
+
+// drainOutput returns false when the context expires or the script stops before its output has drained.
+func (s *Script) drainOutput(ctx context.Context) bool {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+
+	for len(s.Output()) > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.Done():
+			return false
+		case <-t.C:
+		}
+	}
+	return true
+}
+
 // Wrapper so that scripts can send FQDNs found in the content to Amass.
 func (s *Script) sendNames(L *lua.LState) int {
 	var num int
@@ -258,3 +325,206 @@ func (s *Script) associated(L *lua.LState) int {
 	}
 	return 0
 }
+
+var emailRegex = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@` + amassdns.AnySubdomainRegexString())
+
+// Wrapper so that scripts can send a discovered email address to Amass. The optional
+// table of properties, such as breach details, is merged into the stored finding.
+func (s *Script) newEmail(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	addr := L.CheckString(2)
+	if addr == "" {
+		return 0
+	}
+
+	var props map[string]string
+	if tbl, ok := L.Get(3).(*lua.LTable); ok {
+		props = make(map[string]string)
+		tbl.ForEach(func(k, v lua.LValue) {
+			if key, ok := k.(lua.LString); ok && v.Type() != lua.LTNil {
+				props[string(key)] = v.String()
+			}
+		})
+	}
+
+	s.newEmailWithContext(ctx, addr, props)
+	return 0
+}
+
+// Wrapper so that scripts can send email addresses found in the content to Amass.
+func (s *Script) sendEmails(L *lua.LState) int {
+	var num int
+
+	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
+		if content := L.CheckString(2); content != "" {
+			filter := stringset.New()
+			defer filter.Close()
+
+			for _, addr := range emailRegex.FindAllString(content, -1) {
+				if !filter.Has(addr) && s.newEmailWithContext(ctx, addr, nil) {
+					num++
+				}
+				filter.Insert(addr)
+			}
+		}
+	}
+
+	L.Push(lua.LNumber(num))
+	return 1
+}
+
+// newEmailWithContext stores email addresses belonging to in-scope domains as findings and
+// sends the newly discovered addresses to Amass, so other data sources can enrich them.
+func (s *Script) newEmailWithContext(ctx context.Context, addr string, props map[string]string) bool {
+	a, err := mail.ParseAddress(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+
+	email := strings.ToLower(a.Address)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return false
+	}
+
+	domain := s.sys.Config().WhichDomain(email[at+1:])
+	if domain == "" {
+		return false
+	}
+
+	if !s.sys.Findings().Add(&systems.Finding{
+		Type:       "EmailAddress",
+		Value:      email,
+		Domain:     domain,
+		Relation:   "email_address",
+		Source:     s.String(),
+		Properties: props,
+	}) {
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- &requests.EmailRequest{
+		Address: email,
+		Domain:  domain,
+	}:
+	}
+	return true
+}
+
+// Wrapper so that scripts can send a discovered account, such as a GitHub organization or package
+// registry namespace, that belongs to the organization operating the provided in-scope domain name.
+func (s *Script) newAccount(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	name := strings.ToLower(strings.TrimSpace(L.CheckString(2)))
+	domain := s.sys.Config().WhichDomain(strings.TrimPrefix(name, "www."))
+	if domain == "" {
+		return 0
+	}
+
+	tbl := L.CheckTable(3)
+	if tbl == nil {
+		return 0
+	}
+
+	u, _ := getStringField(L, tbl, "url")
+	platform, _ := getStringField(L, tbl, "platform")
+	if u == "" || platform == "" {
+		return 0
+	}
+
+	account, _ := getStringField(L, tbl, "name")
+	props := map[string]string{"platform": platform}
+	if account != "" {
+		props["name"] = account
+	}
+
+	if !s.sys.Findings().Add(&systems.Finding{
+		Type:       "Account",
+		Value:      u,
+		Domain:     domain,
+		Relation:   "account",
+		Source:     s.String(),
+		Properties: props,
+	}) {
+		return 0
+	}
+	// Provide the new account to the data sources that examine accounts
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- &requests.AccountRequest{
+		Platform: platform,
+		Name:     account,
+		URL:      u,
+		Domain:   domain,
+	}:
+	}
+	return 0
+}
+
+// Wrapper so that scripts can send other discoveries linked to the provided in-scope name, such as
+// exposed container images. The table fields other than type, value and relation become properties.
+func (s *Script) newFinding(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	domain := s.sys.Config().WhichDomain(strings.ToLower(strings.TrimSpace(L.CheckString(2))))
+	if domain == "" {
+		return 0
+	}
+
+	tbl := L.CheckTable(3)
+	if tbl == nil {
+		return 0
+	}
+
+	f := &systems.Finding{
+		Domain:     domain,
+		Source:     s.String(),
+		Properties: make(map[string]string),
+	}
+	tbl.ForEach(func(k, v lua.LValue) {
+		key, ok := k.(lua.LString)
+		if !ok || v.Type() == lua.LTNil {
+			return
+		}
+
+		switch string(key) {
+		case "type":
+			f.Type = v.String()
+		case "value":
+			f.Value = v.String()
+		case "relation":
+			f.Relation = v.String()
+		default:
+			f.Properties[string(key)] = v.String()
+		}
+	})
+	if f.Relation == "" {
+		f.Relation = "associated_with"
+	}
+	// The confidence configured for the data source replaces the confidence provided by the script
+	if c := s.confidence(); c != "" {
+		f.Properties["confidence"] = c
+	}
+	// The services and ports found by scanning are only kept when the host providing them is in scope
+	if (f.Type == "Service" || f.Type == "Port") && !systems.AssetInScope(s.sys.Config(), f.Type, f.Value) {
+		return 0
+	}
+
+	s.sys.Findings().Add(f)
+	return 0
+}
@@ -5,11 +5,14 @@
 package scripting
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/caffix/stringset"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 )
 
@@ -67,6 +70,57 @@ func TestNewNames(t *testing.T) {
 	}
 }
 
+func TestNewNamesBatches(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="bulk_names"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local names = {"www.example.com"}
+			for i = 1, 600 do
+				table.insert(names, "host" .. i .. "." .. domain)
+				table.insert(names, "HOST" .. i .. "." .. domain)
+			end
+
+			if new_names(ctx, names) == 600 then
+				new_name(ctx, "zzdone." .. domain)
+			end
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	names := stringset.New()
+	defer names.Close()
+
+	timer := time.NewTimer(10 * time.Second)
+	defer timer.Stop()
+loop:
+	for {
+		select {
+		case <-timer.C:
+			t.Fatal("The test timed out")
+		case req := <-sys.DataSources()[0].Output():
+			if d, ok := req.(*requests.DNSRequest); ok {
+				if d.Name == "zzdone."+domain {
+					break loop
+				}
+				names.Insert(d.Name)
+			}
+		}
+	}
+
+	if names.Len() != 600 || !names.Has("host600."+domain) {
+		t.Errorf("Expected 600 unique names in scope, received %d", names.Len())
+	}
+}
+
 func TestSendDNSRecords(t *testing.T) {
 	script, sys := setupMockScriptEnv(`
 		name="dns_records"
@@ -216,3 +270,276 @@ func TestAssociated(t *testing.T) {
 		}
 	}
 }
+
+func TestNewEmails(t *testing.T) {
+	expected := stringset.New("admin@owasp.org", "security@www.owasp.org", "info@owasp.org")
+	defer expected.Close()
+
+	script, sys := setupMockScriptEnv(`
+		name="emails"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_email(ctx, "Admin@owasp.org", {['confidence']=90})
+			new_email(ctx, "admin@example.com")
+
+			local content = [[
+				Contact security@www.owasp.org or info@owasp.org
+				Repeated: info@owasp.org, admin@owasp.org
+			]]
+			send_emails(ctx, content)
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(t.TempDir(), systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	num := expected.Len()
+	for i := 0; i < num; i++ {
+		req := <-sys.DataSources()[0].Output()
+
+		if e, ok := req.(*requests.EmailRequest); !ok || !expected.Has(e.Address) || e.Domain != domain {
+			t.Errorf("Email %d: %v was not found in the list of expected addresses", i+1, req)
+		} else {
+			expected.Remove(e.Address)
+		}
+	}
+
+	found := findings.Find(time.Time{}, "EmailAddress")
+	if len(found) != num {
+		t.Fatalf("Expected %d email addresses to be stored, but %d were found", num, len(found))
+	}
+	for _, f := range found {
+		if f.Value == "admin@owasp.org" && f.Properties["confidence"] != "90" {
+			t.Errorf("The properties of %s were not stored", f.Value)
+		}
+	}
+}
+
+func TestNewAccounts(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="accounts"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_account(ctx, "www.owasp.org", {
+				['platform']="github",
+				['name']="OWASP",
+				['url']="https://github.com/OWASP",
+			})
+			new_account(ctx, "example.com", {
+				['platform']="github",
+				['name']="example",
+				['url']="https://github.com/example",
+			})
+			new_account(ctx, "owasp.org", {['platform']="npm"})
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(t.TempDir(), systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	req := <-sys.DataSources()[0].Output()
+	if a, ok := req.(*requests.AccountRequest); !ok || a.Platform != "github" || a.Name != "OWASP" || a.Domain != domain {
+		t.Errorf("The account request was not sent as expected: %v", req)
+	}
+
+	found := findings.Find(time.Time{}, "Account")
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 account to be stored, but %d were found", len(found))
+	}
+	if a := found[0]; a.Value != "https://github.com/OWASP" || a.Domain != domain || a.Properties["platform"] != "github" {
+		t.Errorf("The account was not stored as expected: %v", a)
+	}
+}
+
+func TestNewFinding(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="findings"
+		type="testing"
+
+		function account(ctx, platform, name, url, domain)
+			new_finding(ctx, domain, {
+				['type']="ContainerImage",
+				['value']="ghcr.io/" .. name .. "/app",
+				['relation']="container_image",
+				['public']=true,
+			})
+			new_finding(ctx, "example.com", {['type']="ContainerImage", ['value']="ghcr.io/example/app"})
+			new_finding(ctx, domain, {['type']="Service", ['value']="www." .. domain .. ":443", ['banner']="nginx"})
+			new_finding(ctx, domain, {['type']="Service", ['value']="cdn.example.com:443"})
+			new_finding(ctx, domain, {['type']="Port", ['value']="192.0.2.1:8443/tcp"})
+			new_email(ctx, "done@" .. domain)
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(t.TempDir(), systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.AccountRequest{
+		Platform: "github",
+		Name:     "owasp",
+		URL:      "https://github.com/owasp",
+		Domain:   domain,
+	}
+	// The email address is sent after the findings have been stored
+	<-sys.DataSources()[0].Output()
+
+	found := findings.Find(time.Time{}, "ContainerImage")
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 container image to be stored, but %d were found", len(found))
+	}
+	if f := found[0]; f.Value != "ghcr.io/owasp/app" || f.Relation != "container_image" || f.Properties["public"] != "true" {
+		t.Errorf("The finding was not stored as expected: %v", f)
+	}
+	// Only the service provided by a host in scope is kept
+	services := findings.Find(time.Time{}, "Service", "Port")
+	if len(services) != 1 || services[0].Value != "www.owasp.org:443" || services[0].Properties["banner"] != "nginx" {
+		t.Errorf("The services were not scoped by their hosts: %v", services)
+	}
+}
+
+func TestSourceConfidence(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="findings"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_finding(ctx, domain, {
+				['type']="FQDN",
+				['value']="dev." .. domain,
+				['relation']="mentioned_in",
+				['confidence']="0.6",
+			})
+			new_name(ctx, "zzdone." .. domain)
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	findings, err := systems.NewFindingStore(filepath.Join(t.TempDir(), systems.FindingsFile))
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys.(*systems.SimpleSystem).Finds = findings
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.Config().Options["source_confidence"] = map[string]interface{}{"Findings": 0.8, "default": 0.5}
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+	<-sys.DataSources()[0].Output()
+
+	// The confidence configured for the data source replaces the confidence of the script
+	found := findings.Find(time.Time{}, "FQDN")
+	if len(found) != 1 || found[0].Properties["confidence"] != "0.8" {
+		t.Errorf("The configured confidence was not kept with the finding: %v", found)
+	}
+}
+
+func TestBruteForceQPS(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["bruteforce"] = map[string]interface{}{"enabled": true, "qps": 20}
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	s := NewScript(`
+		name="brute_qps"
+		type="brute"
+
+		function vertical(ctx, domain)
+			for i = 1, 10 do
+				new_name(ctx, "host" .. i .. "." .. domain)
+			end
+		end
+	`, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	start := time.Now()
+	s.Input() <- &requests.DNSRequest{Domain: domain}
+
+	for i := 0; i < 10; i++ {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("The test timed out")
+		case <-s.Output():
+		}
+	}
+	// The first name is sent at once, and the following names at 20 per second
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("The 10 brute forcing names were sent in %s, expected at least 450ms at 20 per second", elapsed)
+	}
+}
+
+func TestCandidateQPS(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["bruteforce"] = map[string]interface{}{"enabled": true, "qps": 20}
+	cfg.Options["alterations"] = map[string]interface{}{"enabled": true, "qps": 2.5}
+	cfg.Options["guessing"] = map[string]interface{}{"enabled": true, "qps": 1}
+
+	for stype, expected := range map[string]float64{"brute": 20, "alt": 2.5, "guess": 1, "api": 0} {
+		if qps := candidateQPS(cfg, stype); qps != expected {
+			t.Errorf("candidateQPS returned %f for the %s scripts, expected %f", qps, stype, expected)
+		}
+	}
+	if qps := candidateQPS(config.NewConfig(), "alt"); qps != 0 {
+		t.Errorf("candidateQPS returned %f without the alterations section", qps)
+	}
+}
+
+func TestSwapKeywords(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["alterations"] = map[string]interface{}{
+		"enabled": true,
+		"swap_keywords": []interface{}{
+			[]interface{}{"dev", "Stage", "prod", "dev"},
+			[]interface{}{"lonely"},
+			"us", "eu", " ",
+		},
+	}
+
+	got := swapKeywords(cfg)
+	expected := [][]string{{"dev", "stage", "prod"}, {"us", "eu"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("swapKeywords returned %v, expected %v", got, expected)
+	}
+	if groups := swapKeywords(config.NewConfig()); len(groups) != 0 {
+		t.Errorf("swapKeywords returned %v without the alterations section", groups)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/resolve"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// The tokens of context used to predict the next token, when the 'order' setting is not provided, and at most
+	ngramDefaultOrder = 1
+	ngramMaxOrder     = 4
+	ngramMaxTokens    = 5
+	ngramMaxLabelLen  = 63
+	ngramStart        = "^"
+	ngramEnd          = "$"
+	// Transitions less likely than this are not explored
+	ngramMinProbability = 0.001
+)
+
+// The words and numbers of a label, each with the hyphen preceding it
+var labelTokenRegex = regexp.MustCompile(`-*[a-z_]+|-*[0-9]+`)
+
+// labelModel is a token n-gram model, interpolated with the token frequencies by position, of the leftmost labels
+// discovered for a domain name. It proposes the labels that most resemble the naming conventions of
+// the target, such as 'prod-web' after learning 'dev-web' and 'prod-api'. Each token is predicted from
+// the tokens preceding it, interpolating the contexts from the order of the model down to one token.
+type labelModel struct {
+	sync.Mutex
+	order    int
+	ngrams   map[string]map[string]int
+	totals   map[string]int
+	unigrams map[string]int
+	position []map[string]int
+	counts   []int
+	known    map[string]struct{}
+	proposed map[string]struct{}
+}
+
+func newLabelModel(order int) *labelModel {
+	if order <= 0 {
+		order = ngramDefaultOrder
+	} else if order > ngramMaxOrder {
+		order = ngramMaxOrder
+	}
+
+	return &labelModel{
+		order:    order,
+		ngrams:   make(map[string]map[string]int),
+		totals:   make(map[string]int),
+		unigrams: make(map[string]int),
+		known:    make(map[string]struct{}),
+		proposed: make(map[string]struct{}),
+	}
+}
+
+// train adds the label to the model and returns false when the label was already learned.
+func (m *labelModel) train(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+
+	tokens := labelTokenRegex.FindAllString(label, -1)
+	if len(tokens) == 0 || strings.Join(tokens, "") != label {
+		return false
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, found := m.known[label]; found {
+		return false
+	}
+	m.known[label] = struct{}{}
+
+	padded := append(m.startTokens(), append(tokens, ngramEnd)...)
+	for i := m.order; i < len(padded); i++ {
+		token := padded[i]
+
+		for k := 1; k <= m.order; k++ {
+			ctx := ngramContext(padded[:i], k)
+			if _, found := m.ngrams[ctx]; !found {
+				m.ngrams[ctx] = make(map[string]int)
+			}
+			m.ngrams[ctx][token]++
+			m.totals[ctx]++
+		}
+		m.unigrams[token]++
+
+		pos := i - m.order
+		if pos >= len(m.position) {
+			m.position = append(m.position, make(map[string]int))
+			m.counts = append(m.counts, 0)
+		}
+		m.position[pos][token]++
+		m.counts[pos]++
+	}
+	return true
+}
+
+func (m *labelModel) startTokens() []string {
+	tokens := make([]string, m.order)
+	for i := range tokens {
+		tokens[i] = ngramStart
+	}
+	return tokens
+}
+
+// ngramContext returns the last k tokens of the history as the key of the context.
+func ngramContext(history []string, k int) string {
+	return strings.Join(history[len(history)-k:], " ")
+}
+
+// probability interpolates the frequencies of the token following the contexts of the history, where the
+// longer contexts weigh twice as much as the next shorter one, and the frequency of the token at the position,
+// so the model proposes combinations not seen in the learned labels, while keeping the tokens where they appear.
+func (m *labelModel) probability(history []string, pos int, token string) float64 {
+	var ngram, weights, unigram float64
+
+	for k := 1; k <= m.order; k++ {
+		w := math.Pow(2, float64(k))
+		weights += w
+
+		ctx := ngramContext(history, k)
+		if total := m.totals[ctx]; total > 0 {
+			ngram += w * float64(m.ngrams[ctx][token]) / float64(total)
+		}
+	}
+	if pos < len(m.position) && m.counts[pos] > 0 {
+		unigram = float64(m.position[pos][token]) / float64(m.counts[pos])
+	}
+	return 0.7*ngram/weights + 0.3*unigram
+}
+
+type ngramCandidate struct {
+	tokens []string
+	label  string
+	prob   float64
+}
+
+// generate returns up to num labels, not learned or proposed before, in order of likelihood.
+// The labels are built with a beam search over the tokens of the learned labels.
+func (m *labelModel) generate(num int) []string {
+	if num <= 0 {
+		return nil
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	width := num * 4
+	beam := []ngramCandidate{{tokens: m.startTokens()}}
+	var complete []ngramCandidate
+
+	for step := 0; step <= ngramMaxTokens && len(beam) > 0; step++ {
+		var next []ngramCandidate
+
+		for _, c := range beam {
+			for token := range m.unigrams {
+				p := m.probability(c.tokens, len(c.tokens)-m.order, token)
+				if p < ngramMinProbability {
+					continue
+				}
+				prob := c.prob + math.Log(p)
+
+				if token != ngramEnd {
+					label := c.label + token
+					// Labels cannot begin with a hyphen
+					if len(label) <= ngramMaxLabelLen && !strings.HasPrefix(label, "-") {
+						tokens := append(append([]string{}, c.tokens...), token)
+						next = append(next, ngramCandidate{tokens: tokens, label: label, prob: prob})
+					}
+					continue
+				}
+
+				if _, found := m.known[c.label]; found || c.label == "" {
+					continue
+				}
+				if _, found := m.proposed[c.label]; found {
+					continue
+				}
+				complete = append(complete, ngramCandidate{label: c.label, prob: prob})
+			}
+		}
+
+		sort.Slice(next, func(i, j int) bool {
+			if next[i].prob == next[j].prob {
+				return next[i].label < next[j].label
+			}
+			return next[i].prob > next[j].prob
+		})
+		if len(next) > width {
+			next = next[:width]
+		}
+		beam = next
+	}
+
+	sort.Slice(complete, func(i, j int) bool {
+		if complete[i].prob == complete[j].prob {
+			return complete[i].label < complete[j].label
+		}
+		return complete[i].prob > complete[j].prob
+	})
+
+	var results []string
+	for _, c := range complete {
+		if len(results) >= num {
+			break
+		}
+		if _, found := m.proposed[c.label]; found {
+			continue
+		}
+
+		m.proposed[c.label] = struct{}{}
+		results = append(results, c.label)
+	}
+	return results
+}
+
+func (s *Script) labelModel(domain string) *labelModel {
+	s.ngramLock.Lock()
+	defer s.ngramLock.Unlock()
+
+	if s.ngrams == nil {
+		s.ngrams = make(map[string]*labelModel)
+	}
+
+	m, found := s.ngrams[domain]
+	if !found {
+		m = newLabelModel(guessingNumber(s.sys.Config(), "order"))
+		s.ngrams[domain] = m
+	}
+	return m
+}
+
+// Wrapper so that scripts can teach the n-gram model the labels of the names discovered for a domain.
+func (s *Script) ngramTrain(L *lua.LState) int {
+	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
+		name := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(L.CheckString(2))))
+		domain := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(L.CheckString(3))))
+
+		if domain != "" && strings.HasSuffix(name, "."+domain) {
+			sub := strings.TrimSuffix(name, "."+domain)
+
+			if label := strings.Split(sub, ".")[0]; s.labelModel(domain).train(label) {
+				L.Push(lua.LTrue)
+				return 1
+			}
+		}
+	}
+
+	L.Push(lua.LFalse)
+	return 1
+}
+
+// Wrapper so that scripts can obtain the most likely names, not yet seen, from the n-gram model of a domain.
+func (s *Script) ngramNames(L *lua.LState) int {
+	tb := L.NewTable()
+
+	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
+		domain := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(L.CheckString(2))))
+		num := int(L.OptNumber(3, 100))
+
+		if domain != "" {
+			for _, label := range s.labelModel(domain).generate(num) {
+				tb.Append(lua.LString(label + "." + domain))
+			}
+		}
+	}
+
+	L.Push(tb)
+	return 1
+}
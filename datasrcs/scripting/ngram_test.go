@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+)
+
+func TestLabelModel(t *testing.T) {
+	m := newLabelModel(0)
+
+	for _, label := range []string{"dev-api", "dev-web", "dev-mail", "prod-api", "prod-mail", "test-api"} {
+		if !m.train(label) {
+			t.Errorf("train returned false for the new label %s", label)
+		}
+	}
+	if m.train("DEV-API") {
+		t.Errorf("train returned true for a label already learned")
+	}
+
+	labels := m.generate(10)
+	if len(labels) == 0 {
+		t.Fatal("generate did not return any labels")
+	}
+
+	expected := map[string]struct{}{"prod-web": {}, "test-web": {}, "test-mail": {}}
+	var found bool
+	for _, label := range labels {
+		if _, known := m.known[label]; known {
+			t.Errorf("generate returned the learned label %s", label)
+		}
+		if _, ok := expected[label]; ok {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("generate returned %v, expected labels such as prod-web or test-mail", labels)
+	}
+
+	for _, label := range m.generate(5) {
+		for _, prev := range labels {
+			if label == prev {
+				t.Errorf("generate proposed the label %s a second time", label)
+			}
+		}
+	}
+}
+
+func TestLabelModelOrder(t *testing.T) {
+	if m := newLabelModel(10); m.order != ngramMaxOrder {
+		t.Errorf("Expected the order to be limited to %d, got %d", ngramMaxOrder, m.order)
+	}
+
+	m := newLabelModel(2)
+	for _, label := range []string{"eu-west-1", "eu-west-2", "us-west-1", "us-east-1", "us-east-2"} {
+		if !m.train(label) {
+			t.Errorf("train returned false for the new label %s", label)
+		}
+	}
+
+	labels := m.generate(5)
+	if len(labels) == 0 {
+		t.Fatal("generate did not return any labels")
+	}
+	var found bool
+	for _, label := range labels {
+		if _, known := m.known[label]; known {
+			t.Errorf("generate returned the learned label %s", label)
+		}
+		if label == "eu-east-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("generate returned %v, expected labels such as eu-east-1", labels)
+	}
+}
@@ -0,0 +1,463 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// nsec3MaxQueries is the number of queries sent to a nameserver to collect the NSEC3 records of a zone
+	nsec3MaxQueries = 5000
+	// nsec3MaxGuesses is the number of random labels hashed to find a label within a gap of the NSEC3 chain
+	nsec3MaxGuesses = 1000000
+	// nsec3ChunkSize is the number of candidates hashed by a worker of the 'cpu' backend at a time
+	nsec3ChunkSize = 1024
+	// nsec3ProgressInterval is the time between the reports of the cracking progress
+	nsec3ProgressInterval = 10 * time.Second
+)
+
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// nsec3Backend returns the backend cracking the NSEC3 hashes, set by the 'nsec3_cracking' option.
+// The NSEC3 records are not collected when the option is not provided.
+func nsec3Backend(opts map[string]interface{}) string {
+	v, _ := opts["nsec3_cracking"].(string)
+
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "cpu", "hashcat":
+		return v
+	}
+	return ""
+}
+
+// nsec3Chain holds the NSEC3 records collected from a zone, as the hashed owner names linked to the next hashed names.
+type nsec3Chain struct {
+	zone       string
+	known      bool
+	salt       []byte
+	iterations uint16
+	next       map[string]string
+	owners     []string
+}
+
+func newNSEC3Chain(zone string) *nsec3Chain {
+	return &nsec3Chain{
+		zone: strings.ToLower(resolve.RemoveLastDot(zone)),
+		next: make(map[string]string),
+	}
+}
+
+// add keeps the NSEC3 records of the zone found in the response, and returns the number of records added.
+func (c *nsec3Chain) add(resp *dns.Msg) int {
+	var added int
+
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		n, ok := rr.(*dns.NSEC3)
+		if !ok || n.Hash != dns.SHA1 {
+			continue
+		}
+
+		owner, zone, found := strings.Cut(strings.ToLower(resolve.RemoveLastDot(n.Hdr.Name)), ".")
+		if !found || zone != c.zone {
+			continue
+		}
+
+		salt, err := hex.DecodeString(n.Salt)
+		if err != nil {
+			continue
+		}
+		// The records of a zone share the parameters, so the records of a zone being re-signed are skipped
+		if !c.known {
+			c.known = true
+			c.salt = salt
+			c.iterations = n.Iterations
+		} else if n.Iterations != c.iterations || string(salt) != string(c.salt) {
+			continue
+		}
+
+		owner = strings.ToUpper(owner)
+		if _, dup := c.next[owner]; dup {
+			continue
+		}
+		c.next[owner] = strings.ToUpper(n.NextDomain)
+
+		i := sort.SearchStrings(c.owners, owner)
+		c.owners = append(c.owners, "")
+		copy(c.owners[i+1:], c.owners[i:])
+		c.owners[i] = owner
+		added++
+	}
+	return added
+}
+
+// covered returns true when the hashed name is the owner of a collected record or falls within its gap.
+func (c *nsec3Chain) covered(h string) bool {
+	if len(c.owners) == 0 {
+		return false
+	}
+
+	i := sort.SearchStrings(c.owners, h)
+	if i < len(c.owners) && c.owners[i] == h {
+		return true
+	}
+
+	// The gap of the last owner wraps around to the first hashed name of the chain
+	prev := c.owners[len(c.owners)-1]
+	if i > 0 {
+		prev = c.owners[i-1]
+	}
+
+	next := c.next[prev]
+	if prev < next {
+		return h > prev && h < next
+	}
+	return h > prev || h < next
+}
+
+// complete returns true when the next hashed name of each record is the owner of a collected record.
+func (c *nsec3Chain) complete() bool {
+	if len(c.next) == 0 {
+		return false
+	}
+
+	for _, next := range c.next {
+		if _, found := c.next[next]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// guess returns a random label whose hashed name is within a gap of the chain not yet collected,
+// so each query provides a new record, as the labels can be hashed without contacting the nameserver.
+func (c *nsec3Chain) guess() (string, bool) {
+	if !c.known {
+		return randomNSEC3Label(), true
+	}
+
+	h := newNSEC3Hasher(c.zone, c.salt, c.iterations)
+	for i := 0; i < nsec3MaxGuesses; i++ {
+		label := randomNSEC3Label()
+
+		if !c.covered(nsec3Encoding.EncodeToString(h.sum(label))) {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+func randomNSEC3Label() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return strings.ToLower(nsec3Encoding.EncodeToString(b))
+}
+
+// nsec3Hasher computes the NSEC3 hashes of the names within a zone, reusing its buffers between the names.
+type nsec3Hasher struct {
+	suffix     []byte
+	salt       []byte
+	iterations uint16
+	h          hash.Hash
+	wire       []byte
+	digest     []byte
+}
+
+func newNSEC3Hasher(zone string, salt []byte, iterations uint16) *nsec3Hasher {
+	return &nsec3Hasher{
+		suffix:     append(nsec3Wire(nil, zone), 0),
+		salt:       salt,
+		iterations: iterations,
+		h:          sha1.New(),
+		wire:       make([]byte, 0, 256),
+		digest:     make([]byte, 0, sha1.Size),
+	}
+}
+
+// sum returns the NSEC3 hash of the name made of the prefix and the zone, which is only valid until the next call.
+func (n *nsec3Hasher) sum(prefix string) []byte {
+	n.wire = append(nsec3Wire(n.wire[:0], prefix), n.suffix...)
+
+	n.h.Reset()
+	n.h.Write(n.wire)
+	n.h.Write(n.salt)
+	n.digest = n.h.Sum(n.digest[:0])
+
+	for k := uint16(0); k < n.iterations; k++ {
+		n.h.Reset()
+		n.h.Write(n.digest)
+		n.h.Write(n.salt)
+		n.digest = n.h.Sum(n.digest[:0])
+	}
+	return n.digest
+}
+
+// nsec3Wire appends the lowercase labels of the name in wire format, without the root label.
+func nsec3Wire(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.ToLower(name), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return b
+}
+
+// nsec3Walk collects the NSEC3 records of the zone from the nameserver, until the chain is complete or the queries run out.
+func (s *Script) nsec3Walk(ctx context.Context, r *resolve.Resolvers, zone string) (*nsec3Chain, error) {
+	c := newNSEC3Chain(zone)
+
+	var queries int
+	for ; queries < nsec3MaxQueries && !c.complete(); queries++ {
+		label, ok := c.guess()
+		if !ok {
+			break
+		}
+
+		resp, err := r.QueryBlocking(ctx, resolve.WalkMsg(label+"."+c.zone, dns.TypeA))
+		if err != nil {
+			return c, err
+		}
+		if resp.Rcode == resolve.RcodeNoResponse {
+			continue
+		}
+		if c.add(resp) == 0 && !c.known {
+			return nil, fmt.Errorf("%s NSEC3 records not found", zone)
+		}
+	}
+
+	s.sys.Config().Log.Printf("%s: collected %d NSEC3 hashes of %s with %d queries, the chain is complete: %t",
+		s.String(), len(c.owners), c.zone, queries, c.complete())
+	return c, nil
+}
+
+// nsec3Crack returns the names of the zone whose hashes were collected, hashing each candidate label with the backend.
+func (s *Script) nsec3Crack(ctx context.Context, c *nsec3Chain, backend string) ([]string, error) {
+	words := s.nsec3Candidates()
+	if len(words) == 0 || len(c.owners) == 0 {
+		return nil, nil
+	}
+
+	if backend == "hashcat" {
+		return s.hashcatCrack(ctx, c, words)
+	}
+	return s.cpuCrack(ctx, c, words), nil
+}
+
+// nsec3Candidates returns the brute forcing wordlist, or the default wordlist when brute forcing is not enabled.
+func (s *Script) nsec3Candidates() []string {
+	words := s.sys.Config().Wordlist
+
+	if len(words) == 0 {
+		if f, err := resources.GetResourceFile("namelist.txt"); err == nil {
+			scanner := bufio.NewScanner(f)
+
+			for scanner.Scan() {
+				if w := strings.TrimSpace(scanner.Text()); w != "" && !strings.HasPrefix(w, "#") {
+					words = append(words, w)
+				}
+			}
+		}
+	}
+
+	var results []string
+	for _, w := range words {
+		if w = strings.ToLower(strings.Trim(w, ".")); w != "" && validNSEC3Labels(w) {
+			results = append(results, w)
+		}
+	}
+	return results
+}
+
+func validNSEC3Labels(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || strings.ContainsAny(label, ": \t") {
+			return false
+		}
+	}
+	return len(name) < 200
+}
+
+// cpuCrack hashes the candidates on all the CPU cores, comparing the digests with the collected hashes.
+func (s *Script) cpuCrack(ctx context.Context, c *nsec3Chain, words []string) []string {
+	targets := make(map[[sha1.Size]byte]struct{}, len(c.owners))
+	for _, owner := range c.owners {
+		var key [sha1.Size]byte
+
+		if b, err := nsec3Encoding.DecodeString(owner); err == nil && len(b) == sha1.Size {
+			copy(key[:], b)
+			targets[key] = struct{}{}
+		}
+	}
+
+	var tested int64
+	var lock sync.Mutex
+	var names []string
+	progress := s.nsec3Progress(c, len(words), &tested, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(names)
+	})
+	defer progress()
+
+	var next int64
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			h := newNSEC3Hasher(c.zone, c.salt, c.iterations)
+			for ctx.Err() == nil {
+				start := int(atomic.AddInt64(&next, nsec3ChunkSize)) - nsec3ChunkSize
+				if start >= len(words) {
+					return
+				}
+
+				end := start + nsec3ChunkSize
+				if end > len(words) {
+					end = len(words)
+				}
+				for _, w := range words[start:end] {
+					var key [sha1.Size]byte
+
+					copy(key[:], h.sum(w))
+					if _, found := targets[key]; found {
+						lock.Lock()
+						names = append(names, w+"."+c.zone)
+						lock.Unlock()
+					}
+				}
+				atomic.AddInt64(&tested, int64(end-start))
+			}
+		}()
+	}
+	wg.Wait()
+	return names
+}
+
+// hashcatStatus is the part of the status written by hashcat with the '--status-json' flag used to report the progress.
+type hashcatStatus struct {
+	Progress  []int64 `json:"progress"`
+	Recovered []int64 `json:"recovered_hashes"`
+}
+
+// hashcatCrack cracks the collected hashes with hashcat in the 8300 (DNSSEC NSEC3) mode, which uses the GPUs of the host.
+// The path of the hashcat binary is provided by the 'hashcat_path' option.
+func (s *Script) hashcatCrack(ctx context.Context, c *nsec3Chain, words []string) ([]string, error) {
+	bin := "hashcat"
+	if v := options.String(s.sys.Config(), "hashcat_path"); v != "" {
+		bin = v
+	}
+
+	dir, err := os.MkdirTemp("", "amass-nsec3-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var lines []string
+	for _, owner := range c.owners {
+		lines = append(lines, fmt.Sprintf("%s:.%s:%s:%d",
+			strings.ToLower(owner), c.zone, hex.EncodeToString(c.salt), c.iterations))
+	}
+
+	hashes := filepath.Join(dir, "hashes.txt")
+	wordlist := filepath.Join(dir, "words.txt")
+	outfile := filepath.Join(dir, "cracked.txt")
+	if err := os.WriteFile(hashes, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(wordlist, []byte(strings.Join(words, "\n")+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, bin, "-m", "8300", "-a", "0", "--potfile-disable",
+		"--status", "--status-json", "--status-timer", fmt.Sprint(int(nsec3ProgressInterval.Seconds())),
+		"--outfile", outfile, "--outfile-format", "2", hashes, wordlist)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var st hashcatStatus
+
+		if err := json.Unmarshal(scanner.Bytes(), &st); err == nil && len(st.Progress) == 2 && len(st.Recovered) == 2 {
+			s.sys.Config().Log.Printf("%s: NSEC3 cracking of %s: %d of %d candidates tested, %d of %d hashes found",
+				s.String(), c.zone, st.Progress[0], st.Progress[1], st.Recovered[0], st.Recovered[1])
+		}
+	}
+
+	// The exit status is 1 when the wordlist was exhausted without cracking every hash
+	if err := cmd.Wait(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() != 1 {
+			return nil, fmt.Errorf("hashcat failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(outfile)
+	if err != nil {
+		return nil, nil
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if w := strings.ToLower(strings.TrimSpace(line)); w != "" {
+			names = append(names, w+"."+c.zone)
+		}
+	}
+	return names, nil
+}
+
+// nsec3Progress reports the progress of the cracking until the returned function is called.
+func (s *Script) nsec3Progress(c *nsec3Chain, total int, tested *int64, found func() int) func() {
+	done := make(chan struct{})
+
+	go func() {
+		t := time.NewTicker(nsec3ProgressInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				s.sys.Config().Log.Printf("%s: NSEC3 cracking of %s: %d of %d candidates tested, %d of %d hashes found",
+					s.String(), c.zone, atomic.LoadInt64(tested), total, found(), len(c.owners))
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func nsec3TestMsg(zone, salt string, iterations uint16, labels ...string) *dns.Msg {
+	var hashes []string
+	for _, label := range labels {
+		hashes = append(hashes, dns.HashName(dns.Fqdn(label+"."+zone), dns.SHA1, iterations, salt))
+	}
+	sort.Strings(hashes)
+
+	msg := new(dns.Msg)
+	for i, h := range hashes {
+		msg.Ns = append(msg.Ns, &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: h + "." + zone + ".", Rrtype: dns.TypeNSEC3, Class: dns.ClassINET},
+			Hash:       dns.SHA1,
+			Iterations: iterations,
+			SaltLength: uint8(len(salt) / 2),
+			Salt:       salt,
+			NextDomain: hashes[(i+1)%len(hashes)],
+		})
+	}
+	return msg
+}
+
+func TestNSEC3Hasher(t *testing.T) {
+	for _, salt := range []string{"", "aabbccdd"} {
+		for _, iterations := range []uint16{0, 10} {
+			b, _ := hex.DecodeString(salt)
+			h := newNSEC3Hasher("owasp.org", b, iterations)
+
+			for _, label := range []string{"www", "Mail", "dev.api"} {
+				expected := dns.HashName(label+".owasp.org.", dns.SHA1, iterations, salt)
+
+				if got := nsec3Encoding.EncodeToString(h.sum(label)); got != expected {
+					t.Errorf("salt %q with %d iterations: %s hashed to %s, expected %s", salt, iterations, label, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestNSEC3Chain(t *testing.T) {
+	c := newNSEC3Chain("owasp.org.")
+	partial := nsec3TestMsg("owasp.org", "aabbccdd", 1, "www", "mail", "vpn")
+	partial.Ns = partial.Ns[:2]
+	if n := c.add(partial); n != 2 {
+		t.Errorf("add returned %d records, expected 2", n)
+	}
+	if c.complete() {
+		t.Errorf("complete returned true for a chain missing a record")
+	}
+
+	full := nsec3TestMsg("owasp.org", "aabbccdd", 1, "www", "mail", "vpn")
+	if n := c.add(full); n != 1 {
+		t.Errorf("add returned %d records, expected only the missing record", n)
+	}
+	if !c.complete() {
+		t.Errorf("complete returned false for the whole chain")
+	}
+	for _, h := range []string{"0", "G", "VVVV", c.owners[0]} {
+		if !c.covered(h) {
+			t.Errorf("covered returned false for %s in the complete chain", h)
+		}
+	}
+	if _, ok := c.guess(); ok {
+		t.Errorf("guess returned a label for the complete chain")
+	}
+
+	other := nsec3TestMsg("example.com", "aabbccdd", 1, "www")
+	if n := c.add(other); n != 0 {
+		t.Errorf("add returned %d records of another zone", n)
+	}
+	resigned := nsec3TestMsg("owasp.org", "01", 1, "ftp")
+	if n := c.add(resigned); n != 0 {
+		t.Errorf("add returned %d records with other parameters", n)
+	}
+}
+
+func TestNSEC3CpuCrack(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="nsec3"
+		type="testing"
+	`)
+	if script == nil {
+		t.Fatal("failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	c := newNSEC3Chain("owasp.org")
+	c.add(nsec3TestMsg("owasp.org", "aabbccdd", 5, "www", "mail", "secret-vpn"))
+
+	words := []string{"ftp", "www", "mail", "api"}
+	for i := 0; i < 3*nsec3ChunkSize; i++ {
+		words = append(words, "word"+hex.EncodeToString([]byte{byte(i >> 8), byte(i)}))
+	}
+
+	names := script.(*Script).cpuCrack(context.Background(), c, words)
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "mail.owasp.org" || names[1] != "www.owasp.org" {
+		t.Errorf("cpuCrack returned %v, expected mail.owasp.org and www.owasp.org", names)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"sync"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// ScriptPool is the Service that distributes the requests for a data source across several
+// instances of the script, each with its own Lua state, so the requests are handled concurrently.
+type ScriptPool struct {
+	service.BaseService
+	lock    sync.Mutex
+	script  string
+	sys     systems.System
+	scripts []*Script
+	active  int
+	started bool
+	resized chan struct{}
+}
+
+// NewScriptPool returns the pool for the script already loaded as the first instance.
+// The additional instances are created as the number of instances is increased.
+func NewScriptPool(script string, first *Script, sys systems.System, instances int) *ScriptPool {
+	p := &ScriptPool{
+		script:  script,
+		sys:     sys,
+		resized: make(chan struct{}),
+	}
+	p.BaseService = *service.NewBaseService(p, first.String())
+
+	p.addInstance(first)
+	p.SetInstances(instances)
+	return p
+}
+
+// MaxInstances returns the number of script instances for the data source, as provided by the
+// 'source_instances' option. The option is either a number used for all the data sources, or
+// a table of data source names and numbers, where the 'default' entry applies to the others.
+func MaxInstances(cfg *config.Config, name string) int {
+	if num := options.IntValue(options.Source(cfg, "source_instances", name)); num > 1 {
+		return num
+	}
+	return 1
+}
+
+// Description implements the Service interface.
+func (p *ScriptPool) Description() string {
+	return p.first().Description()
+}
+
+// HandlesReq implements the Service interface.
+func (p *ScriptPool) HandlesReq(req interface{}) bool {
+	return p.first().HandlesReq(req)
+}
+
+// OnStart implements the Service interface.
+func (p *ScriptPool) OnStart() error {
+	first := p.first()
+	if err := first.Start(); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	p.started = true
+	scripts := p.scripts[1:]
+	p.lock.Unlock()
+
+	for _, s := range scripts {
+		if err := s.Start(); err != nil {
+			p.sys.Config().Log.Printf("%s: failed to start an additional instance: %v", p.String(), err)
+		}
+	}
+	// The rate limit set by the script is only known once it has started
+	if first.seconds > 0 {
+		p.SetInstances(p.Instances())
+	}
+	return nil
+}
+
+// OnStop implements the Service interface.
+func (p *ScriptPool) OnStop() error {
+	p.lock.Lock()
+	scripts := p.scripts
+	p.lock.Unlock()
+
+	for _, s := range scripts {
+		_ = s.Stop()
+	}
+	return nil
+}
+
+// Instances returns the number of script instances currently handling requests.
+func (p *ScriptPool) Instances() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.active
+}
+
+// SetInstances changes the number of script instances handling requests, which can be done while
+// the data source is running. Instances are created as needed and kept idle when the number is reduced.
+func (p *ScriptPool) SetInstances(num int) {
+	if num < 1 {
+		num = 1
+	}
+	// The rate limit of the data source cannot be shared by the instances
+	if first := p.first(); first.seconds > 0 && num > 1 {
+		p.sys.Config().Log.Printf("%s: ignoring the %d instances requested, since the data source has a rate limit", p.String(), num)
+		num = 1
+	}
+
+	p.lock.Lock()
+	started := p.started
+	count := len(p.scripts)
+	p.lock.Unlock()
+
+	for ; count < num; count++ {
+		s := NewScript(p.script, p.sys)
+		if s == nil {
+			break
+		}
+		if started {
+			if err := s.Start(); err != nil {
+				p.sys.Config().Log.Printf("%s: failed to start an additional instance: %v", p.String(), err)
+				break
+			}
+		}
+		p.addInstance(s)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if num > len(p.scripts) {
+		num = len(p.scripts)
+	}
+	p.active = num
+	// Wake up the instances so they check the new number of active instances
+	close(p.resized)
+	p.resized = make(chan struct{})
+}
+
+func (p *ScriptPool) first() *Script {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.scripts[0]
+}
+
+func (p *ScriptPool) addInstance(s *Script) {
+	p.lock.Lock()
+	idx := len(p.scripts)
+	p.scripts = append(p.scripts, s)
+	p.lock.Unlock()
+
+	go p.forwardRequests(idx, s)
+	go p.forwardOutput(s)
+}
+
+func (p *ScriptPool) forwardRequests(idx int, s *Script) {
+	for {
+		p.lock.Lock()
+		active := idx < p.active
+		resized := p.resized
+		p.lock.Unlock()
+
+		if !active {
+			select {
+			case <-p.Done():
+				return
+			case <-resized:
+			}
+			continue
+		}
+
+		select {
+		case <-p.Done():
+			return
+		case <-resized:
+		case req := <-p.Input():
+			select {
+			case <-p.Done():
+				return
+			case s.Input() <- req:
+			}
+		}
+	}
+}
+
+func (p *ScriptPool) forwardOutput(s *Script) {
+	for {
+		select {
+		case <-p.Done():
+			return
+		case out := <-s.Output():
+			select {
+			case <-p.Done():
+				return
+			case p.Output() <- out:
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestMaxInstances(t *testing.T) {
+	cfg := config.NewConfig()
+	if n := MaxInstances(cfg, "Example"); n != 1 {
+		t.Errorf("Expected 1 instance without the option, but %d were returned", n)
+	}
+
+	cfg.Options["source_instances"] = 4
+	if n := MaxInstances(cfg, "Example"); n != 4 {
+		t.Errorf("Expected 4 instances, but %d were returned", n)
+	}
+
+	cfg.Options["source_instances"] = map[string]interface{}{"default": 2, "Example": 8}
+	if n := MaxInstances(cfg, "Example"); n != 8 {
+		t.Errorf("Expected 8 instances for the named data source, but %d were returned", n)
+	}
+	if n := MaxInstances(cfg, "Other"); n != 2 {
+		t.Errorf("Expected the default of 2 instances, but %d were returned", n)
+	}
+}
+
+func TestScriptPool(t *testing.T) {
+	script := `
+		name="pool"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_name(ctx, "www." .. domain)
+		end
+	`
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	first := NewScript(script, sys)
+	if first == nil {
+		t.Fatal("Failed to load the script")
+	}
+	pool := NewScriptPool(script, first, sys, 3)
+	if err := sys.AddAndStart(pool); err != nil {
+		t.Fatalf("Failed to start the pool: %v", err)
+	}
+	if n := pool.Instances(); n != 3 {
+		t.Errorf("Expected 3 instances, but %d are active", n)
+	}
+
+	domains := []string{"owasp.org", "example.com", "example.net", "example.org"}
+	for _, d := range domains {
+		sys.Config().AddDomain(d)
+	}
+	go func() {
+		for _, d := range domains {
+			pool.Input() <- &requests.DNSRequest{Domain: d}
+		}
+	}()
+
+	var count int
+	timer := time.NewTimer(3 * time.Second)
+	defer timer.Stop()
+loop:
+	for count < len(domains) {
+		select {
+		case <-pool.Output():
+			count++
+		case <-timer.C:
+			break loop
+		}
+	}
+	if count != len(domains) {
+		t.Errorf("Expected %d names from the instances, but %d were returned", len(domains), count)
+	}
+
+	pool.SetInstances(1)
+	if n := pool.Instances(); n != 1 {
+		t.Errorf("Expected 1 instance after the reduction, but %d are active", n)
+	}
+}
@@ -14,10 +14,12 @@ import (
 	"github.com/caffix/service"
 	luaurl "github.com/cjoudrey/gluaurl"
 	"github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/net/http"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/time/rate"
 	luajson "layeh.com/gopher-json"
 )
 
@@ -32,6 +34,8 @@ type callbacks struct {
 	Asn        lua.LValue
 	Resolved   lua.LValue
 	Subdomain  lua.LValue
+	Email      lua.LValue
+	Account    lua.LValue
 }
 
 // Script is the Service that handles access to the Script data source.
@@ -49,6 +53,10 @@ type Script struct {
 	seconds    int
 	ctx        context.Context
 	cancel     context.CancelFunc
+	ngrams     map[string]*labelModel
+	ngramLock  sync.Mutex
+	urls       *http.URLPrefixes
+	names      *rate.Limiter
 }
 
 // NewScript returns the object initialized, but not yet started.
@@ -58,12 +66,19 @@ func NewScript(script string, sys systems.System) *Script {
 		return nil
 	}
 
+	urls, err := systems.ScopeURLs(sys.Config())
+	if err != nil {
+		sys.Config().Log.Printf("Script: %v", err)
+		return nil
+	}
+
 	s := &Script{
 		start:    make(chan struct{}, 1),
 		startRet: make(chan error, 1),
 		stop:     make(chan struct{}, 1),
 		sys:      sys,
 		subre:    re,
+		urls:     urls,
 	}
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	L := s.newLuaState(sys.Config())
@@ -87,6 +102,11 @@ func NewScript(script string, sys systems.System) *Script {
 	}
 
 	s.BaseService = *service.NewBaseService(s, name)
+	// The brute forcing, alteration and guessed candidates are sent at the rate of the 'qps' setting of their section,
+	// shared by the instances of the script
+	if qps := candidateQPS(sys.Config(), s.SourceType); qps > 0 {
+		s.names = rate.NewLimiter(rate.Limit(qps/float64(MaxInstances(sys.Config(), name))), 1)
+	}
 	s.assignCallbacks()
 	go s.requests()
 	return s
@@ -107,18 +127,32 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.PreloadModule("url", luaurl.Loader)
 	L.PreloadModule("json", luajson.Loader)
 	L.SetGlobal("config", L.NewFunction(s.config))
+	L.SetGlobal("page_limit", L.NewFunction(s.pageLimit))
+	L.SetGlobal("search_regions", L.NewFunction(s.searchRegions))
+	L.SetGlobal("search_endpoints", L.NewFunction(s.searchEndpoints))
 	L.SetGlobal("datasrc_config", L.NewFunction(s.dataSourceConfig))
 	L.SetGlobal("brute_wordlist", L.NewFunction(s.bruteWordlist))
 	L.SetGlobal("alt_wordlist", L.NewFunction(s.altWordlist))
+	L.SetGlobal("ngram_train", L.NewFunction(s.ngramTrain))
+	L.SetGlobal("ngram_names", L.NewFunction(s.ngramNames))
 	L.SetGlobal("log", L.NewFunction(s.log))
+	L.SetGlobal("report_error", L.NewFunction(s.reportError))
 	L.SetGlobal("find", L.NewFunction(s.find))
 	L.SetGlobal("submatch", L.NewFunction(s.submatch))
 	L.SetGlobal("mtime", L.NewFunction(s.modDateTime))
 	L.SetGlobal("new_name", L.NewFunction(s.newName))
+	L.SetGlobal("new_names", L.NewFunction(s.newNames))
 	L.SetGlobal("send_names", L.NewFunction(s.sendNames))
 	L.SetGlobal("send_dns_records", L.NewFunction(s.sendDNSRecords))
 	L.SetGlobal("new_addr", L.NewFunction(s.newAddr))
 	L.SetGlobal("new_asn", L.NewFunction(s.newASN))
+	L.SetGlobal("new_email", L.NewFunction(s.newEmail))
+	L.SetGlobal("send_emails", L.NewFunction(s.sendEmails))
+	L.SetGlobal("new_account", L.NewFunction(s.newAccount))
+	L.SetGlobal("new_finding", L.NewFunction(s.newFinding))
+	L.SetGlobal("send_app_names", L.NewFunction(s.sendAppNames))
+	L.SetGlobal("new_archived_url", L.NewFunction(s.newArchivedURL))
+	L.SetGlobal("crtsh_query", L.NewFunction(s.crtshQuery))
 	L.SetGlobal("associated", L.NewFunction(s.associated))
 	L.SetGlobal("in_scope", L.NewFunction(s.inScope))
 	L.SetGlobal("request", L.NewFunction(s.request))
@@ -151,6 +185,8 @@ func (s *Script) assignCallbacks() {
 		Asn:        L.GetGlobal("asn"),
 		Resolved:   L.GetGlobal("resolved"),
 		Subdomain:  L.GetGlobal("subdomain"),
+		Email:      L.GetGlobal("email"),
+		Account:    L.GetGlobal("account"),
 	}
 }
 
@@ -228,6 +264,14 @@ func (s *Script) HandlesReq(req interface{}) bool {
 		if s.cbs.Horizontal.Type() != lua.LTNil {
 			handles = true
 		}
+	case *requests.EmailRequest:
+		if s.cbs.Email.Type() != lua.LTNil && t != nil && t.Address != "" {
+			handles = true
+		}
+	case *requests.AccountRequest:
+		if s.cbs.Account.Type() != lua.LTNil && t != nil && t.URL != "" {
+			handles = true
+		}
 	}
 	return handles
 }
@@ -370,28 +414,33 @@ func (s *Script) dispatch(in interface{}) {
 			s.CheckRateLimit()
 			s.whoisRequest(s.ctx, callback, req)
 		}
+	case *requests.EmailRequest:
+		if s.cbs.Email.Type() != lua.LTNil && req != nil && req.Address != "" {
+			callback := s.cbs.Email
+			s.cbsLock.Unlock()
+			s.CheckRateLimit()
+			s.emailRequest(s.ctx, callback, req)
+		}
+	case *requests.AccountRequest:
+		if s.cbs.Account.Type() != lua.LTNil && req != nil && req.URL != "" {
+			callback := s.cbs.Account
+			s.cbsLock.Unlock()
+			s.CheckRateLimit()
+			s.accountRequest(s.ctx, callback, req)
+		}
 	default:
 		s.cbsLock.Unlock()
 	}
 }
 
 func (s *Script) dnsRequest(ctx context.Context, callback lua.LValue, req *requests.DNSRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
 	s.sys.Config().Log.Printf("Querying %s for %s subdomains", s.String(), req.Domain)
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
-		NRet:    0,
-		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Domain))
-	if err != nil {
-		s.sys.Config().Log.Printf("%s: vertical callback: %v", s.String(), err)
-	}
+	s.callback(ctx, "vertical", req, callback, s.contextToUserData(ctx), lua.LString(req.Domain))
 }
 
 func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *requests.ResolvedRequest) {
@@ -411,80 +460,90 @@ func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *
 		records.Append(tb)
 	}
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
-		NRet:    0,
-		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), records)
-	if err != nil {
-		s.sys.Config().Log.Printf("%s: resolved callback: %v", s.String(), err)
-	}
+	s.callback(ctx, "resolved", req, callback, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), records)
 }
 
 func (s *Script) subdomainRequest(ctx context.Context, callback lua.LValue, req *requests.SubdomainRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
-		NRet:    0,
-		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), lua.LNumber(req.Times))
-	if err != nil {
-		s.sys.Config().Log.Printf("%s: subdomain callback: %v", s.String(), err)
-	}
+	s.callback(ctx, "subdomain", req, callback, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), lua.LNumber(req.Times))
 }
 
 func (s *Script) addrRequest(ctx context.Context, callback lua.LValue, req *requests.AddrRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
-		NRet:    0,
-		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Address))
-	if err != nil {
-		s.sys.Config().Log.Printf("%s: address callback: %v", s.String(), err)
-	}
+	s.callback(ctx, "address", req, callback, s.contextToUserData(ctx), lua.LString(req.Address))
 }
 
 func (s *Script) asnRequest(ctx context.Context, callback lua.LValue, req *requests.ASNRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
-		NRet:    0,
-		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Address), lua.LNumber(req.ASN))
-	if err != nil {
-		s.sys.Config().Log.Printf("%s: asn callback: %v", s.String(), err)
-	}
+	s.callback(ctx, "asn", req, callback, s.contextToUserData(ctx), lua.LString(req.Address), lua.LNumber(req.ASN))
 }
 
 func (s *Script) whoisRequest(ctx context.Context, callback lua.LValue, req *requests.WhoisRequest) {
-	L := s.luaState
+	if contextExpired(ctx) {
+		return
+	}
+
+	s.callback(ctx, "horizontal", req, callback, s.contextToUserData(ctx), lua.LString(req.Domain))
+}
 
+func (s *Script) emailRequest(ctx context.Context, callback lua.LValue, req *requests.EmailRequest) {
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
-		Fn:      callback,
+	s.callback(ctx, "email", req, callback, s.contextToUserData(ctx), lua.LString(req.Address), lua.LString(req.Domain))
+}
+
+func (s *Script) accountRequest(ctx context.Context, callback lua.LValue, req *requests.AccountRequest) {
+	if contextExpired(ctx) {
+		return
+	}
+
+	s.callback(ctx, "account", req, callback, s.contextToUserData(ctx), lua.LString(req.Platform),
+		lua.LString(req.Name), lua.LString(req.URL), lua.LString(req.Domain))
+}
+
+// callback executes the script callback for the request. The requests where the script reported
+// a failure, or where the requests sent by the script failed, are entered into the dead-letter queue
+// so they can be replayed. The errors raised by the script itself are only logged, since running
+// the callback again would fail the same way.
+func (s *Script) callback(ctx context.Context, name string, req interface{}, fn lua.LValue, args ...lua.LValue) {
+	failures := new(callbackFailures)
+	if len(args) > 0 {
+		if ud, ok := args[0].(*lua.LUserData); ok {
+			if w, ok := ud.Value.(*contextWrapper); ok {
+				w.failures = failures
+			}
+		}
+	}
+
+	if err := s.luaState.CallByParam(lua.P{
+		Fn:      fn,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Domain))
+	}, args...); err != nil {
+		s.sys.Config().Log.Printf("%s: %s callback: %v", s.String(), name, err)
+	}
+
+	err := failures.err()
+	if err == nil || contextExpired(ctx) {
+		return
+	}
+
+	d, err := systems.NewDeadLetter(s.String(), req, err, 1)
+	if err == nil {
+		err = s.sys.DeadLetters().Add(d)
+	}
 	if err != nil {
-		s.sys.Config().Log.Printf("%s: horizontal callback: %v", s.String(), err)
+		s.sys.Config().Log.Printf("%s: failed to enter the %s request into the dead-letter queue: %v", s.String(), name, err)
 	}
 }
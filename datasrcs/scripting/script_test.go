@@ -5,6 +5,9 @@
 package scripting
 
 import (
+	"testing"
+	"time"
+
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/requests"
@@ -39,3 +42,85 @@ func newMockSystem(cfg *config.Config) systems.System {
 	_ = ss.Trusted.AddResolvers(20, "8.8.8.8")
 	return ss
 }
+
+func TestPageLimit(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="pages"
+		type="testing"
+
+		function vertical(ctx, domain)
+			for i=1,page_limit(20) do
+				new_name(ctx, "page" .. tostring(i) .. "." .. domain)
+			end
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.Config().Options["max_pages"] = 2
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	var count int
+	timer := time.NewTimer(3 * time.Second)
+	defer timer.Stop()
+loop:
+	for {
+		select {
+		case <-script.Output():
+			count++
+		case <-timer.C:
+			break loop
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("Expected the script to request 2 pages, but %d names were provided", count)
+	}
+}
+
+func TestAllCredentials(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="accounts"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local cfg = datasrc_config()
+			for _, c in pairs(cfg.all_credentials) do
+				new_name(ctx, c.key .. "." .. domain)
+			end
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	cfg := sys.Config()
+	cfg.DataSrcConfigs = &config.DataSourceConfig{
+		Datasources: []*config.DataSource{{
+			Name: "accounts",
+			Creds: map[string]*config.Credentials{
+				"second": {Name: "second", Apikey: "key2"},
+				"first":  {Name: "first", Apikey: "key1"},
+			},
+		}},
+	}
+	cfg.AddDomain("owasp.org")
+	script.Input() <- &requests.DNSRequest{Domain: "owasp.org"}
+
+	// The credentials of every account are provided in the order of the account names
+	for _, expected := range []string{"key1.owasp.org", "key2.owasp.org"} {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("The names were not sent")
+		case req := <-script.Output():
+			if d, ok := req.(*requests.DNSRequest); !ok || d.Name != expected {
+				t.Errorf("Expected %s, but received %v", expected, req)
+			}
+		}
+	}
+}
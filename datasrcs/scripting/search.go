@@ -0,0 +1,76 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/options"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Language codes, optionally followed by a country code, such as 'de' and 'de-DE'
+var searchRegionRegex = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_]([a-zA-Z]{2}))?$`)
+
+// Wrapper so that scripts can obtain the regions selected by the 'search_regions' option.
+// Each region is a table with the locale, language and country fields. A single empty
+// table is returned when no regions are selected, so the scripts perform their default queries.
+func (s *Script) searchRegions(L *lua.LState) int {
+	tb := L.NewTable()
+
+	for _, region := range options.Strings(s.sys.Config(), "search_regions") {
+		matches := searchRegionRegex.FindStringSubmatch(region)
+		if matches == nil {
+			s.sys.Config().Log.Printf("%s: The search region %s is not a language or language-country code", s.String(), region)
+			continue
+		}
+
+		r := L.NewTable()
+		lang := strings.ToLower(matches[1])
+		r.RawSetString("language", lua.LString(lang))
+		if country := strings.ToUpper(matches[2]); country != "" {
+			r.RawSetString("country", lua.LString(country))
+			r.RawSetString("locale", lua.LString(lang+"-"+country))
+		} else {
+			r.RawSetString("locale", lua.LString(lang))
+		}
+		tb.Append(r)
+	}
+
+	if tb.Len() == 0 {
+		tb.Append(L.NewTable())
+	}
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so that scripts can obtain the endpoints of the search engine. The endpoints
+// provided by the script are replaced by the entry for the data source in the 'search_endpoints' option.
+func (s *Script) searchEndpoints(L *lua.LState) int {
+	tb := L.NewTable()
+
+	for src, val := range options.Table(s.sys.Config(), "search_endpoints") {
+		if !strings.EqualFold(src, s.String()) {
+			continue
+		}
+
+		for _, endpoint := range options.StringsValue(val) {
+			tb.Append(lua.LString(endpoint))
+		}
+	}
+
+	if tb.Len() == 0 {
+		if defaults := L.OptTable(1, nil); defaults != nil {
+			defaults.ForEach(func(_, v lua.LValue) {
+				if str, ok := v.(lua.LString); ok && str != "" {
+					tb.Append(str)
+				}
+			})
+		}
+	}
+	L.Push(tb)
+	return 1
+}
@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestSearchRegionsAndEndpoints(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="SearchTest"
+		type="testing"
+
+		function vertical(ctx, domain)
+			for _, region in ipairs(search_regions()) do
+				local label = "default"
+				if region.locale ~= nil then
+					label = string.lower(region.locale)
+				end
+				new_name(ctx, label .. "." .. domain)
+			end
+
+			for _, endpoint in ipairs(search_endpoints({"https://www." .. domain .. "/search"})) do
+				local host = string.match(endpoint, "//([^/]+)")
+				new_name(ctx, host)
+			end
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	cfg := sys.Config()
+	cfg.AddDomain(domain)
+
+	tests := []struct {
+		options  map[string]interface{}
+		expected []string
+	}{
+		{
+			options:  map[string]interface{}{},
+			expected: []string{"default.owasp.org", "www.owasp.org"},
+		},
+		{
+			options: map[string]interface{}{
+				"search_regions": []interface{}{"de_de", "ja", "not a region"},
+				"search_endpoints": map[string]interface{}{
+					"searchtest": []interface{}{"https://edition1.owasp.org/s", "https://edition2.owasp.org/s"},
+					"Other":      "https://other.owasp.org/s",
+				},
+			},
+			expected: []string{"de-de.owasp.org", "edition1.owasp.org", "edition2.owasp.org", "ja.owasp.org"},
+		},
+	}
+
+	for _, test := range tests {
+		cfg.Options = test.options
+		script.Input() <- &requests.DNSRequest{Domain: domain}
+
+		var names []string
+		timer := time.NewTimer(2 * time.Second)
+	loop:
+		for {
+			select {
+			case out := <-script.Output():
+				if req, ok := out.(*requests.DNSRequest); ok {
+					names = append(names, req.Name)
+				}
+			case <-timer.C:
+				break loop
+			}
+		}
+		timer.Stop()
+
+		sort.Strings(names)
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Expected the names %v, but got %v", test.expected, names)
+		}
+	}
+}
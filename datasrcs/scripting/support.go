@@ -9,12 +9,52 @@ import (
 	"errors"
 	"os"
 	"regexp"
+	"sync"
 
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	lua "github.com/yuin/gopher-lua"
 )
 
 type contextWrapper struct {
 	Ctx context.Context
+	// failures is set for the context provided to the callbacks handling requests
+	failures *callbackFailures
+}
+
+// callbackFailures holds the first failure signaled while a callback handled its request.
+type callbackFailures struct {
+	sync.Mutex
+	first error
+}
+
+func (cf *callbackFailures) fail(err error) {
+	if cf == nil || err == nil {
+		return
+	}
+
+	cf.Lock()
+	defer cf.Unlock()
+
+	if cf.first == nil {
+		cf.first = err
+	}
+}
+
+func (cf *callbackFailures) err() error {
+	cf.Lock()
+	defer cf.Unlock()
+
+	return cf.first
+}
+
+// failRequest flags the request handled by the callback, which provided the context, as failed.
+func failRequest(udata *lua.LUserData, err error) {
+	if udata == nil {
+		return
+	}
+	if w, ok := udata.Value.(*contextWrapper); ok {
+		w.failures.fail(err)
+	}
 }
 
 // Converts Go Context to Lua UserData.
@@ -72,6 +112,32 @@ func (s *Script) log(L *lua.LState) int {
 	return 0
 }
 
+// Wrapper so that scripts can report the failures they detect, such as responses that cannot be parsed.
+func (s *Script) reportError(L *lua.LState) int {
+	if _, err := extractContext(L.CheckUserData(1)); err != nil {
+		return 0
+	}
+
+	kind := amassnet.ErrorKind(L.CheckString(2))
+	switch kind {
+	case amassnet.ErrAuth, amassnet.ErrQuota, amassnet.ErrParse, amassnet.ErrTimeout, amassnet.ErrOutOfScope, amassnet.ErrNetwork:
+	default:
+		L.ArgError(2, "the error kind is not known")
+		return 0
+	}
+
+	var cause error
+	if msg := L.OptString(3, ""); msg != "" {
+		cause = errors.New(msg)
+	}
+
+	se := amassnet.NewSourceError(s.String(), kind, cause)
+	amassnet.RecordSourceError(se)
+	s.sys.Config().Log.Print(se.Error())
+	failRequest(L.CheckUserData(1), se)
+	return 0
+}
+
 // Wrapper that exposes a simple regular expression matching function.
 func (s *Script) find(L *lua.LState) int {
 	tb := L.NewTable()
@@ -5,7 +5,9 @@
 package datasrcs
 
 import (
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/caffix/service"
 	"github.com/caffix/stringset"
@@ -14,17 +16,41 @@ import (
 	"github.com/owasp-amass/config/config"
 )
 
+var scriptNameRE = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+
 // GetAllSources returns a slice of all data source services initialized.
 func GetAllSources(sys systems.System) []service.Service {
 	var srvs []service.Service
 
-	if scripts, err := sys.Config().AcquireScripts(); err == nil {
+	descs := acquireDescriptors(sys.Config())
+	if scripts, err := acquireScripts(sys.Config()); err == nil {
+		allowSourceEgress(sys.Config(), append(scripts, descs...))
+		scripts = overrideScripts(sys.Config(), scripts)
 		for _, script := range scripts {
-			if s := scripting.NewScript(script, sys); s != nil {
-				srvs = append(srvs, s)
+			s := scripting.NewScript(script, sys)
+			if s == nil {
+				continue
+			}
+			// Data sources can be configured to handle requests with several script instances
+			if n := scripting.MaxInstances(sys.Config(), s.String()); n > 1 {
+				srvs = append(srvs, scripting.NewScriptPool(script, s, sys, n))
+				continue
 			}
+			srvs = append(srvs, s)
 		}
 	}
+	// The simple REST APIs declared by YAML descriptors are queried without writing scripts
+	srvs = append(srvs, NewGenericSources(sys, descs, srvs)...)
+	// The rules declared in the configuration are applied by a built-in data source
+	if rules := NewRules(sys); rules != nil {
+		srvs = append(srvs, rules)
+	}
+	// The plugins in the configuration are executed as separate processes
+	srvs = append(srvs, NewPlugins(sys, srvs)...)
+	// The certificate transparency logs in the configuration are followed while the enumeration runs
+	if stream := NewCTStream(sys); stream != nil {
+		srvs = append(srvs, stream)
+	}
 
 	sort.Slice(srvs, func(i, j int) bool {
 		return srvs[i].String() < srvs[j].String()
@@ -32,6 +58,27 @@ func GetAllSources(sys systems.System) []service.Service {
 	return srvs
 }
 
+// overrideScripts removes the scripts replaced by a following script with the same name. The external scripts
+// follow the scripts embedded in the binary, so a data source can be hotfixed without waiting for a release.
+func overrideScripts(cfg *config.Config, scripts []string) []string {
+	last := make(map[string]int, len(scripts))
+	for i, script := range scripts {
+		if m := scriptNameRE.FindStringSubmatch(script); m != nil {
+			last[strings.ToLower(m[1])] = i
+		}
+	}
+
+	var results []string
+	for i, script := range scripts {
+		if m := scriptNameRE.FindStringSubmatch(script); m != nil && last[strings.ToLower(m[1])] != i {
+			cfg.Log.Printf("Script: the %s script was replaced by an external script with the same name", m[1])
+			continue
+		}
+		results = append(results, script)
+	}
+	return results
+}
+
 // SelectedDataSources uses the config and available data sources to return the selected data sources.
 func SelectedDataSources(cfg *config.Config, avail []service.Service) []service.Service {
 	specified := stringset.New()
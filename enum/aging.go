@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+const (
+	// AgingFile is the name of the file in the output directory that counts the enumerations
+	// where each of the names resolving previously did not resolve.
+	AgingFile = "aging.json"
+	// AgingSource is the source of the findings produced by the asset aging analysis.
+	AgingSource = "AssetAging"
+	// RelationGoneDark is the relation of the findings for names that stopped resolving.
+	RelationGoneDark = "gone_dark"
+
+	defaultStaleAfter = 3
+)
+
+// agingRecord holds the last time a name resolved and the number of consecutive enumerations where it did not.
+type agingRecord struct {
+	Domain       string    `json:"domain"`
+	LastResolved time.Time `json:"last_resolved"`
+	MissedRuns   int       `json:"missed_runs"`
+}
+
+// AgingPath returns the path of the aging file in the output directory of the configuration.
+func AgingPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), AgingFile)
+}
+
+// analyzeAging counts the consecutive enumerations where each in-scope name that resolved previously
+// did not resolve, and keeps the names missed by at least the 'stale_after' option as Stale findings,
+// so the inventories built from the graph database can leave out the names that went dark.
+// Enumerations that resolved none of the names are not counted, since the resolvers were likely unreachable.
+func (e *Enumeration) analyzeAging(ctx context.Context) {
+	after := staleAfter(e.Config)
+	if after <= 0 {
+		return
+	}
+
+	path := AgingPath(e.Config)
+	previous, err := loadAging(path)
+	if err != nil {
+		e.Config.Log.Printf("Failed to read the aging file %s: %v", path, err)
+		return
+	}
+
+	start := collectionStart(e.Config)
+
+	var resolved int
+	records := make(map[string]*agingRecord)
+	for _, name := range e.namesKnown() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		last, found := e.lastResolved(name)
+		if !found {
+			continue
+		}
+
+		rec := &agingRecord{
+			Domain:       e.Config.WhichDomain(name),
+			LastResolved: last.UTC(),
+		}
+		if !last.Before(start) {
+			resolved++
+		} else if prev, ok := previous[name]; ok {
+			rec.MissedRuns = prev.MissedRuns + 1
+		} else {
+			rec.MissedRuns = 1
+		}
+		records[name] = rec
+	}
+	if resolved == 0 {
+		return
+	}
+
+	if err := saveAging(path, records); err != nil {
+		e.Config.Log.Printf("Failed to write the aging file %s: %v", path, err)
+	}
+	for name, rec := range records {
+		if rec.MissedRuns >= after {
+			e.addStaleFinding(name, rec)
+		}
+	}
+}
+
+// namesKnown returns the in-scope names stored in the graph database by this and the previous enumerations.
+func (e *Enumeration) namesKnown() []string {
+	var fqdns []oam.Asset
+	for _, d := range e.Config.Domains() {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+	if len(fqdns) == 0 {
+		return nil
+	}
+
+	assets, err := e.graph.DB.FindByScope(fqdns, time.Time{})
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.WhichDomain(fqdn.Name) != "" {
+			names = append(names, fqdn.Name)
+		}
+	}
+	return names
+}
+
+// lastResolved returns the last time the A, AAAA or CNAME records of the name were seen,
+// and false when the name never resolved.
+func (e *Enumeration) lastResolved(name string) (time.Time, bool) {
+	var last time.Time
+
+	assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		return last, false
+	}
+
+	rels, err := e.graph.DB.OutgoingRelations(assets[0], time.Time{}, "a_record", "aaaa_record", "cname_record")
+	if err != nil || len(rels) == 0 {
+		return last, false
+	}
+
+	for _, rel := range rels {
+		if rel.LastSeen.After(last) {
+			last = rel.LastSeen
+		}
+	}
+	return last, true
+}
+
+func (e *Enumeration) addStaleFinding(name string, rec *agingRecord) {
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     "Stale",
+		Value:    name,
+		Domain:   rec.Domain,
+		Relation: RelationGoneDark,
+		Source:   AgingSource,
+		Properties: map[string]string{
+			"last_resolved": rec.LastResolved.Format(time.RFC3339),
+			"missed_runs":   strconv.Itoa(rec.MissedRuns),
+			"severity":      "info",
+		},
+	})
+}
+
+func staleAfter(cfg *config.Config) int {
+	if _, found := cfg.Options["stale_after"]; !found {
+		return defaultStaleAfter
+	}
+	return options.Int(cfg, "stale_after")
+}
+
+func loadAging(path string) (map[string]*agingRecord, error) {
+	records := make(map[string]*agingRecord)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveAging(path string, records map[string]*agingRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
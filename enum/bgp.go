@@ -0,0 +1,66 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	// BGPSource is the source of the findings comparing the prefixes announced by the ASNs in scope between runs.
+	BGPSource = "BGPFootprint"
+	// RelationBGPFootprint is the relation of the prefixes announced or withdrawn since the previous enumerations.
+	RelationBGPFootprint = "bgp_footprint"
+)
+
+// analyzePrefixChanges compares the prefixes announced by the ASNs in scope during the current enumeration
+// with the prefixes seen by previous enumerations, and keeps the prefixes announced or withdrawn since
+// as PrefixChange findings. The ASNs without prefixes from both periods are skipped, since the first
+// enumeration of an ASN, or one that failed to obtain its prefixes, would report every prefix.
+func (e *Enumeration) analyzePrefixChanges(ctx context.Context) {
+	start := collectionStart(e.Config)
+	earlier := systems.TimeWindow{End: start.Add(-time.Second)}
+	later := systems.TimeWindow{Start: start}
+
+	for _, asn := range e.Config.Scope.ASNs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		changes, err := systems.ReadASPrefixChanges(ctx, e.graph, asn, earlier, later)
+		if err != nil {
+			e.Config.Log.Printf("Failed to compare the prefixes announced by AS%d: %v", asn, err)
+			continue
+		}
+		if len(changes.Announced)+len(changes.Unchanged) == 0 || len(changes.Withdrawn)+len(changes.Unchanged) == 0 {
+			continue
+		}
+
+		for _, prefix := range changes.Announced {
+			e.addPrefixChangeFinding(asn, prefix, "announced")
+		}
+		for _, prefix := range changes.Withdrawn {
+			e.addPrefixChangeFinding(asn, prefix, "withdrawn")
+		}
+	}
+}
+
+func (e *Enumeration) addPrefixChangeFinding(asn int, prefix, change string) {
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     "PrefixChange",
+		Value:    prefix,
+		Relation: RelationBGPFootprint,
+		Source:   BGPSource,
+		Properties: map[string]string{
+			"asn":      strconv.Itoa(asn),
+			"change":   change,
+			"severity": "info",
+		},
+	})
+}
@@ -0,0 +1,204 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/owasp-amass/amass/v4/analytics"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const (
+	// CentralitySource is the source of the findings produced by the graph analytics.
+	CentralitySource = "Centrality"
+	// RelationCriticalInfrastructure is the relation of the findings for the infrastructure most assets depend on.
+	RelationCriticalInfrastructure = "critical_infrastructure"
+	// The number of infrastructure assets reported when the 'critical_infrastructure_top' option is not provided
+	defaultCriticalTop = 10
+	// The longest chain of relations followed from the names in scope, such as alias, address, netblock and AS
+	maxCentralityDepth = 6
+)
+
+// criticalKinds are the kinds of graph nodes reported as infrastructure, as opposed to the names in scope.
+var criticalKinds = []string{"NameServer", "MailServer", "Alias", "IPAddress", "Netblock", "ASN"}
+
+// analyzeCentrality builds a graph of the names in scope discovered by the enumeration and the infrastructure
+// they rely on, and computes the degree, betweenness centrality and articulation points of the graph. The
+// infrastructure with the most names depending on it, such as the single name server or netblock of many
+// names, is kept in the findings, limited to the 'critical_infrastructure_top' option, along with its rank.
+func (e *Enumeration) analyzeCentrality(ctx context.Context) {
+	names := e.namesDiscovered()
+	if len(names) == 0 {
+		return
+	}
+
+	g := e.infrastructureGraph(ctx, names)
+	if g.Len() == 0 {
+		return
+	}
+	g.Analyze()
+
+	top := defaultCriticalTop
+	if n := options.Int(e.Config, "critical_infrastructure_top"); n > 0 {
+		top = n
+	}
+
+	var rank int
+	for _, n := range g.Ranked(criticalKinds...) {
+		if rank >= top {
+			break
+		}
+		// Infrastructure used by a single name is not critical to the others
+		if n.Dependents == 0 && n.Betweenness == 0 {
+			continue
+		}
+
+		rank++
+		e.Sys.Findings().Add(&systems.Finding{
+			Type:     "Infrastructure",
+			Value:    n.ID,
+			Relation: RelationCriticalInfrastructure,
+			Source:   CentralitySource,
+			Properties: map[string]string{
+				"kind":         n.Kind,
+				"rank":         strconv.Itoa(rank),
+				"degree":       strconv.Itoa(n.Degree),
+				"betweenness":  strconv.FormatFloat(n.Betweenness, 'f', 4, 64),
+				"articulation": strconv.FormatBool(n.Articulation),
+				"dependents":   strconv.Itoa(n.Dependents),
+			},
+		})
+	}
+}
+
+// infrastructureGraph follows the relations of the current enumeration from the names in scope to the aliases,
+// name servers, mail servers and addresses, and from the addresses to the netblocks and autonomous systems.
+func (e *Enumeration) infrastructureGraph(ctx context.Context, names []string) *analytics.Graph {
+	since := collectionStart(e.Config)
+	g := analytics.NewGraph()
+
+	// Only a sample of the relations of the high-degree assets, such as popular name servers, is followed
+	threshold, sample := systems.RollupSettings(e.Config)
+	rollups, err := systems.NewRollupStore(systems.RollupPath(e.Config), threshold, sample)
+	if err != nil {
+		e.Config.Log.Printf("Failed to read the relation rollups: %v", err)
+	}
+	defer func() {
+		if err := rollups.Save(); err != nil {
+			e.Config.Log.Printf("Failed to save the relation rollups: %v", err)
+		}
+	}()
+
+	seen := make(map[string]struct{})
+	var queue []*types.Asset
+	for _, name := range names {
+		assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, since)
+		if err != nil || len(assets) == 0 {
+			continue
+		}
+
+		g.AddNode(name, "FQDN", 1)
+		if _, found := seen[assets[0].ID]; !found {
+			seen[assets[0].ID] = struct{}{}
+			queue = append(queue, assets[0])
+		}
+	}
+
+	for depth := 0; depth < maxCentralityDepth && len(queue) > 0; depth++ {
+		var next []*types.Asset
+
+		for _, a := range queue {
+			select {
+			case <-ctx.Done():
+				return g
+			default:
+			}
+
+			from := centralityLabel(a.Asset)
+			var rels []*types.Relation
+			if out, _, err := rollups.Relations(e.graph, a, since, false); err == nil {
+				rels = append(rels, out...)
+			}
+			if in, _, err := rollups.Relations(e.graph, a, since, true, "contains", "announces"); err == nil {
+				rels = append(rels, in...)
+			}
+
+			for _, rel := range rels {
+				id := rel.ToAsset.ID
+				if id == a.ID {
+					id = rel.FromAsset.ID
+				}
+
+				target, err := e.graph.DB.FindById(id, since)
+				if err != nil || target == nil {
+					continue
+				}
+
+				to := centralityLabel(target.Asset)
+				if to == "" || from == "" {
+					continue
+				}
+
+				n := g.AddNode(to, "", 0)
+				if kind := e.centralityKind(target.Asset, rel.Type); n.Kind == "" || kind != "FQDN" {
+					n.Kind = kind
+				}
+				g.AddEdge(from, to)
+
+				if _, found := seen[target.ID]; !found {
+					seen[target.ID] = struct{}{}
+					next = append(next, target)
+				}
+			}
+		}
+		queue = next
+	}
+	return g
+}
+
+// centralityKind returns the role of the asset reached by the relation.
+func (e *Enumeration) centralityKind(asset oam.Asset, relation string) string {
+	switch v := asset.(type) {
+	case domain.FQDN:
+		switch relation {
+		case "ns_record":
+			return "NameServer"
+		case "mx_record":
+			return "MailServer"
+		}
+		if e.Config.WhichDomain(v.Name) == "" {
+			return "Alias"
+		}
+		return "FQDN"
+	case network.IPAddress:
+		return "IPAddress"
+	case network.Netblock:
+		return "Netblock"
+	case network.AutonomousSystem:
+		return "ASN"
+	}
+	return ""
+}
+
+func centralityLabel(asset oam.Asset) string {
+	switch v := asset.(type) {
+	case domain.FQDN:
+		return v.Name
+	case network.IPAddress:
+		return v.Address.String()
+	case network.Netblock:
+		return v.Cidr.String()
+	case network.AutonomousSystem:
+		return "AS" + strconv.Itoa(v.Number)
+	}
+	return ""
+}
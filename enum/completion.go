@@ -0,0 +1,218 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// CompletionFile is the name of the file in the output directory that records why the last enumeration ended.
+const CompletionFile = "completion.json"
+
+// CompletionReason identifies the criteria that ended an enumeration.
+type CompletionReason string
+
+// The reasons an enumeration ends. The enumerations that completed or converged have finished,
+// while the others gave up before the planned work was done.
+const (
+	// ReasonCompleted means all the planned work was done
+	ReasonCompleted CompletionReason = "completed"
+	// ReasonConverged means no new assets in scope were discovered during the 'idle_minutes' option
+	ReasonConverged CompletionReason = "converged"
+	// ReasonBudget means the 'max_assets' option was reached
+	ReasonBudget CompletionReason = "budget_exhausted"
+	// ReasonTimeout means the time allowed for the enumeration elapsed
+	ReasonTimeout CompletionReason = "timeout"
+	// ReasonInterrupted means the enumeration was cancelled, such as by the user
+	ReasonInterrupted CompletionReason = "interrupted"
+)
+
+// Finished returns true when the enumeration ended without giving up.
+func (r CompletionReason) Finished() bool {
+	return r == ReasonCompleted || r == ReasonConverged
+}
+
+// Completion is the session metadata recording why an enumeration ended.
+type Completion struct {
+	Reason   CompletionReason `json:"reason"`
+	Finished bool             `json:"finished"`
+	Detail   string           `json:"detail,omitempty"`
+	Assets   int              `json:"assets"`
+	Started  time.Time        `json:"started"`
+	Ended    time.Time        `json:"ended"`
+	// LastAsset is the time the last new asset in scope was discovered
+	LastAsset time.Time `json:"last_asset"`
+}
+
+// CompletionPath returns the path of the completion file in the output directory of the configuration.
+func CompletionPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), CompletionFile)
+}
+
+// LoadCompletion returns the completion of the enumeration held by the file at the provided path,
+// or nil when the file does not exist.
+func LoadCompletion(path string) (*Completion, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var c Completion
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// completionMonitor tracks the discovery of new assets in scope and ends the
+// enumeration once the 'idle_minutes' or 'max_assets' criteria are met.
+type completionMonitor struct {
+	sync.Mutex
+	enum    *Enumeration
+	idle    time.Duration
+	max     int
+	assets  int
+	started time.Time
+	last    time.Time
+	ended   time.Time
+	reason  CompletionReason
+	detail  string
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newCompletionMonitor(e *Enumeration) *completionMonitor {
+	now := time.Now()
+
+	return &completionMonitor{
+		enum:    e,
+		idle:    time.Duration(options.Int(e.Config, "idle_minutes")) * time.Minute,
+		max:     options.Int(e.Config, "max_assets"),
+		started: now,
+		last:    now,
+		done:    make(chan struct{}),
+	}
+}
+
+// start begins checking for convergence once the pipeline input source is in place.
+func (c *completionMonitor) start() {
+	if c.idle > 0 {
+		go c.monitor()
+	}
+}
+
+func (c *completionMonitor) stop() {
+	c.once.Do(func() { close(c.done) })
+}
+
+func (c *completionMonitor) monitor() {
+	t := time.NewTicker(waitForDuration)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+		}
+
+		c.Lock()
+		idle := time.Since(c.last)
+		c.Unlock()
+
+		if idle >= c.idle {
+			c.end(ReasonConverged, "no new assets in scope were discovered for "+c.idle.String())
+			return
+		}
+	}
+}
+
+// discovered records a new asset in scope stored by the enumeration.
+func (c *completionMonitor) discovered() {
+	c.Lock()
+	c.assets++
+	c.last = time.Now()
+	exhausted := c.max > 0 && c.assets >= c.max
+	c.Unlock()
+
+	if exhausted {
+		c.end(ReasonBudget, "the limit of "+strconv.Itoa(c.max)+" new assets in scope was reached")
+	}
+}
+
+// end records the first reason provided and stops the release of names into the pipeline,
+// so the work in progress is still stored and analyzed.
+func (c *completionMonitor) end(reason CompletionReason, detail string) {
+	if c.record(reason, detail) {
+		c.enum.Config.Log.Printf("Ending the enumeration: %s", detail)
+		c.enum.nameSrc.markDone()
+	}
+}
+
+// record keeps the reason when the enumeration has not ended already.
+func (c *completionMonitor) record(reason CompletionReason, detail string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.reason != "" {
+		return false
+	}
+	c.reason = reason
+	c.detail = detail
+	return true
+}
+
+// finish records the reason of the enumerations that ended without meeting the criteria of the configuration.
+func (c *completionMonitor) finish(ctx context.Context) {
+	c.stop()
+
+	switch ctx.Err() {
+	case nil:
+		c.record(ReasonCompleted, "all the planned work was done")
+	case context.DeadlineExceeded:
+		c.record(ReasonTimeout, "the time allowed for the enumeration elapsed")
+	default:
+		c.record(ReasonInterrupted, "the enumeration was cancelled")
+	}
+
+	c.Lock()
+	c.ended = time.Now()
+	c.Unlock()
+}
+
+func (c *completionMonitor) completion() *Completion {
+	c.Lock()
+	defer c.Unlock()
+
+	return &Completion{
+		Reason:    c.reason,
+		Finished:  c.reason.Finished(),
+		Detail:    c.detail,
+		Assets:    c.assets,
+		Started:   c.started,
+		Ended:     c.ended,
+		LastAsset: c.last,
+	}
+}
+
+func (c *completionMonitor) save(path string) error {
+	data, err := json.MarshalIndent(c.completion(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
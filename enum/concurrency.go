@@ -0,0 +1,40 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"github.com/caffix/pipeline"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+const defaultPipelineBuffer = 50
+
+// newStage returns a pipeline stage executing the task with the number of workers provided
+// for the stage by the 'stage_workers' option, or a single worker preserving the data order.
+func (e *Enumeration) newStage(id, name string, task pipeline.Task) pipeline.Stage {
+	if n := stageWorkers(e.Config, name); n > 1 {
+		return pipeline.DynamicPool(id, task, n)
+	}
+	return pipeline.FIFO(id, task)
+}
+
+// stageWorkers returns the number of workers for the pipeline stage. The 'stage_workers'
+// option is either a number used for all the stages, or a table of stage names and numbers.
+func stageWorkers(cfg *config.Config, stage string) int {
+	if m := options.Table(cfg, "stage_workers"); m != nil {
+		return options.IntValue(m[stage])
+	}
+	return options.Int(cfg, "stage_workers")
+}
+
+// pipelineBuffer returns the 'pipeline_buffer' option, the number of names and addresses
+// the input source can have in the pipeline before waiting on the stages.
+func pipelineBuffer(cfg *config.Config) int {
+	if n := options.Int(cfg, "pipeline_buffer"); n > 0 {
+		return n
+	}
+	return defaultPipelineBuffer
+}
@@ -0,0 +1,224 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// CoverageFile is the name of the file in the output directory that records which data
+// sources contributed each of the assets discovered by the enumerations.
+const CoverageFile = "coverage.json"
+
+// CoverageRecord identifies an asset provided by a data source.
+type CoverageRecord struct {
+	Asset     string    `json:"asset"`
+	Type      string    `json:"type"`
+	Domain    string    `json:"domain,omitempty"`
+	Source    string    `json:"source"`
+	Technique string    `json:"technique"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+func (c *CoverageRecord) key() string {
+	return c.Source + "|" + c.Type + "|" + c.Asset
+}
+
+// CoveragePath returns the path of the coverage file in the output directory of the configuration.
+func CoveragePath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), CoverageFile)
+}
+
+// LoadCoverage returns the records held by the coverage file at the provided path.
+func LoadCoverage(path string) ([]*CoverageRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*CoverageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var c CoverageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &c); err == nil {
+			records = append(records, &c)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// maxCoverageClaims bounds the assets provided by the data sources that are waiting to be stored,
+// since the names that never resolve are not confirmed.
+const maxCoverageClaims = 100000
+
+// coverageRecorder collects the in-scope assets provided by each data source, including
+// the assets already provided by other sources, so the overlap between sources is known.
+// The assets provided are claims until the enumeration stores them, after the names have been
+// resolved and checked, so only the assets kept in the results are recorded.
+type coverageRecorder struct {
+	sync.Mutex
+	records map[string]*CoverageRecord
+	claims  map[string][]*CoverageRecord
+	stored  map[string]struct{}
+}
+
+func newCoverageRecorder() *coverageRecorder {
+	return &coverageRecorder{
+		records: make(map[string]*CoverageRecord),
+		claims:  make(map[string][]*CoverageRecord),
+		stored:  make(map[string]struct{}),
+	}
+}
+
+// claim notes the asset provided by the data source, which is recorded once the asset is stored.
+func (c *coverageRecorder) claim(e *Enumeration, srv service.Service, data interface{}) {
+	rec := &CoverageRecord{
+		Source:    srv.String(),
+		Technique: srv.Description(),
+		FirstSeen: time.Now(),
+	}
+
+	switch v := data.(type) {
+	case *requests.DNSRequest:
+		name := strings.ToLower(strings.TrimSpace(v.Name))
+		domain := e.Config.WhichDomain(name)
+		if name == "" || domain == "" || e.Config.Blacklisted(name) {
+			return
+		}
+
+		rec.Asset = name
+		rec.Type = "fqdn"
+		rec.Domain = domain
+	case *requests.AddrRequest:
+		if v.Address == "" || !v.InScope {
+			return
+		}
+
+		rec.Asset = v.Address
+		rec.Type = "address"
+		rec.Domain = v.Domain
+	default:
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	asset := rec.Type + "|" + rec.Asset
+	if _, found := c.stored[asset]; found {
+		c.add(rec)
+		return
+	}
+	for _, cl := range c.claims[asset] {
+		if cl.Source == rec.Source {
+			return
+		}
+	}
+	if _, found := c.claims[asset]; found || len(c.claims) < maxCoverageClaims {
+		c.claims[asset] = append(c.claims[asset], rec)
+	}
+}
+
+// confirm records the data sources that provided the asset stored by the enumeration.
+func (c *coverageRecorder) confirm(atype, asset string) {
+	asset = atype + "|" + strings.ToLower(strings.TrimSpace(asset))
+
+	c.Lock()
+	defer c.Unlock()
+
+	if _, found := c.stored[asset]; found {
+		return
+	}
+	c.stored[asset] = struct{}{}
+
+	for _, rec := range c.claims[asset] {
+		c.add(rec)
+	}
+	delete(c.claims, asset)
+}
+
+func (c *coverageRecorder) add(rec *CoverageRecord) {
+	if _, found := c.records[rec.key()]; !found {
+		c.records[rec.key()] = rec
+	}
+}
+
+// save merges the records collected during this enumeration into the coverage file, sorted by source, type and asset.
+func (c *coverageRecorder) save(path string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.records) == 0 {
+		return nil
+	}
+
+	existing, err := LoadCoverage(path)
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]*CoverageRecord, len(existing)+len(c.records))
+	var order []string
+	for _, rec := range existing {
+		if _, found := merged[rec.key()]; !found {
+			order = append(order, rec.key())
+		}
+		merged[rec.key()] = rec
+	}
+	for k, rec := range c.records {
+		if _, found := merged[k]; !found {
+			merged[k] = rec
+			order = append(order, k)
+		}
+	}
+	// The records are always written in the same order, so the coverage files can be compared
+	sort.Strings(order)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), CoverageFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, k := range order {
+		if err := enc.Encode(merged[k]); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
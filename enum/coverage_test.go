@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+type coverageSource struct {
+	service.BaseService
+}
+
+func newCoverageSource(name string) *coverageSource {
+	s := new(coverageSource)
+	s.BaseService = *service.NewBaseService(s, name)
+	return s
+}
+
+func (s *coverageSource) Description() string { return "testing" }
+
+func TestCoverageRecorder(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	c := newCoverageRecorder()
+	first := newCoverageSource("First")
+	second := newCoverageSource("Second")
+
+	c.claim(e, first, &requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"})
+	c.claim(e, first, &requests.DNSRequest{Name: "missing.owasp.org", Domain: "owasp.org"})
+	c.claim(e, first, &requests.DNSRequest{Name: "www.example.com", Domain: "example.com"})
+	if len(c.records) != 0 {
+		t.Errorf("Expected the assets not stored to be left out, got %d records", len(c.records))
+	}
+
+	c.confirm("fqdn", "www.owasp.org")
+	// The asset provided by another data source after being stored is recorded immediately
+	c.claim(e, second, &requests.DNSRequest{Name: "WWW.owasp.org", Domain: "owasp.org"})
+	if len(c.records) != 2 {
+		t.Errorf("Expected the stored asset to be recorded for both data sources, got %d records", len(c.records))
+	}
+	if _, found := c.claims["fqdn|missing.owasp.org"]; !found {
+		t.Errorf("Expected the asset not resolved to remain a claim")
+	}
+}
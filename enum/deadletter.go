@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// replayDeadLetters sends the requests in the dead-letter queue that are within the scope of
+// the enumeration back to the data sources that failed to handle them. The requests are removed
+// from the queue, and will be entered again if the data sources continue to fail.
+func (e *Enumeration) replayDeadLetters() {
+	dlq := e.Sys.DeadLetters()
+
+	letters, err := dlq.List()
+	if err != nil {
+		e.Config.Log.Printf("Failed to read the dead-letter queue: %v", err)
+		return
+	}
+
+	srcs := make(map[string]service.Service, len(e.srcs))
+	for _, src := range e.srcs {
+		srcs[src.String()] = src
+	}
+
+	for _, d := range letters {
+		src, found := srcs[d.Source]
+		if !found {
+			continue
+		}
+
+		req, err := d.Req()
+		if err != nil || !e.deadLetterInScope(req) || !src.HandlesReq(req) {
+			continue
+		}
+		if err := dlq.Remove(d.ID); err != nil {
+			e.Config.Log.Printf("Failed to remove %s from the dead-letter queue: %v", d.ID, err)
+			continue
+		}
+
+		e.Config.Log.Printf("Replaying the %s request for %s that %s failed to handle", d.Kind, d.Target(), d.Source)
+		select {
+		case <-e.done:
+			return
+		case <-e.ctx.Done():
+			return
+		case <-src.Done():
+		case src.Input() <- req:
+		}
+	}
+}
+
+func (e *Enumeration) deadLetterInScope(req interface{}) bool {
+	switch v := req.(type) {
+	case *requests.DNSRequest:
+		return e.Config.IsDomainInScope(v.Domain)
+	case *requests.ResolvedRequest:
+		return e.Config.IsDomainInScope(v.Name)
+	case *requests.SubdomainRequest:
+		return e.Config.IsDomainInScope(v.Name)
+	case *requests.WhoisRequest:
+		return e.Config.IsDomainInScope(v.Domain)
+	case *requests.EmailRequest:
+		return e.Config.IsDomainInScope(v.Domain)
+	case *requests.AccountRequest:
+		return e.Config.IsDomainInScope(v.Domain)
+	case *requests.AddrRequest:
+		return e.Config.IsAddressInScope(v.Address)
+	case *requests.ASNRequest:
+		return true
+	}
+	return false
+}
+
+// replayDeadLettersEnabled checks the 'replay_dead_letters' option of the configuration.
+func replayDeadLettersEnabled(e *Enumeration) bool {
+	return options.Bool(e.Config, "replay_dead_letters")
+}
@@ -0,0 +1,193 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	dedupPruneInterval = time.Minute
+	// dedupMaxEntries is the number of requests tracked at most, beyond which the oldest are forgotten
+	dedupMaxEntries = 500000
+	// The freshness window of the global markers when the 'dedup_ttl' option covers the entire enumeration
+	defaultGlobalDedupTTL = 24 * time.Hour
+	// markerPruneInterval is the time between the removals of the expired global markers
+	markerPruneInterval = time.Hour
+)
+
+// requestDedup keeps repeated requests for the same asset from being sent to the data sources
+// more than once per freshness window. A zero TTL keeps the entries for the entire enumeration,
+// or until the number of entries reaches the maximum. The entries are kept in two generations,
+// and the older generation is dropped when the current one fills up, so the oldest requests
+// are forgotten first.
+// With the global scope, the markers shared by the sessions also keep the concurrent sessions
+// and replicas from sending the requests already sent by another session.
+type requestDedup struct {
+	sync.Mutex
+	ttl          time.Duration
+	max          int
+	seen         map[string]time.Time
+	older        map[string]time.Time
+	suppressed   int
+	lastPrune    time.Time
+	markers      *systems.MarkerStore
+	markerTTL    time.Duration
+	markerPruned time.Time
+}
+
+func newRequestDedup(ttl time.Duration, markers *systems.MarkerStore) *requestDedup {
+	markerTTL := ttl
+	if markerTTL == 0 {
+		markerTTL = defaultGlobalDedupTTL
+	}
+
+	return &requestDedup{
+		ttl:       ttl,
+		max:       dedupMaxEntries,
+		seen:      make(map[string]time.Time),
+		older:     make(map[string]time.Time),
+		lastPrune: time.Now(),
+		markers:   markers,
+		markerTTL: markerTTL,
+	}
+}
+
+// accept returns true when the request has not been seen within the freshness window.
+func (d *requestDedup) accept(req interface{}) bool {
+	key := dedupKey(req)
+	if key == "" {
+		return true
+	}
+
+	d.Lock()
+	now := time.Now()
+	if d.ttl > 0 && now.Sub(d.lastPrune) > dedupPruneInterval {
+		d.prune(now)
+	}
+
+	expires, found := d.seen[key]
+	if !found {
+		expires, found = d.older[key]
+	}
+	if found && (d.ttl == 0 || now.Before(expires)) {
+		d.suppressed++
+		d.Unlock()
+		return false
+	}
+
+	delete(d.older, key)
+	d.seen[key] = now.Add(d.ttl)
+	if len(d.seen) >= d.max/2 {
+		d.older = d.seen
+		d.seen = make(map[string]time.Time)
+	}
+
+	prune := d.markers != nil && now.Sub(d.markerPruned) > markerPruneInterval
+	if prune {
+		d.markerPruned = now
+	}
+	d.Unlock()
+
+	if d.markers == nil {
+		return true
+	}
+	if prune {
+		go d.markers.Prune(d.markerTTL)
+	}
+	// The request is sent when the marker cannot be set, rather than lost
+	if marked, err := d.markers.Mark(key, d.markerTTL); err == nil && !marked {
+		d.Lock()
+		d.suppressed++
+		d.Unlock()
+		return false
+	}
+	return true
+}
+
+func (d *requestDedup) prune(now time.Time) {
+	for _, m := range []map[string]time.Time{d.seen, d.older} {
+		for key, expires := range m {
+			if !now.Before(expires) {
+				delete(m, key)
+			}
+		}
+	}
+	d.lastPrune = now
+}
+
+// flush releases the entries, so repeated requests can be sent again, to reduce the memory usage.
+func (d *requestDedup) flush() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.seen = make(map[string]time.Time)
+	d.older = make(map[string]time.Time)
+	d.lastPrune = time.Now()
+}
+
+// stats returns the number of unique requests tracked and the number of repeated requests suppressed.
+func (d *requestDedup) stats() (int, int) {
+	d.Lock()
+	defer d.Unlock()
+
+	return len(d.seen) + len(d.older), d.suppressed
+}
+
+// dedupKey identifies the asset of the request along with the kind of work it schedules.
+func dedupKey(req interface{}) string {
+	switch v := req.(type) {
+	case *requests.DNSRequest:
+		return "dns|" + v.Name
+	case *requests.ResolvedRequest:
+		return "resolved|" + v.Name
+	case *requests.SubdomainRequest:
+		// Data sources act on the number of times a subdomain has been seen
+		return "subdomain|" + v.Name + "|" + strconv.Itoa(v.Times)
+	case *requests.AddrRequest:
+		return "address|" + v.Address
+	case *requests.ASNRequest:
+		if v.ASN != 0 {
+			return "asn|" + strconv.Itoa(v.ASN)
+		}
+		return "asn|" + v.Address
+	case *requests.WhoisRequest:
+		return "whois|" + v.Domain
+	case *requests.EmailRequest:
+		return "email|" + v.Address
+	case *requests.AccountRequest:
+		return "account|" + v.URL
+	}
+	return ""
+}
+
+// newRequestMarkers returns the markers shared by the sessions when the 'dedup_scope' option is set to 'global'.
+// The default 'session' scope keeps the deduplication within the enumeration.
+func newRequestMarkers(cfg *config.Config) *systems.MarkerStore {
+	if !strings.EqualFold(options.String(cfg, "dedup_scope"), "global") {
+		return nil
+	}
+
+	markers, err := systems.NewMarkerStore(systems.MarkersPath(cfg))
+	if err != nil {
+		cfg.Log.Printf("Failed to open the markers shared by the sessions: %v", err)
+		return nil
+	}
+	return markers
+}
+
+// dedupTTL returns the freshness window from the 'dedup_ttl' option, provided
+// as a duration string or number of seconds. Zero covers the entire enumeration.
+func dedupTTL(cfg *config.Config) time.Duration {
+	return options.Duration(cfg, "dedup_ttl")
+}
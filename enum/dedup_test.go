@@ -0,0 +1,58 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestRequestDedupTTL(t *testing.T) {
+	d := newRequestDedup(50*time.Millisecond, nil)
+	req := &requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"}
+
+	if !d.accept(req) {
+		t.Fatal("Expected the first request to be accepted")
+	}
+	if d.accept(req) {
+		t.Error("Expected the repeated request to be suppressed within the freshness window")
+	}
+	if !d.accept(&requests.AddrRequest{Address: "192.0.2.1"}) {
+		t.Error("Expected a request for another asset to be accepted")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !d.accept(req) {
+		t.Error("Expected the request to be accepted again after the freshness window expired")
+	}
+
+	// The expired entries are removed by the pruning
+	d.prune(time.Now().Add(time.Second))
+	if tracked, suppressed := d.stats(); tracked != 0 || suppressed != 1 {
+		t.Errorf("Expected no entries and one suppressed request, got %d and %d", tracked, suppressed)
+	}
+}
+
+func TestRequestDedupBounded(t *testing.T) {
+	d := newRequestDedup(0, nil)
+	d.max = 10
+
+	for i := 0; i < 100; i++ {
+		d.accept(&requests.DNSRequest{Name: strconv.Itoa(i) + ".owasp.org"})
+	}
+	if tracked, _ := d.stats(); tracked > d.max {
+		t.Errorf("Expected at most %d entries, got %d", d.max, tracked)
+	}
+	// The most recent requests are still suppressed, while the oldest are forgotten
+	if d.accept(&requests.DNSRequest{Name: "99.owasp.org"}) {
+		t.Error("Expected the recent request to be suppressed")
+	}
+	if !d.accept(&requests.DNSRequest{Name: "0.owasp.org"}) {
+		t.Error("Expected the oldest request to be forgotten")
+	}
+}
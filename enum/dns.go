@@ -15,8 +15,11 @@ import (
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/resolve"
+	"sync/atomic"
 )
 
 const (
@@ -24,6 +27,7 @@ const (
 	maxRcodeServerFails int           = 3
 	initialBackoffDelay time.Duration = 250 * time.Millisecond
 	maximumBackoffDelay time.Duration = 4 * time.Second
+	dnsBandwidthSource  string        = "DNS"
 )
 
 // FwdQueryTypes include the DNS record types that are queried for a discovered name.
@@ -35,6 +39,15 @@ var FwdQueryTypes = []uint16{
 
 var fwdQueryTypesLookup = map[uint16]int{dns.TypeCNAME: 0, dns.TypeA: 1, dns.TypeAAAA: 2}
 
+// SubdomainRecordTypes include the additional DNS record types queried for the
+// root domain names and proper subdomains, since they can reveal other endpoints.
+var SubdomainRecordTypes = []uint16{
+	dns.TypeTXT,
+	dns.TypeCAA,
+	dns.TypeNAPTR,
+	dns.TypeSVCB,
+}
+
 type req struct {
 	Ctx        context.Context
 	Data       pipeline.Data
@@ -60,6 +73,7 @@ type dnsTask struct {
 	resps     chan *dns.Msg
 	respQueue queue.Queue
 	release   chan struct{}
+	bindings  int64
 }
 
 // newDNSTask returns a dNSTask specific to the provided Enumeration.
@@ -73,6 +87,9 @@ func newDNSTask(e *Enumeration, trusted bool) *dnsTask {
 		qps = e.Config.TrustedQPS
 	}
 	plen := pool.Len() * qps
+	if e.offline != nil {
+		plen = offlineQueries
+	}
 
 	dt := &dnsTask{
 		trust:     trust,
@@ -180,8 +197,9 @@ func (dt *dnsTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 			Attempts:   1,
 			HasRecords: len(v.Records) > 0,
 		}) {
-			dt.pool.Query(ctx, msg, dt.resps)
+			dt.query(ctx, msg)
 		} else {
+			dt.enum.tracer.finish(v.Name)
 			dt.enum.Config.Log.Printf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
 		}
 		return nil, nil
@@ -258,6 +276,9 @@ func (dt *dnsTask) delReqWithDecrement(key string) {
 
 		if !req.Sent && (req.InScope || req.HasRecords) {
 			dt.nextStage(req.Ctx, req.Data)
+			dt.serviceBindingQueries(req.Ctx, req.Data)
+		} else if !req.Sent {
+			dt.enum.tracer.finish(eventKey(req.Data))
 		}
 	}
 }
@@ -270,6 +291,9 @@ func (dt *dnsTask) processResponses() {
 		case <-dt.respQueue.Signal():
 			if element, ok := dt.respQueue.Next(); ok {
 				if msg, valid := element.(*dns.Msg); valid {
+					amassnet.RecordBandwidth(dnsBandwidthSource, 0, msg.Len())
+					_ = amassnet.ThrottleBandwidth(context.Background(), msg.Len())
+					dt.enum.recordResponse(msg)
 					dt.processResp(msg)
 				}
 			}
@@ -277,6 +301,25 @@ func (dt *dnsTask) processResponses() {
 	}
 }
 
+// query sends the DNS message once the bandwidth cap allows it.
+func (dt *dnsTask) query(ctx context.Context, msg *dns.Msg) {
+	if dt.enum.offline != nil {
+		dt.answer(msg)
+		return
+	}
+	if err := amassnet.ThrottleBandwidth(ctx, msg.Len()); err != nil {
+		return
+	}
+
+	amassnet.RecordBandwidth(dnsBandwidthSource, msg.Len(), 0)
+	dt.pool.Query(ctx, msg, dt.resps)
+}
+
+// answer sends the response built from the offline datasets in place of querying the resolvers.
+func (dt *dnsTask) answer(msg *dns.Msg) {
+	dt.resps <- dt.enum.offline.Answer(msg)
+}
+
 func (dt *dnsTask) processResp(resp *dns.Msg) {
 	k := key(resp.Id, resp.Question[0].Name)
 
@@ -333,7 +376,7 @@ func (dt *dnsTask) retry(msg *dns.Msg, id uint16, entry *req) {
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
 		time.Sleep(resolve.TruncatedExponentialBackoff(entry.Attempts-1, initialBackoffDelay, maximumBackoffDelay))
-		dt.pool.Query(entry.Ctx, msg, dt.resps)
+		dt.query(entry.Ctx, msg)
 	} else {
 		dt.enum.Config.Log.Printf("%s was dropped after failing to resolve %d times on the %s DNS task", msg.Question[0].Name, entry.Attempts-1, dt.trust)
 		dt.delReqWithDecrement(k)
@@ -350,7 +393,7 @@ func (dt *dnsTask) nextType(ctx context.Context, name string, id, qtype uint16,
 		msg := resolve.QueryMsg(name, entry.Qtype)
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
-		dt.pool.Query(ctx, msg, dt.resps)
+		dt.query(ctx, msg)
 	} else {
 		dt.delReqWithDecrement(k)
 	}
@@ -394,14 +437,15 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 }
 
 func (dt *dnsTask) subdomainQueries(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) {
-	ch := make(chan []requests.DNSAnswer, 4)
+	ch := make(chan []requests.DNSAnswer, 5)
 
 	go dt.queryNS(ctx, req.Name, req.Domain, ch, tp)
 	go dt.queryMX(ctx, req.Name, ch, tp)
 	go dt.querySOA(ctx, req.Name, ch, tp)
 	go dt.querySPF(ctx, req.Name, ch, tp)
+	go func() { ch <- dt.queryRecords(ctx, req.Name, SubdomainRecordTypes...) }()
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < 5; i++ {
 		if rr := <-ch; rr != nil {
 			req.Records = append(req.Records, rr...)
 		}
@@ -481,6 +525,57 @@ func (dt *dnsTask) querySPF(ctx context.Context, name string, ch chan []requests
 	ch <- nil
 }
 
+// queryRecords returns the answers of the record types, including the types the resolve package does not extract.
+func (dt *dnsTask) queryRecords(ctx context.Context, name string, qtypes ...uint16) []requests.DNSAnswer {
+	var records []requests.DNSAnswer
+
+	for _, qtype := range qtypes {
+		resp, err := dt.enum.dnsQuery(ctx, name, qtype, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		if rr := resolve.AnswersByType(amassdns.ExtractRecords(resp), qtype); len(rr) > 0 {
+			records = append(records, convertAnswers(rr)...)
+		}
+	}
+	return records
+}
+
+// serviceBindingQueries obtains the HTTPS records of the names resolved by the trusted resolvers, which can
+// advertise alternative endpoints. The queries are counted until the records have been sent to the store stage.
+func (dt *dnsTask) serviceBindingQueries(ctx context.Context, data pipeline.Data) {
+	req, ok := data.(*requests.DNSRequest)
+	if !dt.trusted || !ok || !req.Valid() || !dt.enum.Config.IsDomainInScope(req.Name) {
+		return
+	}
+
+	dt.Lock()
+	params := dt.params
+	dt.Unlock()
+	if params == nil {
+		return
+	}
+
+	atomic.AddInt64(&dt.bindings, 1)
+	go func() {
+		defer atomic.AddInt64(&dt.bindings, -1)
+
+		if rr := dt.queryRecords(ctx, req.Name, dns.TypeHTTPS); len(rr) > 0 {
+			pipeline.SendData(ctx, "store", &requests.DNSRequest{
+				Name:    req.Name,
+				Domain:  req.Domain,
+				Records: rr,
+			}, params)
+		}
+	}()
+}
+
+// bindingsPending returns true while HTTPS records are being obtained for the resolved names.
+func (dt *dnsTask) bindingsPending() bool {
+	return atomic.LoadInt64(&dt.bindings) > 0
+}
+
 func (e *Enumeration) fwdQuery(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
 	resp, err := e.dnsQuery(ctx, name, qtype, e.Sys.Resolvers(), maxDNSQueryAttempts)
 	if err != nil {
@@ -499,6 +594,17 @@ func (e *Enumeration) fwdQuery(ctx context.Context, name string, qtype uint16) (
 
 func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r *resolve.Resolvers, attempts int) (*dns.Msg, error) {
 	msg := resolve.QueryMsg(name, qtype)
+	// The records of the local datasets answer the queries in offline mode
+	if e.offline != nil {
+		resp := e.offline.Answer(msg)
+
+		if resp.Rcode == dns.RcodeNameError {
+			return nil, errors.New("name does not exist")
+		} else if len(resp.Answer) == 0 {
+			return nil, errors.New("no record of this type")
+		}
+		return resp, nil
+	}
 
 	for num := 0; num < attempts; num++ {
 		select {
@@ -507,10 +613,19 @@ func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r
 		default:
 		}
 
+		if err := amassnet.ThrottleBandwidth(ctx, msg.Len()); err != nil {
+			return nil, err
+		}
+
 		resp, err := r.QueryBlocking(ctx, msg)
 		if err != nil {
+			amassnet.RecordBandwidth(dnsBandwidthSource, msg.Len(), 0)
 			continue
 		}
+		amassnet.RecordBandwidth(dnsBandwidthSource, msg.Len(), resp.Len())
+		_ = amassnet.ThrottleBandwidth(ctx, resp.Len())
+		e.recordResponse(resp)
+
 		if resp.Rcode == dns.RcodeNameError {
 			return nil, errors.New("name does not exist")
 		}
@@ -0,0 +1,180 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caffix/netmap"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/systems"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+const (
+	// DualStackSource is the source of the findings produced by the dual-stack analysis.
+	DualStackSource = "DualStack"
+	// RelationIPv4Only is the relation of the findings for names and services only available over IPv4.
+	RelationIPv4Only = "ipv4_only"
+	// RelationIPv6Only is the relation of the findings for names and services only available over IPv6.
+	RelationIPv6Only = "ipv6_only"
+
+	dualStackDialTimeout = 3 * time.Second
+	maxDualStackDials    = 50
+)
+
+// stackAddrs holds the addresses of a name for each IP version.
+type stackAddrs struct {
+	domain string
+	v4     []string
+	v6     []string
+}
+
+// analyzeDualStack compares the IPv4 and IPv6 addresses of the names discovered by the
+// enumeration, and stores findings for the names only resolving over one of the stacks.
+// In active mode, the ports in scope are also checked for the names resolving over both
+// stacks, since services reachable over one stack only are often left unmonitored.
+func (e *Enumeration) analyzeDualStack(ctx context.Context) {
+	names := e.namesDiscovered()
+	if len(names) == 0 {
+		return
+	}
+
+	size := systems.NameBatchSize(e.Config)
+	pairs := make(chan *netmap.NameAddrPair, size)
+	go func() {
+		_ = systems.NamesToAddrsStream(ctx, e.graph, e.Config.CollectionStartTime, size, pairs, names...)
+	}()
+
+	stacks := make(map[string]*stackAddrs)
+	for p := range pairs {
+		if p.FQDN == nil || p.Addr == nil {
+			continue
+		}
+
+		name := p.FQDN.Name
+		if _, found := stacks[name]; !found {
+			stacks[name] = &stackAddrs{domain: e.Config.WhichDomain(name)}
+		}
+
+		s := stacks[name]
+		if p.Addr.Address.Is4() || p.Addr.Address.Is4In6() {
+			s.v4 = append(s.v4, p.Addr.Address.Unmap().String())
+		} else {
+			s.v6 = append(s.v6, p.Addr.Address.String())
+		}
+	}
+
+	var both []string
+	for name, s := range stacks {
+		switch {
+		case len(s.v6) == 0:
+			e.addDualStackFinding("FQDN", name, s.domain, RelationIPv4Only, nil)
+		case len(s.v4) == 0:
+			e.addDualStackFinding("FQDN", name, s.domain, RelationIPv6Only, nil)
+		default:
+			both = append(both, name)
+		}
+	}
+
+	if e.Config.Active && len(e.Config.Scope.Ports) > 0 {
+		e.compareStackServices(ctx, both, stacks)
+	}
+}
+
+func (e *Enumeration) namesDiscovered() []string {
+	var fqdns []oam.Asset
+	for _, d := range e.Config.Domains() {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+	if len(fqdns) == 0 {
+		return nil
+	}
+
+	assets, err := e.graph.DB.FindByScope(fqdns, e.Config.CollectionStartTime)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.WhichDomain(fqdn.Name) != "" {
+			names = append(names, fqdn.Name)
+		}
+	}
+	return names
+}
+
+// compareStackServices attempts TCP connections to the ports in scope using the first address of each stack.
+func (e *Enumeration) compareStackServices(ctx context.Context, names []string, stacks map[string]*stackAddrs) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxDualStackDials)
+
+	for _, name := range names {
+		s := stacks[name]
+
+		for _, port := range e.Config.Scope.Ports {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(name string, s *stackAddrs, port int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				p := strconv.Itoa(port)
+				v4 := reachable(ctx, net.JoinHostPort(s.v4[0], p))
+				v6 := reachable(ctx, net.JoinHostPort(s.v6[0], p))
+				if v4 == v6 {
+					return
+				}
+
+				rel := RelationIPv4Only
+				addr := s.v4[0]
+				if v6 {
+					rel = RelationIPv6Only
+					addr = s.v6[0]
+				}
+				e.addDualStackFinding("Service", net.JoinHostPort(name, p), s.domain, rel, map[string]string{
+					"address": addr,
+					"port":    p,
+				})
+			}(name, s, port)
+		}
+	}
+	wg.Wait()
+}
+
+func reachable(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, dualStackDialTimeout)
+	defer cancel()
+
+	conn, err := amassnet.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (e *Enumeration) addDualStackFinding(atype, value, domain, relation string, props map[string]string) {
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:       atype,
+		Value:      value,
+		Domain:     domain,
+		Relation:   relation,
+		Source:     DualStackSource,
+		Properties: props,
+	})
+}
@@ -6,6 +6,7 @@ package enum
 
 import (
 	"context"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -14,8 +15,10 @@ import (
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/datasrcs"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/wordlist"
 	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
@@ -23,20 +26,31 @@ import (
 
 // Enumeration is the object type used to execute a DNS enumeration.
 type Enumeration struct {
-	Config   *config.Config
-	Sys      systems.System
-	ctx      context.Context
-	graph    *netmap.Graph
-	srcs     []service.Service
-	done     chan struct{}
-	nameSrc  *enumSource
-	subTask  *subdomainTask
-	dnsTask  *dnsTask
-	valTask  *dnsTask
-	store    *dataManager
-	requests queue.Queue
-	plock    sync.Mutex
-	pending  bool
+	Config     *config.Config
+	Sys        systems.System
+	ctx        context.Context
+	graph      *netmap.Graph
+	srcs       []service.Service
+	done       chan struct{}
+	nameSrc    *enumSource
+	subTask    *subdomainTask
+	dnsTask    *dnsTask
+	valTask    *dnsTask
+	store      *dataManager
+	tracer     *eventTracer
+	dedup      *requestDedup
+	coverage   *coverageRecorder
+	junk       *junkStage
+	thirdParty *thirdPartyCNAMEs
+	memory     *memoryWatchdog
+	completion *completionMonitor
+	offline    *amassdns.Dataset
+	recorder   *amassdns.Recorder
+	wordlists  []*wordlist.List
+	nat64      []netip.Prefix
+	requests   queue.Queue
+	plock      sync.Mutex
+	pending    bool
 }
 
 // NewEnumeration returns an initialized Enumeration that has not been started yet.
@@ -63,6 +77,31 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	var cancel context.CancelFunc
 	e.ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
+
+	offline, err := newOfflineDataset(e)
+	if err != nil {
+		return err
+	}
+	e.offline = offline
+	e.recorder = newDNSRecorder(e)
+	defer e.stopRecording()
+	e.loadWordlists(e.ctx)
+	e.detectNAT64(e.ctx)
+
+	e.tracer = newEventTracer(e, eventBudget(e.Config))
+	defer e.tracer.stop()
+	e.dedup = newRequestDedup(dedupTTL(e.Config), newRequestMarkers(e.Config))
+	defer e.logDedupStats()
+	e.coverage = newCoverageRecorder()
+	defer e.saveCoverage()
+	e.junk = newJunkStage(e.Config)
+	e.thirdParty = newThirdPartyCNAMEs(e.Config)
+	defer e.logQuarantined()
+	e.memory = newMemoryWatchdog(e, memoryLimit(e.Config))
+	defer e.memory.stop()
+	e.completion = newCompletionMonitor(e)
+	defer e.saveCompletion()
+	defer e.completion.stop()
 	go e.manageDataSrcRequests()
 
 	e.dnsTask = newDNSTask(e, false)
@@ -74,19 +113,25 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	defer e.valTask.stop()
 
 	var stages []pipeline.Stage
-	stages = append(stages, pipeline.FIFO("root", e.valTask.rootTaskFunc()))
-	stages = append(stages, pipeline.FIFO("dns", e.dnsTask))
-	stages = append(stages, pipeline.FIFO("validate", e.valTask))
-	stages = append(stages, pipeline.FIFO("store", e.store))
-	stages = append(stages, pipeline.FIFO("", e.subTask))
+	stages = append(stages, e.newStage("root", "root", e.traceStage("root", e.valTask.rootTaskFunc(), true, false)))
+	stages = append(stages, e.newStage("dns", "dns", e.traceStage("dns", e.dnsTask, true, false)))
+	stages = append(stages, e.newStage("validate", "validate", e.traceStage("validate", e.valTask, true, false)))
+	stages = append(stages, e.newStage("store", "store", e.traceStage("store", e.store, false, false)))
+	stages = append(stages, e.newStage("", "subdomain", e.traceStage("subdomain", e.subTask, false, true)))
 
 	p := pipeline.NewPipeline(stages...)
 	// The pipeline input source will receive all the names
 	e.nameSrc = newEnumSource(p, e)
 	defer e.nameSrc.Stop()
+	e.memory.start()
+	e.completion.start()
+	go e.watchInstances()
 
 	e.submitASNs()
 	e.submitDomainNames()
+	if replayDeadLettersEnabled(e) {
+		go e.replayDeadLetters()
+	}
 	/*
 	 * Now that the pipeline input source has been setup, names provided
 	 * by the user and names acquired from the graph database can be brought
@@ -94,10 +139,25 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	 */
 	go e.submitKnownNames()
 	go e.submitProvidedNames()
+	go e.submitDatasetNames()
 
-	err := p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
+	err = p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), pipelineBuffer(e.Config))
+	e.completion.finish(e.ctx)
 	// Ensure all data has been stored
 	<-e.store.Stop()
+	if e.ctx.Err() == nil {
+		e.analyzeDualStack(e.ctx)
+		e.analyzeHistory(e.ctx)
+		e.checkRegistrations()
+		e.analyzeLookalikes(e.ctx)
+		e.checkIssuances(e.ctx)
+		e.validateOriginations(e.ctx)
+		e.analyzeCentrality(e.ctx)
+		e.compareBaselines(e.ctx)
+		e.analyzePrefixChanges(e.ctx)
+		e.analyzeAging(e.ctx)
+		e.measureWordlists()
+	}
 	return err
 }
 
@@ -139,6 +199,11 @@ func (e *Enumeration) manageDataSrcRequests() {
 
 	finished := make(chan string, len(e.srcs)*2)
 	requestsMap := make(map[string][]interface{})
+	next := func(name string) {
+		go e.fireRequest(nameToSrc[name], requestsMap[name][0], finished)
+		requestsMap[name] = requestsMap[name][1:]
+		pending[name] = true
+	}
 loop:
 	for {
 		select {
@@ -148,34 +213,70 @@ loop:
 			break loop
 		case <-e.requests.Signal():
 			element, ok := e.requests.Next()
-			if !ok {
+			if !ok || !e.dedup.accept(element) {
 				continue loop
 			}
 
+			var held bool
 			for name := range nameToSrc {
 				if src := nameToSrc[name]; src != nil && src.HandlesReq(element) {
-					if len(requestsMap[name]) == 0 && !pending[name] {
+					paused := e.memory.paused(src.Description())
+
+					if len(requestsMap[name]) == 0 && !pending[name] && !paused {
 						go e.fireRequest(src, element, finished)
 						pending[name] = true
 					} else {
 						requestsMap[name] = append(requestsMap[name], element)
+						held = held || paused
 					}
 				}
 			}
+			if held {
+				e.setRequestsPending(pending, requestsMap)
+			}
+		case <-e.memory.resumed():
+			// Send the requests held back while the memory usage was high
+			for name, src := range nameToSrc {
+				if !pending[name] && len(requestsMap[name]) > 0 && !e.memory.paused(src.Description()) {
+					next(name)
+				}
+			}
+			e.setRequestsPending(pending, requestsMap)
 		case name := <-finished:
-			if len(requestsMap[name]) == 0 {
-				pending[name] = false
-				e.setRequestsPending(pending)
-				continue loop
+			pending[name] = false
+			if len(requestsMap[name]) > 0 && !e.memory.paused(nameToSrc[name].Description()) {
+				next(name)
 			}
-
-			go e.fireRequest(nameToSrc[name], requestsMap[name][0], finished)
-			requestsMap[name] = requestsMap[name][1:]
+			e.setRequestsPending(pending, requestsMap)
 		}
 	}
 	e.requests.Process(func(e interface{}) {})
 }
 
+func (e *Enumeration) saveCoverage() {
+	if err := e.coverage.save(CoveragePath(e.Config)); err != nil {
+		e.Config.Log.Printf("Failed to save the data source coverage: %v", err)
+	}
+}
+
+func (e *Enumeration) saveCompletion() {
+	if err := e.completion.save(CompletionPath(e.Config)); err != nil {
+		e.Config.Log.Printf("Failed to save the completion of the enumeration: %v", err)
+	}
+}
+
+func (e *Enumeration) logDedupStats() {
+	if unique, suppressed := e.dedup.stats(); suppressed > 0 {
+		e.Config.Log.Printf("Deduplication suppressed %d repeated requests for %d unique assets sent to the data sources", suppressed, unique)
+	}
+}
+
+// collectionStart returns the time the enumeration started as it is compared with the times in the graph database.
+func collectionStart(cfg *config.Config) time.Time {
+	// The database keeps the times with a resolution of one second
+	return cfg.CollectionStartTime.Truncate(time.Second)
+}
+
 func (e *Enumeration) requestsPending() bool {
 	e.plock.Lock()
 	defer e.plock.Unlock()
@@ -183,11 +284,12 @@ func (e *Enumeration) requestsPending() bool {
 	return e.pending
 }
 
-func (e *Enumeration) setRequestsPending(p map[string]bool) {
+// setRequestsPending records whether data sources are handling requests or have requests waiting.
+func (e *Enumeration) setRequestsPending(p map[string]bool, waiting map[string][]interface{}) {
 	var pending bool
 
-	for _, b := range p {
-		if b {
+	for name, b := range p {
+		if b || len(waiting[name]) > 0 {
 			pending = true
 			break
 		}
@@ -199,15 +301,32 @@ func (e *Enumeration) setRequestsPending(p map[string]bool) {
 }
 
 func (e *Enumeration) fireRequest(srv service.Service, req interface{}, finished chan string) {
+	start := time.Now()
+
 	select {
 	case <-e.done:
 	case <-e.ctx.Done():
 	case <-srv.Done():
 	case srv.Input() <- req:
+		e.tracer.slowSource(srv.String(), req, time.Since(start))
 	}
 	finished <- srv.String()
 }
 
+// EventBudgetStats returns the number of events that exceeded the 'event_budget' option,
+// keyed by the queue, pipeline stage or data source where each of them spent the most time.
+func (e *Enumeration) EventBudgetStats() map[string]int {
+	return e.tracer.Stats()
+}
+
+// Completion returns the reason the enumeration ended, which is nil before the enumeration has started.
+func (e *Enumeration) Completion() *Completion {
+	if e.completion == nil {
+		return nil
+	}
+	return e.completion.completion()
+}
+
 func (e *Enumeration) makeOutputSink() pipeline.SinkFunc {
 	return pipeline.SinkFunc(func(ctx context.Context, data pipeline.Data) error {
 		return nil
@@ -0,0 +1,268 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// HijackSource is the source of the findings produced by the DNS history analysis.
+	HijackSource = "DNSHistory"
+	// RelationAddrsMoved is the relation of the findings for names whose addresses moved to unrelated ASNs.
+	RelationAddrsMoved = "addresses_moved"
+	// RelationNSMoved is the relation of the findings for names whose name servers moved to an unrelated provider.
+	RelationNSMoved = "nameservers_moved"
+)
+
+// The ASNs of the content delivery networks, where names routinely move between runs
+var defaultHijackAllowlist = []string{
+	"AS13335",  // Cloudflare
+	"AS209242", // Cloudflare
+	"AS20940",  // Akamai
+	"AS16625",  // Akamai
+	"AS54113",  // Fastly
+	"AS15133",  // Edgecast
+	"AS22822",  // Limelight
+	"AS60068",  // CDN77
+	"AS16509",  // Amazon CloudFront
+	"AS8075",   // Azure Front Door
+}
+
+// dnsHistory holds the infrastructure of a name seen by previous enumerations and by the current one.
+type dnsHistory struct {
+	prevASNs    map[int]string
+	currASNs    map[int]string
+	prevServers map[string]struct{}
+	currServers map[string]struct{}
+}
+
+// analyzeHistory compares the A, AAAA and NS records of the in-scope names discovered by the
+// enumeration with the records stored by previous enumerations. Names whose addresses moved
+// entirely to unrelated ASNs, or whose name servers moved entirely to an unrelated provider,
+// receive a high-severity finding, since this is how hijacked and expired domains appear.
+// The 'hijack_allowlist' option extends the ASNs and providers where such moves are expected.
+func (e *Enumeration) analyzeHistory(ctx context.Context) {
+	allow := hijackAllowlist(e)
+
+	for _, name := range e.namesDiscovered() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		h := e.dnsHistory(name)
+		if h == nil {
+			continue
+		}
+
+		d := e.Config.WhichDomain(name)
+		if len(h.prevASNs) > 0 && len(h.currASNs) > 0 && disjointASNs(h.prevASNs, h.currASNs) &&
+			!allow.asns(h.prevASNs) && !allow.asns(h.currASNs) {
+			e.addHijackFinding("FQDN", name, d, RelationAddrsMoved, describeASNs(h.prevASNs), describeASNs(h.currASNs))
+		}
+		if len(h.prevServers) > 0 && len(h.currServers) > 0 && disjointServers(h.prevServers, h.currServers) &&
+			!allow.servers(h.prevServers) && !allow.servers(h.currServers) {
+			e.addHijackFinding("Domain", name, d, RelationNSMoved, describeServers(h.prevServers), describeServers(h.currServers))
+		}
+	}
+}
+
+// dnsHistory returns nil when the name has no records from previous enumerations.
+func (e *Enumeration) dnsHistory(name string) *dnsHistory {
+	assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, e.Config.CollectionStartTime)
+	if err != nil || len(assets) == 0 {
+		return nil
+	}
+
+	rels, err := e.graph.DB.OutgoingRelations(assets[0], e.Config.CollectionStartTime, "a_record", "aaaa_record", "ns_record")
+	if err != nil || len(rels) == 0 {
+		return nil
+	}
+
+	h := &dnsHistory{
+		prevASNs:    make(map[int]string),
+		currASNs:    make(map[int]string),
+		prevServers: make(map[string]struct{}),
+		currServers: make(map[string]struct{}),
+	}
+
+	start := collectionStart(e.Config)
+
+	var previous bool
+	for _, rel := range rels {
+		current := !rel.LastSeen.Before(start)
+		if !current {
+			previous = true
+		}
+
+		// The targets not seen by the current enumeration must also be found
+		target, err := e.graph.DB.FindById(rel.ToAsset.ID, time.Time{})
+		if err != nil || target == nil {
+			continue
+		}
+
+		switch v := target.Asset.(type) {
+		case network.IPAddress:
+			if r := e.Sys.Cache().AddrSearch(v.Address.String()); r != nil && r.ASN != 0 {
+				if current {
+					h.currASNs[r.ASN] = r.Description
+				} else {
+					h.prevASNs[r.ASN] = r.Description
+				}
+			}
+		case domain.FQDN:
+			if rel.Type != "ns_record" {
+				continue
+			}
+			if provider := nameServerProvider(v.Name); current {
+				h.currServers[provider] = struct{}{}
+			} else {
+				h.prevServers[provider] = struct{}{}
+			}
+		}
+	}
+
+	if !previous {
+		return nil
+	}
+	return h
+}
+
+// addHijackFinding stores the name server moves as Domain findings, since the
+// delegation belongs to the registered domain rather than the name itself.
+func (e *Enumeration) addHijackFinding(atype, name, domain, relation, previous, current string) {
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     atype,
+		Value:    name,
+		Domain:   domain,
+		Relation: relation,
+		Source:   HijackSource,
+		Properties: map[string]string{
+			"severity": "high",
+			"previous": previous,
+			"current":  current,
+		},
+	})
+}
+
+// nameServerProvider returns the registered domain of the name server, such as 'awsdns-12.org'.
+func nameServerProvider(ns string) string {
+	ns = strings.ToLower(strings.TrimSuffix(ns, "."))
+
+	if d, err := publicsuffix.EffectiveTLDPlusOne(ns); err == nil {
+		return d
+	}
+	return ns
+}
+
+func disjointASNs(prev, curr map[int]string) bool {
+	for asn := range curr {
+		if _, found := prev[asn]; found {
+			return false
+		}
+	}
+	return true
+}
+
+func disjointServers(prev, curr map[string]struct{}) bool {
+	for s := range curr {
+		if _, found := prev[s]; found {
+			return false
+		}
+	}
+	return true
+}
+
+func describeASNs(asns map[int]string) string {
+	var list []string
+	for asn, desc := range asns {
+		entry := "AS" + strconv.Itoa(asn)
+		if desc != "" {
+			entry += " " + desc
+		}
+		list = append(list, entry)
+	}
+
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+func describeServers(servers map[string]struct{}) string {
+	var list []string
+	for s := range servers {
+		list = append(list, s)
+	}
+
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+// hijackAllow contains the ASNs and the terms matched against the AS descriptions and name server providers.
+type hijackAllow struct {
+	numbers map[int]struct{}
+	terms   []string
+}
+
+func hijackAllowlist(e *Enumeration) *hijackAllow {
+	allow := &hijackAllow{numbers: make(map[int]struct{})}
+
+	entries := append([]string{}, defaultHijackAllowlist...)
+	entries = append(entries, options.Strings(e.Config, "hijack_allowlist")...)
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if asn, err := strconv.Atoi(strings.TrimPrefix(entry, "as")); err == nil {
+			allow.numbers[asn] = struct{}{}
+			continue
+		}
+		allow.terms = append(allow.terms, entry)
+	}
+	return allow
+}
+
+func (a *hijackAllow) asns(asns map[int]string) bool {
+	for asn, desc := range asns {
+		if _, found := a.numbers[asn]; found || a.match(desc) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *hijackAllow) servers(servers map[string]struct{}) bool {
+	for s := range servers {
+		if a.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *hijackAllow) match(s string) bool {
+	s = strings.ToLower(s)
+
+	for _, term := range a.terms {
+		if s != "" && strings.Contains(s, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/iac"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	// IaCSource is the source of the findings comparing the assets discovered with the infrastructure as code.
+	IaCSource = "IaC"
+	// RelationExpected is the relation of the assets declared by the infrastructure as code baselines.
+	RelationExpected = "expected"
+	// RelationIaCDrift is the relation of the assets discovered but not declared, or declared but not discovered.
+	RelationIaCDrift = "iac_drift"
+	// The drift property values
+	driftShadow  = "shadow"
+	driftMissing = "missing"
+)
+
+// compareBaselines imports the names and public addresses declared by the Terraform states and CloudFormation exports
+// in the 'iac_baselines' option as ExpectedAsset findings, and compares them with the assets discovered by the
+// enumeration. The names in scope and their addresses found externally but not declared are shadow assets, while
+// the declared assets not found are missing, and both are kept as IaCDrift findings with the 'drift' property.
+func (e *Enumeration) compareBaselines(ctx context.Context) {
+	baselines := options.Strings(e.Config, "iac_baselines")
+	if len(baselines) == 0 {
+		return
+	}
+
+	expected := make(map[string]*iac.Asset)
+	for _, baseline := range baselines {
+		data, err := readFeed(ctx, baseline)
+		if err != nil {
+			e.Config.Log.Printf("Failed to obtain the IaC baseline %s: %v", baseline, err)
+			continue
+		}
+
+		assets, err := iac.Parse(data)
+		if err != nil {
+			e.Config.Log.Printf("Failed to parse the IaC baseline %s: %v", baseline, err)
+			continue
+		}
+
+		for _, a := range assets {
+			domain := e.Config.WhichDomain(a.Value)
+			if a.Type == "FQDN" && domain == "" {
+				continue
+			}
+
+			expected[a.Value] = a
+			e.Sys.Findings().Add(&systems.Finding{
+				Type:     "ExpectedAsset",
+				Value:    a.Value,
+				Domain:   domain,
+				Relation: RelationExpected,
+				Source:   a.Source,
+				Properties: map[string]string{
+					"kind":     a.Type,
+					"resource": a.Resource,
+					"baseline": baseline,
+				},
+			})
+		}
+	}
+	// Without a baseline, every asset discovered would be reported as a shadow asset
+	if len(expected) == 0 {
+		return
+	}
+
+	names := e.namesDiscovered()
+	discovered := make(map[string]struct{}, len(names))
+	var shadows []string
+	for _, name := range names {
+		discovered[name] = struct{}{}
+
+		// The domain names in scope are the zones declaring the records
+		if _, found := expected[name]; !found && e.Config.WhichDomain(name) != name {
+			shadows = append(shadows, name)
+			e.addDriftFinding("FQDN", name, driftShadow, "")
+		}
+	}
+
+	// The addresses of the declared names are often provided by load balancers and content delivery
+	// networks, so the addresses are checked for all the names, but only reported for the shadow names
+	size := systems.NameBatchSize(e.Config)
+	pairs := make(chan *netmap.NameAddrPair, size)
+	go func() {
+		_ = systems.NamesToAddrsStream(ctx, e.graph, e.Config.CollectionStartTime, size, pairs, names...)
+	}()
+
+	shadowNames := make(map[string]struct{}, len(shadows))
+	for _, name := range shadows {
+		shadowNames[name] = struct{}{}
+	}
+	for p := range pairs {
+		if p.FQDN == nil || p.Addr == nil {
+			continue
+		}
+
+		addr := p.Addr.Address.Unmap().String()
+		discovered[addr] = struct{}{}
+		if _, found := expected[addr]; found {
+			continue
+		}
+		if _, found := shadowNames[p.FQDN.Name]; found {
+			e.addDriftFinding("IPAddress", addr, driftShadow, "")
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	for value, a := range expected {
+		if _, found := discovered[value]; !found {
+			e.addDriftFinding(a.Type, value, driftMissing, a.Resource)
+		}
+	}
+}
+
+func (e *Enumeration) addDriftFinding(kind, value, drift, resource string) {
+	severity := "medium"
+	if drift == driftMissing {
+		severity = "low"
+	}
+
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     "IaCDrift",
+		Value:    value,
+		Domain:   e.Config.WhichDomain(value),
+		Relation: RelationIaCDrift,
+		Source:   IaCSource,
+		Properties: map[string]string{
+			"kind":     kind,
+			"drift":    drift,
+			"resource": resource,
+			"severity": severity,
+		},
+	})
+}
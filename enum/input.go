@@ -7,13 +7,13 @@ package enum
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/requests"
-	bf "github.com/tylertreat/BoomFilters"
 )
 
 const waitForDuration = 10 * time.Second
@@ -23,11 +23,13 @@ type enumSource struct {
 	pipeline *pipeline.Pipeline
 	enum     *Enumeration
 	queue    queue.Queue
-	filter   *bf.StableBloomFilter
+	filter   nameSet
 	done     chan struct{}
 	doneOnce sync.Once
 	release  chan struct{}
 	max      int
+	// throttled is set while fewer names are released due to memory pressure
+	throttled int32
 }
 
 // newEnumSource returns an initialized input source for the enumeration pipeline.
@@ -38,7 +40,7 @@ func newEnumSource(p *pipeline.Pipeline, e *Enumeration) *enumSource {
 		pipeline: p,
 		enum:     e,
 		queue:    queue.NewQueue(),
-		filter:   bf.NewDefaultStableBloomFilter(1000000, 0.01),
+		filter:   newNameSet(e),
 		done:     make(chan struct{}),
 		release:  make(chan struct{}, size),
 		max:      size,
@@ -65,7 +67,7 @@ func newEnumSource(p *pipeline.Pipeline, e *Enumeration) *enumSource {
 func (r *enumSource) Stop() {
 	r.markDone()
 	r.queue.Process(func(e interface{}) {})
-	r.filter.Reset()
+	_ = r.filter.Close()
 }
 
 func (r *enumSource) markDone() {
@@ -75,6 +77,12 @@ func (r *enumSource) markDone() {
 }
 
 func (r *enumSource) newName(req *requests.DNSRequest) {
+	r.newNameFrom(req, "", time.Time{}, queue.PriorityNormal)
+}
+
+// newNameFrom queues the name discovered by the source at the priority provided, so the pipeline
+// takes the names of the lower priorities only while no other names are waiting.
+func (r *enumSource) newNameFrom(req *requests.DNSRequest, source string, since time.Time, priority int) {
 	select {
 	case <-r.done:
 		return
@@ -96,10 +104,20 @@ func (r *enumSource) newName(req *requests.DNSRequest) {
 		r.releaseOutput(1)
 		return
 	}
-	r.queue.Append(req)
+	// Only the names scraped by the data sources are checked for garbage
+	if source != "" && r.enum.junk.check(r.enum, req, source) {
+		r.releaseOutput(1)
+		return
+	}
+	r.enum.tracer.start(req.Name, source, since)
+	r.queue.AppendPriority(req, priority)
 }
 
 func (r *enumSource) newAddr(req *requests.AddrRequest) {
+	r.newAddrFrom(req, "", time.Time{})
+}
+
+func (r *enumSource) newAddrFrom(req *requests.AddrRequest, source string, since time.Time) {
 	select {
 	case <-r.done:
 		return
@@ -107,18 +125,19 @@ func (r *enumSource) newAddr(req *requests.AddrRequest) {
 	}
 
 	if req.Valid() && req.InScope && r.accept(req.Address) {
+		r.enum.tracer.start(req.Address, source, since)
 		r.queue.Append(req)
 	}
 }
 
 func (r *enumSource) accept(s string) bool {
-	return !r.filter.TestAndAdd([]byte(s))
+	return !r.filter.TestAndAdd(s)
 }
 
 // Next implements the pipeline InputSource interface.
 func (r *enumSource) Next(ctx context.Context) bool {
 	// Low if below 75%
-	if p := (float32(r.queue.Len()) / float32(r.max)) * 100; p < 75 {
+	if p := (float32(r.queue.Len()) / float32(r.capacity())) * 100; p < 75 {
 		r.fillQueue()
 	}
 
@@ -134,7 +153,7 @@ func (r *enumSource) Next(ctx context.Context) bool {
 			return false
 		case <-t.C:
 			count := r.pipeline.DataItemCount()
-			if !r.enum.requestsPending() && count <= 0 {
+			if !r.enum.requestsPending() && !r.enum.valTask.bindingsPending() && count <= 0 {
 				if r.enum.store.queue.Len() == 0 {
 					r.markDone()
 					return false
@@ -164,13 +183,29 @@ func (r *enumSource) Error() error {
 }
 
 func (r *enumSource) fillQueue() {
-	if unfilled := r.max - r.queue.Len(); unfilled > 0 {
+	if unfilled := r.capacity() - r.queue.Len(); unfilled > 0 {
 		if fill := unfilled - len(r.release); fill > 0 {
 			r.releaseOutput(fill)
 		}
 	}
 }
 
+// throttle reduces the number of names and addresses released into the pipeline to a quarter.
+func (r *enumSource) throttle(enabled bool) {
+	var val int32
+	if enabled {
+		val = 1
+	}
+	atomic.StoreInt32(&r.throttled, val)
+}
+
+func (r *enumSource) capacity() int {
+	if atomic.LoadInt32(&r.throttled) == 1 && r.max > 4 {
+		return r.max / 4
+	}
+	return r.max
+}
+
 func (r *enumSource) releaseOutput(num int) {
 loop:
 	for i := 0; i < num; i++ {
@@ -183,6 +218,13 @@ loop:
 }
 
 func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
+	limiter := newSourceLimiter(r.enum, srv.String())
+	// The guessed names are the least likely to exist, so they are resolved after the wordlist brute forcing
+	priority := queue.PriorityNormal
+	if srv.Description() == "guess" {
+		priority = queue.PriorityLow
+	}
+
 	for {
 		select {
 		case <-r.done:
@@ -190,6 +232,26 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 		case <-srv.Done():
 			return
 		case in := <-srv.Output():
+			// Continue draining the output of data sources that have reached their limits
+			if !limiter.accept(in) {
+				continue
+			}
+			r.enum.coverage.claim(r.enum, srv, in)
+			// Email addresses and accounts are only passed along to the data sources that enrich them
+			switch req := in.(type) {
+			case *requests.EmailRequest:
+				if req.Valid() && r.enum.Config.IsDomainInScope(req.Domain) {
+					r.enum.sendRequests(req)
+				}
+				continue
+			case *requests.AccountRequest:
+				if req.Valid() && r.enum.Config.IsDomainInScope(req.Domain) {
+					r.enum.sendRequests(req)
+				}
+				continue
+			}
+			// Time spent waiting here is caused by the enumeration pipeline being at capacity
+			since := time.Now()
 			select {
 			case <-r.done:
 				return
@@ -200,9 +262,9 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 
 			switch req := in.(type) {
 			case *requests.DNSRequest:
-				r.newName(req)
+				r.newNameFrom(req, srv.String(), since, priority)
 			case *requests.AddrRequest:
-				r.newAddr(req)
+				r.newAddrFrom(req, srv.String(), since)
 			}
 		}
 	}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// InstancesFile is the name of the file in the output directory holding the numbers of script instances
+// requested for the data sources while the enumeration is running, such as by the webhook sessions.
+const InstancesFile = "source_instances.json"
+
+const instancesCheckInterval = 5 * time.Second
+
+// watchInstances applies the numbers of instances of the InstancesFile each time the file changes.
+func (e *Enumeration) watchInstances() {
+	path := filepath.Join(config.OutputDirectory(e.Config.Dir), InstancesFile)
+
+	t := time.NewTicker(instancesCheckInterval)
+	defer t.Stop()
+
+	var last time.Time
+	for {
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(last) {
+			last = info.ModTime()
+			e.loadInstances(path)
+		}
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (e *Enumeration) loadInstances(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var instances map[string]int
+	if err := json.Unmarshal(data, &instances); err != nil {
+		e.Config.Log.Printf("Failed to parse the data source instances in %s: %v", path, err)
+		return
+	}
+
+	for name, num := range instances {
+		if !e.setSourceInstances(name, num) {
+			e.Config.Log.Printf("%s: the number of instances cannot be changed", name)
+		}
+	}
+}
+
+// setSourceInstances changes the number of script instances handling the requests of the named data source,
+// and returns false when the data source is not used by the enumeration or does not have several instances.
+func (e *Enumeration) setSourceInstances(name string, num int) bool {
+	for _, src := range e.srcs {
+		if s, ok := src.(scalableSource); ok && strings.EqualFold(src.String(), name) {
+			e.memory.setInstances(src.String(), s, num)
+			return true
+		}
+	}
+	return false
+}
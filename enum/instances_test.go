@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+type scalableTestSource struct {
+	service.BaseService
+	instances int
+}
+
+func newScalableTestSource(name string) *scalableTestSource {
+	s := &scalableTestSource{instances: 1}
+	s.BaseService = *service.NewBaseService(s, name)
+	return s
+}
+
+func (s *scalableTestSource) Instances() int       { return s.instances }
+func (s *scalableTestSource) SetInstances(num int) { s.instances = num }
+
+func TestLoadInstances(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Log = log.New(io.Discard, "", 0)
+
+	src := newScalableTestSource("Crtsh")
+	other := newCoverageSource("Other")
+	e := &Enumeration{Config: cfg, srcs: []service.Service{src, other}}
+	e.memory = newMemoryWatchdog(e, 0)
+
+	path := filepath.Join(cfg.Dir, InstancesFile)
+	if err := os.WriteFile(path, []byte(`{"crtsh": 4, "Other": 2}`), 0600); err != nil {
+		t.Fatalf("Failed to write the instances: %v", err)
+	}
+	e.loadInstances(path)
+	if src.instances != 4 {
+		t.Errorf("Expected the data source to use 4 instances, got %d", src.instances)
+	}
+	if e.setSourceInstances("Other", 2) {
+		t.Errorf("Expected the data source without instances not to be changed")
+	}
+
+	// While the memory usage is high, the number is applied once the usage recovers
+	e.memory.level = memoryHigh
+	e.memory.instances[src.String()] = 4
+	src.instances = 1
+	if !e.setSourceInstances("Crtsh", 8) {
+		t.Fatal("Expected the number of instances to be accepted")
+	}
+	if src.instances != 1 {
+		t.Errorf("Expected the data source to keep a single instance under memory pressure, got %d", src.instances)
+	}
+	e.memory.level = memoryNormal
+	e.memory.apply(memoryHigh, memoryNormal)
+	if src.instances != 8 {
+		t.Errorf("Expected the data source to use 8 instances once the memory usage recovered, got %d", src.instances)
+	}
+}
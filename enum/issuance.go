@@ -0,0 +1,162 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// defaultIssuanceWindowDays is the number of days the certificates are checked for after being issued.
+const defaultIssuanceWindowDays = 30
+
+// The layouts of the dates provided by the certificate transparency data sources
+var issuanceLayouts = []string{time.RFC3339, "2006-01-02T15:04:05"}
+
+// issuedCert is a Certificate finding with the parsed issuance date and issuing organization.
+type issuedCert struct {
+	finding *systems.Finding
+	issued  time.Time
+	ca      string
+}
+
+// checkIssuances applies the issuance rules to the Certificate findings obtained from the
+// certificate transparency logs, as an early warning of phishing and compromise. Certificates
+// issued within the 'issuance_window_days' option are checked: 'new_ca' is true when the domain
+// had certificates before, but none from the issuing organization or the 'known_cas' option,
+// and 'undeployed_names' lists the names in scope covered by the certificate that are not in DNS.
+func (e *Enumeration) checkIssuances(ctx context.Context) {
+	window := defaultIssuanceWindowDays
+	if days := options.Int(e.Config, "issuance_window_days"); days > 0 {
+		window = days
+	}
+	cutoff := time.Now().AddDate(0, 0, -window)
+
+	known := make(map[string]struct{})
+	for _, ca := range options.Strings(e.Config, "known_cas") {
+		if ca = strings.ToLower(strings.TrimSpace(ca)); ca != "" {
+			known[ca] = struct{}{}
+		}
+	}
+
+	byDomain := make(map[string][]*issuedCert)
+	// The certificates seen by previous enumerations provide the history of each domain
+	for _, f := range e.Sys.Findings().Find(time.Time{}, "Certificate") {
+		if e.Config.WhichDomain(f.Domain) == "" {
+			continue
+		}
+
+		c := &issuedCert{finding: f, ca: issuerOrganization(f.Properties["issuer"])}
+		for _, layout := range issuanceLayouts {
+			if t, err := time.Parse(layout, f.Properties["not_before"]); err == nil {
+				c.issued = t
+				break
+			}
+		}
+		if !c.issued.IsZero() && c.ca != "" {
+			byDomain[f.Domain] = append(byDomain[f.Domain], c)
+		}
+	}
+
+	for _, certs := range byDomain {
+		sort.Slice(certs, func(i, j int) bool {
+			return certs[i].issued.Before(certs[j].issued)
+		})
+
+		used := make(map[string]struct{})
+		for i, c := range certs {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			_, knownCA := known[strings.ToLower(c.ca)]
+			_, usedCA := used[c.ca]
+			used[c.ca] = struct{}{}
+			if c.issued.Before(cutoff) {
+				continue
+			}
+
+			props := map[string]string{
+				"new_ca":           "false",
+				"undeployed_names": "",
+				"severity":         "none",
+			}
+			// The first certificate of the domain provides no baseline
+			if i > 0 && !usedCA && !knownCA {
+				props["new_ca"] = "true"
+				props["severity"] = "high"
+			}
+			if undeployed := e.undeployedNames(c.finding.Properties["names"]); len(undeployed) > 0 {
+				props["undeployed_names"] = strings.Join(undeployed, ",")
+				if props["severity"] == "none" {
+					props["severity"] = "medium"
+				}
+			}
+
+			c.finding.Properties = props
+			e.Sys.Findings().Add(c.finding)
+		}
+	}
+}
+
+// undeployedNames returns the names in scope, among those covered by the certificate, that have
+// no address or alias records in the graph database. The wildcard names cannot be checked.
+func (e *Enumeration) undeployedNames(names string) []string {
+	var undeployed []string
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || strings.HasPrefix(name, "*.") || e.Config.WhichDomain(name) == "" {
+			continue
+		}
+
+		assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, time.Time{})
+		if err == nil && len(assets) > 0 {
+			rels, err := e.graph.DB.OutgoingRelations(assets[0], time.Time{}, "a_record", "aaaa_record", "cname_record")
+			if err == nil && len(rels) > 0 {
+				continue
+			}
+		}
+		undeployed = append(undeployed, name)
+	}
+	return undeployed
+}
+
+// issuerOrganization returns the organization in the distinguished name of the issuer, such as
+// "Let's Encrypt", since the authorities issue from several intermediate certificates.
+func issuerOrganization(dn string) string {
+	for _, part := range splitDN(dn) {
+		if k, v, found := strings.Cut(strings.TrimSpace(part), "="); found && strings.EqualFold(k, "O") {
+			return strings.Trim(strings.TrimSpace(v), "\"")
+		}
+	}
+	return strings.TrimSpace(dn)
+}
+
+// splitDN splits the distinguished name at the commas found outside of quoted values.
+func splitDN(dn string) []string {
+	var parts []string
+	var quoted bool
+
+	start := 0
+	for i, r := range dn {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, dn[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, dn[start:])
+}
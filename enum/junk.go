@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/filter"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// junkStage quarantines the names scraped by the data sources that match the garbage
+// heuristics, so they are neither resolved nor stored in the graph database.
+type junkStage struct {
+	filter     *filter.JunkFilter
+	quarantine *systems.Quarantine
+	count      int64
+}
+
+// newJunkStage returns nil when the 'junk_heuristics' option disables the junk filtering.
+func newJunkStage(cfg *config.Config) *junkStage {
+	heuristics, enabled := junkHeuristics(cfg)
+	if !enabled {
+		return nil
+	}
+
+	f := filter.NewJunkFilter(heuristics...)
+	if n := options.Int(cfg, "junk_max_label_length"); n > 0 {
+		f.MaxLabelLength = n
+	}
+	if n := options.Int(cfg, "junk_min_hex_length"); n > 0 {
+		f.MinHexLength = n
+	}
+
+	return &junkStage{
+		filter:     f,
+		quarantine: systems.NewQuarantine(systems.QuarantinePath(cfg)),
+	}
+}
+
+// check returns true when the name was quarantined. The methods of a nil junkStage accept all names.
+func (j *junkStage) check(e *Enumeration, req *requests.DNSRequest, source string) bool {
+	if j == nil {
+		return false
+	}
+
+	domain := req.Domain
+	if d := e.Config.WhichDomain(req.Name); d != "" {
+		domain = d
+	}
+
+	reasons := j.filter.Score(req.Name, domain)
+	if len(reasons) == 0 {
+		return false
+	}
+
+	atomic.AddInt64(&j.count, 1)
+	if err := j.quarantine.Add(&systems.QuarantinedName{
+		Name:        req.Name,
+		Domain:      domain,
+		Source:      source,
+		Reasons:     reasons,
+		Quarantined: time.Now(),
+	}); err != nil {
+		e.Config.Log.Printf("Failed to quarantine %s: %v", req.Name, err)
+	}
+	return true
+}
+
+func (e *Enumeration) logQuarantined() {
+	if e.junk == nil {
+		return
+	}
+	if count := atomic.LoadInt64(&e.junk.count); count > 0 {
+		e.Config.Log.Printf("%d junk names were quarantined in %s", count, systems.QuarantinePath(e.Config))
+	}
+}
+
+// junkHeuristics returns the heuristics selected by the 'junk_heuristics' option.
+// All the heuristics are used when the option is not set, and none when it is false or 'none'.
+func junkHeuristics(cfg *config.Config) ([]string, bool) {
+	if cfg.Options["junk_heuristics"] == nil || options.Bool(cfg, "junk_heuristics") {
+		return nil, true
+	}
+
+	var results []string
+	for _, value := range options.Strings(cfg, "junk_heuristics") {
+		value = strings.ToLower(value)
+		if value == "none" || value == "false" {
+			return nil, false
+		}
+		results = append(results, value)
+	}
+	return results, len(results) > 0
+}
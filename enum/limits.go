@@ -0,0 +1,106 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// sourceSamplingThreshold is the number of results accepted from a data source for a domain
+// before the 'source_sampling' rate is applied to the remaining results.
+const sourceSamplingThreshold = 1000
+
+// sourceLimiter bounds the results accepted from a data source, so a misbehaving source
+// flooding the enumeration with junk names cannot dominate the database and the run time.
+// It is only used by the goroutine monitoring the output of the data source.
+type sourceLimiter struct {
+	enum      *Enumeration
+	source    string
+	max       int
+	total     int
+	perDomain int
+	rate      float64
+	counts    map[string]int
+	reported  map[string]struct{}
+}
+
+func newSourceLimiter(e *Enumeration, source string) *sourceLimiter {
+	rate := options.FloatValue(options.Source(e.Config, "source_sampling", source))
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+
+	return &sourceLimiter{
+		enum:      e,
+		source:    source,
+		max:       maxSourceResults(e.Config),
+		perDomain: options.IntValue(options.Source(e.Config, "source_domain_results", source)),
+		rate:      rate,
+		counts:    make(map[string]int),
+		reported:  make(map[string]struct{}),
+	}
+}
+
+// accept returns true when the result of the data source is within the limits of the configuration.
+func (l *sourceLimiter) accept(in interface{}) bool {
+	if l.max > 0 && l.total >= l.max {
+		l.report("", "reached the limit of "+strconv.Itoa(l.max)+" results for this enumeration")
+		return false
+	}
+
+	var value, domain string
+	switch v := in.(type) {
+	case *requests.DNSRequest:
+		value = strings.ToLower(strings.TrimSpace(v.Name))
+		if domain = l.enum.Config.WhichDomain(value); domain == "" {
+			domain = v.Domain
+		}
+	case *requests.AddrRequest:
+		value, domain = v.Address, v.Domain
+	}
+
+	if value != "" && domain != "" {
+		count := l.counts[domain]
+
+		if l.perDomain > 0 && count >= l.perDomain {
+			l.report(domain, "reached the limit of "+strconv.Itoa(l.perDomain)+" results for "+domain)
+			return false
+		}
+		if l.rate < 1 && count >= sourceSamplingThreshold && !sampled(value, l.rate) {
+			return false
+		}
+		l.counts[domain] = count + 1
+	}
+
+	l.total++
+	return true
+}
+
+func (l *sourceLimiter) report(domain, msg string) {
+	if _, found := l.reported[domain]; !found {
+		l.reported[domain] = struct{}{}
+		l.enum.Config.Log.Printf("%s: %s", l.source, msg)
+	}
+}
+
+// sampled selects the value using its hash, so the same names are kept by repeated enumerations.
+func sampled(value string, rate float64) bool {
+	h := fnv.New32a()
+
+	_, _ = h.Write([]byte(value))
+	return float64(h.Sum32()%10000) < rate*10000
+}
+
+// maxSourceResults returns the 'max_source_results' option, the maximum number of names
+// and addresses accepted from each data source. Zero means the results are not limited.
+func maxSourceResults(cfg *config.Config) int {
+	return options.Int(cfg, "max_source_results")
+}
@@ -0,0 +1,218 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/filter"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// LookalikeSource is the source of the findings produced from the newly registered domain feeds.
+	LookalikeSource = "NRDFeed"
+	// RelationImpersonation is the relation of the findings for registered domains impersonating a brand in scope.
+	RelationImpersonation = "impersonation"
+)
+
+// analyzeLookalikes matches the entries of the newly registered domain feeds in the 'nrd_feeds'
+// option against the brand tokens in scope, and stores a finding for each domain impersonating
+// a brand. The tokens are the labels of the registered domains in scope, unless provided by the
+// 'brand_tokens' option. The feeds are URLs or file paths listing one domain name on each line,
+// as provided by the zone file and NRD services, and can be compressed with gzip or zip.
+func (e *Enumeration) analyzeLookalikes(ctx context.Context) {
+	feeds := options.Strings(e.Config, "nrd_feeds")
+	if len(feeds) == 0 {
+		return
+	}
+
+	brands := e.brandTokens()
+	if len(brands) == 0 {
+		return
+	}
+
+	var tokens []string
+	for token := range brands {
+		tokens = append(tokens, token)
+	}
+
+	m := filter.NewLookalikeMatcher(tokens...)
+	if d := options.Int(e.Config, "lookalike_max_distance"); d > 0 {
+		m.MaxDistance = d
+	}
+
+	for _, feed := range feeds {
+		if feed = strings.TrimSpace(feed); feed == "" {
+			continue
+		}
+
+		data, err := readFeed(ctx, feed)
+		if err != nil {
+			e.Config.Log.Printf("Failed to obtain the newly registered domain feed %s: %v", feed, err)
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			name := feedEntry(scanner.Text())
+			if name == "" || e.Config.WhichDomain(name) != "" {
+				continue
+			}
+
+			if token, techniques := m.Match(name); token != "" {
+				e.addLookalikeFinding(name, brands[token], token, feed, techniques)
+			}
+		}
+	}
+}
+
+// brandTokens returns the brand tokens and the registered domains in scope they belong to.
+func (e *Enumeration) brandTokens() map[string]string {
+	brands := make(map[string]string)
+
+	for _, d := range e.Config.Domains() {
+		reg, err := publicsuffix.EffectiveTLDPlusOne(d)
+		if err != nil {
+			continue
+		}
+
+		label, _, _ := strings.Cut(reg, ".")
+		if _, found := brands[label]; !found {
+			brands[label] = reg
+		}
+	}
+
+	if custom := options.Strings(e.Config, "brand_tokens"); len(custom) > 0 {
+		domains := brands
+		brands = make(map[string]string)
+
+		for _, token := range custom {
+			if token = strings.ToLower(strings.TrimSpace(token)); token != "" {
+				brands[token] = domains[token]
+			}
+		}
+	}
+	return brands
+}
+
+// addLookalikeFinding considers the homographs and the brand registered under other public
+// suffixes to be of high severity, since these are indistinguishable from the brand by users.
+func (e *Enumeration) addLookalikeFinding(name, domain, token, feed string, techniques []string) {
+	severity := "medium"
+	for _, t := range techniques {
+		if t == filter.Homograph || t == filter.TLDSwap {
+			severity = "high"
+		}
+	}
+
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     "Domain",
+		Value:    name,
+		Domain:   domain,
+		Relation: RelationImpersonation,
+		Source:   LookalikeSource,
+		Properties: map[string]string{
+			"severity":   severity,
+			"token":      token,
+			"techniques": strings.Join(techniques, ","),
+			"feed":       feed,
+		},
+	})
+}
+
+// readFeed returns the decompressed content of the feed at the URL or file path.
+func readFeed(ctx context.Context, feed string) ([]byte, error) {
+	var data []byte
+
+	if strings.HasPrefix(feed, "http://") || strings.HasPrefix(feed, "https://") {
+		resp, err := amasshttp.RequestWebPage(ctx, &amasshttp.Request{URL: feed})
+		if err != nil {
+			return nil, err
+		} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return nil, errors.New(resp.Status)
+		}
+		data = []byte(resp.Body)
+	} else {
+		var err error
+
+		data, err = os.ReadFile(feed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return io.ReadAll(r)
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		var all []byte
+		for _, f := range z.File {
+			r, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, content...)
+			all = append(all, '\n')
+		}
+		return all, nil
+	}
+	return data, nil
+}
+
+// feedEntry returns the domain name in the first field of the feed line, ignoring comments.
+func feedEntry(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\t' || r == ' '
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+
+	name := strings.ToLower(strings.Trim(fields[0], "\"."))
+	if !strings.Contains(name, ".") {
+		return ""
+	}
+	return name
+}
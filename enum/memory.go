@@ -0,0 +1,219 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+const memoryCheckInterval = 5 * time.Second
+
+// The memory pressure levels, as percentages of the 'memory_limit' option
+const (
+	memoryRecoveryPercent = 70
+	memoryHighPercent     = 80
+	memoryCriticalPercent = 95
+)
+
+type memoryLevel int
+
+const (
+	memoryNormal memoryLevel = iota
+	memoryHigh
+	memoryCritical
+)
+
+func (l memoryLevel) String() string {
+	switch l {
+	case memoryHigh:
+		return "high"
+	case memoryCritical:
+		return "critical"
+	}
+	return "normal"
+}
+
+// memoryWatchdog monitors the heap of the process and degrades the enumeration as the usage
+// approaches the limit, so a long enumeration slows down instead of being ended by the OOM killer.
+// At the high level, brute forcing, alterations and name guessing are paused and the data sources
+// use a single script instance. At the critical level, the request deduplication cache is flushed,
+// the name filters are written to disk and fewer names are released into the pipeline. The
+// enumeration recovers as the usage drops.
+type memoryWatchdog struct {
+	sync.Mutex
+	enum      *Enumeration
+	limit     uint64
+	prevLimit int64
+	level     memoryLevel
+	instances map[string]int
+	resume    chan struct{}
+	done      chan struct{}
+}
+
+// scalableSource is implemented by the data sources that handle requests with several instances.
+type scalableSource interface {
+	Instances() int
+	SetInstances(num int)
+}
+
+func newMemoryWatchdog(e *Enumeration, limit uint64) *memoryWatchdog {
+	m := &memoryWatchdog{
+		enum:      e,
+		limit:     limit,
+		prevLimit: -1,
+		instances: make(map[string]int),
+		resume:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	return m
+}
+
+// start begins monitoring the memory usage once the enumeration components are in place.
+func (m *memoryWatchdog) start() {
+	if m.limit > 0 {
+		// Have the garbage collector work harder before the watchdog needs to intervene
+		m.prevLimit = debug.SetMemoryLimit(int64(m.limit))
+		go m.monitor()
+	}
+}
+
+// stop ends the monitoring and restores the memory limit the process had before the enumeration.
+func (m *memoryWatchdog) stop() {
+	close(m.done)
+	// A negative limit leaves the limit unchanged when the monitoring was never started
+	debug.SetMemoryLimit(m.prevLimit)
+}
+
+// setInstances changes the number of instances of the data source, or the number restored once the
+// memory usage recovers, while the data sources are limited to a single instance.
+func (m *memoryWatchdog) setInstances(name string, s scalableSource, num int) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.level >= memoryHigh {
+		m.instances[name] = num
+		return
+	}
+	s.SetInstances(num)
+}
+
+// pressure returns the current memory pressure level.
+func (m *memoryWatchdog) pressure() memoryLevel {
+	if m == nil {
+		return memoryNormal
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	return m.level
+}
+
+// paused returns true when requests for the data source are held back due to memory pressure.
+func (m *memoryWatchdog) paused(description string) bool {
+	return m.pressure() >= memoryHigh && (description == "brute" || description == "alt" || description == "guess")
+}
+
+// resumed returns the channel signaled when the held back requests can be sent again.
+func (m *memoryWatchdog) resumed() <-chan struct{} {
+	return m.resume
+}
+
+func (m *memoryWatchdog) monitor() {
+	t := time.NewTicker(memoryCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.enum.ctx.Done():
+			return
+		case <-t.C:
+			var stats runtime.MemStats
+
+			runtime.ReadMemStats(&stats)
+			m.check(stats.HeapAlloc)
+		}
+	}
+}
+
+func (m *memoryWatchdog) check(used uint64) {
+	percent := used * 100 / m.limit
+
+	m.Lock()
+	prev := m.level
+	level := prev
+	switch {
+	case percent >= memoryCriticalPercent:
+		level = memoryCritical
+	case percent >= memoryHighPercent:
+		if prev < memoryHigh {
+			level = memoryHigh
+		}
+	case percent < memoryRecoveryPercent:
+		level = memoryNormal
+	}
+	m.level = level
+	m.Unlock()
+
+	if level == prev {
+		if level == memoryCritical {
+			debug.FreeOSMemory()
+		}
+		return
+	}
+
+	m.enum.Config.Log.Printf("Memory usage of %d MB is %d%% of the limit, the pressure level changed from %s to %s",
+		used/(1<<20), percent, prev, level)
+	m.apply(prev, level)
+}
+
+func (m *memoryWatchdog) apply(prev, level memoryLevel) {
+	e := m.enum
+
+	if level >= memoryHigh && prev < memoryHigh {
+		for _, src := range e.srcs {
+			if s, ok := src.(scalableSource); ok {
+				m.instances[src.String()] = s.Instances()
+				s.SetInstances(1)
+			}
+		}
+	} else if level == memoryNormal {
+		for _, src := range e.srcs {
+			if s, ok := src.(scalableSource); ok && m.instances[src.String()] > 1 {
+				s.SetInstances(m.instances[src.String()])
+			}
+		}
+		select {
+		case m.resume <- struct{}{}:
+		default:
+		}
+	}
+
+	if level == memoryCritical {
+		e.dedup.flush()
+		e.nameSrc.throttle(true)
+		// Move the names already seen out of memory
+		_ = e.nameSrc.filter.Spill()
+		_ = e.store.filter.Spill()
+		debug.FreeOSMemory()
+	} else if prev == memoryCritical {
+		e.nameSrc.throttle(false)
+	}
+}
+
+// memoryLimit returns the 'memory_limit' option in bytes. The option is a number of bytes
+// or a size such as '4GB' or '512MB'. Zero means the memory usage is not monitored.
+func memoryLimit(cfg *config.Config) uint64 {
+	return uint64(options.Size(cfg, "memory_limit"))
+}
@@ -0,0 +1,34 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"runtime/debug"
+	"testing"
+)
+
+func TestMemoryLimitRestored(t *testing.T) {
+	prev := debug.SetMemoryLimit(-1)
+	defer debug.SetMemoryLimit(prev)
+
+	e := &Enumeration{ctx: context.Background()}
+	m := newMemoryWatchdog(e, 1<<30)
+	m.start()
+	if limit := debug.SetMemoryLimit(-1); limit != 1<<30 {
+		t.Errorf("Expected the memory limit to be set while monitoring, got %d", limit)
+	}
+
+	m.stop()
+	if limit := debug.SetMemoryLimit(-1); limit != prev {
+		t.Errorf("Expected the memory limit %d to be restored, got %d", prev, limit)
+	}
+
+	// Stopping a watchdog that was never started leaves the limit unchanged
+	newMemoryWatchdog(e, 1<<30).stop()
+	if limit := debug.SetMemoryLimit(-1); limit != prev {
+		t.Errorf("Expected the memory limit %d to remain, got %d", prev, limit)
+	}
+}
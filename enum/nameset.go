@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"github.com/owasp-amass/amass/v4/filter"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+// nameSet filters the names and addresses already seen in high-volume paths of the enumeration,
+// such as the brute forcing candidates and the names scraped by the data sources.
+type nameSet interface {
+	// TestAndAdd returns true when the value was already added to the set
+	TestAndAdd(value string) bool
+	// Spill moves the values held in memory to disk when the set supports it
+	Spill() error
+	Close() error
+}
+
+// newNameSet returns a set keeping most of the values on disk within the output directory, so
+// enumerations producing tens of millions of candidates stay within a few GB of memory. The
+// 'dedup_memory_entries' option sets the number of values held in memory before being written.
+// A stable bloom filter is used when the output directory cannot be written.
+func newNameSet(e *Enumeration) nameSet {
+	s, err := filter.NewDiskSet(config.OutputDirectory(e.Config.Dir), options.Int(e.Config, "dedup_memory_entries"))
+	if err != nil {
+		e.Config.Log.Printf("Failed to create the disk-backed name filter: %v", err)
+		return &bloomSet{filter: bf.NewDefaultStableBloomFilter(1000000, 0.01)}
+	}
+	return s
+}
+
+// bloomSet is the in-memory nameSet, which can evict values and accept them more than once.
+type bloomSet struct {
+	filter *bf.StableBloomFilter
+}
+
+func (b *bloomSet) TestAndAdd(value string) bool {
+	return b.filter.TestAndAdd([]byte(value))
+}
+
+func (b *bloomSet) Spill() error { return nil }
+
+func (b *bloomSet) Close() error {
+	b.filter.Reset()
+	return nil
+}
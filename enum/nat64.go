@@ -0,0 +1,121 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// NAT64Source is the source of the findings for the AAAA records synthesized by DNS64 resolvers.
+	NAT64Source = "NAT64"
+	// RelationSynthesized is the relation of the IPv6 addresses synthesized from the IPv4 address of a name.
+	RelationSynthesized = "dns64_synthesized"
+)
+
+// detectNAT64 obtains the prefixes used by the DNS64 resolvers of the vantage point to synthesize
+// AAAA records, so the synthesized addresses are not stored as addresses of the target. The reserved
+// prefixes and the prefixes of the 'nat64_prefixes' option are always considered.
+func (e *Enumeration) detectNAT64(ctx context.Context) {
+	e.nat64 = []netip.Prefix{amassdns.WellKnownNAT64Prefix, amassdns.LocalNAT64Prefix}
+
+	for _, s := range options.Strings(e.Config, "nat64_prefixes") {
+		p, err := netip.ParsePrefix(strings.TrimSpace(s))
+		if err != nil || !p.Addr().Is6() {
+			e.Config.Log.Printf("The NAT64 prefix %s is not a valid IPv6 prefix", s)
+			continue
+		}
+		e.nat64 = append(e.nat64, p.Masked())
+	}
+	// The local datasets are not answered by a DNS64 resolver
+	if e.offline != nil {
+		return
+	}
+
+	resp, err := e.dnsQuery(ctx, amassdns.IPv4OnlyName, dns.TypeAAAA, e.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+	if err != nil {
+		return
+	}
+
+	var answers []netip.Addr
+	for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeAAAA) {
+		if addr, err := netip.ParseAddr(rr.Data); err == nil {
+			answers = append(answers, addr)
+		}
+	}
+	for _, p := range amassdns.NAT64Prefixes(answers) {
+		e.Config.Log.Printf("DNS64 resolvers detected, the AAAA records synthesized with the prefix %s are tagged", p)
+		if !containsNAT64Prefix(e.nat64, p) {
+			e.nat64 = append(e.nat64, p)
+		}
+	}
+}
+
+func containsNAT64Prefix(prefixes []netip.Prefix, p netip.Prefix) bool {
+	for _, cur := range prefixes {
+		if cur == p {
+			return true
+		}
+	}
+	return false
+}
+
+// synthesizedAddr returns the IPv4 address embedded in the address, when a DNS64 resolver synthesized it.
+func (e *Enumeration) synthesizedAddr(addr string) (netip.Addr, netip.Prefix, bool) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, false
+	}
+	return amassdns.SynthesizedIPv4(ip, e.nat64)
+}
+
+// translateNAT64 checks the 'dns64_translate' option of the configuration.
+func translateNAT64(e *Enumeration) bool {
+	return options.Bool(e.Config, "dns64_translate")
+}
+
+// insertSynthesized tags the AAAA record synthesized by a DNS64 resolver, in place of storing the
+// address. When the 'dns64_translate' option is enabled, the embedded IPv4 address is stored instead.
+func (dm *dataManager) insertSynthesized(ctx context.Context, req *requests.DNSRequest, addr string, v4 netip.Addr, prefix netip.Prefix) error {
+	translate := translateNAT64(dm.enum)
+
+	dm.enum.Sys.Findings().Add(&systems.Finding{
+		Type:     "IPAddress",
+		Value:    addr,
+		Domain:   dm.enum.Config.WhichDomain(req.Name),
+		Relation: RelationSynthesized,
+		Source:   NAT64Source,
+		Properties: map[string]string{
+			"name":       req.Name,
+			"ipv4":       v4.String(),
+			"prefix":     prefix.String(),
+			"translated": strconv.FormatBool(translate),
+		},
+	})
+	if !translate {
+		return nil
+	}
+
+	dm.enum.nameSrc.newAddr(&requests.AddrRequest{
+		Address: v4.String(),
+		InScope: true,
+		Domain:  req.Domain,
+	})
+	if err := dm.enum.graph.UpsertA(ctx, req.Name, v4.String()); err != nil {
+		return fmt.Errorf("failed to insert A record: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+// The number of queries answered by the local datasets that can be outstanding at once
+const offlineQueries = 1000
+
+// newOfflineDataset returns nil unless the 'offline' option is enabled. The records in scope
+// are imported from the zone files and passive DNS dumps in the 'offline_datasets' option,
+// and the network egress must already be disabled, so no queries can leave the host.
+func newOfflineDataset(e *Enumeration) (*amassdns.Dataset, error) {
+	if !systems.Offline(e.Config) {
+		return nil, nil
+	}
+	if !amassnet.EgressDisabled() {
+		return nil, errors.New("the network egress must be disabled in offline mode")
+	}
+
+	ds := amassdns.NewDataset(func(name string) bool {
+		return e.Config.WhichDomain(name) != ""
+	})
+	for _, path := range options.Strings(e.Config, "offline_datasets") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		num, err := ds.LoadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import the dataset %s: %v", path, err)
+		}
+		e.Config.Log.Printf("Imported %d records in scope from the dataset %s", num, path)
+	}
+
+	// The DNS responses recorded by previous enumerations are replayed along with the cached HTTP responses
+	if path := systems.DNSCachePath(e.Config); pathExists(path) {
+		num, err := ds.LoadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import the recorded DNS responses %s: %v", path, err)
+		}
+		e.Config.Log.Printf("Imported %d records in scope from the recorded DNS responses", num)
+	}
+
+	if ds.Len() == 0 {
+		e.Config.Log.Print("The offline datasets provided no records in scope")
+	}
+	return ds, nil
+}
+
+// newDNSRecorder returns nil unless the 'dns_cache' option is enabled. The records of the DNS responses
+// are then kept in the output directory, so the enumeration can be replayed in offline mode.
+func newDNSRecorder(e *Enumeration) *amassdns.Recorder {
+	if e.offline != nil {
+		return nil
+	}
+
+	if !options.Bool(e.Config, "dns_cache") {
+		return nil
+	}
+
+	r, err := amassdns.NewRecorder(systems.DNSCachePath(e.Config))
+	if err != nil {
+		e.Config.Log.Printf("Failed to record the DNS responses: %v", err)
+		return nil
+	}
+	return r
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// recordResponse keeps the records of the DNS response when the responses are recorded.
+func (e *Enumeration) recordResponse(resp *dns.Msg) {
+	if e.recorder != nil {
+		e.recorder.Record(resp)
+	}
+}
+
+func (e *Enumeration) stopRecording() {
+	if e.recorder != nil {
+		if err := e.recorder.Close(); err != nil {
+			e.Config.Log.Printf("Failed to write the recorded DNS responses: %v", err)
+		}
+	}
+}
+
+// submitDatasetNames enters the names in scope owning records in the offline datasets.
+func (e *Enumeration) submitDatasetNames() {
+	if e.offline == nil {
+		return
+	}
+
+	for _, name := range e.offline.Names() {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		if domain := e.Config.WhichDomain(name); domain != "" {
+			e.nameSrc.newName(&requests.DNSRequest{
+				Name:   name,
+				Domain: domain,
+			})
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+)
+
+// defaultExpiryWarningDays is the number of days before the expiration of a registered domain that it is reported.
+const defaultExpiryWarningDays = 30
+
+// checkRegistrations applies the monitoring rules to the DomainRecord findings of the registered
+// domain names in scope, which hold the expiration dates and status codes obtained from RDAP.
+// The results are kept as properties of each record, so they are current after every enumeration:
+// 'expiring' is true within the 'expiry_warning_days' option, 'transfer_unlocked' is true when
+// no transfer prohibition is among the status codes, and 'severity' reflects the results.
+func (e *Enumeration) checkRegistrations() {
+	warning := defaultExpiryWarningDays
+	if days := options.Int(e.Config, "expiry_warning_days"); days > 0 {
+		warning = days
+	}
+	requireLock := true
+	if v, found := e.Config.Options["transfer_lock_required"]; found {
+		requireLock = options.BoolValue(v)
+	}
+
+	for _, f := range e.Sys.Findings().Find(e.Config.CollectionStartTime, "DomainRecord") {
+		if e.Config.WhichDomain(f.Value) == "" {
+			continue
+		}
+
+		props := map[string]string{
+			"expiring":          "false",
+			"transfer_unlocked": "false",
+			"severity":          "none",
+		}
+
+		if exp, err := time.Parse(time.RFC3339, f.Properties["expiration"]); err == nil {
+			days := int(time.Until(exp).Hours() / 24)
+
+			props["days_to_expiration"] = strconv.Itoa(days)
+			if days <= warning {
+				props["expiring"] = "true"
+				props["severity"] = "medium"
+				if days <= 7 {
+					props["severity"] = "high"
+				}
+			}
+		}
+
+		if status, found := f.Properties["status"]; found && requireLock && !strings.Contains(status, "transfer prohibited") {
+			props["transfer_unlocked"] = "true"
+			if props["severity"] == "none" {
+				props["severity"] = "medium"
+			}
+		}
+
+		f.Properties = props
+		e.Sys.Findings().Add(f)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	// RPKISource is the source of the findings holding the route origin validation states.
+	RPKISource = "RPKI"
+	// RelationAnnouncedBy links a netblock to the in-scope ASN originating it.
+	RelationAnnouncedBy = "announced_by"
+)
+
+// rpkiConfidence is the confidence that a netblock belongs to the ASN announcing it, for each validation state.
+var rpkiConfidence = map[string]float64{
+	amassnet.RPKIValid:    1.0,
+	amassnet.RPKINotFound: 0.6,
+	amassnet.RPKIInvalid:  0.2,
+}
+
+// validateOriginations checks the netblocks announced by the ASNs in scope against the validated ROA payloads
+// exported by the relying party software in the 'rpki_roas' option. Each netblock is kept as a Netblock
+// finding with the 'rpki' property holding the validation state, and the 'confidence' property lowered
+// for the originations not found or invalid, which are also given a 'severity' for the reports.
+// The validation is only performed when the option is provided.
+func (e *Enumeration) validateOriginations(ctx context.Context) {
+	if len(e.Config.Scope.ASNs) == 0 {
+		return
+	}
+
+	exports := options.Strings(e.Config, "rpki_roas")
+	if len(exports) == 0 {
+		return
+	}
+
+	roas := amassnet.NewROASet()
+	for _, export := range exports {
+		data, err := readFeed(ctx, export)
+		if err != nil {
+			e.Config.Log.Printf("Failed to obtain the validated ROA payloads %s: %v", export, err)
+			continue
+		}
+		if _, err := roas.LoadJSON(bytes.NewReader(data)); err != nil {
+			e.Config.Log.Printf("Failed to parse the validated ROA payloads %s: %v", export, err)
+		}
+	}
+	// Without ROAs, every origination would be reported as not found
+	if roas.Len() == 0 {
+		return
+	}
+
+	for _, asn := range e.Config.Scope.ASNs {
+		rec := e.Sys.Cache().ASNSearch(asn)
+		if rec == nil {
+			continue
+		}
+
+		for _, netblock := range announcedNetblocks(rec) {
+			state, reason := roas.Validate(asn, netblock)
+
+			props := map[string]string{
+				"asn":        strconv.Itoa(asn),
+				"rpki":       state,
+				"reason":     reason,
+				"confidence": strconv.FormatFloat(rpkiConfidence[state], 'f', 1, 64),
+				"severity":   "none",
+			}
+			switch state {
+			case amassnet.RPKIInvalid:
+				props["severity"] = "high"
+			case amassnet.RPKINotFound:
+				props["severity"] = "low"
+			}
+
+			e.Sys.Findings().Add(&systems.Finding{
+				Type:       "Netblock",
+				Value:      netblock,
+				Relation:   RelationAnnouncedBy,
+				Source:     RPKISource,
+				Properties: props,
+			})
+		}
+	}
+}
+
+// announcedNetblocks returns the distinct netblocks of the ASN in canonical form.
+func announcedNetblocks(rec *requests.ASNRequest) []string {
+	seen := make(map[string]struct{})
+
+	var netblocks []string
+	for _, cidr := range append([]string{rec.Prefix}, rec.Netblocks...) {
+		_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			continue
+		}
+
+		netblock := ipnet.String()
+		if _, found := seen[netblock]; !found {
+			seen[netblock] = struct{}{}
+			netblocks = append(netblocks, netblock)
+		}
+	}
+	return netblocks
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,18 +20,23 @@ import (
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/resolve"
-	bf "github.com/tylertreat/BoomFilters"
 	"golang.org/x/net/publicsuffix"
 )
 
+// addrRules is implemented by the services applying the user-defined rules to the addresses stored.
+type addrRules interface {
+	ApplyToAddr(addr, domain string)
+}
+
 // dataManager is the stage that stores all data processed by the pipeline.
 type dataManager struct {
 	enum        *Enumeration
 	queue       queue.Queue
 	signalDone  chan struct{}
 	confirmDone chan struct{}
-	filter      *bf.StableBloomFilter
+	filter      nameSet
 }
 
 // newDataManager returns a dataManager specific to the provided Enumeration.
@@ -40,7 +46,7 @@ func newDataManager(e *Enumeration) *dataManager {
 		queue:       queue.NewQueue(),
 		signalDone:  make(chan struct{}, 2),
 		confirmDone: make(chan struct{}, 2),
-		filter:      bf.NewDefaultStableBloomFilter(1000000, 0.01),
+		filter:      newNameSet(e),
 	}
 
 	go dm.processASNRequests()
@@ -48,7 +54,7 @@ func newDataManager(e *Enumeration) *dataManager {
 }
 
 func (dm *dataManager) Stop() chan struct{} {
-	dm.filter.Reset()
+	_ = dm.filter.Close()
 	close(dm.signalDone)
 	return dm.confirmDone
 }
@@ -62,6 +68,7 @@ func (dm *dataManager) Process(ctx context.Context, data pipeline.Data, tp pipel
 	}
 
 	var id string
+	var inScope bool
 	switch v := data.(type) {
 	case *requests.DNSRequest:
 		if v == nil {
@@ -69,23 +76,31 @@ func (dm *dataManager) Process(ctx context.Context, data pipeline.Data, tp pipel
 		}
 
 		id = v.Name
+		inScope = dm.enum.Config.WhichDomain(v.Name) != ""
 		if err := dm.dnsRequest(ctx, v, tp); err != nil {
 			dm.enum.Config.Log.Print(err.Error())
+		} else if inScope {
+			dm.enum.coverage.confirm("fqdn", v.Name)
 		}
 	case *requests.AddrRequest:
 		if v == nil {
 			return nil, nil
 		}
 
-		id = v.Address
+		id, inScope = v.Address, v.InScope
 		if err := dm.addrRequest(ctx, v, tp); err != nil {
 			dm.enum.Config.Log.Print(err.Error())
+		} else if inScope {
+			dm.enum.coverage.confirm("address", v.Address)
 		}
 	}
 
-	if id != "" && dm.filter.TestAndAdd([]byte(id)) {
+	if id != "" && dm.filter.TestAndAdd(id) {
 		return nil, nil
 	}
+	if inScope {
+		dm.enum.completion.discovered()
+	}
 	return data, nil
 }
 
@@ -97,6 +112,10 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 	for i, r := range req.Records {
 		req.Records[i].Name = strings.Trim(strings.ToLower(r.Name), ".")
 		req.Records[i].Data = strings.Trim(strings.ToLower(r.Data), ".")
+		// The ECH configurations in service bindings are case-sensitive
+		if t := uint16(r.Type); t == dns.TypeHTTPS || t == dns.TypeSVCB {
+			req.Records[i].Data = strings.TrimSpace(r.Data)
+		}
 
 		if uint16(r.Type) == dns.TypeCNAME {
 			// Do not enter more than the CNAME record
@@ -132,6 +151,8 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 			e = dm.insertSOA(ctx, req, i, tp)
 		case dns.TypeSPF:
 			e = dm.insertSPF(ctx, req, i, tp)
+		case dns.TypeCAA, dns.TypeNAPTR, dns.TypeHTTPS, dns.TypeSVCB:
+			e = dm.insertRecord(ctx, req, i, tp)
 		}
 		if err == nil {
 			err = e
@@ -150,11 +171,14 @@ func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest
 	if err != nil || domain == "" {
 		return errors.New("failed to extract a domain name from the FQDN")
 	}
-	// Important - Allows chained CNAME records to be resolved until an A/AAAA record
-	dm.enum.nameSrc.newName(&requests.DNSRequest{
-		Name:   target,
-		Domain: strings.ToLower(domain),
-	})
+	// Important - Allows chained CNAME records to be resolved until an A/AAAA record,
+	// except for the targets of third-party providers, which are only recorded
+	if !dm.enum.thirdPartyTarget(req.Name, target) {
+		dm.enum.nameSrc.newName(&requests.DNSRequest{
+			Name:   target,
+			Domain: strings.ToLower(domain),
+		})
+	}
 	if err := dm.enum.graph.UpsertCNAME(ctx, req.Name, target); err != nil {
 		return fmt.Errorf("failed to insert CNAME: %v", err)
 	}
@@ -183,6 +207,10 @@ func (dm *dataManager) insertAAAA(ctx context.Context, req *requests.DNSRequest,
 	if addr == "" {
 		return errors.New("failed to extract an IP address from the DNS answer data")
 	}
+	// The addresses synthesized by DNS64 resolvers do not belong to the target
+	if v4, prefix, ok := dm.enum.synthesizedAddr(addr); ok {
+		return dm.insertSynthesized(ctx, req, addr, v4, prefix)
+	}
 	dm.enum.checkForMissedWildcards(addr)
 	dm.enum.nameSrc.newAddr(&requests.AddrRequest{
 		Address: addr,
@@ -281,10 +309,108 @@ func (dm *dataManager) insertMX(ctx context.Context, req *requests.DNSRequest, r
 func (dm *dataManager) insertTXT(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	if dm.enum.Config.IsDomainInScope(req.Name) {
 		dm.findNamesAndAddresses(ctx, req.Records[recidx].Data, req.Domain, tp)
+		dm.storeRecord(req, recidx)
 	}
 	return nil
 }
 
+// insertRecord handles the record types that the graph database has no relations for.
+func (dm *dataManager) insertRecord(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
+	if !dm.enum.Config.IsDomainInScope(req.Name) {
+		return nil
+	}
+
+	rr := req.Records[recidx]
+	if target := amassdns.RecordTarget(uint16(rr.Type), rr.Data); target != "" {
+		if domain := dm.enum.Config.WhichDomain(target); domain != "" {
+			dm.enum.nameSrc.newName(&requests.DNSRequest{
+				Name:   target,
+				Domain: domain,
+			})
+		}
+	}
+	// The address hints of the HTTPS and SVCB records are found here
+	dm.findNamesAndAddresses(ctx, rr.Data, req.Domain, tp)
+	dm.storeRecord(req, recidx)
+	if t := uint16(rr.Type); t == dns.TypeHTTPS || t == dns.TypeSVCB {
+		dm.serviceBinding(req, recidx)
+	}
+	return nil
+}
+
+// serviceBinding stores the service advertised by the HTTPS or SVCB record, including the alternative
+// port and ALPNs, and the client-facing names that front the origin when ECH is offered.
+func (dm *dataManager) serviceBinding(req *requests.DNSRequest, recidx int) {
+	rr := req.Records[recidx]
+
+	sb, err := amassdns.ParseServiceBinding(uint16(rr.Type), rr.Data)
+	// The alias mode records only provide the target name
+	if err != nil || sb.Priority == 0 {
+		return
+	}
+
+	host := req.Name
+	if sb.Target != "" {
+		host = sb.Target
+	}
+	port := 443
+	if sb.Port != 0 {
+		port = sb.Port
+	}
+
+	props := map[string]string{
+		"name":     req.Name,
+		"port":     strconv.Itoa(port),
+		"priority": strconv.Itoa(sb.Priority),
+	}
+	if len(sb.ALPN) > 0 {
+		props["alpn"] = strings.Join(sb.ALPN, ",")
+	}
+	if sb.ECH {
+		props["ech"] = "true"
+	}
+	if len(sb.ECHPublicNames) > 0 {
+		props["ech_public_name"] = strings.Join(sb.ECHPublicNames, ",")
+	}
+
+	dm.enum.Sys.Findings().Add(&systems.Finding{
+		Type:       "Service",
+		Value:      net.JoinHostPort(host, strconv.Itoa(port)),
+		Domain:     req.Domain,
+		Relation:   "service_binding",
+		Source:     dnsBandwidthSource,
+		Properties: props,
+	})
+	// The client-facing names in scope are brought into the enumeration
+	for _, public := range sb.ECHPublicNames {
+		if domain := dm.enum.Config.WhichDomain(public); domain != "" {
+			dm.enum.nameSrc.newName(&requests.DNSRequest{
+				Name:   public,
+				Domain: domain,
+			})
+		}
+	}
+}
+
+// storeRecord keeps the record as a finding, since the graph database cannot hold it.
+func (dm *dataManager) storeRecord(req *requests.DNSRequest, recidx int) {
+	rr := req.Records[recidx]
+	rrtype := dns.TypeToString[uint16(rr.Type)]
+
+	dm.enum.Sys.Findings().Add(&systems.Finding{
+		Type:     "DNSRecord",
+		Value:    req.Name + " " + rrtype + " " + rr.Data,
+		Domain:   req.Domain,
+		Relation: strings.ToLower(rrtype) + "_record",
+		Source:   dnsBandwidthSource,
+		Properties: map[string]string{
+			"name": req.Name,
+			"type": rrtype,
+			"data": rr.Data,
+		},
+	})
+}
+
 func (dm *dataManager) insertSOA(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	if dm.enum.Config.IsDomainInScope(req.Name) {
 		dm.findNamesAndAddresses(ctx, req.Records[recidx].Data, req.Domain, tp)
@@ -329,6 +455,11 @@ func (dm *dataManager) addrRequest(ctx context.Context, req *requests.AddrReques
 	if req == nil || !req.InScope {
 		return nil
 	}
+	for _, src := range dm.enum.srcs {
+		if r, ok := src.(addrRules); ok {
+			r.ApplyToAddr(req.Address, req.Domain)
+		}
+	}
 	if yes, prefix := amassnet.IsReservedAddress(req.Address); yes {
 		var err error
 		if e := dm.enum.graph.UpsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
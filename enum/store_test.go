@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/queue"
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestAddressRules(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Log = log.New(io.Discard, "", 0)
+	cfg.Options["rules"] = []interface{}{
+		map[string]interface{}{
+			"type":     "address",
+			"netblock": "192.0.2.0/24",
+			"tags":     map[string]interface{}{"region": "eu"},
+		},
+	}
+
+	finds, err := systems.NewFindingStore("")
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+	sys := &systems.SimpleSystem{
+		Cfg:      cfg,
+		ASNCache: requests.NewASNCache(),
+		Finds:    finds,
+	}
+
+	rules := datasrcs.NewRules(sys)
+	if rules == nil {
+		t.Fatal("Failed to create the rules service")
+	}
+	graph := netmap.NewGraph("memory", "", "")
+	defer graph.Remove()
+
+	e := &Enumeration{
+		Config: cfg,
+		Sys:    sys,
+		graph:  graph,
+		srcs:   []service.Service{rules},
+	}
+	dm := &dataManager{
+		enum:  e,
+		queue: queue.NewQueue(),
+	}
+
+	for _, addr := range []string{"192.0.2.10", "198.51.100.10"} {
+		req := &requests.AddrRequest{
+			Address: addr,
+			Domain:  "owasp.org",
+			InScope: true,
+		}
+		if err := dm.addrRequest(context.Background(), req, nil); err != nil {
+			t.Errorf("Failed to store the address %s: %v", addr, err)
+		}
+	}
+
+	f := finds.Get("IPAddress", "192.0.2.10", "owasp.org")
+	if f == nil {
+		t.Fatal("Expected the address within the netblock to be tagged")
+	}
+	if f.Relation != "tagged" || f.Properties["region"] != "eu" {
+		t.Errorf("Unexpected finding for the address within the netblock: %+v", f)
+	}
+	if f := finds.Get("IPAddress", "198.51.100.10", "owasp.org"); f != nil {
+		t.Errorf("Expected the address outside the netblock not to be tagged: %+v", f)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// ThirdPartySource is the source of the findings for the aliases of names in scope to third-party services.
+	ThirdPartySource = "ThirdParty"
+	// RelationThirdPartyCNAME is the relation of the CNAME targets recorded without being resolved.
+	RelationThirdPartyCNAME = "third_party_cname"
+)
+
+// The CNAME target suffixes of the SaaS, hosting and content delivery providers
+var defaultThirdPartyCNAMEs = []string{
+	"cloudfront.net",
+	"elb.amazonaws.com",
+	"s3.amazonaws.com",
+	"s3-website.amazonaws.com",
+	"awsglobalaccelerator.com",
+	"azurewebsites.net",
+	"azureedge.net",
+	"azurefd.net",
+	"cloudapp.azure.com",
+	"cloudapp.net",
+	"trafficmanager.net",
+	"blob.core.windows.net",
+	"appspot.com",
+	"ghs.googlehosted.com",
+	"googleusercontent.com",
+	"akamaiedge.net",
+	"akamaized.net",
+	"edgekey.net",
+	"edgesuite.net",
+	"fastly.net",
+	"fastlylb.net",
+	"cdn.cloudflare.net",
+	"github.io",
+	"herokuapp.com",
+	"herokudns.com",
+	"netlify.app",
+	"vercel-dns.com",
+	"myshopify.com",
+	"zendesk.com",
+	"hubspot.net",
+	"wpengine.com",
+	"pantheonsite.io",
+	"ghost.io",
+	"helpscoutdocs.com",
+	"statuspage.io",
+	"unbouncepages.com",
+}
+
+// thirdPartyCNAMEs holds the suffixes of the CNAME targets that are recorded, but neither resolved nor
+// probed, so the enumeration does not send activity to the providers behind the aliases.
+type thirdPartyCNAMEs struct {
+	suffixes []string
+}
+
+// newThirdPartyCNAMEs returns the curated suffixes extended by the 'third_party_cnames' option,
+// where the leading asterisk is optional, such as '*.example-saas.com'.
+func newThirdPartyCNAMEs(cfg *config.Config) *thirdPartyCNAMEs {
+	t := &thirdPartyCNAMEs{}
+
+	entries := append([]string{}, defaultThirdPartyCNAMEs...)
+	entries = append(entries, options.Strings(cfg, "third_party_cnames")...)
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		entry = strings.Trim(strings.TrimPrefix(entry, "*"), ".")
+		if entry != "" {
+			t.suffixes = append(t.suffixes, entry)
+		}
+	}
+	return t
+}
+
+// match returns the suffix of the third-party provider hosting the name, or an empty string.
+func (t *thirdPartyCNAMEs) match(name string) string {
+	if t == nil {
+		return ""
+	}
+
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, suffix := range t.suffixes {
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// thirdPartyTarget returns true when the CNAME target is hosted by a third-party provider and not in scope,
+// and keeps the alias as a finding, since the target will not be resolved by the enumeration.
+func (e *Enumeration) thirdPartyTarget(alias, target string) bool {
+	if e.Config.WhichDomain(target) != "" {
+		return false
+	}
+
+	suffix := e.thirdParty.match(target)
+	if suffix == "" {
+		return false
+	}
+
+	e.Sys.Findings().Add(&systems.Finding{
+		Type:     "FQDN",
+		Value:    target,
+		Domain:   e.Config.WhichDomain(alias),
+		Relation: RelationThirdPartyCNAME,
+		Source:   ThirdPartySource,
+		Properties: map[string]string{
+			"alias":  alias,
+			"suffix": suffix,
+		},
+	})
+	return true
+}
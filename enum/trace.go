@@ -0,0 +1,294 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/pipeline"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	minTraceSweepInterval = time.Second
+	// maxTracedEvents is the number of events followed at once, beyond which new events are not traced
+	maxTracedEvents = 100000
+)
+
+type traceStep struct {
+	stage string
+	at    time.Time
+}
+
+// eventTrace records when an event entered each queue and stage of the enumeration.
+type eventTrace struct {
+	source string
+	steps  []traceStep
+}
+
+// eventTracer follows the events moving through the enumeration and reports those
+// that take longer than the configured budget, along with where the time was spent.
+type eventTracer struct {
+	sync.Mutex
+	enum     *Enumeration
+	budget   time.Duration
+	events   map[string]*eventTrace
+	overruns map[string]int
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newEventTracer(e *Enumeration, budget time.Duration) *eventTracer {
+	t := &eventTracer{
+		enum:     e,
+		budget:   budget,
+		events:   make(map[string]*eventTrace),
+		overruns: make(map[string]int),
+		done:     make(chan struct{}),
+	}
+
+	if budget > 0 {
+		go t.sweep()
+	}
+	return t
+}
+
+func (t *eventTracer) enabled() bool {
+	return t != nil && t.budget > 0
+}
+
+func (t *eventTracer) stop() {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// start begins the trace of an event accepted into the enumeration. When the event was
+// provided by a data source, since is the time it was received from the named source.
+func (t *eventTracer) start(key, source string, since time.Time) {
+	if !t.enabled() || key == "" {
+		return
+	}
+
+	now := time.Now()
+	ev := &eventTrace{source: source}
+	if !since.IsZero() {
+		ev.steps = append(ev.steps, traceStep{stage: "release", at: since})
+	}
+	ev.steps = append(ev.steps, traceStep{stage: "input", at: now})
+
+	t.Lock()
+	if _, found := t.events[key]; found || len(t.events) < maxTracedEvents {
+		t.events[key] = ev
+	}
+	t.Unlock()
+}
+
+// checkpoint records that the event has entered the named pipeline stage.
+func (t *eventTracer) checkpoint(key, stage string) {
+	if !t.enabled() || key == "" {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if ev, found := t.events[key]; found {
+		ev.steps = append(ev.steps, traceStep{stage: stage, at: time.Now()})
+	}
+}
+
+// finish removes the event from the tracer and reports it if the budget was exceeded.
+func (t *eventTracer) finish(key string) {
+	if !t.enabled() || key == "" {
+		return
+	}
+
+	t.Lock()
+	ev, found := t.events[key]
+	delete(t.events, key)
+	t.Unlock()
+
+	if found {
+		if now := time.Now(); now.Sub(ev.steps[0].at) > t.budget {
+			t.report(key, ev, now, false)
+		}
+	}
+}
+
+// slowSource records a data source that took longer than the budget to accept a request.
+func (t *eventTracer) slowSource(source string, req interface{}, elapsed time.Duration) {
+	if !t.enabled() || elapsed <= t.budget {
+		return
+	}
+
+	t.Lock()
+	t.overruns[source]++
+	t.Unlock()
+
+	t.enum.Config.Log.Printf("Event budget of %s exceeded: %s took %s to accept the %s request",
+		t.budget, source, elapsed.Round(time.Millisecond), eventKey(req))
+}
+
+func (t *eventTracer) report(key string, ev *eventTrace, now time.Time, pending bool) {
+	var slowest string
+	var longest time.Duration
+	var hops []string
+
+	for i, step := range ev.steps {
+		end := now
+		if i+1 < len(ev.steps) {
+			end = ev.steps[i+1].at
+		}
+
+		spent := end.Sub(step.at)
+		if spent >= longest {
+			slowest = step.stage
+			longest = spent
+		}
+		hops = append(hops, fmt.Sprintf("%s %s", step.stage, spent.Round(time.Millisecond)))
+	}
+
+	t.Lock()
+	t.overruns[slowest]++
+	t.Unlock()
+
+	var origin string
+	if ev.source != "" {
+		origin = " from " + ev.source
+	}
+
+	state := "after"
+	if pending {
+		state = "and is still pending after"
+	}
+	t.enum.Config.Log.Printf("Event budget of %s exceeded: %s%s %s %s: %s", t.budget, key, origin,
+		state, now.Sub(ev.steps[0].at).Round(time.Millisecond), strings.Join(hops, " -> "))
+}
+
+// sweep periodically reports the events that are still in flight beyond the budget.
+func (t *eventTracer) sweep() {
+	interval := t.budget / 2
+	if interval < minTraceSweepInterval {
+		interval = minTraceSweepInterval
+	}
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-tick.C:
+			t.sweepOnce(time.Now())
+		}
+	}
+}
+
+// sweepOnce reports the events in flight beyond the budget and stops following them,
+// since the events dropped by the pipeline never reach the last stage.
+func (t *eventTracer) sweepOnce(now time.Time) {
+	type overdue struct {
+		key string
+		ev  *eventTrace
+	}
+
+	var late []overdue
+	t.Lock()
+	for key, ev := range t.events {
+		if now.Sub(ev.steps[0].at) > t.budget {
+			late = append(late, overdue{key: key, ev: ev})
+			delete(t.events, key)
+		}
+	}
+	t.Unlock()
+
+	for _, o := range late {
+		t.report(o.key, o.ev, now, true)
+	}
+}
+
+// Stats returns the number of events that exceeded the budget, keyed by the
+// queue, stage or data source where each of them spent the most time.
+func (t *eventTracer) Stats() map[string]int {
+	stats := make(map[string]int)
+	if t == nil {
+		return stats
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for k, v := range t.overruns {
+		stats[k] = v
+	}
+	return stats
+}
+
+// tracedTask records each event that enters the wrapped pipeline stage.
+type tracedTask struct {
+	stage  string
+	task   pipeline.Task
+	tracer *eventTracer
+	// async is set when the wrapped task forwards events on its own
+	async bool
+	last  bool
+}
+
+func (e *Enumeration) traceStage(stage string, task pipeline.Task, async, last bool) pipeline.Task {
+	if !e.tracer.enabled() {
+		return task
+	}
+	return &tracedTask{
+		stage:  stage,
+		task:   task,
+		tracer: e.tracer,
+		async:  async,
+		last:   last,
+	}
+}
+
+// Process implements the pipeline Task interface.
+func (tt *tracedTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.TaskParams) (pipeline.Data, error) {
+	key := eventKey(data)
+
+	tt.tracer.checkpoint(key, tt.stage)
+	out, err := tt.task.Process(ctx, data, tp)
+	if tt.last || (out == nil && !tt.async) {
+		tt.tracer.finish(key)
+	}
+	return out, err
+}
+
+func eventKey(data interface{}) string {
+	switch v := data.(type) {
+	case *requests.DNSRequest:
+		return v.Name
+	case *requests.AddrRequest:
+		return v.Address
+	case *requests.ASNRequest:
+		return "AS" + strconv.Itoa(v.ASN)
+	case *requests.WhoisRequest:
+		return v.Domain
+	}
+	return ""
+}
+
+// eventBudget returns the maximum lifetime of an event from the 'event_budget' option,
+// provided as a duration string or number of seconds. Zero disables the budget.
+func eventBudget(cfg *config.Config) time.Duration {
+	return options.Duration(cfg, "event_budget")
+}
@@ -0,0 +1,91 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func newTestTracer(budget time.Duration) *eventTracer {
+	cfg := config.NewConfig()
+	cfg.Log = log.New(io.Discard, "", 0)
+	// The budget is set afterwards, so the sweep is driven by the tests
+	t := newEventTracer(&Enumeration{Config: cfg}, 0)
+	t.budget = budget
+	return t
+}
+
+func TestEventTracerBudget(t *testing.T) {
+	tracer := newTestTracer(50 * time.Millisecond)
+
+	// The event spent most of its lifetime waiting to be released by the data source
+	tracer.start("slow.owasp.org", "TestSource", time.Now().Add(-200*time.Millisecond))
+	tracer.checkpoint("slow.owasp.org", "dns")
+	tracer.finish("slow.owasp.org")
+
+	tracer.start("fast.owasp.org", "TestSource", time.Time{})
+	tracer.checkpoint("fast.owasp.org", "dns")
+	tracer.finish("fast.owasp.org")
+
+	tracer.slowSource("TestSource", nil, 100*time.Millisecond)
+	tracer.slowSource("FastSource", nil, time.Millisecond)
+
+	stats := tracer.Stats()
+	if stats["release"] != 1 {
+		t.Errorf("Expected the slow event to be charged to the release, got %v", stats)
+	}
+	if stats["TestSource"] != 1 {
+		t.Errorf("Expected the slow source to be recorded, got %v", stats)
+	}
+	if len(stats) != 2 {
+		t.Errorf("Expected only the overruns to be counted, got %v", stats)
+	}
+	if len(tracer.events) != 0 {
+		t.Errorf("Expected the finished events to be removed, %d remain", len(tracer.events))
+	}
+}
+
+func TestEventTracerSweep(t *testing.T) {
+	tracer := newTestTracer(50 * time.Millisecond)
+
+	tracer.start("lost.owasp.org", "", time.Time{})
+	tracer.checkpoint("lost.owasp.org", "store")
+	tracer.start("new.owasp.org", "", time.Time{})
+	tracer.events["lost.owasp.org"].steps[0].at = time.Now().Add(-time.Second)
+
+	tracer.sweepOnce(time.Now())
+	if stats := tracer.Stats(); stats["input"] != 1 || len(stats) != 1 {
+		t.Errorf("Expected the pending event to be charged to the input, got %v", stats)
+	}
+	if _, found := tracer.events["lost.owasp.org"]; found {
+		t.Errorf("Expected the pending event to be dropped after being reported")
+	}
+	if _, found := tracer.events["new.owasp.org"]; !found {
+		t.Errorf("Expected the event within the budget to be followed")
+	}
+
+	// The event dropped by the sweep is not reported twice
+	tracer.finish("lost.owasp.org")
+	if stats := tracer.Stats(); stats["input"] != 1 {
+		t.Errorf("Expected the dropped event to be reported once, got %v", stats)
+	}
+}
+
+func TestEventTracerBounded(t *testing.T) {
+	tracer := newTestTracer(time.Minute)
+
+	for i := 0; i < maxTracedEvents+10; i++ {
+		tracer.start(strconv.Itoa(i), "", time.Time{})
+	}
+	if num := len(tracer.events); num != maxTracedEvents {
+		t.Errorf("Expected %d events to be followed, got %d", maxTracedEvents, num)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/wordlist"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// WordlistSource is the source of the findings measuring the managed wordlists.
+	WordlistSource = "Wordlists"
+	// RelationWordlistStats is the relation of the findings holding the words of a list that found names.
+	RelationWordlistStats = "wordlist_stats"
+)
+
+// loadWordlists obtains the managed wordlists declared by the 'managed_wordlists' option, caching the lists
+// downloaded in the output directory, and adds their words to the brute forcing and alteration wordlists.
+// The lists that cannot be obtained, or do not match their pinned hashes, are left out of the enumeration.
+func (e *Enumeration) loadWordlists(ctx context.Context) {
+	lists, err := wordlist.Parse(e.Config.Options["managed_wordlists"])
+	if err != nil {
+		e.Config.Log.Printf("Wordlists: %v", err)
+	}
+
+	dir := filepath.Join(config.OutputDirectory(e.Config.Dir), wordlist.CacheDir)
+	for _, l := range lists {
+		if err := l.Load(ctx, dir); err != nil {
+			e.Config.Log.Printf("Failed to obtain the wordlist %s from %s: %v", l.Name, l.Source, err)
+			continue
+		}
+
+		if l.UsedFor(wordlist.UseBrute) {
+			e.Config.Wordlist = stringset.Deduplicate(append(e.Config.Wordlist, l.Words...))
+		}
+		if l.UsedFor(wordlist.UseAlterations) {
+			e.Config.AltWordlist = stringset.Deduplicate(append(e.Config.AltWordlist, l.Words...))
+		}
+		e.wordlists = append(e.wordlists, l)
+	}
+}
+
+// measureWordlists keeps the number of words of each managed wordlist found in the names discovered,
+// and the hit rate of the list, as Wordlist findings, so the lists can be compared across engagements.
+func (e *Enumeration) measureWordlists() {
+	if len(e.wordlists) == 0 {
+		return
+	}
+
+	names := e.namesDiscovered()
+	for _, l := range e.wordlists {
+		s := wordlist.Measure(l, names, e.Config.Domains())
+
+		e.Sys.Findings().Add(&systems.Finding{
+			Type:     "Wordlist",
+			Value:    l.Name,
+			Relation: RelationWordlistStats,
+			Source:   WordlistSource,
+			Properties: map[string]string{
+				"source":   l.Source,
+				"sha256":   l.Hash,
+				"use":      strings.Join(l.Uses, ","),
+				"words":    strconv.Itoa(s.Words),
+				"hits":     strconv.Itoa(s.Hits),
+				"hit_rate": strconv.FormatFloat(s.HitRate, 'f', 4, 64),
+				"top":      strings.Join(s.Top, ","),
+			},
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+const (
+	numBuckets = 256
+	// DefaultMemoryEntries is the number of entries a DiskSet keeps in memory before writing them to disk
+	DefaultMemoryEntries = 1 << 20
+)
+
+// DiskSet is a set of strings for enumerations producing tens of millions of values. Each value is
+// kept as a 64-bit hash, recent hashes are held in memory and the others are appended to disk as sorted
+// runs, which are merged once runs of a similar size accumulate. A bloom filter answers most of the tests
+// for new values without reading the files. Unlike a stable bloom filter, the set never forgets a value,
+// and a value that was not added is only reported as present when its hash collides with another value.
+type DiskSet struct {
+	sync.Mutex
+	dir    string
+	bloom  *bf.ScalableBloomFilter
+	mem    []map[uint64]struct{}
+	memMax int
+	runs   [][]*diskRun
+	seq    int
+	count  int
+}
+
+// diskRun is a file holding a sorted run of hashes of a bucket.
+type diskRun struct {
+	path    string
+	file    *os.File
+	entries int64
+}
+
+// NewDiskSet returns a DiskSet that writes the bucket files to a new directory within the provided
+// directory, or within the temporary directory when the path is empty. The set holds no more than
+// memEntries hashes in memory, and DefaultMemoryEntries is used when the number is not positive.
+func NewDiskSet(dir string, memEntries int) (*DiskSet, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.MkdirTemp(dir, ".diskset-")
+	if err != nil {
+		return nil, err
+	}
+	if memEntries <= 0 {
+		memEntries = DefaultMemoryEntries
+	}
+
+	s := &DiskSet{
+		dir:    tmp,
+		bloom:  bf.NewScalableBloomFilter(uint(memEntries), 0.01, 0.8),
+		mem:    make([]map[uint64]struct{}, numBuckets),
+		memMax: memEntries / numBuckets,
+		runs:   make([][]*diskRun, numBuckets),
+	}
+	if s.memMax < 1 {
+		s.memMax = 1
+	}
+	for i := range s.mem {
+		s.mem[i] = make(map[uint64]struct{})
+	}
+	return s, nil
+}
+
+// TestAndAdd adds the value to the set and returns true when it was already in the set.
+func (s *DiskSet) TestAndAdd(value string) bool {
+	h, key := hashValue(value)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.bloom.Test(key) && s.has(h) {
+		return true
+	}
+
+	s.bloom.Add(key)
+	b := bucket(h)
+	s.mem[b][h] = struct{}{}
+	s.count++
+	if len(s.mem[b]) >= s.memMax {
+		// When the write fails, the hashes remain in memory and the next addition tries again
+		_ = s.spill(b)
+	}
+	return false
+}
+
+// Has returns true when the value is in the set.
+func (s *DiskSet) Has(value string) bool {
+	h, key := hashValue(value)
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.bloom.Test(key) && s.has(h)
+}
+
+// Len returns the number of values in the set.
+func (s *DiskSet) Len() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.count
+}
+
+// Spill writes the hashes held in memory to disk, such as when the memory usage is high.
+func (s *DiskSet) Spill() error {
+	s.Lock()
+	defer s.Unlock()
+
+	for b := range s.mem {
+		if len(s.mem[b]) == 0 {
+			continue
+		}
+		if err := s.spill(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the resources of the set and removes the bucket files.
+func (s *DiskSet) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	for b, runs := range s.runs {
+		for _, r := range runs {
+			r.file.Close()
+		}
+		s.runs[b] = nil
+	}
+	for i := range s.mem {
+		s.mem[i] = make(map[uint64]struct{})
+	}
+	s.count = 0
+	return os.RemoveAll(s.dir)
+}
+
+func (s *DiskSet) has(h uint64) bool {
+	b := bucket(h)
+
+	if _, found := s.mem[b][h]; found {
+		return true
+	}
+
+	for i := len(s.runs[b]) - 1; i >= 0; i-- {
+		if s.runs[b][i].has(h) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *diskRun) has(h uint64) bool {
+	var buf [8]byte
+
+	lo, hi := int64(0), r.entries
+	for lo < hi {
+		mid := (lo + hi) / 2
+
+		if _, err := r.file.ReadAt(buf[:], mid*8); err != nil {
+			return false
+		}
+		v := binary.BigEndian.Uint64(buf[:])
+		if v == h {
+			return true
+		} else if v < h {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return false
+}
+
+// spill appends the hashes of the bucket held in memory to disk as a new sorted run. The memory
+// is only released once the run is in place, and the runs of the bucket are then merged when needed.
+func (s *DiskSet) spill(b int) error {
+	hashes := make([]uint64, 0, len(s.mem[b]))
+	for h := range s.mem[b] {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	r, err := s.writeRun(b, func(write func(uint64) error) error {
+		for _, h := range hashes {
+			if err := write(h); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.runs[b] = append(s.runs[b], r)
+	s.mem[b] = make(map[uint64]struct{})
+	// A failed merge leaves the runs in place, so the hashes remain available
+	_ = s.merge(b)
+	return nil
+}
+
+// merge combines the last runs of the bucket while the newer run is not smaller than the run before it,
+// which keeps a logarithmic number of runs and writes each hash a logarithmic number of times.
+func (s *DiskSet) merge(b int) error {
+	for n := len(s.runs[b]); n > 1; n = len(s.runs[b]) {
+		older, newer := s.runs[b][n-2], s.runs[b][n-1]
+		if newer.entries < older.entries {
+			break
+		}
+
+		r, err := s.writeRun(b, func(write func(uint64) error) error {
+			return mergeRuns(older, newer, write)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.runs[b] = append(s.runs[b][:n-2], r)
+		for _, old := range []*diskRun{older, newer} {
+			old.file.Close()
+			_ = os.Remove(old.path)
+		}
+	}
+	return nil
+}
+
+// writeRun writes the hashes provided by the fill function to a new run file of the bucket. The file
+// is written under a temporary name, and nothing is left on disk when the writing or renaming fails.
+func (s *DiskSet) writeRun(b int, fill func(write func(uint64) error) error) (*diskRun, error) {
+	s.seq++
+	path := filepath.Join(s.dir, fmt.Sprintf("%02x-%d", b, s.seq))
+
+	out, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(out)
+	var written int64
+	write := func(h uint64) error {
+		var buf [8]byte
+
+		binary.BigEndian.PutUint64(buf[:], h)
+		written++
+		_, err := w.Write(buf[:])
+		return err
+	}
+
+	err = fill(write)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return &diskRun{path: path, file: f, entries: written}, nil
+}
+
+// mergeRuns writes the hashes of both sorted runs in order, and each hash held by both runs once.
+func mergeRuns(a, b *diskRun, write func(uint64) error) error {
+	ra := bufio.NewReader(io.NewSectionReader(a.file, 0, a.entries*8))
+	rb := bufio.NewReader(io.NewSectionReader(b.file, 0, b.entries*8))
+
+	next := func(r *bufio.Reader) (uint64, bool, error) {
+		var buf [8]byte
+
+		if _, err := io.ReadFull(r, buf[:]); err == io.EOF {
+			return 0, false, nil
+		} else if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf[:]), true, nil
+	}
+
+	va, oka, err := next(ra)
+	if err != nil {
+		return err
+	}
+	vb, okb, err := next(rb)
+	if err != nil {
+		return err
+	}
+
+	for oka || okb {
+		var v uint64
+		advanceA, advanceB := false, false
+
+		switch {
+		case oka && okb && va == vb:
+			v, advanceA, advanceB = va, true, true
+		case oka && (!okb || va < vb):
+			v, advanceA = va, true
+		default:
+			v, advanceB = vb, true
+		}
+		if err := write(v); err != nil {
+			return err
+		}
+
+		if advanceA {
+			if va, oka, err = next(ra); err != nil {
+				return err
+			}
+		}
+		if advanceB {
+			if vb, okb, err = next(rb); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hashValue(value string) (uint64, []byte) {
+	h := fnv.New64a()
+
+	_, _ = h.Write([]byte(strings.ToLower(value)))
+	sum := h.Sum64()
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sum)
+	return sum, key
+}
+
+func bucket(h uint64) int {
+	return int(h >> 56)
+}
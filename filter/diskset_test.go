@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestDiskSet(t *testing.T) {
+	// Hold few entries in memory so the buckets are written to disk and merged several times
+	s, err := NewDiskSet(t.TempDir(), 512)
+	if err != nil {
+		t.Fatalf("NewDiskSet failed: %v", err)
+	}
+
+	num := 20000
+	for i := 0; i < num; i++ {
+		if s.TestAndAdd("www" + strconv.Itoa(i) + ".owasp.org") {
+			t.Fatalf("TestAndAdd returned true for the new name www%d.owasp.org", i)
+		}
+	}
+	if l := s.Len(); l != num {
+		t.Errorf("Len returned %d, expected %d", l, num)
+	}
+
+	if err := s.Spill(); err != nil {
+		t.Errorf("Spill failed: %v", err)
+	}
+	for i := 0; i < num; i++ {
+		if !s.TestAndAdd("WWW" + strconv.Itoa(i) + ".owasp.org") {
+			t.Fatalf("TestAndAdd returned false for the name www%d.owasp.org already in the set", i)
+		}
+	}
+	if l := s.Len(); l != num {
+		t.Errorf("Len returned %d after adding the names again, expected %d", l, num)
+	}
+	if s.Has("mail.owasp.org") {
+		t.Errorf("Has returned true for a name not in the set")
+	}
+
+	dir := s.dir
+	if err := s.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Close did not remove the directory %s", dir)
+	}
+}
+
+func TestDiskSetRuns(t *testing.T) {
+	s, err := NewDiskSet(t.TempDir(), numBuckets)
+	if err != nil {
+		t.Fatalf("NewDiskSet failed: %v", err)
+	}
+	defer s.Close()
+
+	num := 10000
+	for i := 0; i < num; i++ {
+		s.TestAndAdd("www" + strconv.Itoa(i) + ".owasp.org")
+	}
+	if err := s.Spill(); err != nil {
+		t.Errorf("Spill failed: %v", err)
+	}
+	for b, runs := range s.runs {
+		if len(runs) > 8 {
+			t.Errorf("Bucket %02x holds %d runs after merging", b, len(runs))
+		}
+	}
+
+	// The writes fail once the directory is gone, and the hashes must remain in the set
+	if err := os.RemoveAll(s.dir); err != nil {
+		t.Fatalf("Failed to remove the directory: %v", err)
+	}
+	for i := num; i < 2*num; i++ {
+		s.TestAndAdd("www" + strconv.Itoa(i) + ".owasp.org")
+	}
+	if err := s.Spill(); err == nil {
+		t.Errorf("Spill succeeded without the directory")
+	}
+	for i := 0; i < 2*num; i++ {
+		if !s.Has("www" + strconv.Itoa(i) + ".owasp.org") {
+			t.Fatalf("Has returned false for the name www%d.owasp.org after the writes failed", i)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"strings"
+)
+
+// The heuristics used by the JunkFilter to recognize garbage names.
+const (
+	// HexBlob matches labels that are long random hexadecimal strings, such as hashes and UUIDs
+	HexBlob = "hex_blob"
+	// LongLabel matches labels longer than the MaxLabelLength
+	LongLabel = "long_label"
+	// InvalidCharacters matches labels that remain invalid after the name has been normalized
+	InvalidCharacters = "invalid_characters"
+)
+
+// JunkHeuristics contains the names of all the heuristics known to the JunkFilter.
+var JunkHeuristics = []string{HexBlob, LongLabel, InvalidCharacters}
+
+const (
+	// DefaultMaxLabelLength is the longest label accepted by the LongLabel heuristic
+	DefaultMaxLabelLength = 40
+	// DefaultMinHexLength is the shortest label matched by the HexBlob heuristic
+	DefaultMinHexLength = 16
+)
+
+// JunkFilter scores the names scraped by the data sources for patterns that
+// indicate garbage, such as session identifiers and fragments of encoded data.
+type JunkFilter struct {
+	heuristics map[string]struct{}
+	// MaxLabelLength is the longest label accepted by the LongLabel heuristic
+	MaxLabelLength int
+	// MinHexLength is the shortest label, not counting hyphens, matched by the HexBlob heuristic
+	MinHexLength int
+}
+
+// NewJunkFilter returns a JunkFilter applying the named heuristics.
+// All the heuristics are applied when none are provided.
+func NewJunkFilter(heuristics ...string) *JunkFilter {
+	if len(heuristics) == 0 {
+		heuristics = JunkHeuristics
+	}
+
+	set := make(map[string]struct{}, len(heuristics))
+	for _, h := range heuristics {
+		set[strings.ToLower(strings.TrimSpace(h))] = struct{}{}
+	}
+
+	return &JunkFilter{
+		heuristics:     set,
+		MaxLabelLength: DefaultMaxLabelLength,
+		MinHexLength:   DefaultMinHexLength,
+	}
+}
+
+// Score returns the heuristics matched by the labels of the name found below the domain.
+// A name that matches no heuristics receives a score of zero and is not considered junk.
+func (f *JunkFilter) Score(name, domain string) []string {
+	sub := name
+	if domain != "" {
+		if !strings.HasSuffix(name, "."+domain) {
+			return nil
+		}
+		sub = strings.TrimSuffix(name, "."+domain)
+	}
+	if sub == "" {
+		return nil
+	}
+
+	var matched []string
+	labels := strings.Split(sub, ".")
+	for _, h := range JunkHeuristics {
+		if _, enabled := f.heuristics[h]; !enabled {
+			continue
+		}
+
+		for _, label := range labels {
+			if f.match(h, label) {
+				matched = append(matched, h)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func (f *JunkFilter) match(heuristic, label string) bool {
+	switch heuristic {
+	case HexBlob:
+		return f.hexBlob(label)
+	case LongLabel:
+		return f.MaxLabelLength > 0 && len(label) > f.MaxLabelLength
+	case InvalidCharacters:
+		return invalidLabel(label)
+	}
+	return false
+}
+
+// hexBlob checks for a label made of hexadecimal digits, mixing numbers and letters as random data does.
+func (f *JunkFilter) hexBlob(label string) bool {
+	hex := strings.ReplaceAll(label, "-", "")
+	if f.MinHexLength <= 0 || len(hex) < f.MinHexLength {
+		return false
+	}
+
+	var digits, letters bool
+	for _, c := range hex {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'a' && c <= 'f':
+			letters = true
+		default:
+			return false
+		}
+	}
+	return digits && letters
+}
+
+func invalidLabel(label string) bool {
+	if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return true
+	}
+
+	for _, c := range label {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJunkFilterScore(t *testing.T) {
+	f := NewJunkFilter()
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"www.owasp.org", nil},
+		{"owasp.org", nil},
+		{"api-v2.dev_01.owasp.org", nil},
+		{"deadbeefdeadbeef.owasp.org", nil},
+		{"2023061512000000.owasp.org", nil},
+		{"3f2a9c1b7d04e5f6.owasp.org", []string{HexBlob}},
+		{"cdn.6f1c2e3a-4b5d-4c7e-9f80-a1b2c3d4e5f6.owasp.org", []string{HexBlob}},
+		{"this-label-is-far-too-long-to-be-a-real-host-name.owasp.org", []string{LongLabel}},
+		{"-www.owasp.org", []string{InvalidCharacters}},
+		{"www..owasp.org", []string{InvalidCharacters}},
+		{"25www%2f.owasp.org", []string{InvalidCharacters}},
+		{"0123456789abcdef0123456789abcdef0123456789abcdef.owasp.org", []string{HexBlob, LongLabel}},
+		{"www.example.com", nil},
+	}
+
+	for _, test := range tests {
+		if got := f.Score(test.name, "owasp.org"); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Score(%s) returned %v, expected %v", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestJunkFilterHeuristics(t *testing.T) {
+	f := NewJunkFilter(LongLabel)
+	f.MaxLabelLength = 10
+
+	if got := f.Score("3f2a9c1b7d04e5f6.owasp.org", "owasp.org"); len(got) != 1 || got[0] != LongLabel {
+		t.Errorf("Only the enabled heuristics should match, but got %v", got)
+	}
+	if got := f.Score("%www.owasp.org", "owasp.org"); len(got) != 0 {
+		t.Errorf("The disabled heuristics matched the name: %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// The techniques recognized by the LookalikeMatcher.
+const (
+	// Embedded matches names containing the brand token within a longer label, such as 'example-login'
+	Embedded = "embedded"
+	// Typo matches labels within the maximum edit distance of the brand token
+	Typo = "typo"
+	// Homograph matches labels that look identical to the brand token once confusable characters are replaced
+	Homograph = "homograph"
+	// TLDSwap matches the brand token registered under another public suffix, such as example.co for example.com
+	TLDSwap = "tld_swap"
+)
+
+// DefaultLookalikeDistance is the largest edit distance matched by the Typo technique.
+const DefaultLookalikeDistance = 1
+
+// Tokens shorter than this are only matched as homographs, since nearly any short label is a typo of them
+const minTypoTokenLength = 5
+
+// The characters, including Cyrillic and Greek letters, that render like the Latin letters used in names
+var confusables = map[rune]string{
+	'а': "a", 'в': "b", 'с': "c", 'ԁ': "d", 'е': "e", 'һ': "h", 'і': "i", 'ј': "j",
+	'к': "k", 'м': "m", 'о': "o", 'р': "p", 'ԛ': "q", 'ѕ': "s", 'т': "t",
+	'у': "y", 'х': "x", 'ԝ': "w", 'ь': "b", 'ӏ': "l",
+	'α': "a", 'β': "b", 'ε': "e", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o", 'ρ': "p",
+	'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ç': "c", 'è': "e",
+	'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ù': "u", 'ú': "u",
+	'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ı': "i", 'ł': "l", 'ś': "s", 'ź': "z",
+	'ż': "z", 'ğ': "g", 'ş': "s",
+	'0': "o", '1': "l", '3': "e", '5': "s",
+}
+
+// Sequences of Latin letters that render like a single letter
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// LookalikeMatcher compares the names registered by others, such as those in the feeds of newly
+// registered domains, with the brand tokens in scope to recognize names impersonating the brand.
+type LookalikeMatcher struct {
+	tokens map[string]string
+	// MaxDistance is the largest edit distance matched by the Typo technique
+	MaxDistance int
+}
+
+// NewLookalikeMatcher returns a LookalikeMatcher for the brand tokens, such as 'example' for example.com.
+func NewLookalikeMatcher(tokens ...string) *LookalikeMatcher {
+	m := &LookalikeMatcher{
+		tokens:      make(map[string]string, len(tokens)),
+		MaxDistance: DefaultLookalikeDistance,
+	}
+
+	for _, t := range tokens {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			m.tokens[t] = skeleton(t)
+		}
+	}
+	return m
+}
+
+// Match returns the brand token impersonated by the name and the techniques used, or an empty token.
+// The registered domains in scope must not be provided, since they would be matched as TLDSwap.
+func (m *LookalikeMatcher) Match(name string) (string, []string) {
+	label := registeredLabel(name)
+	if label == "" {
+		return "", nil
+	}
+
+	skel := skeleton(label)
+	for token, tskel := range m.tokens {
+		if label == token {
+			return token, []string{TLDSwap}
+		}
+
+		var techniques []string
+		if skel == tskel {
+			techniques = append(techniques, Homograph)
+		} else if len(token) >= minTypoTokenLength && editDistance(label, token) <= m.MaxDistance {
+			techniques = append(techniques, Typo)
+		}
+		if len(skel) > len(tskel) && strings.Contains(skel, tskel) {
+			techniques = append(techniques, Embedded)
+		}
+
+		if len(techniques) > 0 {
+			return token, techniques
+		}
+	}
+	return "", nil
+}
+
+// registeredLabel returns the label of the name registered below the public suffix, in Unicode.
+func registeredLabel(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+
+	if u, err := idna.ToUnicode(name); err == nil {
+		name = u
+	}
+
+	reg, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return ""
+	}
+
+	label, _, _ := strings.Cut(reg, ".")
+	return label
+}
+
+// skeleton replaces the confusable characters, so labels rendering alike are equal.
+func skeleton(label string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(label) {
+		if s, found := confusables[r]; found {
+			b.WriteString(s)
+		} else if r != '-' {
+			b.WriteRune(r)
+		}
+	}
+	// The dotless forms of 'i' and 'l' are indistinguishable in many fonts
+	return strings.ReplaceAll(confusableSequences.Replace(b.String()), "i", "l")
+}
+
+// editDistance returns the Damerau-Levenshtein distance, counting the transposition of adjacent characters as one edit.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
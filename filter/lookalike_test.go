@@ -0,0 +1,60 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookalikeMatcherMatch(t *testing.T) {
+	m := NewLookalikeMatcher("owasp", "example")
+
+	tests := []struct {
+		name       string
+		token      string
+		techniques []string
+	}{
+		{"0wasp.org", "owasp", []string{Homograph}},
+		{"xn--wasp-45d.org", "owasp", []string{Homograph}},
+		{"exarnple.com", "example", []string{Homograph}},
+		{"exampel.net", "example", []string{Typo}},
+		{"exmple.com", "example", []string{Typo}},
+		{"owasp-login.com", "owasp", []string{Embedded}},
+		{"secure-examp1e.com", "example", []string{Embedded}},
+		{"owasp.net", "owasp", []string{TLDSwap}},
+		{"www.owasp.co.uk", "owasp", []string{TLDSwap}},
+		{"owsap.org", "owasp", []string{Typo}},
+		{"owl.org", "", nil},
+		{"unrelated.com", "", nil},
+		{"com", "", nil},
+	}
+
+	for _, test := range tests {
+		token, techniques := m.Match(test.name)
+		if token != test.token || !reflect.DeepEqual(techniques, test.techniques) {
+			t.Errorf("Match(%s) returned %s %v, expected %s %v", test.name, token, techniques, test.token, test.techniques)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"example", "example", 0},
+		{"example", "exampel", 1},
+		{"example", "exmple", 1},
+		{"example", "examples", 1},
+		{"example", "sample", 2},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.expected {
+			t.Errorf("editDistance(%s, %s) returned %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+}
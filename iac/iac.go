@@ -0,0 +1,311 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package iac extracts the DNS names and public addresses declared by infrastructure as code,
+// so the assets discovered externally can be compared with the assets expected to exist.
+package iac
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The sources of the expected assets.
+const (
+	SourceTerraform      = "Terraform"
+	SourceCloudFormation = "CloudFormation"
+)
+
+// Asset is a DNS name or public IP address declared by infrastructure as code.
+type Asset struct {
+	// Type is 'FQDN' or 'IPAddress'
+	Type  string
+	Value string
+	// Resource is the address of the resource declaring the asset, such as 'aws_eip.web'
+	Resource string
+	Source   string
+}
+
+// terraformAttributes are the attributes holding the names and addresses of each resource type.
+var terraformAttributes = map[string][]string{
+	"aws_route53_record":            {"fqdn", "name", "records"},
+	"aws_eip":                       {"public_ip", "public_dns"},
+	"aws_instance":                  {"public_ip", "public_dns", "ipv6_addresses"},
+	"aws_lb":                        {"dns_name"},
+	"aws_alb":                       {"dns_name"},
+	"aws_elb":                       {"dns_name"},
+	"aws_cloudfront_distribution":   {"domain_name", "aliases"},
+	"aws_apigatewayv2_domain_name":  {"domain_name"},
+	"google_dns_record_set":         {"name", "rrdatas"},
+	"google_compute_address":        {"address"},
+	"google_compute_global_address": {"address"},
+	"azurerm_public_ip":             {"ip_address", "fqdn"},
+	"azurerm_dns_a_record":          {"fqdn", "records"},
+	"azurerm_dns_aaaa_record":       {"fqdn", "records"},
+	"azurerm_dns_cname_record":      {"fqdn", "record"},
+	"cloudflare_record":             {"hostname", "value", "content"},
+	"digitalocean_record":           {"fqdn", "value"},
+	"digitalocean_droplet":          {"ipv4_address", "ipv6_address"},
+}
+
+var hostnameRE = regexp.MustCompile(`^([a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?\.)+[a-z][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// terraformState holds the resources of the state files and of the 'terraform show -json' output.
+type terraformState struct {
+	Version   int                 `json:"version"`
+	Resources []terraformResource `json:"resources"`
+	Values    *struct {
+		RootModule terraformModule `json:"root_module"`
+	} `json:"values"`
+}
+
+type terraformResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Instances []struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	} `json:"instances"`
+}
+
+type terraformModule struct {
+	Resources []struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []terraformModule `json:"child_modules"`
+}
+
+// cloudFormation holds the output of 'aws cloudformation list-exports' and 'describe-stacks', and the templates.
+type cloudFormation struct {
+	Exports []struct {
+		Name  string `json:"Name"`
+		Value string `json:"Value"`
+	} `json:"Exports"`
+	Stacks []struct {
+		StackName string `json:"StackName"`
+		Outputs   []struct {
+			OutputKey   string `json:"OutputKey"`
+			OutputValue string `json:"OutputValue"`
+		} `json:"Outputs"`
+	} `json:"Stacks"`
+	Resources map[string]struct {
+		Type       string                 `json:"Type"`
+		Properties map[string]interface{} `json:"Properties"`
+	} `json:"Resources"`
+}
+
+// Parse returns the assets declared by the Terraform state or CloudFormation export in the JSON data. The Terraform
+// state files and 'terraform show -json' output are accepted, along with the output of the 'aws cloudformation
+// list-exports' and 'describe-stacks' commands and the JSON templates. The private addresses are not returned.
+func Parse(data []byte) ([]*Asset, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	switch {
+	case keys["terraform_version"] != nil || keys["format_version"] != nil:
+		var state terraformState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		assets = terraformAssets(&state)
+	case keys["Exports"] != nil || keys["Stacks"] != nil || keys["Resources"] != nil:
+		var cf cloudFormation
+		if err := json.Unmarshal(data, &cf); err != nil {
+			return nil, err
+		}
+		assets = cloudFormationAssets(&cf)
+	default:
+		return nil, errors.New("the data is not a Terraform state or CloudFormation export")
+	}
+	return unique(assets), nil
+}
+
+func terraformAssets(state *terraformState) []*Asset {
+	var assets []*Asset
+
+	for _, res := range state.Resources {
+		if res.Mode != "" && res.Mode != "managed" {
+			continue
+		}
+
+		addr := res.Type + "." + res.Name
+		if res.Module != "" {
+			addr = res.Module + "." + addr
+		}
+		for _, inst := range res.Instances {
+			assets = append(assets, resourceAssets(res.Type, addr, inst.Attributes)...)
+		}
+	}
+
+	if state.Values != nil {
+		modules := []terraformModule{state.Values.RootModule}
+
+		for len(modules) > 0 {
+			m := modules[0]
+			modules = append(modules[1:], m.ChildModules...)
+
+			for _, res := range m.Resources {
+				if res.Mode == "" || res.Mode == "managed" {
+					assets = append(assets, resourceAssets(res.Type, res.Address, res.Values)...)
+				}
+			}
+		}
+	}
+	return assets
+}
+
+func resourceAssets(rtype, addr string, attrs map[string]interface{}) []*Asset {
+	keys, found := terraformAttributes[rtype]
+	if !found {
+		return nil
+	}
+	// The internal addresses reserved by Google Cloud are not reachable
+	if t, ok := attrs["address_type"].(string); ok && strings.EqualFold(t, "INTERNAL") {
+		return nil
+	}
+
+	var assets []*Asset
+	for _, key := range keys {
+		for _, v := range stringValues(attrs[key]) {
+			if a := classify(v); a != nil {
+				a.Resource = addr
+				a.Source = SourceTerraform
+				assets = append(assets, a)
+			}
+		}
+	}
+	return assets
+}
+
+func cloudFormationAssets(cf *cloudFormation) []*Asset {
+	var assets []*Asset
+
+	add := func(resource, value string) {
+		if a := classify(value); a != nil {
+			a.Resource = resource
+			a.Source = SourceCloudFormation
+			assets = append(assets, a)
+		}
+	}
+
+	for _, e := range cf.Exports {
+		add(e.Name, e.Value)
+	}
+	for _, s := range cf.Stacks {
+		for _, o := range s.Outputs {
+			add(s.StackName+"."+o.OutputKey, o.OutputValue)
+		}
+	}
+	names := make([]string, 0, len(cf.Resources))
+	for name := range cf.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		res := cf.Resources[name]
+
+		switch res.Type {
+		case "AWS::Route53::RecordSet":
+			for _, v := range recordSetValues(res.Properties) {
+				add(name, v)
+			}
+		case "AWS::Route53::RecordSetGroup":
+			sets, _ := res.Properties["RecordSets"].([]interface{})
+			for _, set := range sets {
+				if props, ok := set.(map[string]interface{}); ok {
+					for _, v := range recordSetValues(props) {
+						add(name, v)
+					}
+				}
+			}
+		}
+	}
+	return assets
+}
+
+// recordSetValues returns the literal name and records of the record set, since the intrinsic functions are not resolved.
+func recordSetValues(props map[string]interface{}) []string {
+	return append(stringValues(props["Name"]), stringValues(props["ResourceRecords"])...)
+}
+
+// classify returns the asset named by the value, which can be an address, a name,
+// a URL, or the data of a record ending with a name, such as '10 mail.example.com.'.
+func classify(value string) *Asset {
+	v := strings.TrimSpace(value)
+	if fields := strings.Fields(v); len(fields) > 1 {
+		v = fields[len(fields)-1]
+	}
+	if strings.Contains(v, "://") {
+		if u, err := url.Parse(v); err == nil {
+			v = u.Hostname()
+		}
+	}
+
+	if ip := net.ParseIP(v); ip != nil {
+		if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+			return nil
+		}
+		return &Asset{Type: "IPAddress", Value: ip.String()}
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(v, "."))
+	if strings.HasPrefix(name, "*.") || !hostnameRE.MatchString(name) {
+		return nil
+	}
+	return &Asset{Type: "FQDN", Value: name}
+}
+
+// stringValues accepts a string or a list of strings.
+func stringValues(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		if t != "" {
+			return []string{t}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// unique removes the repeated assets, keeping the first resource declaring each, and sorts them.
+func unique(assets []*Asset) []*Asset {
+	seen := make(map[string]struct{})
+
+	var results []*Asset
+	for _, a := range assets {
+		key := a.Type + "|" + a.Value
+		if _, found := seen[key]; !found {
+			seen[key] = struct{}{}
+			results = append(results, a)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		return results[i].Value < results[j].Value
+	})
+	return results
+}
@@ -0,0 +1,158 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package iac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTerraformState(t *testing.T) {
+	state := `{
+		"version": 4,
+		"terraform_version": "1.5.7",
+		"resources": [
+			{"mode": "managed", "type": "aws_route53_record", "name": "www", "instances": [
+				{"attributes": {"fqdn": "www.owasp.org", "name": "www", "type": "A", "records": ["192.0.2.10", "10.0.0.5"]}}
+			]},
+			{"mode": "managed", "type": "aws_route53_record", "name": "mail", "instances": [
+				{"attributes": {"fqdn": "owasp.org", "type": "MX", "records": ["10 Mail.OWASP.org."]}}
+			]},
+			{"mode": "managed", "type": "aws_route53_record", "name": "spf", "instances": [
+				{"attributes": {"fqdn": "owasp.org", "type": "TXT", "records": ["v=spf1 include:_spf.owasp.org ~all"]}}
+			]},
+			{"module": "module.edge", "mode": "managed", "type": "aws_eip", "name": "web", "instances": [
+				{"attributes": {"public_ip": "198.51.100.7", "private_ip": "10.0.0.7"}}
+			]},
+			{"mode": "managed", "type": "google_compute_address", "name": "internal", "instances": [
+				{"attributes": {"address": "203.0.113.9", "address_type": "INTERNAL"}}
+			]},
+			{"mode": "data", "type": "aws_eip", "name": "lookup", "instances": [
+				{"attributes": {"public_ip": "203.0.113.1"}}
+			]},
+			{"mode": "managed", "type": "aws_s3_bucket", "name": "logs", "instances": [
+				{"attributes": {"bucket": "logs.owasp.org"}}
+			]}
+		]
+	}`
+
+	assets, err := Parse([]byte(state))
+	if err != nil {
+		t.Fatalf("Failed to parse the Terraform state: %v", err)
+	}
+
+	expected := []*Asset{
+		{Type: "FQDN", Value: "mail.owasp.org", Resource: "aws_route53_record.mail", Source: SourceTerraform},
+		{Type: "FQDN", Value: "owasp.org", Resource: "aws_route53_record.mail", Source: SourceTerraform},
+		{Type: "FQDN", Value: "www.owasp.org", Resource: "aws_route53_record.www", Source: SourceTerraform},
+		{Type: "IPAddress", Value: "192.0.2.10", Resource: "aws_route53_record.www", Source: SourceTerraform},
+		{Type: "IPAddress", Value: "198.51.100.7", Resource: "module.edge.aws_eip.web", Source: SourceTerraform},
+	}
+	if !reflect.DeepEqual(assets, expected) {
+		for _, a := range assets {
+			t.Logf("%+v", *a)
+		}
+		t.Errorf("Parse returned %d assets, expected %d", len(assets), len(expected))
+	}
+}
+
+func TestParseTerraformShow(t *testing.T) {
+	show := `{
+		"format_version": "1.0",
+		"values": {"root_module": {
+			"resources": [
+				{"address": "azurerm_public_ip.api", "mode": "managed", "type": "azurerm_public_ip",
+					"values": {"ip_address": "2001:db8::10", "fqdn": "api.westeurope.cloudapp.azure.com"}}
+			],
+			"child_modules": [{"resources": [
+				{"address": "module.dns.cloudflare_record.app", "mode": "managed", "type": "cloudflare_record",
+					"values": {"hostname": "app.owasp.org", "value": "owasp.github.io"}}
+			]}]
+		}}
+	}`
+
+	assets, err := Parse([]byte(show))
+	if err != nil {
+		t.Fatalf("Failed to parse the Terraform output: %v", err)
+	}
+
+	var got []string
+	for _, a := range assets {
+		got = append(got, a.Type+" "+a.Value+" "+a.Resource)
+	}
+	expected := []string{
+		"FQDN api.westeurope.cloudapp.azure.com azurerm_public_ip.api",
+		"FQDN app.owasp.org module.dns.cloudflare_record.app",
+		"FQDN owasp.github.io module.dns.cloudflare_record.app",
+		"IPAddress 2001:db8::10 azurerm_public_ip.api",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Parse returned %v, expected %v", got, expected)
+	}
+}
+
+func TestParseCloudFormation(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "exports",
+			data: `{"Exports": [
+				{"Name": "web-url", "Value": "https://Web.OWASP.org/login"},
+				{"Name": "nat-ip", "Value": "198.51.100.20"},
+				{"Name": "vpc", "Value": "vpc-0a1b2c3d"}
+			]}`,
+			expected: []string{"FQDN web.owasp.org web-url", "IPAddress 198.51.100.20 nat-ip"},
+		},
+		{
+			name: "stacks",
+			data: `{"Stacks": [{"StackName": "edge", "Outputs": [
+				{"OutputKey": "Endpoint", "OutputValue": "edge.owasp.org"}
+			]}]}`,
+			expected: []string{"FQDN edge.owasp.org edge.Endpoint"},
+		},
+		{
+			name: "template",
+			data: `{"AWSTemplateFormatVersion": "2010-09-09", "Resources": {
+				"Www": {"Type": "AWS::Route53::RecordSet", "Properties": {
+					"Name": "www.owasp.org.", "Type": "A", "ResourceRecords": ["192.0.2.30"]}},
+				"Group": {"Type": "AWS::Route53::RecordSetGroup", "Properties": {"RecordSets": [
+					{"Name": "api.owasp.org", "Type": "CNAME", "ResourceRecords": [{"Fn::GetAtt": ["LB", "DNSName"]}]}
+				]}},
+				"LB": {"Type": "AWS::ElasticLoadBalancingV2::LoadBalancer", "Properties": {}}
+			}}`,
+			expected: []string{"FQDN api.owasp.org Group", "FQDN www.owasp.org Www", "IPAddress 192.0.2.30 Www"},
+		},
+	}
+
+	for _, test := range tests {
+		assets, err := Parse([]byte(test.data))
+		if err != nil {
+			t.Errorf("Failed to parse the CloudFormation %s: %v", test.name, err)
+			continue
+		}
+
+		var got []string
+		for _, a := range assets {
+			if a.Source != SourceCloudFormation {
+				t.Errorf("The %s asset %s has the source %s", test.name, a.Value, a.Source)
+			}
+			got = append(got, a.Type+" "+a.Value+" "+a.Resource)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Parse of the %s returned %v, expected %v", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	for _, data := range []string{`{"name": "value"}`, `[1, 2]`, `not json`} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse accepted %s", data)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// BandwidthUsage is the number of bytes sent and received on behalf of a source.
+type BandwidthUsage struct {
+	Sent     uint64 `json:"sent"`
+	Received uint64 `json:"received"`
+}
+
+var bandwidth struct {
+	sync.Mutex
+	limiter *rate.Limiter
+	usage   map[string]*BandwidthUsage
+}
+
+// SetBandwidthLimit caps the number of bytes per second sent and received by the
+// HTTP and DNS traffic of Amass. A limit of zero removes the cap.
+func SetBandwidthLimit(bps int) {
+	bandwidth.Lock()
+	defer bandwidth.Unlock()
+
+	if bps <= 0 {
+		bandwidth.limiter = nil
+		return
+	}
+	bandwidth.limiter = rate.NewLimiter(rate.Limit(bps), bps)
+}
+
+// BandwidthLimit returns the current cap in bytes per second, or zero when traffic is not limited.
+func BandwidthLimit() int {
+	bandwidth.Lock()
+	defer bandwidth.Unlock()
+
+	if bandwidth.limiter == nil {
+		return 0
+	}
+	return bandwidth.limiter.Burst()
+}
+
+// ThrottleBandwidth blocks until n bytes can be transferred without exceeding the bandwidth cap.
+func ThrottleBandwidth(ctx context.Context, n int) error {
+	bandwidth.Lock()
+	limiter := bandwidth.limiter
+	bandwidth.Unlock()
+
+	if limiter == nil {
+		return nil
+	}
+
+	burst := limiter.Burst()
+	for n > 0 {
+		chunk := n
+		if chunk > burst {
+			chunk = burst
+		}
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// RecordBandwidth adds the bytes sent and received on behalf of the named source to the usage statistics.
+func RecordBandwidth(source string, sent, received int) {
+	bandwidth.Lock()
+	defer bandwidth.Unlock()
+
+	if bandwidth.usage == nil {
+		bandwidth.usage = make(map[string]*BandwidthUsage)
+	}
+
+	u, found := bandwidth.usage[source]
+	if !found {
+		u = new(BandwidthUsage)
+		bandwidth.usage[source] = u
+	}
+	u.Sent += uint64(sent)
+	u.Received += uint64(received)
+}
+
+// BandwidthStats returns a copy of the bandwidth usage statistics keyed by source name.
+func BandwidthStats() map[string]BandwidthUsage {
+	bandwidth.Lock()
+	defer bandwidth.Unlock()
+
+	stats := make(map[string]BandwidthUsage, len(bandwidth.usage))
+	for source, u := range bandwidth.usage {
+		stats[source] = *u
+	}
+	return stats
+}
+
+// throttledConn enforces the bandwidth cap on the data read from and written to the connection.
+// The waits last as long as the connection rather than the dial, since the HTTP transport keeps
+// the connections in its pool after the request that dialed them, and closing it stops the waits.
+type throttledConn struct {
+	net.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newThrottledConn(conn net.Conn) *throttledConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &throttledConn{Conn: conn, ctx: ctx, cancel: cancel}
+}
+
+func (c *throttledConn) Close() error {
+	c.cancel()
+	return c.Conn.Close()
+}
+
+func (c *throttledConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		_ = ThrottleBandwidth(c.ctx, n)
+	}
+	return n, err
+}
+
+func (c *throttledConn) Write(b []byte) (int, error) {
+	if err := ThrottleBandwidth(c.ctx, len(b)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestThrottleBandwidth(t *testing.T) {
+	SetBandwidthLimit(1000)
+	defer SetBandwidthLimit(0)
+
+	if limit := BandwidthLimit(); limit != 1000 {
+		t.Errorf("Expected a limit of 1000 bytes per second, got %d", limit)
+	}
+
+	start := time.Now()
+	// The first 1000 bytes are available immediately and the remainder must wait
+	if err := ThrottleBandwidth(context.Background(), 1500); err != nil {
+		t.Fatalf("Failed to throttle the bandwidth: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Expected the transfer to be delayed, it took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ThrottleBandwidth(ctx, 5000); err == nil {
+		t.Errorf("Expected an error when the context is cancelled")
+	}
+}
+
+func TestRecordBandwidth(t *testing.T) {
+	RecordBandwidth("TestSource", 10, 100)
+	RecordBandwidth("TestSource", 5, 50)
+
+	u, found := BandwidthStats()["TestSource"]
+	if !found {
+		t.Fatal("Expected the source to be in the bandwidth statistics")
+	}
+	if u.Sent != 15 || u.Received != 150 {
+		t.Errorf("Unexpected usage, expected 15 sent and 150 received, got %+v", u)
+	}
+}
+
+func TestThrottledConnClose(t *testing.T) {
+	SetBandwidthLimit(10)
+	defer SetBandwidthLimit(0)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+
+	// The waits do not depend on the request that dialed the connection, which the HTTP transport keeps in its pool
+	conn := newThrottledConn(client)
+	if _, err := conn.Write(make([]byte, 5)); err != nil {
+		t.Errorf("Expected the connection to write within the bandwidth cap: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(make([]byte, 1000))
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	conn.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("Expected the write to fail when the connection is closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("The write was blocked by the bandwidth cap after the connection was closed")
+	}
+}
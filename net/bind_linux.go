@@ -0,0 +1,30 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package net
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice binds the sockets to the named interface, so the routing table cannot send the
+// traffic elsewhere. Without the privilege to bind, the source address still selects the interface.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+
+		if err := c.Control(func(fd uintptr) {
+			serr = syscall.BindToDevice(int(fd), iface)
+		}); err != nil {
+			return err
+		}
+		if errors.Is(serr, syscall.EPERM) {
+			return nil
+		}
+		return serr
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package net
+
+import "syscall"
+
+// bindToDevice is not supported on this platform, so the source address selects the interface.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// The longest chain of aliases followed when answering a query
+const maxAliasChain = 10
+
+// Dataset holds the DNS records imported from local files, such as zone files and passive DNS dumps,
+// and answers queries using those records in place of the resolvers, as required by offline analysis.
+type Dataset struct {
+	sync.RWMutex
+	keep    func(name string) bool
+	records map[string][]dns.RR
+}
+
+// passiveRecord is a record of a passive DNS dump in the Common Output Format, as exported by DNSDB and others.
+type passiveRecord struct {
+	RRName string          `json:"rrname"`
+	RRType string          `json:"rrtype"`
+	RData  json.RawMessage `json:"rdata"`
+}
+
+// NewDataset returns an empty Dataset. When the keep function is provided,
+// only the records owned by the names it returns true for are imported.
+func NewDataset(keep func(name string) bool) *Dataset {
+	return &Dataset{
+		keep:    keep,
+		records: make(map[string][]dns.RR),
+	}
+}
+
+// Len returns the number of records in the dataset.
+func (d *Dataset) Len() int {
+	d.RLock()
+	defer d.RUnlock()
+
+	var num int
+	for _, rrs := range d.records {
+		num += len(rrs)
+	}
+	return num
+}
+
+// Names returns the sorted names owning records in the dataset.
+func (d *Dataset) Names() []string {
+	d.RLock()
+	defer d.RUnlock()
+
+	names := make([]string, 0, len(d.records))
+	for name := range d.records {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// LoadFile imports the records of the zone file or passive DNS dump at the provided path, which
+// can be compressed with gzip. The passive DNS dumps contain one JSON object on each line. The
+// names relative to the zone file origin use the file name, such as 'example.com.zone', when
+// the file has no $ORIGIN directive. The number of records imported is returned.
+func (d *Dataset) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+
+		r = bufio.NewReader(gz)
+	}
+
+	cr := bufio.NewReader(r)
+	first, _ := cr.Peek(1)
+	if len(first) > 0 && (first[0] == '{' || first[0] == '[') {
+		return d.LoadPassiveDNS(cr)
+	}
+	return d.LoadZone(cr, zoneOrigin(path), path)
+}
+
+// LoadZone imports the records of the zone file read from r.
+func (d *Dataset) LoadZone(r io.Reader, origin, file string) (int, error) {
+	zp := dns.NewZoneParser(r, dns.Fqdn(origin), file)
+	zp.SetIncludeAllowed(false)
+
+	var num int
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		if d.add(rr) {
+			num++
+		}
+	}
+	return num, zp.Err()
+}
+
+// LoadPassiveDNS imports the records of the passive DNS dump read from r, which contains a
+// record in the Common Output Format on each line. Lines that cannot be parsed are skipped.
+func (d *Dataset) LoadPassiveDNS(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var num int
+	for scanner.Scan() {
+		var rec passiveRecord
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || json.Unmarshal(line, &rec) != nil || rec.RRName == "" || rec.RRType == "" {
+			continue
+		}
+
+		for _, data := range rdataValues(rec.RData) {
+			rr, err := dns.NewRR(fmt.Sprintf("%s 3600 IN %s %s", dns.Fqdn(rec.RRName), rec.RRType, data))
+			if err == nil && rr != nil && d.add(rr) {
+				num++
+			}
+		}
+	}
+	return num, scanner.Err()
+}
+
+// Answer returns the response to the query built from the records in the dataset. The aliases
+// are followed as a recursive resolver would, and names without records do not exist.
+func (d *Dataset) Answer(msg *dns.Msg) *dns.Msg {
+	resp := new(dns.Msg).SetReply(msg)
+	if len(msg.Question) == 0 {
+		resp.Rcode = dns.RcodeFormatError
+		return resp
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	q := msg.Question[0]
+	name := strings.ToLower(resolve.RemoveLastDot(q.Name))
+	if _, found := d.records[name]; !found {
+		resp.Rcode = dns.RcodeNameError
+		return resp
+	}
+
+	for i := 0; i < maxAliasChain; i++ {
+		var alias string
+
+		for _, rr := range d.records[name] {
+			if rr.Header().Rrtype == q.Qtype {
+				resp.Answer = append(resp.Answer, dns.Copy(rr))
+			} else if cname, ok := rr.(*dns.CNAME); ok && q.Qtype != dns.TypeCNAME {
+				resp.Answer = append(resp.Answer, dns.Copy(rr))
+				alias = strings.ToLower(resolve.RemoveLastDot(cname.Target))
+			}
+		}
+
+		if alias == "" || alias == name {
+			break
+		}
+		name = alias
+	}
+	return resp
+}
+
+func (d *Dataset) add(rr dns.RR) bool {
+	name := strings.ToLower(resolve.RemoveLastDot(rr.Header().Name))
+	if name == "" || (d.keep != nil && !d.keep(name)) {
+		return false
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	for _, cur := range d.records[name] {
+		if dns.IsDuplicate(cur, rr) {
+			return false
+		}
+	}
+
+	d.records[name] = append(d.records[name], rr)
+	return true
+}
+
+// rdataValues accepts the record data as a single string or a list of strings.
+func rdataValues(raw json.RawMessage) []string {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// zoneOrigin derives the origin of the zone from the file name, such as 'example.com' for 'example.com.zone.gz'.
+func zoneOrigin(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+
+	for _, ext := range []string{".gz", ".zone", ".txt", ".db"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if strings.HasPrefix(name, "db.") {
+		name = strings.TrimPrefix(name, "db.")
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestDatasetLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	zone := filepath.Join(dir, "owasp.org.zone")
+	if err := os.WriteFile(zone, []byte(strings.Join([]string{
+		"@ 3600 IN SOA ns1 hostmaster 1 7200 3600 1209600 3600",
+		"@ 3600 IN NS ns1",
+		"www 300 IN A 192.0.2.1",
+		"www 300 IN AAAA 2001:db8::1",
+		"docs 300 IN CNAME www",
+		"",
+	}, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dump := filepath.Join(dir, "pdns.json.gz")
+	f, err := os.Create(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	_, _ = gz.Write([]byte(strings.Join([]string{
+		`{"rrname":"mail.owasp.org.","rrtype":"A","rdata":["192.0.2.25","192.0.2.26"],"time_first":1600000000}`,
+		`{"rrname":"www.example.com","rrtype":"A","rdata":"198.51.100.1"}`,
+		`not json`,
+		"",
+	}, "\n")))
+	gz.Close()
+	f.Close()
+
+	d := NewDataset(func(name string) bool {
+		return strings.HasSuffix(name, "owasp.org")
+	})
+	if num, err := d.LoadFile(zone); err != nil || num != 5 {
+		t.Errorf("LoadFile imported %d records from the zone file, expected 5: %v", num, err)
+	}
+	if num, err := d.LoadFile(dump); err != nil || num != 2 {
+		t.Errorf("LoadFile imported %d records from the passive DNS dump, expected 2: %v", num, err)
+	}
+
+	expected := []string{"docs.owasp.org", "mail.owasp.org", "owasp.org", "www.owasp.org"}
+	if names := d.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Names returned %v, expected %v", names, expected)
+	}
+}
+
+func TestDatasetAnswer(t *testing.T) {
+	d := NewDataset(nil)
+	if _, err := d.LoadZone(strings.NewReader(strings.Join([]string{
+		"www 300 IN A 192.0.2.1",
+		"docs 300 IN CNAME www",
+		"",
+	}, "\n")), "owasp.org", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		qtype    uint16
+		rcode    int
+		expected []string
+	}{
+		{"www.owasp.org", dns.TypeA, dns.RcodeSuccess, []string{"192.0.2.1"}},
+		{"www.owasp.org", dns.TypeAAAA, dns.RcodeSuccess, nil},
+		{"docs.owasp.org", dns.TypeA, dns.RcodeSuccess, []string{"www.owasp.org", "192.0.2.1"}},
+		{"docs.owasp.org", dns.TypeCNAME, dns.RcodeSuccess, []string{"www.owasp.org"}},
+		{"missing.owasp.org", dns.TypeA, dns.RcodeNameError, nil},
+	}
+
+	for _, test := range tests {
+		resp := d.Answer(resolve.QueryMsg(test.name, test.qtype))
+		if resp.Rcode != test.rcode {
+			t.Errorf("Answer(%s, %d) returned rcode %d, expected %d", test.name, test.qtype, resp.Rcode, test.rcode)
+		}
+
+		var got []string
+		for _, ans := range resolve.ExtractAnswers(resp) {
+			got = append(got, ans.Data)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Answer(%s, %d) returned %v, expected %v", test.name, test.qtype, got, test.expected)
+		}
+	}
+}
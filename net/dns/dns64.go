@@ -0,0 +1,96 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import "net/netip"
+
+// IPv4OnlyName is the name only having IPv4 addresses, so a DNS64 server answering
+// AAAA queries for it reveals the NAT64 prefix used to synthesize the records (RFC 7050).
+const IPv4OnlyName = "ipv4only.arpa"
+
+var (
+	// WellKnownNAT64Prefix is the prefix reserved for the IPv4 addresses embedded by NAT64 (RFC 6052).
+	WellKnownNAT64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// LocalNAT64Prefix is the prefix reserved for the translation within a network (RFC 8215).
+	LocalNAT64Prefix = netip.MustParsePrefix("64:ff9b:1::/48")
+
+	ipv4OnlyAddrs = []netip.Addr{
+		netip.MustParseAddr("192.0.0.170"),
+		netip.MustParseAddr("192.0.0.171"),
+	}
+)
+
+// The prefix lengths that an IPv4 address can be embedded after (RFC 6052, section 2.2).
+var nat64PrefixLengths = []int{96, 64, 56, 48, 40, 32}
+
+// NAT64Prefixes returns the prefixes used to synthesize the AAAA records provided for IPv4OnlyName.
+func NAT64Prefixes(answers []netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for _, addr := range answers {
+		if !addr.Is6() || addr.Is4In6() {
+			continue
+		}
+
+		for _, bits := range nat64PrefixLengths {
+			v4, ok := embeddedIPv4(addr, bits)
+			if !ok || (v4 != ipv4OnlyAddrs[0] && v4 != ipv4OnlyAddrs[1]) {
+				continue
+			}
+
+			if p := netip.PrefixFrom(addr, bits).Masked(); !containsPrefix(prefixes, p) {
+				prefixes = append(prefixes, p)
+			}
+			break
+		}
+	}
+	return prefixes
+}
+
+// SynthesizedIPv4 returns the IPv4 address embedded in the IPv6 address, and the prefix matched,
+// when the address was synthesized using one of the NAT64 prefixes.
+func SynthesizedIPv4(addr netip.Addr, prefixes []netip.Prefix) (netip.Addr, netip.Prefix, bool) {
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Addr{}, netip.Prefix{}, false
+	}
+
+	for _, p := range prefixes {
+		if !p.Contains(addr) {
+			continue
+		}
+		if v4, ok := embeddedIPv4(addr, p.Bits()); ok {
+			return v4, p, true
+		}
+	}
+	return netip.Addr{}, netip.Prefix{}, false
+}
+
+// embeddedIPv4 extracts the IPv4 address following the prefix of the length provided,
+// skipping the bits 64 to 71 that must be zero.
+func embeddedIPv4(addr netip.Addr, bits int) (netip.Addr, bool) {
+	b := addr.As16()
+	if bits != 96 && b[8] != 0 {
+		return netip.Addr{}, false
+	}
+
+	var v4 [4]byte
+	for i, pos := 0, bits/8; i < len(v4); pos++ {
+		if pos == 8 {
+			continue
+		}
+		v4[i] = b[pos]
+		i++
+	}
+	return netip.AddrFrom4(v4), true
+}
+
+func containsPrefix(prefixes []netip.Prefix, p netip.Prefix) bool {
+	for _, cur := range prefixes {
+		if cur == p {
+			return true
+		}
+	}
+	return false
+}
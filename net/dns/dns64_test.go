@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestSynthesizedIPv4(t *testing.T) {
+	// The examples of RFC 6052, section 2.4, embedding 192.0.2.33
+	tests := []struct {
+		prefix string
+		addr   string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::192.0.2.33"},
+		{"64:ff9b::/96", "64:ff9b::c000:221"},
+	}
+
+	expected := netip.MustParseAddr("192.0.2.33")
+	for _, test := range tests {
+		prefix := netip.MustParsePrefix(test.prefix)
+
+		v4, p, ok := SynthesizedIPv4(netip.MustParseAddr(test.addr), []netip.Prefix{WellKnownNAT64Prefix, prefix})
+		if !ok || v4 != expected || p != prefix {
+			t.Errorf("%s returned %v from %v, expected %v from %v", test.addr, v4, p, expected, prefix)
+		}
+	}
+
+	for _, addr := range []string{"2606:4700::6810:1b4d", "192.0.2.33", "::ffff:192.0.2.33"} {
+		if _, _, ok := SynthesizedIPv4(netip.MustParseAddr(addr), []netip.Prefix{WellKnownNAT64Prefix}); ok {
+			t.Errorf("%s was considered synthesized", addr)
+		}
+	}
+}
+
+func TestNAT64Prefixes(t *testing.T) {
+	answers := []netip.Addr{
+		netip.MustParseAddr("64:ff9b::c000:aa"),
+		netip.MustParseAddr("64:ff9b::c000:ab"),
+		netip.MustParseAddr("2001:db8:122:344:c0:0:aa00:0"),
+		netip.MustParseAddr("2606:4700::6810:1b4d"),
+		netip.MustParseAddr("192.0.0.170"),
+	}
+
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("64:ff9b::/96"),
+		netip.MustParsePrefix("2001:db8:122:344::/64"),
+	}
+	if got := NAT64Prefixes(answers); !reflect.DeepEqual(got, expected) {
+		t.Errorf("NAT64Prefixes returned %v, expected %v", got, expected)
+	}
+}
@@ -5,6 +5,7 @@
 package dns
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -158,3 +159,23 @@ func TestExpandIPv6Addr(t *testing.T) {
 		}
 	}
 }
+
+func TestScutilServers(t *testing.T) {
+	out := `DNS configuration
+
+resolver #1
+  search domain[0] : corp.example
+  nameserver[0] : 10.0.0.53
+  nameserver[1] : fd00::53
+  if_index : 6 (en0)
+
+resolver #2
+  domain   : local
+  options  : mdns
+`
+
+	expected := []string{"10.0.0.53:53", "[fd00::53]:53"}
+	if got := uniqueResolvers(scutilServers(out)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected Result, expected %v, got %v", expected, got)
+	}
+}
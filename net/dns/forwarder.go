@@ -0,0 +1,311 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// The time allowed for an upstream resolver to answer a forwarded query
+	forwardTimeout = 5 * time.Second
+	// The number of upstream resolvers tried before the query fails
+	forwardAttempts = 3
+	// The number of forwarded queries that can be outstanding at once
+	maxForwarded = 1024
+	// The number of idle connections kept for each upstream resolver
+	maxIdleConns = 8
+)
+
+// DialFunc establishes the connections used to reach the upstream resolvers.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Forwarder relays the DNS queries received on a local UDP address to the upstream resolvers, using
+// connections established by the provided dial function. This allows resolver pools that only send
+// UDP datagrams to reach the upstream resolvers through a SOCKS proxy or a selected interface.
+type Forwarder struct {
+	sync.Mutex
+	conn      *net.UDPConn
+	network   string
+	dial      DialFunc
+	upstreams []string
+	routes    map[string][]string
+	next      int
+	idle      map[string][]net.Conn
+	sem       chan struct{}
+	done      chan struct{}
+}
+
+// NewForwarder returns a Forwarder listening on an ephemeral loopback port. The queries are sent to the
+// upstream resolvers in turn, using the 'tcp' or 'udp' network, and the next resolver is tried on failure.
+func NewForwarder(network string, dial DialFunc, upstreams ...string) (*Forwarder, error) {
+	if network != "tcp" && network != "udp" {
+		return nil, errors.New("the forwarder requires the tcp or udp network")
+	}
+
+	addrs := upstreamAddrs(upstreams)
+	if len(addrs) == 0 {
+		return nil, errors.New("the forwarder requires upstream resolvers")
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Forwarder{
+		conn:      conn,
+		network:   network,
+		dial:      dial,
+		upstreams: addrs,
+		routes:    make(map[string][]string),
+		idle:      make(map[string][]net.Conn),
+		sem:       make(chan struct{}, maxForwarded),
+		done:      make(chan struct{}),
+	}
+	go f.serve()
+	return f, nil
+}
+
+func upstreamAddrs(upstreams []string) []string {
+	var addrs []string
+
+	for _, addr := range upstreams {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// AddRoute sends the queries for the domain name and its subdomains to the provided upstream resolvers,
+// such as the internal resolvers of a split-horizon domain. The route with the longest domain name is used.
+func (f *Forwarder) AddRoute(domain string, upstreams ...string) error {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	if domain == "" {
+		return errors.New("the route requires a domain name")
+	}
+
+	addrs := upstreamAddrs(upstreams)
+	if len(addrs) == 0 {
+		return errors.New("the route requires upstream resolvers")
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.routes[domain] = addrs
+	return nil
+}
+
+// routeUpstreams returns the upstream resolvers of the longest route matching the name, or the default upstream resolvers.
+func (f *Forwarder) routeUpstreams(name string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	for {
+		if addrs, found := f.routes[name]; found {
+			return addrs
+		}
+
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			return f.upstreams
+		}
+		name = parent
+	}
+}
+
+// Addr returns the local address that receives the queries to be forwarded.
+func (f *Forwarder) Addr() string {
+	return f.conn.LocalAddr().String()
+}
+
+// Close stops the forwarder and releases the connections to the upstream resolvers.
+func (f *Forwarder) Close() error {
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	close(f.done)
+
+	f.Lock()
+	for _, conns := range f.idle {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	f.idle = make(map[string][]net.Conn)
+	f.Unlock()
+	return f.conn.Close()
+}
+
+func (f *Forwarder) serve() {
+	buf := make([]byte, dns.MaxMsgSize)
+
+	for {
+		n, addr, err := f.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-f.done:
+				return
+			default:
+				continue
+			}
+		}
+
+		msg := new(dns.Msg)
+		if err := msg.Unpack(buf[:n]); err != nil || len(msg.Question) == 0 {
+			continue
+		}
+
+		f.sem <- struct{}{}
+		go func(msg *dns.Msg, addr *net.UDPAddr) {
+			defer func() { <-f.sem }()
+
+			resp := f.forward(msg)
+			if data, err := resp.Pack(); err == nil {
+				_, _ = f.conn.WriteToUDP(data, addr)
+			}
+		}(msg, addr)
+	}
+}
+
+// forward returns the response of the first upstream resolver to answer, or a server failure.
+func (f *Forwarder) forward(msg *dns.Msg) *dns.Msg {
+	f.Lock()
+	upstreams := f.routeUpstreams(msg.Question[0].Name)
+	start := f.next % len(upstreams)
+	f.next++
+	f.Unlock()
+
+	for i := 0; i < forwardAttempts && i < len(upstreams); i++ {
+		upstream := upstreams[(start+i)%len(upstreams)]
+
+		if resp, err := f.exchange(msg, upstream); err == nil {
+			return resp
+		}
+	}
+
+	return new(dns.Msg).SetRcode(msg, dns.RcodeServerFailure)
+}
+
+func (f *Forwarder) exchange(msg *dns.Msg, upstream string) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+	defer cancel()
+
+	conn, err := f.getConn(ctx, upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(forwardTimeout))
+	if err := f.writeMsg(conn, msg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	for {
+		resp, err := f.readMsg(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		// Late responses to abandoned queries can be waiting on reused connections
+		if resp.Id == msg.Id && len(resp.Question) > 0 && resp.Question[0].Name == msg.Question[0].Name {
+			f.putConn(upstream, conn)
+			return resp, nil
+		}
+	}
+}
+
+// writeMsg sends the query with the length prefix required by DNS over TCP. The connections
+// can be wrapped by the dial function, so the network cannot be learned from their type.
+func (f *Forwarder) writeMsg(conn net.Conn, msg *dns.Msg) error {
+	data, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+
+	if f.network == "tcp" {
+		prefix := make([]byte, 2, 2+len(data))
+		binary.BigEndian.PutUint16(prefix, uint16(len(data)))
+		data = append(prefix, data...)
+	}
+
+	_, err = conn.Write(data)
+	return err
+}
+
+func (f *Forwarder) readMsg(conn net.Conn) (*dns.Msg, error) {
+	var buf []byte
+
+	if f.network == "tcp" {
+		var prefix [2]byte
+
+		if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(prefix[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		buf = make([]byte, dns.MaxMsgSize)
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	resp := new(dns.Msg)
+	if err := resp.Unpack(buf); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (f *Forwarder) getConn(ctx context.Context, upstream string) (net.Conn, error) {
+	f.Lock()
+	if conns := f.idle[upstream]; len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		f.idle[upstream] = conns[:len(conns)-1]
+		f.Unlock()
+		return conn, nil
+	}
+	f.Unlock()
+
+	return f.dial(ctx, f.network, upstream)
+}
+
+func (f *Forwarder) putConn(upstream string, conn net.Conn) {
+	f.Lock()
+	defer f.Unlock()
+
+	select {
+	case <-f.done:
+		conn.Close()
+		return
+	default:
+	}
+
+	if len(f.idle[upstream]) >= maxIdleConns {
+		conn.Close()
+		return
+	}
+	f.idle[upstream] = append(f.idle[upstream], conn)
+}
@@ -0,0 +1,142 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestForwarder(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		upstream := startTestServer(t, network, "192.0.2.1")
+
+		var dialed int
+		d := &net.Dialer{}
+		f, err := NewForwarder(network, func(ctx context.Context, n, addr string) (net.Conn, error) {
+			dialed++
+			return d.DialContext(ctx, n, addr)
+		}, upstream)
+		if err != nil {
+			t.Fatalf("%s: failed to create the forwarder: %v", network, err)
+		}
+
+		for i := 0; i < 3; i++ {
+			resp, err := dns.Exchange(new(dns.Msg).SetQuestion("www.owasp.org.", dns.TypeA), f.Addr())
+			if err != nil {
+				t.Fatalf("%s: the forwarded query failed: %v", network, err)
+			}
+			if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "192.0.2.1" {
+				t.Errorf("%s: the forwarded query returned %v", network, resp.Answer)
+			}
+		}
+		if dialed != 1 {
+			t.Errorf("%s: the forwarder established %d connections, expected one to be reused", network, dialed)
+		}
+		f.Close()
+	}
+}
+
+func TestForwarderFailure(t *testing.T) {
+	f, err := NewForwarder("tcp", func(ctx context.Context, n, addr string) (net.Conn, error) {
+		return nil, errors.New("the tunnel is down")
+	}, "192.0.2.53", "192.0.2.54")
+	if err != nil {
+		t.Fatalf("failed to create the forwarder: %v", err)
+	}
+	defer f.Close()
+
+	resp, err := dns.Exchange(new(dns.Msg).SetQuestion("www.owasp.org.", dns.TypeA), f.Addr())
+	if err != nil {
+		t.Fatalf("the forwarded query failed: %v", err)
+	}
+	if resp.Rcode != dns.RcodeServerFailure {
+		t.Errorf("the forwarder returned %s when the upstream resolvers were unreachable", dns.RcodeToString[resp.Rcode])
+	}
+
+	if _, err := NewForwarder("tcp", nil); err == nil {
+		t.Error("the forwarder was created without upstream resolvers")
+	}
+}
+
+func TestForwarderRoutes(t *testing.T) {
+	public := startTestServer(t, "udp", "192.0.2.1")
+	internal := startTestServer(t, "udp", "10.0.0.1")
+	lab := startTestServer(t, "udp", "10.1.0.1")
+
+	d := &net.Dialer{}
+	f, err := NewForwarder("udp", d.DialContext, public)
+	if err != nil {
+		t.Fatalf("failed to create the forwarder: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.AddRoute("corp.owasp.org.", internal); err != nil {
+		t.Fatalf("failed to add the route: %v", err)
+	}
+	if err := f.AddRoute("lab.corp.owasp.org", lab); err != nil {
+		t.Fatalf("failed to add the route: %v", err)
+	}
+	if err := f.AddRoute("owasp.org"); err == nil {
+		t.Error("the route was added without upstream resolvers")
+	}
+
+	for name, expected := range map[string]string{
+		"www.owasp.org.":             "192.0.2.1",
+		"notcorp.owasp.org.":         "192.0.2.1",
+		"corp.owasp.org.":            "10.0.0.1",
+		"VPN.Corp.owasp.org.":        "10.0.0.1",
+		"host.lab.corp.owasp.org.":   "10.1.0.1",
+		"host.lab.corp.example.com.": "192.0.2.1",
+	} {
+		resp, err := dns.Exchange(new(dns.Msg).SetQuestion(name, dns.TypeA), f.Addr())
+		if err != nil {
+			t.Fatalf("the forwarded query for %s failed: %v", name, err)
+		}
+		if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != expected {
+			t.Errorf("the forwarded query for %s returned %v, expected %s", name, resp.Answer, expected)
+		}
+	}
+}
+
+func startTestServer(t *testing.T, network, addr string) string {
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg).SetReply(req)
+		if rr, err := dns.NewRR(req.Question[0].Name + " 300 IN A " + addr); err == nil {
+			resp.Answer = append(resp.Answer, rr)
+		}
+		_ = w.WriteMsg(resp)
+	})
+
+	srv := &dns.Server{Handler: handler}
+	if network == "tcp" {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.Listener = l
+	} else {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.PacketConn = pc
+	}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	if srv.Listener != nil {
+		return srv.Listener.Addr().String()
+	}
+	return srv.PacketConn.LocalAddr().String()
+}
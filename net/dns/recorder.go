@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Recorder appends the records of the DNS responses to a passive DNS dump in the Common Output Format,
+// so the responses obtained by an enumeration can be imported by a Dataset and replayed offline.
+type Recorder struct {
+	sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+	seen map[string]struct{}
+}
+
+// NewRecorder returns a Recorder appending to the file at the provided path.
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		f:    f,
+		w:    bufio.NewWriter(f),
+		seen: make(map[string]struct{}),
+	}, nil
+}
+
+// Record appends the answers of the successful response, skipping the records already written by the Recorder.
+func (r *Recorder) Record(resp *dns.Msg) {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for _, rr := range resp.Answer {
+		hdr := rr.Header()
+		if hdr.Rrtype == dns.TypeOPT {
+			continue
+		}
+
+		rrtype := dns.TypeToString[hdr.Rrtype]
+		rdata := strings.TrimPrefix(rr.String(), hdr.String())
+		// The TTL is left out, so the same record is only written once
+		key := strings.ToLower(hdr.Name) + "|" + rrtype + "|" + rdata
+		if _, found := r.seen[key]; found {
+			continue
+		}
+		r.seen[key] = struct{}{}
+
+		value, _ := json.Marshal(rdata)
+		if data, err := json.Marshal(&passiveRecord{RRName: hdr.Name, RRType: rrtype, RData: value}); err == nil {
+			_, _ = r.w.Write(append(data, '\n'))
+		}
+	}
+}
+
+// Close writes the records remaining in the buffer, and closes the file.
+func (r *Recorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns_cache.json")
+
+	r, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder returned an error: %v", err)
+	}
+
+	resp := new(dns.Msg).SetReply(resolve.QueryMsg("docs.owasp.org", dns.TypeA))
+	for _, s := range []string{
+		"docs.owasp.org. 300 IN CNAME www.owasp.org.",
+		"www.owasp.org. 300 IN A 192.0.2.1",
+		"www.owasp.org. 60 IN A 192.0.2.1",
+	} {
+		rr, _ := dns.NewRR(s)
+		resp.Answer = append(resp.Answer, rr)
+	}
+	r.Record(resp)
+
+	failed := new(dns.Msg).SetRcode(resolve.QueryMsg("mail.owasp.org", dns.TypeA), dns.RcodeServerFailure)
+	rr, _ := dns.NewRR("mail.owasp.org. 300 IN A 192.0.2.25")
+	failed.Answer = append(failed.Answer, rr)
+	r.Record(failed)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	d := NewDataset(nil)
+	if num, err := d.LoadFile(path); err != nil || num != 2 {
+		t.Fatalf("LoadFile imported %d recorded records, expected 2: %v", num, err)
+	}
+
+	ans := d.Answer(resolve.QueryMsg("docs.owasp.org", dns.TypeA))
+	if len(ans.Answer) != 2 {
+		t.Errorf("The recorded records provided %d answers, expected 2", len(ans.Answer))
+	}
+	if ans := d.Answer(resolve.QueryMsg("mail.owasp.org", dns.TypeA)); ans.Rcode != dns.RcodeNameError {
+		t.Errorf("The records of the failed response were recorded")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// ExtractRecords returns the answers in the DNS message, including the CAA, NAPTR, HTTPS
+// and SVCB records that are not extracted by the resolve package. The data of those
+// records is provided in the presentation format, such as "1 . alpn=h3,h2 ipv4hint=192.0.2.1".
+func ExtractRecords(msg *dns.Msg) []*resolve.ExtractedAnswer {
+	data := resolve.ExtractAnswers(msg)
+	if msg == nil {
+		return data
+	}
+
+	for _, rr := range msg.Answer {
+		switch rr.Header().Rrtype {
+		case dns.TypeCAA, dns.TypeNAPTR, dns.TypeHTTPS, dns.TypeSVCB:
+		default:
+			continue
+		}
+
+		if value := strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String())); value != "" {
+			data = append(data, &resolve.ExtractedAnswer{
+				Name: strings.ToLower(resolve.RemoveLastDot(rr.Header().Name)),
+				Type: rr.Header().Rrtype,
+				Data: value,
+			})
+		}
+	}
+	return data
+}
+
+// RecordTarget returns the domain name the NAPTR, HTTPS or SVCB record data refers to,
+// or an empty string when the record refers to the owner name or to no name at all.
+func RecordTarget(rrtype uint16, data string) string {
+	fields := strings.Fields(data)
+
+	var target string
+	switch rrtype {
+	case dns.TypeHTTPS, dns.TypeSVCB:
+		if len(fields) > 1 {
+			target = fields[1]
+		}
+	case dns.TypeNAPTR:
+		if len(fields) > 0 {
+			target = fields[len(fields)-1]
+		}
+	}
+	return strings.ToLower(resolve.RemoveLastDot(target))
+}
+
+// ServiceBinding is the information advertised by an HTTPS or SVCB record.
+type ServiceBinding struct {
+	Priority int
+	// Target is empty when the record refers to the owner name
+	Target string
+	// Port is zero when the record does not advertise an alternative port
+	Port  int
+	ALPN  []string
+	Hints []string
+	ECH   bool
+	// ECHPublicNames are the client-facing names provided in the ECH configurations
+	ECHPublicNames []string
+}
+
+// ParseServiceBinding returns the information held by the HTTPS or SVCB record data.
+func ParseServiceBinding(rrtype uint16, data string) (*ServiceBinding, error) {
+	if rrtype != dns.TypeHTTPS && rrtype != dns.TypeSVCB {
+		return nil, errors.New("the record is not an HTTPS or SVCB record")
+	}
+
+	rr, err := dns.NewRR(". 0 IN " + dns.TypeToString[rrtype] + " " + data)
+	if err != nil {
+		return nil, err
+	}
+
+	var svcb *dns.SVCB
+	switch v := rr.(type) {
+	case *dns.HTTPS:
+		svcb = &v.SVCB
+	case *dns.SVCB:
+		svcb = v
+	default:
+		return nil, errors.New("failed to parse the service binding")
+	}
+
+	sb := &ServiceBinding{
+		Priority: int(svcb.Priority),
+		Target:   strings.ToLower(resolve.RemoveLastDot(svcb.Target)),
+	}
+	for _, kv := range svcb.Value {
+		switch v := kv.(type) {
+		case *dns.SVCBAlpn:
+			sb.ALPN = append(sb.ALPN, v.Alpn...)
+		case *dns.SVCBPort:
+			sb.Port = int(v.Port)
+		case *dns.SVCBIPv4Hint:
+			for _, ip := range v.Hint {
+				sb.Hints = append(sb.Hints, ip.String())
+			}
+		case *dns.SVCBIPv6Hint:
+			for _, ip := range v.Hint {
+				sb.Hints = append(sb.Hints, ip.String())
+			}
+		case *dns.SVCBECHConfig:
+			sb.ECH = true
+			sb.ECHPublicNames = ECHPublicNames(v.ECH)
+		}
+	}
+	return sb, nil
+}
+
+// ECHPublicNames returns the public names of the configurations in the ECHConfigList.
+func ECHPublicNames(list []byte) []string {
+	if len(list) < 2 {
+		return nil
+	}
+
+	var names []string
+	buf := list[2:]
+	for len(buf) >= 4 {
+		version := binary.BigEndian.Uint16(buf)
+		length := int(binary.BigEndian.Uint16(buf[2:]))
+		if len(buf) < 4+length {
+			break
+		}
+
+		contents := buf[4 : 4+length]
+		buf = buf[4+length:]
+		// Only the configuration version defined by the published draft is understood
+		if version != 0xfe0d {
+			continue
+		}
+		if name := echConfigPublicName(contents); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func echConfigPublicName(c []byte) string {
+	// config_id and kem_id
+	pos := 3
+	// public_key and cipher_suites
+	for i := 0; i < 2; i++ {
+		if len(c) < pos+2 {
+			return ""
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(c[pos:]))
+	}
+	// maximum_name_length
+	pos++
+	if len(c) < pos+1 {
+		return ""
+	}
+
+	l := int(c[pos])
+	pos++
+	if len(c) < pos+l {
+		return ""
+	}
+	return strings.ToLower(string(c[pos : pos+l]))
+}
@@ -0,0 +1,131 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestExtractRecords(t *testing.T) {
+	msg := new(dns.Msg)
+	for _, s := range []string{
+		"owasp.org. 300 IN A 192.0.2.1",
+		"owasp.org. 300 IN CAA 0 issue \"letsencrypt.org\"",
+		"owasp.org. 300 IN NAPTR 100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.owasp.org.",
+		"www.owasp.org. 300 IN HTTPS 1 cdn.owasp.org. alpn=\"h3,h2\" port=8443",
+		"_dns.owasp.org. 300 IN SVCB 1 dns.owasp.org. alpn=\"dot\"",
+	} {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatalf("Failed to parse the record %s: %v", s, err)
+		}
+		msg.Answer = append(msg.Answer, rr)
+	}
+
+	expected := map[uint16]string{
+		dns.TypeA:     "192.0.2.1",
+		dns.TypeCAA:   "0 issue \"letsencrypt.org\"",
+		dns.TypeNAPTR: "100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.owasp.org.",
+		dns.TypeHTTPS: "1 cdn.owasp.org. alpn=\"h3,h2\" port=\"8443\"",
+		dns.TypeSVCB:  "1 dns.owasp.org. alpn=\"dot\"",
+	}
+
+	records := ExtractRecords(msg)
+	if len(records) != len(expected) {
+		t.Fatalf("ExtractRecords returned %d records, expected %d", len(records), len(expected))
+	}
+	for _, rr := range records {
+		if rr.Data != expected[rr.Type] {
+			t.Errorf("ExtractRecords returned %s for type %d, expected %s", rr.Data, rr.Type, expected[rr.Type])
+		}
+	}
+}
+
+func TestRecordTarget(t *testing.T) {
+	tests := []struct {
+		rrtype   uint16
+		data     string
+		expected string
+	}{
+		{dns.TypeHTTPS, "1 cdn.owasp.org. alpn=\"h3,h2\"", "cdn.owasp.org"},
+		{dns.TypeHTTPS, "1 . alpn=\"h2\"", ""},
+		{dns.TypeSVCB, "0 Alias.OWASP.org.", "alias.owasp.org"},
+		{dns.TypeNAPTR, "100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.owasp.org.", "_sip._udp.owasp.org"},
+		{dns.TypeCAA, "0 issue \"letsencrypt.org\"", ""},
+	}
+
+	for _, test := range tests {
+		if target := RecordTarget(test.rrtype, test.data); target != test.expected {
+			t.Errorf("RecordTarget returned %s for %s, expected %s", target, test.data, test.expected)
+		}
+	}
+}
+
+func TestParseServiceBinding(t *testing.T) {
+	ech := base64.StdEncoding.EncodeToString(echConfigList("public.owasp.org"))
+
+	sb, err := ParseServiceBinding(dns.TypeHTTPS, "1 origin.owasp.org. alpn=\"h3,h2\" port=8443 ipv4hint=192.0.2.1 ech=\""+ech+"\"")
+	if err != nil {
+		t.Fatalf("ParseServiceBinding failed: %v", err)
+	}
+
+	expected := &ServiceBinding{
+		Priority:       1,
+		Target:         "origin.owasp.org",
+		Port:           8443,
+		ALPN:           []string{"h3", "h2"},
+		Hints:          []string{"192.0.2.1"},
+		ECH:            true,
+		ECHPublicNames: []string{"public.owasp.org"},
+	}
+	if !reflect.DeepEqual(sb, expected) {
+		t.Errorf("ParseServiceBinding returned %+v, expected %+v", sb, expected)
+	}
+
+	if sb, err := ParseServiceBinding(dns.TypeSVCB, "0 alias.owasp.org."); err != nil || sb.Priority != 0 || sb.Target != "alias.owasp.org" {
+		t.Errorf("ParseServiceBinding failed to parse the alias mode record: %v", err)
+	}
+	if _, err := ParseServiceBinding(dns.TypeCAA, "0 issue \"letsencrypt.org\""); err == nil {
+		t.Errorf("ParseServiceBinding accepted a CAA record")
+	}
+}
+
+func TestECHPublicNames(t *testing.T) {
+	if names := ECHPublicNames(echConfigList("public.owasp.org")); !reflect.DeepEqual(names, []string{"public.owasp.org"}) {
+		t.Errorf("ECHPublicNames returned %v", names)
+	}
+	if names := ECHPublicNames([]byte{0x00, 0x04, 0xfe, 0x0d, 0x00, 0x10}); len(names) != 0 {
+		t.Errorf("ECHPublicNames returned %v for a truncated configuration", names)
+	}
+}
+
+func echConfigList(public string) []byte {
+	var contents []byte
+	// config_id and kem_id
+	contents = append(contents, 0x01, 0x00, 0x20)
+	// public_key
+	contents = binary.BigEndian.AppendUint16(contents, 32)
+	contents = append(contents, make([]byte, 32)...)
+	// cipher_suites
+	contents = binary.BigEndian.AppendUint16(contents, 4)
+	contents = append(contents, 0x00, 0x01, 0x00, 0x01)
+	// maximum_name_length and public_name
+	contents = append(contents, 0x00, byte(len(public)))
+	contents = append(contents, []byte(public)...)
+	// extensions
+	contents = binary.BigEndian.AppendUint16(contents, 0)
+
+	config := binary.BigEndian.AppendUint16(nil, 0xfe0d)
+	config = binary.BigEndian.AppendUint16(config, uint16(len(contents)))
+	config = append(config, contents...)
+
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(config)))
+	return append(list, config...)
+}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"errors"
+	"net"
+	"regexp"
+
+	"github.com/miekg/dns"
+)
+
+var scutilNameserverRE = regexp.MustCompile(`nameserver\[[0-9]+\]\s*:\s*(\S+)`)
+
+// SystemResolvers returns the addresses of the DNS resolvers configured by the operating system.
+func SystemResolvers() ([]string, error) {
+	addrs, err := systemResolvers()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs = uniqueResolvers(addrs)
+	if len(addrs) == 0 {
+		return nil, errors.New("no DNS resolvers are configured by the operating system")
+	}
+	return addrs, nil
+}
+
+// resolvConfServers returns the name servers found in the resolv.conf file at the provided path.
+func resolvConfServers(path string) ([]string, error) {
+	conf, err := dns.ClientConfigFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []string
+	for _, server := range conf.Servers {
+		addrs = append(addrs, net.JoinHostPort(server, conf.Port))
+	}
+	return addrs, nil
+}
+
+// scutilServers returns the name servers found in the output of 'scutil --dns'.
+func scutilServers(out string) []string {
+	var addrs []string
+
+	for _, m := range scutilNameserverRE.FindAllStringSubmatch(out, -1) {
+		addrs = append(addrs, net.JoinHostPort(m[1], "53"))
+	}
+	return addrs
+}
+
+func uniqueResolvers(addrs []string) []string {
+	var unique []string
+	seen := make(map[string]struct{})
+
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		// Link-local and loopback stub resolvers are kept, since they are often the only path to the corporate DNS
+		if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+			continue
+		}
+		if _, found := seen[addr]; !found {
+			seen[addr] = struct{}{}
+			unique = append(unique, addr)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,19 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build darwin
+
+package dns
+
+import "os/exec"
+
+func systemResolvers() ([]string, error) {
+	// The resolv.conf file on macOS does not reflect the scoped resolvers used by VPN clients
+	if out, err := exec.Command("scutil", "--dns").Output(); err == nil {
+		if addrs := scutilServers(string(out)); len(addrs) > 0 {
+			return addrs, nil
+		}
+	}
+	return resolvConfServers("/etc/resolv.conf")
+}
@@ -0,0 +1,11 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows && !darwin
+
+package dns
+
+func systemResolvers() ([]string, error) {
+	return resolvConfServers("/etc/resolv.conf")
+}
@@ -0,0 +1,200 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrEgressDisabled is returned for the connections attempted after the network egress has been disabled.
+	ErrEgressDisabled = errors.New("network egress has been disabled")
+	// ErrEgressNotAllowed is returned for the connections to the hosts outside the egress allowlist.
+	ErrEgressNotAllowed = errors.New("the host is outside the egress allowlist")
+)
+
+// The addresses dialed to verify that no connections leave the host
+var egressProbes = []string{"1.1.1.1:53", "8.8.8.8:443", "[2606:4700:4700::1111]:443"}
+
+var egress struct {
+	sync.Mutex
+	disabled   bool
+	restricted bool
+	names      []string
+	prefixes   []netip.Prefix
+	logger     *log.Logger
+	blocked    map[string]int
+}
+
+type approvedEgressKey struct{}
+
+// DisableEgress causes DialContext to refuse all connections for the remainder of the process,
+// as required by offline analysis. The connections refused are kept for BlockedEgress.
+func DisableEgress() {
+	egress.Lock()
+	defer egress.Unlock()
+
+	egress.disabled = true
+	egress.blocked = make(map[string]int)
+}
+
+// EgressDisabled returns true when the network egress has been disabled.
+func EgressDisabled() bool {
+	egress.Lock()
+	defer egress.Unlock()
+
+	return egress.disabled
+}
+
+// RestrictEgress causes DialContext to refuse the connections to the hosts not matching the patterns
+// of the egress allowlist, for the remainder of the process. The refused connections are logged once
+// for each address, and kept for BlockedEgress.
+func RestrictEgress(l *log.Logger, patterns ...string) {
+	egress.Lock()
+	egress.restricted = true
+	egress.logger = l
+	if egress.blocked == nil {
+		egress.blocked = make(map[string]int)
+	}
+	egress.Unlock()
+
+	AllowEgress(patterns...)
+}
+
+// AllowEgress adds patterns to the egress allowlist. The patterns are IP addresses, CIDRs and host
+// names, where the names also match their subdomains, and the leading '*.' of a pattern is ignored.
+func AllowEgress(patterns ...string) {
+	egress.Lock()
+	defer egress.Unlock()
+
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if host, _, err := net.SplitHostPort(p); err == nil {
+			p = host
+		}
+
+		if prefix, err := netip.ParsePrefix(p); err == nil {
+			egress.prefixes = append(egress.prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(p); err == nil {
+			egress.prefixes = append(egress.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else if name := strings.Trim(strings.TrimPrefix(p, "*."), "."); name != "" {
+			egress.names = append(egress.names, name)
+		}
+	}
+}
+
+// EgressRestricted returns true when the connections are limited to the egress allowlist.
+func EgressRestricted() bool {
+	egress.Lock()
+	defer egress.Unlock()
+
+	return egress.restricted
+}
+
+// CheckEgress returns an error when the connection to the address, a host with an optional port,
+// would be refused. The refused connections are counted as blocked egress.
+func CheckEgress(addr string) error {
+	return checkEgress(addr, true)
+}
+
+// ApproveEgress returns a context causing DialContext to accept the addresses outside the egress allowlist,
+// such as the addresses resolved for a host name already accepted by CheckEgress.
+func ApproveEgress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedEgressKey{}, true)
+}
+
+func approvedEgress(ctx context.Context) bool {
+	approved, _ := ctx.Value(approvedEgressKey{}).(bool)
+	return approved
+}
+
+// BlockedEgress returns the addresses of the connections refused since the egress was disabled or restricted,
+// along with the number of attempts made for each.
+func BlockedEgress() map[string]int {
+	egress.Lock()
+	defer egress.Unlock()
+
+	blocked := make(map[string]int, len(egress.blocked))
+	for addr, count := range egress.blocked {
+		blocked[addr] = count
+	}
+	return blocked
+}
+
+// VerifyEgressDisabled attempts connections to well-known addresses, and returns an error
+// when any of them is not refused. The attempts are not counted as blocked egress.
+func VerifyEgressDisabled(ctx context.Context) error {
+	if !EgressDisabled() {
+		return errors.New("network egress has not been disabled")
+	}
+
+	for _, addr := range egressProbes {
+		conn, err := DialContext(ctx, "tcp", addr)
+		if conn != nil {
+			conn.Close()
+		}
+		if !errors.Is(err, ErrEgressDisabled) {
+			return fmt.Errorf("the connection to %s was not refused", addr)
+		}
+	}
+
+	egress.Lock()
+	defer egress.Unlock()
+
+	for _, addr := range egressProbes {
+		delete(egress.blocked, addr)
+	}
+	return nil
+}
+
+func checkEgress(addr string, allowlist bool) error {
+	egress.Lock()
+	defer egress.Unlock()
+
+	if egress.disabled {
+		egress.blocked[addr]++
+		return ErrEgressDisabled
+	}
+	if !allowlist || !egress.restricted || allowedEgress(addr) {
+		return nil
+	}
+
+	if egress.blocked[addr]++; egress.blocked[addr] == 1 && egress.logger != nil {
+		egress.logger.Printf("Blocked the connection to %s outside the egress allowlist", addr)
+	}
+	return ErrEgressNotAllowed
+}
+
+// allowedEgress must be called while holding the egress lock.
+func allowedEgress(addr string) bool {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range egress.prefixes {
+			if prefix.Contains(ip.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	host = strings.Trim(strings.ToLower(host), ".")
+	for _, name := range egress.names {
+		if host == name || strings.HasSuffix(host, "."+name) {
+			return true
+		}
+	}
+	return false
+}
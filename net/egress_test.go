@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDisableEgress(t *testing.T) {
+	defer func() {
+		egress.Lock()
+		egress.disabled = false
+		egress.Unlock()
+	}()
+
+	if err := VerifyEgressDisabled(context.Background()); err == nil {
+		t.Errorf("VerifyEgressDisabled succeeded before the egress was disabled")
+	}
+
+	DisableEgress()
+	if !EgressDisabled() {
+		t.Fatalf("EgressDisabled returned false after the egress was disabled")
+	}
+	if err := VerifyEgressDisabled(context.Background()); err != nil {
+		t.Errorf("VerifyEgressDisabled failed: %v", err)
+	}
+	if blocked := BlockedEgress(); len(blocked) != 0 {
+		t.Errorf("The verification attempts were counted as blocked egress: %v", blocked)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := DialContext(context.Background(), "tcp", "192.0.2.1:443"); !errors.Is(err, ErrEgressDisabled) {
+			t.Errorf("DialContext returned %v, expected ErrEgressDisabled", err)
+		}
+	}
+	if blocked := BlockedEgress(); blocked["192.0.2.1:443"] != 2 {
+		t.Errorf("BlockedEgress returned %v, expected two attempts to 192.0.2.1:443", blocked)
+	}
+}
+
+func TestRestrictEgress(t *testing.T) {
+	defer func() {
+		egress.Lock()
+		egress.restricted = false
+		egress.names = nil
+		egress.prefixes = nil
+		egress.blocked = nil
+		egress.Unlock()
+	}()
+
+	if err := CheckEgress("169.254.169.254:80"); err != nil {
+		t.Errorf("CheckEgress returned %v before the egress was restricted", err)
+	}
+
+	RestrictEgress(nil, "*.owasp.org", "8.8.8.8:53", "192.0.2.0/24")
+	AllowEgress("2001:db8::1")
+	if !EgressRestricted() {
+		t.Fatalf("EgressRestricted returned false after the egress was restricted")
+	}
+
+	for _, addr := range []string{"owasp.org:443", "www.OWASP.org.:443", "8.8.8.8", "192.0.2.25:443", "[2001:db8::1]:443"} {
+		if err := CheckEgress(addr); err != nil {
+			t.Errorf("CheckEgress refused %s: %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"notowasp.org:443", "169.254.169.254:80", "8.8.4.4:53", "[2001:db8::2]:443"} {
+		if err := CheckEgress(addr); !errors.Is(err, ErrEgressNotAllowed) {
+			t.Errorf("CheckEgress returned %v for %s, expected ErrEgressNotAllowed", err, addr)
+		}
+	}
+
+	if _, err := DialContext(context.Background(), "tcp", "169.254.169.254:80"); !errors.Is(err, ErrEgressNotAllowed) {
+		t.Errorf("DialContext returned %v, expected ErrEgressNotAllowed", err)
+	}
+	if blocked := BlockedEgress(); blocked["169.254.169.254:80"] != 2 {
+		t.Errorf("BlockedEgress returned %v, expected two attempts to 169.254.169.254:80", blocked)
+	}
+}
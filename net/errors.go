@@ -0,0 +1,164 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ErrorKind classifies the failures of the data sources, so the users learn why a source provided no results.
+type ErrorKind string
+
+// The kinds of data source failures.
+const (
+	ErrAuth       ErrorKind = "auth_failure"
+	ErrQuota      ErrorKind = "quota_exceeded"
+	ErrParse      ErrorKind = "parse_error"
+	ErrTimeout    ErrorKind = "network_timeout"
+	ErrOutOfScope ErrorKind = "out_of_scope"
+	ErrNetwork    ErrorKind = "network_error"
+)
+
+var errorMessages = map[ErrorKind]string{
+	ErrAuth:       "invalid credentials",
+	ErrQuota:      "quota exceeded",
+	ErrParse:      "failed to parse the response",
+	ErrTimeout:    "the request timed out",
+	ErrOutOfScope: "the request was outside the scope",
+	ErrNetwork:    "the request failed",
+}
+
+// SourceError is a failure of the named data source.
+type SourceError struct {
+	Source string
+	Kind   ErrorKind
+	Err    error
+}
+
+// NewSourceError returns the SourceError of the kind provided, wrapping the error when there is one.
+// The URL of a failed request is removed from the error, since it can hold the key of the data source.
+func NewSourceError(source string, kind ErrorKind, err error) *SourceError {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+
+	return &SourceError{
+		Source: source,
+		Kind:   kind,
+		Err:    err,
+	}
+}
+
+// Error implements the error interface, such as 'Censys: invalid credentials'.
+func (e *SourceError) Error() string {
+	msg := errorMessages[e.Kind]
+	if msg == "" {
+		msg = string(e.Kind)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return e.Source + ": " + msg
+}
+
+// Unwrap returns the error that caused the failure.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf returns the kind of a failed request. Rejected credentials are only assumed for the 403
+// responses when the request was authenticated, since sites often refuse anonymous clients with them.
+// False is returned when the request succeeded.
+func ErrorKindOf(status int, err error, authenticated bool) (ErrorKind, bool) {
+	if err != nil {
+		var se *SourceError
+		var ne net.Error
+
+		switch {
+		case errors.As(err, &se):
+			return se.Kind, true
+		case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+			return ErrTimeout, true
+		}
+		return ErrNetwork, true
+	}
+
+	switch {
+	case status == 401, status == 403 && authenticated:
+		return ErrAuth, true
+	case status == 402, status == 429:
+		return ErrQuota, true
+	}
+	return "", false
+}
+
+// ErrorCount is the number of failures of a kind for a data source, along with the last failure.
+type ErrorCount struct {
+	Kind  ErrorKind `json:"kind"`
+	Count int       `json:"count"`
+	Last  string    `json:"last"`
+}
+
+var sourceErrors struct {
+	sync.Mutex
+	counts map[string]map[ErrorKind]*ErrorCount
+}
+
+// RecordSourceError adds the failure to the error statistics of its data source.
+func RecordSourceError(err *SourceError) {
+	if err == nil || err.Source == "" {
+		return
+	}
+
+	sourceErrors.Lock()
+	defer sourceErrors.Unlock()
+
+	if sourceErrors.counts == nil {
+		sourceErrors.counts = make(map[string]map[ErrorKind]*ErrorCount)
+	}
+
+	kinds, found := sourceErrors.counts[err.Source]
+	if !found {
+		kinds = make(map[ErrorKind]*ErrorCount)
+		sourceErrors.counts[err.Source] = kinds
+	}
+
+	c, found := kinds[err.Kind]
+	if !found {
+		c = &ErrorCount{Kind: err.Kind}
+		kinds[err.Kind] = c
+	}
+	c.Count++
+	c.Last = err.Error()
+}
+
+// SourceErrorStats returns a copy of the error statistics keyed by source name, with the most frequent kinds first.
+func SourceErrorStats() map[string][]ErrorCount {
+	sourceErrors.Lock()
+	defer sourceErrors.Unlock()
+
+	stats := make(map[string][]ErrorCount, len(sourceErrors.counts))
+	for source, kinds := range sourceErrors.counts {
+		var counts []ErrorCount
+		for _, c := range kinds {
+			counts = append(counts, *c)
+		}
+
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Kind < counts[j].Kind
+		})
+		stats[source] = counts
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestErrorKindOf(t *testing.T) {
+	tests := []struct {
+		status        int
+		err           error
+		authenticated bool
+		kind          ErrorKind
+		failed        bool
+	}{
+		{200, nil, true, "", false},
+		{404, nil, true, "", false},
+		{401, nil, false, ErrAuth, true},
+		{403, nil, true, ErrAuth, true},
+		{403, nil, false, "", false},
+		{429, nil, false, ErrQuota, true},
+		{402, nil, true, ErrQuota, true},
+		{0, fmt.Errorf("request: %w", context.DeadlineExceeded), false, ErrTimeout, true},
+		{0, errors.New("connection refused"), false, ErrNetwork, true},
+		{0, NewSourceError("Test", ErrOutOfScope, nil), false, ErrOutOfScope, true},
+	}
+
+	for _, test := range tests {
+		kind, failed := ErrorKindOf(test.status, test.err, test.authenticated)
+		if kind != test.kind || failed != test.failed {
+			t.Errorf("ErrorKindOf(%d, %v, %t) returned %s and %t, expected %s and %t", test.status,
+				test.err, test.authenticated, kind, failed, test.kind, test.failed)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	cause := &url.Error{Op: "Get", URL: "https://api.example.com/?key=secret", Err: context.DeadlineExceeded}
+
+	err := NewSourceError("TestSource", ErrTimeout, cause)
+	if msg := err.Error(); msg != "TestSource: the request timed out: context deadline exceeded" {
+		t.Errorf("Unexpected error message: %s", msg)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap the cause")
+	}
+	if msg := NewSourceError("TestSource", ErrAuth, nil).Error(); msg != "TestSource: invalid credentials" {
+		t.Errorf("Unexpected error message: %s", msg)
+	}
+}
+
+func TestRecordSourceError(t *testing.T) {
+	RecordSourceError(NewSourceError("TestErrors", ErrQuota, nil))
+	RecordSourceError(NewSourceError("TestErrors", ErrAuth, errors.New("401 Unauthorized")))
+	RecordSourceError(NewSourceError("TestErrors", ErrAuth, errors.New("403 Forbidden")))
+
+	counts, found := SourceErrorStats()["TestErrors"]
+	if !found || len(counts) != 2 {
+		t.Fatalf("Expected two kinds of errors for the source, got %+v", counts)
+	}
+	if c := counts[0]; c.Kind != ErrAuth || c.Count != 2 || c.Last != "TestErrors: invalid credentials: 403 Forbidden" {
+		t.Errorf("Unexpected count of the most frequent kind: %+v", c)
+	}
+	if c := counts[1]; c.Kind != ErrQuota || c.Count != 1 {
+		t.Errorf("Unexpected count of the least frequent kind: %+v", c)
+	}
+}
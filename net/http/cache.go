@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotCached is returned by RequestWebPage when responses are replayed and the request has no cached response.
+var ErrNotCached = errors.New("the response was not found in the HTTP cache")
+
+var responseCache struct {
+	sync.Mutex
+	dir    string
+	replay bool
+}
+
+// UseResponseCache causes the successful responses obtained by RequestWebPage to be kept in the
+// provided directory. When replay is true, the requests are answered from the directory only,
+// so previously cached responses are available to offline analysis. An empty directory disables the cache.
+func UseResponseCache(dir string, replay bool) error {
+	if dir != "" && !replay {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	responseCache.Lock()
+	defer responseCache.Unlock()
+
+	responseCache.dir = dir
+	responseCache.replay = replay
+	return nil
+}
+
+// cachedResponse returns the cached response for the request, and true when the request must not be sent.
+func cachedResponse(r *Request) (*Response, bool, error) {
+	responseCache.Lock()
+	dir, replay := responseCache.dir, responseCache.replay
+	responseCache.Unlock()
+
+	if dir == "" || !replay {
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, cacheKey(r)))
+	if err != nil {
+		return nil, true, ErrNotCached
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, true, err
+	}
+	return &resp, true, nil
+}
+
+// cacheResponse writes the response to the cache directory, when responses are being recorded.
+func cacheResponse(r *Request, resp *Response) {
+	responseCache.Lock()
+	dir, replay := responseCache.dir, responseCache.replay
+	responseCache.Unlock()
+
+	if dir == "" || replay || resp == nil || resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return
+	}
+
+	// The connection state is not needed to replay the response
+	c := *resp
+	c.TLS = nil
+	if data, err := json.Marshal(&c); err == nil {
+		_ = os.WriteFile(filepath.Join(dir, cacheKey(r)), data, 0644)
+	}
+}
+
+// cacheKey identifies the request by the method, URL and body, so the responses to POST requests are kept apart.
+func cacheKey(r *Request) string {
+	h := sha256.New()
+
+	h.Write([]byte(r.Method + "\n" + r.URL + "\n" + r.Body))
+	return hex.EncodeToString(h.Sum(nil)) + ".json"
+}
@@ -0,0 +1,47 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	var count int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		fmt.Fprintf(w, "response %d", count)
+	}))
+	defer ts.Close()
+	defer func() { _ = UseResponseCache("", false) }()
+
+	dir := t.TempDir()
+	if err := UseResponseCache(dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := RequestWebPage(context.Background(), &Request{URL: ts.URL + "/cached"})
+	if err != nil || resp.Body != "response 1" {
+		t.Fatalf("The request to be cached failed: %v", err)
+	}
+
+	_ = UseResponseCache(dir, true)
+	resp, err = RequestWebPage(context.Background(), &Request{URL: ts.URL + "/cached"})
+	if err != nil || resp.Body != "response 1" || resp.StatusCode != http.StatusOK {
+		t.Errorf("The cached response was not replayed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("The server received %d requests, expected 1", count)
+	}
+
+	if _, err := RequestWebPage(context.Background(), &Request{URL: ts.URL + "/cached", Method: "POST", Body: "data"}); !errors.Is(err, ErrNotCached) {
+		t.Errorf("The request without a cached response returned %v, expected ErrNotCached", err)
+	}
+}
@@ -41,6 +41,10 @@ const (
 	darwinUserAgent  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.0.0 Safari/537.36"
 	httpTimeout      = 10 * time.Second
 	handshakeTimeout = 5 * time.Second
+	// Idle connections are kept long enough to be reused by data sources that page through results
+	idleConnTimeout     = 90 * time.Second
+	maxIdleConnsPerHost = 10
+	maxConnsPerHost     = 50
 )
 
 var (
@@ -63,6 +67,10 @@ type Request struct {
 	Header Header
 	Body   string
 	Auth   *BasicAuth
+	// MaxBodySize limits the response body, and the MaxBodySize function provides the limit when zero
+	MaxBodySize int64
+	// ContentTypes are the media types allowed in the response, such as 'application/json' or 'text/*'
+	ContentTypes []string
 }
 
 // Response represents the HTTP response in the Amass preferred format.
@@ -89,16 +97,24 @@ func init() {
 	DefaultClient = &http.Client{
 		Timeout: httpTimeout,
 		Transport: &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
-			DialContext:           amassnet.DialContext,
+			Proxy:                 trackProxies(http.ProxyFromEnvironment),
+			DialContext:           dialContext,
+			ForceAttemptHTTP2:     true,
 			MaxIdleConns:          200,
-			MaxConnsPerHost:       50,
-			IdleConnTimeout:       10 * time.Second,
+			MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+			MaxConnsPerHost:       maxConnsPerHost,
+			IdleConnTimeout:       idleConnTimeout,
 			TLSHandshakeTimeout:   handshakeTimeout,
 			ExpectContinueTimeout: 5 * time.Second,
 			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
 		},
 		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkURLEgress(req.URL)
+		},
 	}
 
 	switch runtime.GOOS {
@@ -206,11 +222,17 @@ func RequestWebPage(ctx context.Context, r *Request) (*Response, error) {
 		return nil, errors.New("failed to provide a valid HTTP method")
 	}
 
+	if resp, cached, err := cachedResponse(r); cached {
+		return resp, err
+	}
+
 	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, strings.NewReader(r.Body))
 	if err != nil {
 		return nil, err
 	}
-	req.Close = true
+	if err := checkURLEgress(req.URL); err != nil {
+		return nil, err
+	}
 
 	if r.Auth != nil && r.Auth.Username != "" && r.Auth.Password != "" {
 		req.SetBasicAuth(r.Auth.Username, r.Auth.Password)
@@ -227,16 +249,43 @@ func RequestWebPage(ctx context.Context, r *Request) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	return RespToAmassResponse(resp), nil
+
+	aresp, err := limitedResponse(resp, r)
+	if err != nil {
+		return nil, err
+	}
+	cacheResponse(r, aresp)
+	return aresp, nil
+}
+
+// checkURLEgress checks the egress allowlist for the host of the URL, since the requests
+// sent through a proxy only dial the address of the proxy.
+func checkURLEgress(u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return amassnet.CheckEgress(net.JoinHostPort(u.Hostname(), port))
 }
 
 // Crawl will spider the web page at the URL argument looking while staying within the scope provided.
-func Crawl(ctx context.Context, u string, scope []string, max int, callback func(*Request, *Response)) error {
+// The URLs of the hosts limited by the prefixes are only followed when allowed, and the prefixes may be nil.
+func Crawl(ctx context.Context, u string, scope []string, prefixes *URLPrefixes, max int, callback func(*Request, *Response)) error {
 	select {
 	case <-ctx.Done():
 		return fmt.Errorf("the context expired")
 	default:
 	}
+	if !prefixes.AllowedString(u) {
+		return fmt.Errorf("the URL %s is outside the URL scope", u)
+	}
+	// The crawler uses its own client, so the dialer cannot refuse the connections
+	if amassnet.EgressDisabled() {
+		return amassnet.ErrEgressDisabled
+	}
 
 	var count int
 	var m sync.Mutex
@@ -248,14 +297,17 @@ func Crawl(ctx context.Context, u string, scope []string, max int, callback func
 		"embed", "form", "frame", "frameset", "html", "iframe", "img", "input",
 		"ins", "link", "noframes", "object", "q", "script", "source", "track", "video"}
 
+	// The politeness policy limits the requests sent to each host along with the other active web modules
+	policy := CurrentPoliteness()
 	g := geziyor.NewGeziyor(&geziyor.Options{
 		StartURLs:             []string{u},
-		RobotsTxtDisabled:     true,
+		RobotsTxtDisabled:     !policy.RobotsTxt,
 		UserAgent:             UserAgent,
 		LogDisabled:           true,
 		ConcurrentRequests:    5,
 		RequestDelay:          50 * time.Millisecond,
 		RequestDelayRandomize: true,
+		MaxBodySize:           MaxBodySize(),
 		ParseFunc: func(g *geziyor.Geziyor, r *client.Response) {
 			select {
 			case <-ctx.Done():
@@ -268,7 +320,7 @@ func Crawl(ctx context.Context, u string, scope []string, max int, callback func
 				if err != nil {
 					return
 				}
-				if host := u.Hostname(); host == "" || whichDomain(host, scope) == "" {
+				if host := u.Hostname(); host == "" || whichDomain(host, scope) == "" || !prefixes.Allowed(u) {
 					return
 				}
 
@@ -307,12 +359,15 @@ func Crawl(ctx context.Context, u string, scope []string, max int, callback func
 			})
 		},
 	})
+	// The robots.txt files are requested with the client provided when the crawler was created
+	robots := g.Client
 	g.Client = client.NewClient(&client.Options{
 		MaxBodySize:    50 * 1024 * 1024, // 50MB
 		RetryTimes:     2,
 		RetryHTTPCodes: []int{408, 500, 502, 503, 504, 522, 524},
 	})
-	g.Client.Client = DefaultClient
+	g.Client.Client = politeClient()
+	robots.Client = g.Client.Client
 
 	g.Start()
 	return nil
@@ -339,6 +394,12 @@ func PullCertificateNames(ctx context.Context, addr string, ports []int) []strin
 	var names []string
 	// check hosts for certificates that contain subdomain names
 	for _, port := range ports {
+		// The probes are limited by the politeness policy along with the crawler
+		release, err := WaitPolitely(ctx, addr)
+		if err != nil {
+			return names
+		}
+
 		if c, err := TLSConn(ctx, addr, port); err == nil {
 			// get the correct certificate in the chain
 			certChain := c.ConnectionState().PeerCertificates
@@ -346,6 +407,7 @@ func PullCertificateNames(ctx context.Context, addr string, ports []int) []strin
 			names = append(names, NamesFromCert(certChain[0])...)
 			c.Close()
 		}
+		release()
 
 		select {
 		case <-ctx.Done():
@@ -362,7 +424,7 @@ func TLSConn(ctx context.Context, host string, port int) (*tls.Conn, error) {
 	tCtx, cancel := context.WithTimeout(ctx, handshakeTimeout)
 	defer cancel()
 	// obtain the connection
-	conn, err := amassnet.DialContext(tCtx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	conn, err := dialContext(tCtx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
 	if err != nil {
 		return nil, err
 	}
@@ -188,7 +188,7 @@ func TestCrawl(t *testing.T) {
 		set := stringset.New(test.want...)
 		defer set.Close()
 
-		err := Crawl(context.Background(), ts.URL, []string{"127.0.0.1"}, test.depth, func(req *Request, resp *Response) {
+		err := Crawl(context.Background(), ts.URL, []string{"127.0.0.1"}, nil, test.depth, func(req *Request, resp *Response) {
 			if u, err := url.Parse(req.URL); err == nil {
 				got.Insert(CleanName(u.Hostname()))
 			}
@@ -205,15 +205,25 @@ func TestCrawl(t *testing.T) {
 		}
 	}
 
+	prefixes, err := NewURLPrefixes(ts.URL + "/private/*")
+	if err != nil {
+		t.Fatalf("Failed to create the URL prefixes: %v", err)
+	}
+	if err := Crawl(context.Background(), ts.URL, []string{"127.0.0.1"}, prefixes, 0, func(req *Request, resp *Response) {
+		t.Errorf("The crawl requested %s outside the URL scope", req.URL)
+	}); err == nil {
+		t.Errorf("Failed to refuse the crawl outside the URL scope")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	err = Crawl(ctx, ts.URL, []string{"127.0.0.1"}, 0, func(req *Request, resp *Response) {})
+	err = Crawl(ctx, ts.URL, []string{"127.0.0.1"}, nil, 0, func(req *Request, resp *Response) {})
 	if err != nil && err.Error() != "the context expired during the crawl of "+ts.URL {
 		t.Errorf("Failed to catch the expired context during the crawl")
 	}
 
-	err = Crawl(ctx, ts.URL, []string{"127.0.0.1"}, 0, func(req *Request, resp *Response) {})
+	err = Crawl(ctx, ts.URL, []string{"127.0.0.1"}, nil, 0, func(req *Request, resp *Response) {})
 	if err != nil && err.Error() != "the context expired" {
 		t.Errorf("Failed to catch the expired context before the crawl")
 	}
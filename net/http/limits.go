@@ -0,0 +1,111 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMaxBodySize is the largest response body read for the requests that do not provide a limit.
+const DefaultMaxBodySize int64 = 128 << 20
+
+var (
+	// ErrBodyTooLarge is returned when the response body, once decompressed, exceeds the size limit.
+	ErrBodyTooLarge = errors.New("the response body exceeds the size limit")
+	// ErrContentType is returned when the content type of the response is not allowed by the request.
+	ErrContentType = errors.New("the response content type is not allowed")
+)
+
+var maxBodySize atomic.Int64
+
+// SetMaxBodySize sets the size limit of the response bodies for the requests that do not provide one.
+// A size of zero or less restores the default limit.
+func SetMaxBodySize(size int64) {
+	if size <= 0 {
+		size = 0
+	}
+	maxBodySize.Store(size)
+}
+
+// MaxBodySize returns the size limit of the response bodies for the requests that do not provide one.
+func MaxBodySize() int64 {
+	if size := maxBodySize.Load(); size > 0 {
+		return size
+	}
+	return DefaultMaxBodySize
+}
+
+// limitedResponse converts the response, refusing the content types not allowed by the request and
+// the bodies larger than its limit. The limit applies to the bodies decompressed by the transport,
+// so a small compressed response cannot expand beyond the memory the request was willing to use.
+func limitedResponse(resp *http.Response, r *Request) (*Response, error) {
+	defer func() { _ = resp.Body.Close() }()
+
+	if !AllowedContentType(resp.Header.Get("Content-Type"), r.ContentTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrContentType, resp.Header.Get("Content-Type"))
+	}
+
+	max := r.MaxBodySize
+	if max <= 0 {
+		max = MaxBodySize()
+	}
+	if !resp.Uncompressed && resp.ContentLength > max {
+		return nil, fmt.Errorf("%w of %d bytes (%d bytes)", ErrBodyTooLarge, max, resp.ContentLength)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if int64(len(b)) > max {
+		return nil, fmt.Errorf("%w of %d bytes", ErrBodyTooLarge, max)
+	}
+
+	var body string
+	if err == nil {
+		body = string(b)
+	}
+
+	return &Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		ProtoMajor: resp.ProtoMajor,
+		ProtoMinor: resp.ProtoMinor,
+		Header:     HdrToAmassHeader(resp.Header),
+		Body:       body,
+		Length:     resp.ContentLength,
+		TLS:        resp.TLS,
+	}, nil
+}
+
+// AllowedContentType returns true when the media type of the Content-Type header matches one of the
+// allowed types, such as 'application/json' or 'text/*'. Every type is allowed when none are provided,
+// and the responses without a Content-Type header are always allowed.
+func AllowedContentType(header string, allowed []string) bool {
+	if len(allowed) == 0 || strings.TrimSpace(header) == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+
+		if a == "*/*" || a == mt {
+			return true
+		}
+		if prefix := strings.TrimSuffix(a, "*"); prefix != a && strings.HasPrefix(mt, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseLimits(t *testing.T) {
+	var bomb bytes.Buffer
+	zw := gzip.NewWriter(&bomb)
+	_, _ = zw.Write(make([]byte, 4<<20))
+	_ = zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			fmt.Fprint(w, `{"names":["www.owasp.org"]}`)
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, strings.Repeat("a", 2048))
+		case "/bomb":
+			// The transport decompresses the body, since it requested the compression
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(bomb.Bytes())
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	resp, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/json", MaxBodySize: 1024, ContentTypes: []string{"application/json"}})
+	if err != nil || resp.Body != `{"names":["www.owasp.org"]}` {
+		t.Errorf("The response within the limits was not returned: %v", err)
+	}
+
+	if _, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/json", ContentTypes: []string{"text/*"}}); !errors.Is(err, ErrContentType) {
+		t.Errorf("The content type not allowed returned %v", err)
+	}
+	if _, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/large", MaxBodySize: 1024}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("The response larger than the limit returned %v", err)
+	}
+	if _, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/bomb", MaxBodySize: 1 << 20}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("The compressed response expanding beyond the limit returned %v", err)
+	}
+
+	SetMaxBodySize(1024)
+	defer SetMaxBodySize(0)
+	if _, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/large"}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("The default limit was not applied: %v", err)
+	}
+	if resp, err := RequestWebPage(ctx, &Request{URL: srv.URL + "/large", MaxBodySize: 4096}); err != nil || len(resp.Body) != 2048 {
+		t.Errorf("The limit of the request did not replace the default limit: %v", err)
+	}
+}
+
+func TestAllowedContentType(t *testing.T) {
+	tests := []struct {
+		header   string
+		allowed  []string
+		expected bool
+	}{
+		{"text/html; charset=utf-8", nil, true},
+		{"", []string{"application/json"}, true},
+		{"Application/JSON", []string{"application/json"}, true},
+		{"text/html; charset=utf-8", []string{"application/json", "text/*"}, true},
+		{"text/html", []string{"application/json"}, false},
+		{"application/octet-stream", []string{"*/*"}, true},
+		{"not a media type;;", []string{"text/*"}, false},
+	}
+
+	for _, test := range tests {
+		if got := AllowedContentType(test.header, test.allowed); got != test.expected {
+			t.Errorf("AllowedContentType(%q, %v) returned %v, expected %v", test.header, test.allowed, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxPerHost is the number of requests sent to a host at the same time by the active web modules.
+	DefaultMaxPerHost = 2
+	// DefaultCrawlDelay is the time waited between the requests sent to a host by the active web modules.
+	DefaultCrawlDelay = 250 * time.Millisecond
+)
+
+// Politeness is the policy shared by the active web modules, the crawler and the certificate prober,
+// so the load they put on a host is limited collectively, instead of each module limiting its
+// own requests while the modules together send too many.
+type Politeness struct {
+	// MaxPerHost is the number of requests sent to a host at the same time, either 1 or 2
+	MaxPerHost int
+	// CrawlDelay is the time waited between the requests sent to a host
+	CrawlDelay time.Duration
+	// RobotsTxt is true when the crawler follows the robots.txt rules of the hosts
+	RobotsTxt bool
+	// OffHours is the daily window, in local time, when the requests are sent, and nil when they are always sent
+	OffHours *TimeWindow
+}
+
+// TimeWindow is a daily window, such as '19:00-07:00', which may span midnight.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+type politeHost struct {
+	sync.Mutex
+	sem  chan struct{}
+	next time.Time
+}
+
+var politeness = struct {
+	sync.Mutex
+	policy Politeness
+	hosts  map[string]*politeHost
+}{
+	policy: Politeness{MaxPerHost: DefaultMaxPerHost, CrawlDelay: DefaultCrawlDelay},
+	hosts:  make(map[string]*politeHost),
+}
+
+// SetPoliteness sets the policy of the active web modules, and nil restores the default policy.
+func SetPoliteness(p *Politeness) {
+	policy := Politeness{MaxPerHost: DefaultMaxPerHost, CrawlDelay: DefaultCrawlDelay}
+	if p != nil {
+		policy = *p
+	}
+
+	politeness.Lock()
+	defer politeness.Unlock()
+
+	politeness.policy = policy
+	politeness.hosts = make(map[string]*politeHost)
+}
+
+// CurrentPoliteness returns the policy of the active web modules.
+func CurrentPoliteness() Politeness {
+	politeness.Lock()
+	defer politeness.Unlock()
+
+	return politeness.policy
+}
+
+// ParsePoliteness returns the policy provided by the 'politeness' option, a table with the
+// 'max_per_host', 'crawl_delay', 'robots_txt' and 'off_hours' entries. The entries not provided
+// keep their default values.
+func ParsePoliteness(v interface{}) (*Politeness, error) {
+	p := &Politeness{MaxPerHost: DefaultMaxPerHost, CrawlDelay: DefaultCrawlDelay}
+	if v == nil {
+		return p, nil
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the politeness policy must be a table")
+	}
+
+	for key, val := range m {
+		str := strings.TrimSpace(fmt.Sprint(val))
+
+		switch strings.ToLower(key) {
+		case "max_per_host":
+			n, err := strconv.Atoi(str)
+			if err != nil || n < 1 || n > 2 {
+				return nil, fmt.Errorf("the max_per_host of the politeness policy must be 1 or 2")
+			}
+			p.MaxPerHost = n
+		case "crawl_delay":
+			d, err := time.ParseDuration(str)
+			if err != nil {
+				// A number is the delay in seconds, like the Crawl-delay directive of robots.txt
+				secs, ferr := strconv.ParseFloat(str, 64)
+				if ferr != nil {
+					return nil, fmt.Errorf("the crawl_delay %s of the politeness policy is not valid: %v", str, err)
+				}
+				d = time.Duration(secs * float64(time.Second))
+			}
+			if d < 0 {
+				return nil, fmt.Errorf("the crawl_delay of the politeness policy cannot be negative")
+			}
+			p.CrawlDelay = d
+		case "robots_txt":
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				return nil, fmt.Errorf("the robots_txt of the politeness policy must be true or false")
+			}
+			p.RobotsTxt = b
+		case "off_hours":
+			if str == "" {
+				continue
+			}
+			w, err := ParseTimeWindow(str)
+			if err != nil {
+				return nil, err
+			}
+			p.OffHours = w
+		default:
+			return nil, fmt.Errorf("the politeness policy has the unknown entry %s", key)
+		}
+	}
+	return p, nil
+}
+
+// ParseTimeWindow returns the daily window of a range such as '19:00-07:00'.
+func ParseTimeWindow(s string) (*TimeWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("the time window %s must be formatted as HH:MM-HH:MM", s)
+	}
+
+	var offsets [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("the time window %s must be formatted as HH:MM-HH:MM", s)
+		}
+		offsets[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if offsets[0] == offsets[1] {
+		return nil, fmt.Errorf("the time window %s is empty", s)
+	}
+	return &TimeWindow{Start: offsets[0], End: offsets[1]}, nil
+}
+
+// Contains returns true when the time is within the window.
+func (w *TimeWindow) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Next returns the time the window opens next, or the time provided when it is within the window.
+func (w *TimeWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	midnight := t.Add(-sinceMidnight(t))
+	if start := midnight.Add(w.Start); start.After(t) {
+		return start
+	}
+	return midnight.AddDate(0, 0, 1).Add(w.Start)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// WaitPolitely blocks until the policy allows a request to be sent to the host, and returns the
+// function releasing the host once the request is complete.
+func WaitPolitely(ctx context.Context, host string) (func(), error) {
+	politeness.Lock()
+	policy := politeness.policy
+	h, found := politeness.hosts[host]
+	if !found {
+		h = &politeHost{sem: make(chan struct{}, policy.MaxPerHost)}
+		politeness.hosts[host] = h
+	}
+	politeness.Unlock()
+
+	if w := policy.OffHours; w != nil {
+		if now := time.Now(); !w.Contains(now) {
+			if err := sleepContext(ctx, w.Next(now).Sub(now)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case h.sem <- struct{}{}:
+	}
+	release := func() { <-h.sem }
+
+	// Each request reserves the next time a request can be sent to the host
+	h.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+	h.next = at.Add(policy.CrawlDelay)
+	h.Unlock()
+
+	if err := sleepContext(ctx, at.Sub(now)); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+	}
+	return nil
+}
+
+// politeTransport holds the host of each request, including the reading of the response body,
+// as allowed by the politeness policy.
+type politeTransport struct {
+	base http.RoundTripper
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := checkURLEgress(req.URL); err != nil {
+		return nil, err
+	}
+
+	release, err := WaitPolitely(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	var once sync.Once
+	resp.Body = &politeBody{ReadCloser: resp.Body, release: func() { once.Do(release) }}
+	return resp, nil
+}
+
+type politeBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *politeBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// politeClient returns a copy of the default client sending the requests as allowed by the politeness policy.
+func politeClient() *http.Client {
+	c := *DefaultClient
+	c.Transport = &politeTransport{base: DefaultClient.Transport}
+	return &c
+}
@@ -0,0 +1,120 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParsePoliteness(t *testing.T) {
+	p, err := ParsePoliteness(map[string]interface{}{
+		"max_per_host": 1,
+		"crawl_delay":  "2s",
+		"robots_txt":   true,
+		"off_hours":    "19:00-07:00",
+	})
+	if err != nil {
+		t.Fatalf("ParsePoliteness returned an error: %v", err)
+	}
+	if p.MaxPerHost != 1 || p.CrawlDelay != 2*time.Second || !p.RobotsTxt ||
+		p.OffHours == nil || p.OffHours.Start != 19*time.Hour || p.OffHours.End != 7*time.Hour {
+		t.Errorf("The policy was not parsed as expected: %+v", p)
+	}
+
+	if p, err := ParsePoliteness(map[string]interface{}{"crawl_delay": 1.5}); err != nil ||
+		p.CrawlDelay != 1500*time.Millisecond || p.MaxPerHost != DefaultMaxPerHost {
+		t.Errorf("The crawl delay in seconds returned %+v, %v", p, err)
+	}
+
+	for _, m := range []map[string]interface{}{
+		{"max_per_host": 5},
+		{"crawl_delay": "soon"},
+		{"robots_txt": "sometimes"},
+		{"off_hours": "19:00"},
+		{"off_hours": "07:00-07:00"},
+		{"screenshots": true},
+	} {
+		if _, err := ParsePoliteness(m); err == nil {
+			t.Errorf("The invalid policy %v was accepted", m)
+		}
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	w, err := ParseTimeWindow("19:00-07:00")
+	if err != nil {
+		t.Fatalf("ParseTimeWindow returned an error: %v", err)
+	}
+
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)
+	for hour, expected := range map[int]bool{0: true, 6: true, 7: false, 12: false, 19: true, 23: true} {
+		if got := w.Contains(day.Add(time.Duration(hour) * time.Hour)); got != expected {
+			t.Errorf("Contains at %d:00 returned %v, expected %v", hour, got, expected)
+		}
+	}
+
+	if next := w.Next(day.Add(12 * time.Hour)); !next.Equal(day.Add(19 * time.Hour)) {
+		t.Errorf("The window opening after noon was %v", next)
+	}
+	if now := day.Add(22 * time.Hour); !w.Next(now).Equal(now) {
+		t.Errorf("The window was not open at %v", now)
+	}
+
+	w, _ = ParseTimeWindow("09:00-17:00")
+	if next := w.Next(day.Add(18 * time.Hour)); !next.Equal(day.AddDate(0, 0, 1).Add(9 * time.Hour)) {
+		t.Errorf("The window opening the next day was %v", next)
+	}
+}
+
+func TestWaitPolitely(t *testing.T) {
+	SetPoliteness(&Politeness{MaxPerHost: 1, CrawlDelay: 20 * time.Millisecond})
+	defer SetPoliteness(nil)
+
+	var active, highest int32
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			release, err := WaitPolitely(context.Background(), "www.owasp.org")
+			if err != nil {
+				t.Errorf("WaitPolitely returned an error: %v", err)
+				return
+			}
+			if n := atomic.AddInt32(&active, 1); n > atomic.LoadInt32(&highest) {
+				atomic.StoreInt32(&highest, n)
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	if highest != 1 {
+		t.Errorf("%d requests were sent to the host at the same time", highest)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("The crawl delay was not applied between the requests: %v", elapsed)
+	}
+
+	// The requests outside of the off hours wait for the window, or until the context expires
+	now := time.Now()
+	SetPoliteness(&Politeness{MaxPerHost: 1, OffHours: &TimeWindow{
+		Start: sinceMidnight(now.Add(time.Hour)),
+		End:   sinceMidnight(now.Add(2 * time.Hour)),
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := WaitPolitely(ctx, "www.owasp.org"); err == nil {
+		t.Errorf("The request was allowed outside of the off hours")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	scutilKeyRE    = regexp.MustCompile(`(?m)^\s*([A-Za-z]+)\s*:\s*(.+?)\s*$`)
+	scutilExceptRE = regexp.MustCompile(`(?s)ExceptionsList\s*:\s*<array>\s*\{(.*?)\}`)
+	scutilItemRE   = regexp.MustCompile(`(?m)^\s*[0-9]+\s*:\s*(.+?)\s*$`)
+	pacProxyRE     = regexp.MustCompile(`(?i)"\s*(?:PROXY|HTTPS)\s+([^\s;"]+)`)
+)
+
+// proxySettings holds the proxy configuration obtained from the operating system.
+type proxySettings struct {
+	HTTP   string
+	HTTPS  string
+	PACURL string
+	Bypass []string
+}
+
+// UseSystemProxy configures the DefaultClient to send requests through the proxy
+// configured by the operating system. Proxies set in the environment take precedence.
+func UseSystemProxy() error {
+	settings, err := systemProxySettings()
+	if err != nil {
+		return err
+	}
+	if settings.HTTP == "" && settings.HTTPS == "" && settings.PACURL != "" {
+		if err := settings.applyPAC(); err != nil {
+			return err
+		}
+	}
+	if settings.HTTP == "" && settings.HTTPS == "" {
+		return errors.New("no proxy is configured by the operating system")
+	}
+
+	t, ok := DefaultClient.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("the default client does not use an HTTP transport")
+	}
+	t.Proxy = trackProxies(settings.proxyFunc())
+	return nil
+}
+
+func (p *proxySettings) proxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if u, err := http.ProxyFromEnvironment(req); u != nil || err != nil {
+			return u, err
+		}
+		if p.bypassed(req.URL.Hostname()) {
+			return nil, nil
+		}
+
+		addr := p.HTTP
+		if req.URL.Scheme == "https" && p.HTTPS != "" {
+			addr = p.HTTPS
+		}
+		if addr == "" {
+			return nil, nil
+		}
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+		return url.Parse(addr)
+	}
+}
+
+func (p *proxySettings) bypassed(host string) bool {
+	for _, pattern := range p.Bypass {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+		switch {
+		case pattern == "":
+		case pattern == "<local>":
+			if !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.EqualFold(pattern, host):
+			return true
+		default:
+			if ok, _ := path.Match(pattern, strings.ToLower(host)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyPAC obtains the proxy address from the proxy auto-config file. PAC files are not evaluated,
+// so the first PROXY directive found in the script is used for all requests outside the bypass list.
+func (p *proxySettings) applyPAC() error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.PACURL, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: httpTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	script, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if addr := pacProxyAddr(string(script)); addr != "" {
+		p.HTTP = addr
+		p.HTTPS = addr
+	}
+	return nil
+}
+
+func pacProxyAddr(script string) string {
+	if m := pacProxyRE.FindStringSubmatch(script); len(m) > 1 {
+		if _, _, err := net.SplitHostPort(m[1]); err == nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// parseScutilProxy extracts the proxy settings from the output of 'scutil --proxy' on macOS.
+func parseScutilProxy(out string) *proxySettings {
+	var settings proxySettings
+
+	values := make(map[string]string)
+	for _, m := range scutilKeyRE.FindAllStringSubmatch(out, -1) {
+		values[m[1]] = m[2]
+	}
+
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		settings.HTTP = net.JoinHostPort(values["HTTPProxy"], portOrDefault(values["HTTPPort"], "80"))
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		settings.HTTPS = net.JoinHostPort(values["HTTPSProxy"], portOrDefault(values["HTTPSPort"], "443"))
+	}
+	if values["ProxyAutoConfigEnable"] == "1" {
+		settings.PACURL = values["ProxyAutoConfigURLString"]
+	}
+
+	if m := scutilExceptRE.FindStringSubmatch(out); len(m) > 1 {
+		for _, item := range scutilItemRE.FindAllStringSubmatch(m[1], -1) {
+			settings.Bypass = append(settings.Bypass, item[1])
+		}
+	}
+	return &settings
+}
+
+// parseWindowsProxyServer extracts the proxy settings from the Internet Settings registry values.
+// The server is either a single "host:port" or a list such as "http=host:port;https=host:port".
+func parseWindowsProxyServer(server, override string) *proxySettings {
+	var settings proxySettings
+
+	for _, entry := range strings.Split(server, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		scheme, addr, found := strings.Cut(entry, "=")
+		if !found {
+			settings.HTTP = entry
+			settings.HTTPS = entry
+			continue
+		}
+
+		switch strings.ToLower(scheme) {
+		case "http":
+			settings.HTTP = addr
+		case "https":
+			settings.HTTPS = addr
+		}
+	}
+
+	for _, pattern := range strings.Split(override, ";") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			settings.Bypass = append(settings.Bypass, pattern)
+		}
+	}
+	return &settings
+}
+
+func portOrDefault(port, def string) string {
+	if port == "" {
+		return def
+	}
+	return port
+}
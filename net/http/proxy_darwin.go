@@ -0,0 +1,20 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build darwin
+
+package http
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func systemProxySettings() (*proxySettings, error) {
+	out, err := exec.Command("scutil", "--proxy").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain the system proxy settings: %v", err)
+	}
+	return parseScutilProxy(string(out)), nil
+}
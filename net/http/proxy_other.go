@@ -0,0 +1,13 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows && !darwin
+
+package http
+
+import "errors"
+
+func systemProxySettings() (*proxySettings, error) {
+	return nil, errors.New("system proxy settings are only available on Windows and macOS; use the HTTP_PROXY and HTTPS_PROXY environment variables")
+}
@@ -0,0 +1,137 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const scutilProxyOutput = `<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : 169.254/16
+  }
+  FTPPassive : 1
+  HTTPEnable : 1
+  HTTPPort : 8080
+  HTTPProxy : proxy.corp.example
+  HTTPSEnable : 1
+  HTTPSPort : 8443
+  HTTPSProxy : secure.corp.example
+  ProxyAutoConfigEnable : 0
+}`
+
+func TestParseScutilProxy(t *testing.T) {
+	p := parseScutilProxy(scutilProxyOutput)
+
+	if p.HTTP != "proxy.corp.example:8080" {
+		t.Errorf("Unexpected HTTP proxy, got %s", p.HTTP)
+	}
+	if p.HTTPS != "secure.corp.example:8443" {
+		t.Errorf("Unexpected HTTPS proxy, got %s", p.HTTPS)
+	}
+	if p.PACURL != "" {
+		t.Errorf("Unexpected PAC URL, got %s", p.PACURL)
+	}
+	if expected := []string{"*.local", "169.254/16"}; !reflect.DeepEqual(p.Bypass, expected) {
+		t.Errorf("Unexpected bypass list, expected %v, got %v", expected, p.Bypass)
+	}
+}
+
+func TestParseWindowsProxyServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   string
+		override string
+		expected *proxySettings
+	}{
+		{
+			name:     "Single proxy for all protocols",
+			server:   "proxy.corp.example:3128",
+			override: "<local>;*.corp.example",
+			expected: &proxySettings{
+				HTTP:   "proxy.corp.example:3128",
+				HTTPS:  "proxy.corp.example:3128",
+				Bypass: []string{"<local>", "*.corp.example"},
+			},
+		},
+		{
+			name:   "Proxy per protocol",
+			server: "http=web.corp.example:80;https=tls.corp.example:443;ftp=ftp.corp.example:21",
+			expected: &proxySettings{
+				HTTP:  "web.corp.example:80",
+				HTTPS: "tls.corp.example:443",
+			},
+		},
+		{
+			name:     "Proxy disabled",
+			expected: &proxySettings{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := parseWindowsProxyServer(tt.server, tt.override); !reflect.DeepEqual(p, tt.expected) {
+				t.Errorf("Unexpected Result, expected %+v, got %+v", tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("NO_PROXY", "")
+
+	p := &proxySettings{
+		HTTP:   "web.corp.example:80",
+		HTTPS:  "tls.corp.example:443",
+		Bypass: []string{"<local>", "*.corp.example"},
+	}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://www.owasp.org/", "http://web.corp.example:80"},
+		{"https://www.owasp.org/", "http://tls.corp.example:443"},
+		{"https://intranet/", ""},
+		{"https://wiki.corp.example/", ""},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+
+		u, err := p.proxyFunc()(req)
+		if err != nil {
+			t.Errorf("Failed to obtain the proxy for %s: %v", tt.url, err)
+			continue
+		}
+
+		var got string
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.expected {
+			t.Errorf("Unexpected proxy for %s, expected %s, got %s", tt.url, tt.expected, got)
+		}
+	}
+}
+
+func TestPACProxyAddr(t *testing.T) {
+	script := `function FindProxyForURL(url, host) {
+	if (isPlainHostName(host)) return "DIRECT";
+	return "PROXY proxy.corp.example:8080; DIRECT";
+}`
+
+	if addr := pacProxyAddr(script); addr != "proxy.corp.example:8080" {
+		t.Errorf("Unexpected PAC proxy, got %s", addr)
+	}
+	if addr := pacProxyAddr(`function FindProxyForURL(url, host) { return "DIRECT"; }`); addr != "" {
+		t.Errorf("Expected no PAC proxy, got %s", addr)
+	}
+}
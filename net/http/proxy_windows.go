@@ -0,0 +1,33 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package http
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const internetSettingsKey = `Software\Microsoft\Windows\CurrentVersion\Internet Settings`
+
+func systemProxySettings() (*proxySettings, error) {
+	k, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Internet Settings registry key: %v", err)
+	}
+	defer k.Close()
+
+	var server string
+	if enabled, _, err := k.GetIntegerValue("ProxyEnable"); err == nil && enabled == 1 {
+		server, _, _ = k.GetStringValue("ProxyServer")
+	}
+	override, _, _ := k.GetStringValue("ProxyOverride")
+
+	settings := parseWindowsProxyServer(server, override)
+	settings.PACURL, _, _ = k.GetStringValue("AutoConfigURL")
+	return settings, nil
+}
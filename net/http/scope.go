@@ -0,0 +1,129 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// URLPrefixes limits the URLs requested from the hosts to path prefixes, such as 'https://example.com/app/*',
+// for the engagements restricted to specific applications on shared hosts. The hosts without
+// a prefix are not limited.
+type URLPrefixes struct {
+	rules map[string][]urlPrefix
+}
+
+type urlPrefix struct {
+	scheme string
+	port   string
+	path   string
+	// tree is true when the prefix ends with a slash or asterisk, and only matches the paths below it
+	tree bool
+}
+
+// NewURLPrefixes returns the URLPrefixes for the rules provided. The rules require a http or https scheme
+// and a host, while the trailing asterisk of the path is optional: 'https://example.com/app' matches
+// the '/app' path and the paths below it, while 'https://example.com/app/*' only matches the paths below it.
+func NewURLPrefixes(rules ...string) (*URLPrefixes, error) {
+	p := &URLPrefixes{rules: make(map[string][]urlPrefix)}
+
+	for _, rule := range rules {
+		u, err := url.Parse(strings.TrimSpace(rule))
+		if err != nil {
+			return nil, fmt.Errorf("the URL scope %s is not valid: %v", rule, err)
+		}
+
+		scheme := strings.ToLower(u.Scheme)
+		if scheme != "http" && scheme != "https" {
+			return nil, fmt.Errorf("the URL scope %s requires the http or https scheme", rule)
+		}
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		if host == "" {
+			return nil, fmt.Errorf("the URL scope %s requires a host", rule)
+		}
+
+		prefix := strings.TrimSuffix(u.Path, "*")
+		tree := strings.HasSuffix(prefix, "/")
+		if prefix = cleanPath(prefix); prefix == "/" {
+			tree = false
+		}
+
+		p.rules[host] = append(p.rules[host], urlPrefix{
+			scheme: scheme,
+			port:   urlPort(u),
+			path:   prefix,
+			tree:   tree,
+		})
+	}
+	return p, nil
+}
+
+// Len returns the number of hosts limited to path prefixes.
+func (p *URLPrefixes) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
+
+// Allowed returns true when the host of the URL is not limited to path prefixes, or when the
+// scheme, port and path of the URL match one of its prefixes. The path is cleaned before
+// the comparison, so dot segments cannot escape the prefixes.
+func (p *URLPrefixes) Allowed(u *url.URL) bool {
+	if p.Len() == 0 || u == nil {
+		return true
+	}
+
+	rules, found := p.rules[strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))]
+	if !found {
+		return true
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	port := urlPort(u)
+	upath := cleanPath(u.Path)
+	// The trailing slash keeps the index of a prefix, such as '/app/', within the paths below it
+	if strings.HasSuffix(u.Path, "/") && upath != "/" {
+		upath += "/"
+	}
+	for _, r := range rules {
+		if r.scheme != scheme || r.port != port {
+			continue
+		}
+		if r.path == "/" || (!r.tree && upath == r.path) || strings.HasPrefix(upath, r.path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedString parses the URL before checking that it is allowed. URLs that cannot be parsed are not allowed.
+func (p *URLPrefixes) AllowedString(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return false
+	}
+	return p.Allowed(u)
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return path.Clean("/" + p)
+}
+
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(u.Scheme, "http") {
+		return "80"
+	}
+	return "443"
+}
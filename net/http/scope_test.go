@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"testing"
+)
+
+func TestNewURLPrefixes(t *testing.T) {
+	for _, rule := range []string{"example.com/app", "ftp://example.com/app", "https:///app", "https://exa mple.com"} {
+		if _, err := NewURLPrefixes(rule); err == nil {
+			t.Errorf("NewURLPrefixes accepted the rule %s", rule)
+		}
+	}
+
+	p, err := NewURLPrefixes("https://example.com/app/*", "http://Example.com:8080/api", "https://shop.example.com")
+	if err != nil {
+		t.Fatalf("NewURLPrefixes failed: %v", err)
+	}
+	if p.Len() != 2 {
+		t.Errorf("NewURLPrefixes returned %d hosts, expected 2", p.Len())
+	}
+}
+
+func TestURLPrefixesAllowed(t *testing.T) {
+	p, err := NewURLPrefixes("https://example.com/app/*", "http://example.com:8080/api", "https://shop.example.com")
+	if err != nil {
+		t.Fatalf("NewURLPrefixes failed: %v", err)
+	}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://example.com/app/", true},
+		{"https://example.com/app/login?next=/", true},
+		{"https://EXAMPLE.COM:443/app/js/main.js", true},
+		{"https://example.com/app", false},
+		{"https://example.com/application", false},
+		{"https://example.com/admin", false},
+		{"https://example.com/app/../admin", false},
+		{"http://example.com/app/login", false},
+		{"https://example.com:8443/app/login", false},
+		{"http://example.com:8080/api", true},
+		{"http://example.com:8080/api/v1/users", true},
+		{"http://example.com:8080/apiv2", false},
+		{"https://shop.example.com/cart", true},
+		{"http://shop.example.com/cart", false},
+		{"https://www.example.com/admin", true},
+		{"%zz", false},
+	}
+
+	for _, test := range tests {
+		if got := p.AllowedString(test.url); got != test.expected {
+			t.Errorf("AllowedString(%s) returned %v, expected %v", test.url, got, test.expected)
+		}
+	}
+
+	var none *URLPrefixes
+	if !none.AllowedString("https://example.com/admin") {
+		t.Errorf("The nil URLPrefixes did not allow the URL")
+	}
+}
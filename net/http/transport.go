@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/resolve"
+)
+
+const hostCacheTTL = 5 * time.Minute
+
+type hostEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+var hostResolution struct {
+	sync.Mutex
+	pool  *resolve.Resolvers
+	cache map[string]*hostEntry
+}
+
+// UseResolvers causes the host names contacted by the DefaultClient to be resolved using the
+// provided pool instead of the operating system. Providing a nil pool restores the default behavior.
+func UseResolvers(pool *resolve.Resolvers) {
+	hostResolution.Lock()
+	defer hostResolution.Unlock()
+
+	hostResolution.pool = pool
+	hostResolution.cache = make(map[string]*hostEntry)
+}
+
+// The host names of the proxies selected for the requests, which are resolved by the operating system
+var proxyHosts struct {
+	sync.Mutex
+	hosts map[string]struct{}
+}
+
+// trackProxies records the hosts of the proxies selected by the function, so the dialer can
+// tell the proxies apart from the hosts contacted directly.
+func trackProxies(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err == nil && u != nil && u.Hostname() != "" {
+			proxyHosts.Lock()
+			if proxyHosts.hosts == nil {
+				proxyHosts.hosts = make(map[string]struct{})
+			}
+			proxyHosts.hosts[strings.ToLower(u.Hostname())] = struct{}{}
+			proxyHosts.Unlock()
+		}
+		return u, err
+	}
+}
+
+func isProxyHost(host string) bool {
+	proxyHosts.Lock()
+	defer proxyHosts.Unlock()
+
+	_, found := proxyHosts.hosts[strings.ToLower(host)]
+	return found
+}
+
+// dialContext resolves the host name using the resolver pool, when one has been provided,
+// and dials the addresses in order until a connection is established. The proxies are
+// often internal names only known to the system DNS, so they are resolved by the operating system.
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	// The egress allowlist is checked for the host name, rather than the addresses resolved for it
+	if err := amassnet.CheckEgress(addr); err != nil {
+		return nil, err
+	}
+	ctx = amassnet.ApproveEgress(ctx)
+
+	hostResolution.Lock()
+	pool := hostResolution.pool
+	hostResolution.Unlock()
+
+	if pool == nil || net.ParseIP(host) != nil || isProxyHost(host) {
+		return amassnet.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := lookupHost(ctx, pool, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range addrs {
+		var conn net.Conn
+
+		conn, err = amassnet.DialContext(ctx, network, net.JoinHostPort(a, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func lookupHost(ctx context.Context, pool *resolve.Resolvers, host string) ([]string, error) {
+	name := resolve.RemoveLastDot(host)
+
+	hostResolution.Lock()
+	entry, found := hostResolution.cache[name]
+	hostResolution.Unlock()
+
+	if found && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	var addrs []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := pool.QueryBlocking(ctx, resolve.QueryMsg(name, qtype))
+		if err != nil || resp.Rcode != dns.RcodeSuccess {
+			continue
+		}
+
+		for _, ans := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			addrs = append(addrs, ans.Data)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("failed to resolve the host %s", name)
+	}
+
+	hostResolution.Lock()
+	hostResolution.cache[name] = &hostEntry{
+		addrs:   addrs,
+		expires: time.Now().Add(hostCacheTTL),
+	}
+	hostResolution.Unlock()
+	return addrs, nil
+}
@@ -0,0 +1,119 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestUseResolvers(t *testing.T) {
+	var queries int32
+	dns.HandleFunc("owasp.test.", func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		atomic.AddInt32(&queries, 1)
+		if req.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP("127.0.0.1"),
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+	defer dns.HandleRemove("owasp.test.")
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the DNS server: %v", err)
+	}
+	s := &dns.Server{PacketConn: pc}
+	go func() { _ = s.ActivateAndServe() }()
+	defer func() { _ = s.Shutdown() }()
+
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, pc.LocalAddr().String())
+	pool.SetTimeout(time.Second)
+	defer pool.Stop()
+
+	UseResolvers(pool)
+	defer UseResolvers(nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "www.owasp.test")
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	target := "http://owasp.test:" + u.Port() + "/"
+	for i := 0; i < 2; i++ {
+		resp, err := RequestWebPage(context.Background(), &Request{URL: target})
+		if err != nil {
+			t.Fatalf("Failed to request the web page: %v", err)
+		}
+		if resp.Body != "www.owasp.test" {
+			t.Errorf("Unexpected response body: %s", resp.Body)
+		}
+	}
+	// The second request must use the cached addresses or the idle connection
+	if num := atomic.LoadInt32(&queries); num != 2 {
+		t.Errorf("Expected one A and one AAAA query, got %d queries", num)
+	}
+}
+
+func TestProxyResolvedBySystem(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the DNS server: %v", err)
+	}
+	// The resolver pool knows none of the names, including the name of the proxy
+	s := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeNameError)
+		_ = w.WriteMsg(m)
+	})}
+	go func() { _ = s.ActivateAndServe() }()
+	defer func() { _ = s.Shutdown() }()
+
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, pc.LocalAddr().String())
+	pool.SetTimeout(time.Second)
+	defer pool.Stop()
+
+	UseResolvers(pool)
+	defer UseResolvers(nil)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	u, _ := url.Parse(proxy.URL)
+	tr := DefaultClient.Transport.(*http.Transport)
+	orig := tr.Proxy
+	tr.Proxy = trackProxies(func(*http.Request) (*url.URL, error) {
+		return url.Parse("http://localhost:" + u.Port())
+	})
+	defer func() { tr.Proxy = orig }()
+
+	resp, err := RequestWebPage(context.Background(), &Request{URL: "http://proxied.owasp.test/"})
+	if err != nil {
+		t.Fatalf("Failed to request the web page through the proxy: %v", err)
+	}
+	if resp.Body != "proxied.owasp.test" {
+		t.Errorf("Unexpected response body: %s", resp.Body)
+	}
+}
@@ -57,8 +57,28 @@ func init() {
 
 // DialContext performs the dial using global variables (e.g. LocalAddr).
 func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if err := checkEgress(addr, !approvedEgress(ctx)); err != nil {
+		return nil, err
+	}
+
 	d := &net.Dialer{DualStack: true}
 
+	if conn, ok, err := tunnelDial(ctx, d, network, addr); ok {
+		if err != nil {
+			return nil, err
+		}
+		return newThrottledConn(conn), nil
+	}
+
+	if src := selectSource(addr); src != nil {
+		if strings.HasPrefix(network, "tcp") {
+			d.LocalAddr = &net.TCPAddr{IP: src}
+		} else if strings.HasPrefix(network, "udp") {
+			d.LocalAddr = &net.UDPAddr{IP: src}
+		}
+		return dialThrottled(ctx, d, network, addr)
+	}
+
 	_, p, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
@@ -85,7 +105,15 @@ func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
 		}
 	}
 
-	return d.DialContext(ctx, network, addr)
+	return dialThrottled(ctx, d, network, addr)
+}
+
+func dialThrottled(ctx context.Context, d *net.Dialer, network, addr string) (net.Conn, error) {
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return newThrottledConn(conn), nil
 }
 
 // IsIPv4 returns true when the provided net.IP address is an IPv4 address.
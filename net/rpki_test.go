@@ -0,0 +1,58 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestROASetValidate(t *testing.T) {
+	export := `{
+		"metadata": {"generated": 1700000000},
+		"roas": [
+			{"prefix": "192.0.2.0/24", "maxLength": 24, "asn": "AS64500", "ta": "arin"},
+			{"prefix": "198.51.100.0/22", "maxLength": 24, "asn": 64501, "ta": "ripe"},
+			{"prefix": "203.0.113.0/24", "maxLength": 24, "asn": "AS0", "ta": "apnic"},
+			{"prefix": "2001:db8::/32", "maxLength": 48, "asn": "AS64502", "ta": "ripe"},
+			{"prefix": "not a prefix", "maxLength": 24, "asn": "AS64503"}
+		]
+	}`
+
+	set := NewROASet()
+	num, err := set.LoadJSON(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+	if num != 4 || set.Len() != 4 {
+		t.Errorf("LoadJSON imported %d ROAs, expected 4", num)
+	}
+
+	tests := []struct {
+		asn    int
+		prefix string
+		state  string
+		reason string
+	}{
+		{64500, "192.0.2.0/24", RPKIValid, ""},
+		{64500, "192.0.2.0/25", RPKIInvalid, "length"},
+		{64510, "192.0.2.0/24", RPKIInvalid, "origin"},
+		{64501, "198.51.101.0/24", RPKIValid, ""},
+		{64501, "198.51.100.0/22", RPKIValid, ""},
+		{64501, "203.0.113.0/24", RPKIInvalid, "origin"},
+		{64502, "2001:db8:1::/48", RPKIValid, ""},
+		{64502, "2001:db8:1:1::/64", RPKIInvalid, "length"},
+		{64500, "100.64.0.0/24", RPKINotFound, ""},
+		{64500, "192.0.0.0/16", RPKINotFound, ""},
+	}
+
+	for _, test := range tests {
+		state, reason := set.Validate(test.asn, test.prefix)
+		if state != test.state || reason != test.reason {
+			t.Errorf("AS%d originating %s: got %s (%s), expected %s (%s)",
+				test.asn, test.prefix, state, reason, test.state, test.reason)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+	"sync"
+)
+
+// The rotations of the source addresses selected by UseSourceAddresses.
+const (
+	// SourceRoundRobin selects the next source address for each connection.
+	SourceRoundRobin = "round_robin"
+	// SourceAffinity selects the same source address for all the connections to a destination address.
+	SourceAffinity = "affinity"
+)
+
+var sources struct {
+	sync.Mutex
+	addrs    []net.IP
+	rotation string
+	next     int
+}
+
+// UseSourceAddresses causes DialContext to spread the connections across the provided local addresses,
+// so large enumerations can stay under the rate limits enforced for each address. The rotation is
+// SourceRoundRobin or SourceAffinity, and each connection uses an address of the destination family.
+// No addresses restores the default behavior.
+func UseSourceAddresses(addrs []net.IP, rotation string) error {
+	if rotation == "" {
+		rotation = SourceRoundRobin
+	}
+	if rotation != SourceRoundRobin && rotation != SourceAffinity {
+		return fmt.Errorf("the source address rotation %s is not supported", rotation)
+	}
+
+	sources.Lock()
+	defer sources.Unlock()
+
+	sources.addrs = addrs
+	sources.rotation = rotation
+	sources.next = 0
+	return nil
+}
+
+// InterfaceAddresses returns the unicast addresses assigned to the named network interface.
+func InterfaceAddresses(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("network interface '%s' has no assigned addresses", name)
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		if a, ok := addr.(*net.IPNet); ok && a.IP.IsGlobalUnicast() {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("network interface '%s' does not have assigned IP addresses", name)
+	}
+	return ips, nil
+}
+
+// selectSource returns the source address for the connection to addr, or nil when none applies.
+func selectSource(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	sources.Lock()
+	defer sources.Unlock()
+
+	if len(sources.addrs) == 0 {
+		return nil
+	}
+
+	candidates := sources.addrs
+	if ip := net.ParseIP(host); ip != nil {
+		candidates = nil
+		for _, src := range sources.addrs {
+			if IsIPv4(src) == IsIPv4(ip) {
+				candidates = append(candidates, src)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if sources.rotation == SourceAffinity {
+		h := fnv.New32a()
+
+		_, _ = h.Write([]byte(strings.ToLower(host)))
+		return candidates[h.Sum32()%uint32(len(candidates))]
+	}
+
+	src := candidates[sources.next%len(candidates)]
+	sources.next++
+	return src
+}
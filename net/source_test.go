@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestSelectSource(t *testing.T) {
+	defer func() { _ = UseSourceAddresses(nil, "") }()
+
+	if err := UseSourceAddresses(nil, "random"); err == nil {
+		t.Errorf("UseSourceAddresses accepted an unsupported rotation")
+	}
+
+	addrs := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2"), net.ParseIP("2001:db8::1")}
+	if err := UseSourceAddresses(addrs, SourceRoundRobin); err != nil {
+		t.Fatalf("UseSourceAddresses failed: %v", err)
+	}
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, selectSource("198.51.100.1:443").String())
+	}
+	if got[0] == got[1] || got[0] != got[2] {
+		t.Errorf("The round robin rotation selected %v", got)
+	}
+	if src := selectSource("[2001:db8::53]:443"); !src.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("The IPv6 destination was given the source address %v", src)
+	}
+
+	if err := UseSourceAddresses(addrs, SourceAffinity); err != nil {
+		t.Fatalf("UseSourceAddresses failed: %v", err)
+	}
+	first := selectSource("198.51.100.1:443")
+	for i := 0; i < 3; i++ {
+		if src := selectSource("198.51.100.1:80"); !src.Equal(first) {
+			t.Errorf("The affinity rotation selected %v after %v for the same destination", src, first)
+		}
+	}
+
+	_ = UseSourceAddresses(nil, "")
+	if src := selectSource("198.51.100.1:443"); src != nil {
+		t.Errorf("selectSource returned %v without source addresses", src)
+	}
+}
+
+func TestDialContextSourceAddress(t *testing.T) {
+	defer func() { _ = UseSourceAddresses(nil, "") }()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	remote := make(chan net.Addr, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			remote <- conn.RemoteAddr()
+			conn.Close()
+		}
+	}()
+
+	if err := UseSourceAddresses([]net.IP{net.ParseIP("127.0.0.1")}, SourceRoundRobin); err != nil {
+		t.Fatalf("UseSourceAddresses failed: %v", err)
+	}
+	conn, err := DialContext(context.Background(), "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("DialContext failed: %v", err)
+	}
+	defer conn.Close()
+
+	if addr := (<-remote).(*net.TCPAddr); !addr.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("The connection was established from %v", addr)
+	}
+}
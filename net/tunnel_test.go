@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestUseSOCKSProxy(t *testing.T) {
+	defer func() {
+		tunnel.Lock()
+		tunnel.socks = nil
+		tunnel.Unlock()
+	}()
+
+	if err := UseSOCKSProxy("http://127.0.0.1:8080"); err == nil {
+		t.Errorf("UseSOCKSProxy accepted a proxy URL without the socks5 scheme")
+	}
+
+	targets := make(chan string, 1)
+	proxyAddr := startTestSOCKSProxy(t, targets)
+	if err := UseSOCKSProxy("socks5://" + proxyAddr); err != nil {
+		t.Fatalf("UseSOCKSProxy failed: %v", err)
+	}
+	if network := TunnelNetwork(); network != "tcp" {
+		t.Errorf("TunnelNetwork returned %s, expected tcp", network)
+	}
+
+	conn, err := DialContext(context.Background(), "tcp", "192.0.2.1:53")
+	if err != nil {
+		t.Fatalf("DialContext failed through the SOCKS proxy: %v", err)
+	}
+	defer conn.Close()
+
+	if target := <-targets; target != "192.0.2.1:53" {
+		t.Errorf("The SOCKS proxy was asked to connect to %s", target)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("The connection through the SOCKS proxy returned %q: %v", buf, err)
+	}
+
+	if _, err := DialContext(context.Background(), "udp", "192.0.2.1:53"); err == nil {
+		t.Errorf("DialContext sent datagrams outside of the SOCKS proxy")
+	}
+}
+
+// startTestSOCKSProxy accepts a single SOCKS5 connection without authentication, reports the requested
+// destination, and echoes the data in place of connecting to the destination.
+func startTestSOCKSProxy(t *testing.T, targets chan string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		greeting := make([]byte, 2)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, make([]byte, greeting[1])); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{5, 0})
+
+		// Only IPv4 destinations are requested by the test
+		req := make([]byte, 10)
+		if _, err := io.ReadFull(conn, req); err != nil || req[3] != 1 {
+			return
+		}
+		port := binary.BigEndian.Uint16(req[8:])
+		targets <- net.JoinHostPort(net.IP(req[4:8]).String(), strconv.Itoa(int(port)))
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+		_, _ = io.Copy(conn, conn)
+	}()
+	return l.Addr().String()
+}
@@ -0,0 +1,230 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package options reads the settings of the 'options' section of the configuration. The settings are
+// decoded from YAML or provided on the command line, so a number can also be a string, a boolean can
+// be a string, and a list can be a string of values separated by commas.
+package options
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Bool returns the option as a boolean, or false when it is not set.
+func Bool(cfg *config.Config, key string) bool {
+	return BoolValue(cfg.Options[key])
+}
+
+// Int returns the option as a number, or zero when it is not set.
+func Int(cfg *config.Config, key string) int {
+	return IntValue(cfg.Options[key])
+}
+
+// Float returns the option as a real number, or zero when it is not set.
+func Float(cfg *config.Config, key string) float64 {
+	return FloatValue(cfg.Options[key])
+}
+
+// String returns the option as a string, without the surrounding spaces.
+func String(cfg *config.Config, key string) string {
+	return StringValue(cfg.Options[key])
+}
+
+// Strings returns the non-empty values of the option, provided as a list or a string of values separated by commas.
+func Strings(cfg *config.Config, key string) []string {
+	return StringsValue(cfg.Options[key])
+}
+
+// Duration returns the option provided as a duration such as '1h' or a number of seconds.
+func Duration(cfg *config.Config, key string) time.Duration {
+	return DurationValue(cfg.Options[key])
+}
+
+// Table returns the option provided as a table of settings, or nil when it is not a table.
+func Table(cfg *config.Config, key string) map[string]interface{} {
+	m, _ := cfg.Options[key].(map[string]interface{})
+	return m
+}
+
+// BoolValue converts a setting to a boolean.
+func BoolValue(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return b
+	}
+	return false
+}
+
+// IntValue converts a setting to a number.
+func IntValue(val interface{}) int {
+	switch v := val.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		num, _ := strconv.Atoi(strings.TrimSpace(v))
+		return num
+	}
+	return 0
+}
+
+// FloatValue converts a setting to a real number.
+func FloatValue(val interface{}) float64 {
+	switch v := val.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		num, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return num
+	}
+	return 0
+}
+
+// StringValue converts a setting to a string, without the surrounding spaces.
+func StringValue(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+// StringsValue converts a list of values, or a string of values separated by commas, to the non-empty values.
+func StringsValue(val interface{}) []string {
+	var values []string
+
+	switch v := val.(type) {
+	case []interface{}:
+		for _, item := range v {
+			switch i := item.(type) {
+			case string:
+				values = append(values, i)
+			case int:
+				values = append(values, strconv.Itoa(i))
+			}
+		}
+	case []string:
+		values = v
+	case string:
+		values = strings.Split(v, ",")
+	}
+
+	var results []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			results = append(results, value)
+		}
+	}
+	return results
+}
+
+// DurationValue converts a duration such as '1h', or a number of seconds, to a duration.
+func DurationValue(val interface{}) time.Duration {
+	switch v := val.(type) {
+	case int:
+		return time.Duration(v) * time.Second
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	case time.Duration:
+		return v
+	case string:
+		v = strings.TrimSpace(v)
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return 0
+}
+
+// Source returns the value of an option that is either used for all the data sources, or a table of data
+// source names and values, where the 'default' entry applies to the others. The names of the data sources
+// are matched regardless of case, and the exact name takes precedence.
+func Source(cfg *config.Config, key, source string) interface{} {
+	m, ok := cfg.Options[key].(map[string]interface{})
+	if !ok {
+		return cfg.Options[key]
+	}
+
+	if v, found := m[source]; found {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, source) {
+			return v
+		}
+	}
+	return m["default"]
+}
+
+// Size returns the option provided as a number of bytes or a size such as '512MB'.
+func Size(cfg *config.Config, key string) int64 {
+	return SizeValue(cfg.Options[key])
+}
+
+// SizeValue converts a number of bytes, or a size such as '512MB', to a number of bytes.
+func SizeValue(val interface{}) int64 {
+	switch v := val.(type) {
+	case int:
+		if v > 0 {
+			return int64(v)
+		}
+	case int64:
+		if v > 0 {
+			return v
+		}
+	case float64:
+		if v > 0 {
+			return int64(v)
+		}
+	case string:
+		return ParseSize(v)
+	}
+	return 0
+}
+
+// ParseSize returns the number of bytes of a size such as '512KB', '32MB' or '4GB', or of a plain number.
+// Zero is returned when the size cannot be parsed.
+func ParseSize(s string) int64 {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	mult := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			mult = unit.mult
+			break
+		}
+	}
+
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil || num <= 0 {
+		return 0
+	}
+	return int64(num * float64(mult))
+}
@@ -0,0 +1,135 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options = map[string]interface{}{
+		"enabled":  "true",
+		"disabled": false,
+		"count":    "42",
+		"ratio":    0.5,
+		"name":     " owasp ",
+		"list":     []interface{}{"a", " b ", "", 64500},
+		"csv":      "a, b,,c",
+		"ttl":      "90m",
+		"seconds":  30,
+		"table":    map[string]interface{}{"qps": 10},
+	}
+
+	if !Bool(cfg, "enabled") || Bool(cfg, "disabled") || Bool(cfg, "missing") {
+		t.Error("The boolean options were not converted as expected")
+	}
+	if Int(cfg, "count") != 42 || Int(cfg, "ratio") != 0 || Int(cfg, "missing") != 0 {
+		t.Error("The number options were not converted as expected")
+	}
+	if Float(cfg, "ratio") != 0.5 || Float(cfg, "count") != 42 {
+		t.Error("The real number options were not converted as expected")
+	}
+	if String(cfg, "name") != "owasp" || String(cfg, "count") != "42" || String(cfg, "seconds") != "" {
+		t.Error("The string options were not converted as expected")
+	}
+	if got := Strings(cfg, "list"); !reflect.DeepEqual(got, []string{"a", "b", "64500"}) {
+		t.Errorf("Unexpected values of the list: %v", got)
+	}
+	if got := Strings(cfg, "csv"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Unexpected values of the comma-separated string: %v", got)
+	}
+	if Duration(cfg, "ttl") != 90*time.Minute || Duration(cfg, "seconds") != 30*time.Second || Duration(cfg, "count") != 42*time.Second {
+		t.Error("The duration options were not converted as expected")
+	}
+	if IntValue(Table(cfg, "table")["qps"]) != 10 || Table(cfg, "list") != nil {
+		t.Error("The table options were not converted as expected")
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected []string
+	}{
+		{
+			name:     "List of values",
+			value:    []interface{}{"192.0.2.1", " eth1 ", ""},
+			expected: []string{"192.0.2.1", "eth1"},
+		},
+		{
+			name:     "Values separated by commas",
+			value:    "192.0.2.1, 192.0.2.2",
+			expected: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:     "Missing option",
+			value:    nil,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			if test.value != nil {
+				cfg.Options["source_addresses"] = test.value
+			}
+
+			if got := Strings(cfg, "source_addresses"); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Strings returned %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"512":   512,
+		"64KB":  64 << 10,
+		"32 mb": 32 << 20,
+		"1.5GB": 3 << 29,
+		"2TB":   2 << 40,
+		"-1MB":  0,
+		"large": 0,
+	} {
+		if got := ParseSize(s); got != expected {
+			t.Errorf("ParseSize(%q) returned %d, expected %d", s, got, expected)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options = map[string]interface{}{
+		"all": 5,
+		"table": map[string]interface{}{
+			"default":   1,
+			"crtsh":     2,
+			"Crtsh":     3,
+			"HackerOne": 4,
+		},
+	}
+
+	for _, test := range []struct {
+		key, source string
+		expected    int
+	}{
+		{"all", "crtsh", 5},
+		{"table", "Crtsh", 3},
+		{"table", "hackerone", 4},
+		{"table", "Shodan", 1},
+		{"missing", "Shodan", 0},
+	} {
+		if got := IntValue(Source(cfg, test.key, test.source)); got != test.expected {
+			t.Errorf("Source(%q, %q) returned %d, expected %d", test.key, test.source, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package provenance verifies the origin of the code executed by the engine: the signatures of
+// the external data source scripts, and the releases of the engine itself.
+package provenance
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureExt is the extension of the minisign signature file kept next to each signed file.
+const SignatureExt = ".minisig"
+
+var (
+	// ErrUnsigned is returned when a file has no signature.
+	ErrUnsigned = errors.New("the file is not signed")
+	// ErrUntrustedKey is returned when a file was signed by a key that is not trusted.
+	ErrUntrustedKey = errors.New("the file was signed by an untrusted key")
+	// ErrBadSignature is returned when the signature does not match the file.
+	ErrBadSignature = errors.New("the signature does not match the file")
+)
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// Signature is a minisign signature, along with its trusted comment.
+type Signature struct {
+	Algorithm      string
+	KeyID          [8]byte
+	Sig            []byte
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+// ParsePublicKey returns the minisign public key of the base64 value, or of the content
+// of a public key file, where the key follows the untrusted comment.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	var value string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			value = line
+			break
+		}
+	}
+
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return nil, fmt.Errorf("the minisign public key %q is not valid", value)
+	}
+
+	pk := &PublicKey{Key: ed25519.PublicKey(b[10:])}
+	copy(pk.ID[:], b[2:10])
+	return pk, nil
+}
+
+// LoadPublicKeys returns the public keys provided as base64 values or as paths of public key files.
+func LoadPublicKeys(values []string) ([]*PublicKey, error) {
+	var keys []*PublicKey
+
+	for _, v := range values {
+		if data, err := os.ReadFile(v); err == nil {
+			v = string(data)
+		}
+
+		pk, err := ParsePublicKey(v)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, pk)
+	}
+	return keys, nil
+}
+
+// ParseSignature returns the signature of a minisign signature file.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment: ") {
+		return nil, errors.New("the minisign signature file is not valid")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(b) != 2+8+ed25519.SignatureSize {
+		return nil, errors.New("the minisign signature is not valid")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, errors.New("the minisign global signature is not valid")
+	}
+
+	sig := &Signature{
+		Algorithm:      string(b[:2]),
+		Sig:            b[10:],
+		TrustedComment: strings.TrimPrefix(lines[2], "trusted comment: "),
+		GlobalSig:      global,
+	}
+	copy(sig.KeyID[:], b[2:10])
+	if sig.Algorithm != "Ed" && sig.Algorithm != "ED" {
+		return nil, fmt.Errorf("the minisign signature algorithm %q is not supported", sig.Algorithm)
+	}
+	return sig, nil
+}
+
+// Verify checks that the data was signed by one of the trusted keys. The signatures of the
+// prehashed files are supported, and the trusted comment must be signed by the same key.
+func Verify(keys []*PublicKey, data, sigfile []byte) error {
+	if len(bytes.TrimSpace(sigfile)) == 0 {
+		return ErrUnsigned
+	}
+
+	sig, err := ParseSignature(sigfile)
+	if err != nil {
+		return err
+	}
+
+	var pk *PublicKey
+	for _, k := range keys {
+		if k.ID == sig.KeyID {
+			pk = k
+			break
+		}
+	}
+	if pk == nil {
+		return ErrUntrustedKey
+	}
+
+	msg := data
+	if sig.Algorithm == "ED" {
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	}
+	if !ed25519.Verify(pk.Key, msg, sig.Sig) {
+		return ErrBadSignature
+	}
+	if !ed25519.Verify(pk.Key, append(append([]byte{}, sig.Sig...), sig.TrustedComment...), sig.GlobalSig) {
+		return fmt.Errorf("%w: the trusted comment was modified", ErrBadSignature)
+	}
+	return nil
+}
+
+// VerifyFile checks the signature of the file, kept in the file of the same name with the SignatureExt extension.
+func VerifyFile(keys []*PublicKey, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sigfile, err := os.ReadFile(path + SignatureExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrUnsigned
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := Verify(keys, data, sigfile); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
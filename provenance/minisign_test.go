@@ -0,0 +1,113 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey returns a minisign public key, and a function signing the data with its private key.
+func testKey(t *testing.T, id string) (string, func(data []byte, prehash bool, comment string) []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	key := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append([]byte("Ed"+id), pub...)) + "\n"
+	sign := func(data []byte, prehash bool, comment string) []byte {
+		alg, msg := "Ed", data
+		if prehash {
+			sum := blake2b.Sum512(data)
+			alg, msg = "ED", sum[:]
+		}
+
+		sig := ed25519.Sign(priv, msg)
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append([]byte(alg+id), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	return key, sign
+}
+
+func TestVerify(t *testing.T) {
+	key, sign := testKey(t, "01234567")
+	_, other := testKey(t, "89abcdef")
+
+	pk, err := ParsePublicKey(key)
+	if err != nil {
+		t.Fatalf("ParsePublicKey returned an error: %v", err)
+	}
+	keys := []*PublicKey{pk}
+
+	data := []byte(`name = "Custom"`)
+	if err := Verify(keys, data, sign(data, false, "file:custom.ads")); err != nil {
+		t.Errorf("The legacy signature was not verified: %v", err)
+	}
+	if err := Verify(keys, data, sign(data, true, "file:custom.ads")); err != nil {
+		t.Errorf("The prehashed signature was not verified: %v", err)
+	}
+
+	if err := Verify(keys, []byte(`name = "Modified"`), sign(data, true, "")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("The modified file returned %v", err)
+	}
+	if err := Verify(keys, data, other(data, true, "")); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("The file signed by another key returned %v", err)
+	}
+	if err := Verify(keys, data, nil); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("The file without a signature returned %v", err)
+	}
+
+	sig := sign(data, true, "file:custom.ads")
+	forged := bytes.Replace(sig, []byte("trusted comment: file:custom.ads"), []byte("trusted comment: file:other.ads"), 1)
+	if err := Verify(keys, data, forged); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("The modified trusted comment returned %v", err)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	key, sign := testKey(t, "01234567")
+
+	dir := t.TempDir()
+	keyfile := filepath.Join(dir, "scripts.pub")
+	if err := os.WriteFile(keyfile, []byte(key), 0644); err != nil {
+		t.Fatalf("Failed to write the key: %v", err)
+	}
+	keys, err := LoadPublicKeys([]string{keyfile})
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("LoadPublicKeys returned %d keys: %v", len(keys), err)
+	}
+
+	data := []byte(`name = "Custom"`)
+	signed := filepath.Join(dir, "signed.ads")
+	unsigned := filepath.Join(dir, "unsigned.ads")
+	for path, content := range map[string][]byte{
+		signed:                data,
+		signed + SignatureExt: sign(data, true, ""),
+		unsigned:              data,
+	} {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	if got, err := VerifyFile(keys, signed); err != nil || string(got) != string(data) {
+		t.Errorf("The signed file returned %q, %v", got, err)
+	}
+	if _, err := VerifyFile(keys, unsigned); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("The unsigned file returned %v", err)
+	}
+}
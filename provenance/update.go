@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+)
+
+// ReleaseURL is the endpoint providing the latest release of the engine.
+const ReleaseURL = "https://api.github.com/repos/owasp-amass/amass/releases/latest"
+
+// Release is the latest release of the engine.
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"`
+}
+
+// LatestRelease returns the latest release of the engine provided by the endpoint.
+func LatestRelease(ctx context.Context, url string) (*Release, error) {
+	resp, err := http.RequestWebPage(ctx, &http.Request{
+		URL:          url,
+		Header:       http.Header{"Accept": "application/vnd.github+json"},
+		MaxBodySize:  1 << 20,
+		ContentTypes: []string{"application/json"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("the release request returned with status: %s", resp.Status)
+	}
+
+	var r Release
+	if err := json.Unmarshal([]byte(resp.Body), &r); err != nil {
+		return nil, err
+	}
+	if r.Version == "" {
+		return nil, fmt.Errorf("the release response did not provide a version")
+	}
+	return &r, nil
+}
+
+// NewerVersion returns true when the latest version, such as 'v4.2.1', is newer than the current version.
+// The pre-release versions are older than the release of the same version.
+func NewerVersion(current, latest string) bool {
+	cur, cpre, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	last, lpre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+
+	for i := range cur {
+		if last[i] != cur[i] {
+			return last[i] > cur[i]
+		}
+	}
+	return cpre && !lpre
+}
+
+func parseVersion(v string) ([3]int, bool, bool) {
+	var nums [3]int
+
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, prerelease := strings.Cut(v, "-")
+	if prerelease && pre == "" {
+		return nums, false, false
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return nums, false, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nums, false, false
+		}
+		nums[i] = n
+	}
+	return nums, prerelease, true
+}
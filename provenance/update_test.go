@@ -0,0 +1,50 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLatestRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"tag_name":"v4.2.1","html_url":"https://github.com/owasp-amass/amass/releases/tag/v4.2.1"}`)
+	}))
+	defer srv.Close()
+
+	r, err := LatestRelease(context.Background(), srv.URL)
+	if err != nil || r.Version != "v4.2.1" || r.URL == "" {
+		t.Errorf("LatestRelease returned %+v, %v", r, err)
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		current  string
+		latest   string
+		expected bool
+	}{
+		{"v4.2.0", "v4.2.1", true},
+		{"v4.2.0", "v4.10.0", true},
+		{"v4.2.0", "v5", true},
+		{"v4.2.0", "v4.2.0", false},
+		{"v4.2.1", "v4.2.0", false},
+		{"v4.2.0-beta", "v4.2.0", true},
+		{"v4.2.0", "v4.3.0-rc1", true},
+		{"v4.2.0", "latest", false},
+		{"dev", "v4.2.1", false},
+	}
+
+	for _, test := range tests {
+		if got := NewerVersion(test.current, test.latest); got != test.expected {
+			t.Errorf("NewerVersion(%q, %q) returned %v, expected %v", test.current, test.latest, got, test.expected)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package requests
 
 import (
 	"net"
+	"net/mail"
 	"strings"
 	"time"
 
@@ -235,6 +236,66 @@ func (a *ASNRequest) Valid() bool {
 	return true
 }
 
+// EmailRequest handles data needed throughout Service processing of an email address.
+type EmailRequest struct {
+	Address string
+	Domain  string
+}
+
+// Clone implements pipeline Data.
+func (e *EmailRequest) Clone() pipeline.Data {
+	return &EmailRequest{
+		Address: e.Address,
+		Domain:  e.Domain,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (e *EmailRequest) MarkAsProcessed() {}
+
+// Valid performs input validation of the receiver.
+func (e *EmailRequest) Valid() bool {
+	if _, err := mail.ParseAddress(e.Address); err != nil {
+		return false
+	}
+	if _, ok := dns.IsDomainName(e.Domain); !ok {
+		return false
+	}
+	return true
+}
+
+// AccountRequest handles data needed throughout Service processing of an account operated by an organization.
+type AccountRequest struct {
+	Platform string
+	Name     string
+	URL      string
+	Domain   string
+}
+
+// Clone implements pipeline Data.
+func (a *AccountRequest) Clone() pipeline.Data {
+	return &AccountRequest{
+		Platform: a.Platform,
+		Name:     a.Name,
+		URL:      a.URL,
+		Domain:   a.Domain,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (a *AccountRequest) MarkAsProcessed() {}
+
+// Valid performs input validation of the receiver.
+func (a *AccountRequest) Valid() bool {
+	if a.Platform == "" || a.URL == "" {
+		return false
+	}
+	if _, ok := dns.IsDomainName(a.Domain); !ok {
+		return false
+	}
+	return true
+}
+
 // WhoisRequest handles data needed throughout Service processing of reverse whois.
 type WhoisRequest struct {
 	Domain     string
@@ -286,6 +347,11 @@ type AddressInfo struct {
 	CIDRStr     string     `json:"cidr"`
 	ASN         int        `json:"asn"`
 	Description string     `json:"desc"`
+	// The provenance of the record resolving the name to the address, when read from the graph database
+	RecordType string     `json:"record_type,omitempty"`
+	Via        string     `json:"via,omitempty"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
 }
 
 // SanitizeDNSRequest cleans the Name and Domain elements of the receiver.
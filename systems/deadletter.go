@@ -0,0 +1,289 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// DeadLetterFile is the name of the file in the output directory that holds the dead-letter queue.
+const DeadLetterFile = "dead_letters.json"
+
+// DeadLetter is a data source request that failed repeatedly and was set aside to be replayed.
+type DeadLetter struct {
+	ID       string          `json:"id"`
+	Source   string          `json:"source"`
+	Kind     string          `json:"kind"`
+	Request  json.RawMessage `json:"request"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	Failed   time.Time       `json:"failed"`
+}
+
+// NewDeadLetter returns a DeadLetter for the request that the named data source failed to handle.
+func NewDeadLetter(source string, req interface{}, err error, attempts int) (*DeadLetter, error) {
+	var kind string
+	switch req.(type) {
+	case *requests.DNSRequest:
+		kind = "dns"
+	case *requests.ResolvedRequest:
+		kind = "resolved"
+	case *requests.SubdomainRequest:
+		kind = "subdomain"
+	case *requests.AddrRequest:
+		kind = "address"
+	case *requests.ASNRequest:
+		kind = "asn"
+	case *requests.WhoisRequest:
+		kind = "whois"
+	case *requests.EmailRequest:
+		kind = "email"
+	case *requests.AccountRequest:
+		kind = "account"
+	default:
+		return nil, fmt.Errorf("the %T request cannot be entered into the dead-letter queue", req)
+	}
+
+	data, jerr := json.Marshal(req)
+	if jerr != nil {
+		return nil, jerr
+	}
+
+	var msg string
+	if err != nil {
+		msg = err.Error()
+	}
+
+	sum := sha1.Sum([]byte(source + "|" + kind + "|" + string(data)))
+	return &DeadLetter{
+		ID:       hex.EncodeToString(sum[:])[:12],
+		Source:   source,
+		Kind:     kind,
+		Request:  data,
+		Error:    msg,
+		Attempts: attempts,
+		Failed:   time.Now(),
+	}, nil
+}
+
+// Req returns the request held by the DeadLetter so it can be sent to the data source again.
+func (d *DeadLetter) Req() (interface{}, error) {
+	var req interface{}
+
+	switch d.Kind {
+	case "dns":
+		req = new(requests.DNSRequest)
+	case "resolved":
+		req = new(requests.ResolvedRequest)
+	case "subdomain":
+		req = new(requests.SubdomainRequest)
+	case "address":
+		req = new(requests.AddrRequest)
+	case "asn":
+		req = new(requests.ASNRequest)
+	case "whois":
+		req = new(requests.WhoisRequest)
+	case "email":
+		req = new(requests.EmailRequest)
+	case "account":
+		req = new(requests.AccountRequest)
+	default:
+		return nil, fmt.Errorf("the dead letter %s has an unknown kind: %s", d.ID, d.Kind)
+	}
+
+	if err := json.Unmarshal(d.Request, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Target returns the name or address that the request was made for.
+func (d *DeadLetter) Target() string {
+	req, err := d.Req()
+	if err != nil {
+		return ""
+	}
+
+	switch v := req.(type) {
+	case *requests.DNSRequest:
+		return v.Domain
+	case *requests.ResolvedRequest:
+		return v.Name
+	case *requests.SubdomainRequest:
+		return v.Name
+	case *requests.AddrRequest:
+		return v.Address
+	case *requests.ASNRequest:
+		if v.ASN != 0 {
+			return fmt.Sprintf("AS%d", v.ASN)
+		}
+		return v.Address
+	case *requests.WhoisRequest:
+		return v.Domain
+	case *requests.EmailRequest:
+		return v.Address
+	case *requests.AccountRequest:
+		return v.URL
+	}
+	return ""
+}
+
+// DeadLetterQueue persists the dead letters in a file, one JSON object per line.
+// The methods of a nil DeadLetterQueue do nothing.
+type DeadLetterQueue struct {
+	sync.Mutex
+	path string
+}
+
+// NewDeadLetterQueue returns a DeadLetterQueue that stores the dead letters in the provided file.
+func NewDeadLetterQueue(path string) *DeadLetterQueue {
+	return &DeadLetterQueue{path: path}
+}
+
+// DeadLetterPath returns the path of the dead-letter queue in the output directory of the configuration.
+func DeadLetterPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), DeadLetterFile)
+}
+
+// Add enters the DeadLetter into the queue. When the same request from the same data source
+// is already in the queue, the entry is updated and the number of attempts is accumulated.
+func (q *DeadLetterQueue) Add(d *DeadLetter) error {
+	if q == nil || d == nil {
+		return nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	letters, err := q.read()
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for i, l := range letters {
+		if l.ID == d.ID {
+			d.Attempts += l.Attempts
+			letters[i] = d
+			found = true
+			break
+		}
+	}
+	if !found {
+		letters = append(letters, d)
+	}
+	return q.write(letters)
+}
+
+// List returns the dead letters currently in the queue.
+func (q *DeadLetterQueue) List() ([]*DeadLetter, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	return q.read()
+}
+
+// Remove deletes the dead letters with the provided IDs from the queue.
+// When no IDs are provided, the queue is emptied.
+func (q *DeadLetterQueue) Remove(ids ...string) error {
+	if q == nil {
+		return nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if len(ids) == 0 {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	letters, err := q.read()
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		remove[id] = struct{}{}
+	}
+
+	var keep []*DeadLetter
+	for _, l := range letters {
+		if _, found := remove[l.ID]; !found {
+			keep = append(keep, l)
+		}
+	}
+	return q.write(keep)
+}
+
+func (q *DeadLetterQueue) read() ([]*DeadLetter, error) {
+	f, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var letters []*DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var d DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			return nil, fmt.Errorf("failed to parse the dead-letter queue %s: %v", q.path, err)
+		}
+		letters = append(letters, &d)
+	}
+	return letters, scanner.Err()
+}
+
+// write replaces the contents of the queue file without leaving a partially written file behind.
+func (q *DeadLetterQueue) write(letters []*DeadLetter) error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), DeadLetterFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := json.NewEncoder(tmp)
+	for _, l := range letters {
+		if err := enc.Encode(l); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
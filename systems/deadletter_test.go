@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	q := NewDeadLetterQueue(filepath.Join(t.TempDir(), DeadLetterFile))
+
+	d, err := NewDeadLetter("Example", &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}, errors.New("timeout"), 3)
+	if err != nil {
+		t.Fatalf("Failed to create the dead letter: %v", err)
+	}
+	if err := q.Add(d); err != nil {
+		t.Fatalf("Failed to add the dead letter: %v", err)
+	}
+
+	again, _ := NewDeadLetter("Example", &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}, errors.New("timeout"), 3)
+	if err := q.Add(again); err != nil {
+		t.Fatalf("Failed to add the dead letter: %v", err)
+	}
+
+	other, _ := NewDeadLetter("Example", &requests.AddrRequest{Address: "192.0.2.1"}, errors.New("refused"), 3)
+	if err := q.Add(other); err != nil {
+		t.Fatalf("Failed to add the dead letter: %v", err)
+	}
+
+	letters, err := q.List()
+	if err != nil {
+		t.Fatalf("Failed to list the dead letters: %v", err)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, but %d were returned", len(letters))
+	}
+	if letters[0].Attempts != 6 {
+		t.Errorf("Expected the attempts to accumulate to 6, but got %d", letters[0].Attempts)
+	}
+
+	req, err := letters[0].Req()
+	if err != nil {
+		t.Fatalf("Failed to obtain the request: %v", err)
+	}
+	if dns, ok := req.(*requests.DNSRequest); !ok || dns.Domain != "owasp.org" {
+		t.Errorf("The request was not restored from the dead letter: %v", req)
+	}
+	if target := letters[1].Target(); target != "192.0.2.1" {
+		t.Errorf("Expected the target 192.0.2.1, but got %s", target)
+	}
+
+	if err := q.Remove(d.ID); err != nil {
+		t.Fatalf("Failed to remove the dead letter: %v", err)
+	}
+	if letters, _ := q.List(); len(letters) != 1 || letters[0].ID != other.ID {
+		t.Errorf("The dead letter was not removed from the queue")
+	}
+
+	if err := q.Remove(); err != nil {
+		t.Fatalf("Failed to empty the queue: %v", err)
+	}
+	if letters, _ := q.List(); len(letters) != 0 {
+		t.Errorf("The queue was not emptied")
+	}
+}
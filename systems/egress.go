@@ -0,0 +1,27 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/config/config"
+)
+
+// restrictEgress limits the connections to the hosts matching the patterns of the 'egress_allowlist' option,
+// along with the resolvers and the targets in scope. Hosts found in scraped content, such as the links
+// followed by the crawler and the redirects of the web servers, are then refused outside of the allowlist.
+func restrictEgress(cfg *config.Config, patterns []string) {
+	patterns = append(patterns, cfg.Domains()...)
+	patterns = append(patterns, cfg.Resolvers...)
+	patterns = append(patterns, cfg.TrustedResolvers...)
+	for _, addr := range cfg.Scope.Addresses {
+		patterns = append(patterns, addr.String())
+	}
+	for _, cidr := range cfg.Scope.CIDRs {
+		patterns = append(patterns, cidr.String())
+	}
+
+	amassnet.RestrictEgress(cfg.Log, patterns...)
+}
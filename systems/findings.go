@@ -0,0 +1,240 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FindingsFile is the name of the file in the output directory that holds the findings.
+const FindingsFile = "findings.json"
+
+// Finding is an asset discovered by a data source that is not kept in the graph database,
+// such as an email address, along with the in-scope domain name it is linked to.
+type Finding struct {
+	// ID is derived from the type, value and domain, so it is the same in every findings file
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Domain     string            `json:"domain,omitempty"`
+	Relation   string            `json:"relation,omitempty"`
+	Source     string            `json:"source"`
+	Properties map[string]string `json:"properties,omitempty"`
+	FirstSeen  time.Time         `json:"first_seen"`
+	LastSeen   time.Time         `json:"last_seen"`
+}
+
+func (f *Finding) key() string {
+	return f.Type + "|" + strings.ToLower(f.Value) + "|" + strings.ToLower(f.Domain)
+}
+
+// FindingID returns the stable identifier of the finding, derived from its type, value and domain.
+func FindingID(f *Finding) string {
+	sum := sha256.Sum256([]byte(f.key()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// FindingStore keeps the findings in memory and persists them in a file, one JSON object per line.
+// The methods of a nil FindingStore do nothing.
+type FindingStore struct {
+	sync.Mutex
+	path     string
+	findings map[string]*Finding
+}
+
+// FindingsPath returns the path of the findings file in the output directory of the configuration.
+func FindingsPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), FindingsFile)
+}
+
+// NewFindingStore returns a FindingStore loaded with the findings already saved at the provided path.
+func NewFindingStore(path string) (*FindingStore, error) {
+	fs := &FindingStore{
+		path:     path,
+		findings: make(map[string]*Finding),
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var finding Finding
+
+		if err := json.Unmarshal(scanner.Bytes(), &finding); err == nil && finding.Value != "" {
+			finding.ID = FindingID(&finding)
+			fs.findings[finding.key()] = &finding
+		}
+	}
+	return fs, scanner.Err()
+}
+
+// Add enters the finding into the store and returns true when it was not already known.
+// The properties of a known finding are merged and the time it was last seen is updated.
+func (fs *FindingStore) Add(f *Finding) bool {
+	if fs == nil || f == nil || f.Type == "" || f.Value == "" {
+		return false
+	}
+
+	fs.Lock()
+	defer fs.Unlock()
+
+	now := time.Now()
+	if cur, found := fs.findings[f.key()]; found {
+		cur.LastSeen = now
+		for k, v := range f.Properties {
+			if cur.Properties == nil {
+				cur.Properties = make(map[string]string)
+			}
+			cur.Properties[k] = v
+		}
+		return false
+	}
+
+	c := *f
+	c.ID = FindingID(f)
+	c.FirstSeen = now
+	c.LastSeen = now
+	if len(f.Properties) > 0 {
+		c.Properties = make(map[string]string, len(f.Properties))
+		for k, v := range f.Properties {
+			c.Properties[k] = v
+		}
+	}
+	fs.findings[c.key()] = &c
+	return true
+}
+
+// Get returns a copy of the finding with the provided type, value and domain, or nil when it is not kept.
+func (fs *FindingStore) Get(typ, value, domain string) *Finding {
+	if fs == nil {
+		return nil
+	}
+
+	fs.Lock()
+	defer fs.Unlock()
+
+	f, found := fs.findings[(&Finding{Type: typ, Value: value, Domain: domain}).key()]
+	if !found {
+		return nil
+	}
+
+	c := *f
+	if len(f.Properties) > 0 {
+		c.Properties = make(map[string]string, len(f.Properties))
+		for k, v := range f.Properties {
+			c.Properties[k] = v
+		}
+	}
+	return &c
+}
+
+// Find returns copies of the findings last seen after the provided time, sorted by type, value and domain,
+// so the findings are always saved in the same order.
+// When types are provided, only the findings of those types are returned.
+func (fs *FindingStore) Find(since time.Time, types ...string) []*Finding {
+	if fs == nil {
+		return nil
+	}
+
+	fs.Lock()
+	defer fs.Unlock()
+
+	var results []*Finding
+	for _, f := range fs.findings {
+		if f.LastSeen.Before(since) || !findingHasType(f, types) {
+			continue
+		}
+
+		c := *f
+		if len(f.Properties) > 0 {
+			c.Properties = make(map[string]string, len(f.Properties))
+			for k, v := range f.Properties {
+				c.Properties[k] = v
+			}
+		}
+		results = append(results, &c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		if results[i].Value != results[j].Value {
+			return results[i].Value < results[j].Value
+		}
+		return results[i].Domain < results[j].Domain
+	})
+	return results
+}
+
+func findingHasType(f *Finding, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, t := range types {
+		if strings.EqualFold(f.Type, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the findings to the file without leaving a partially written file behind.
+func (fs *FindingStore) Save() error {
+	if fs == nil {
+		return nil
+	}
+
+	findings := fs.Find(time.Time{})
+	if len(findings) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fs.path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), FindingsFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fs.path)
+}
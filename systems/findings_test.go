@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFindingStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FindingsFile)
+
+	fs, err := NewFindingStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create the finding store: %v", err)
+	}
+
+	email := &Finding{
+		Type:     "EmailAddress",
+		Value:    "admin@owasp.org",
+		Domain:   "owasp.org",
+		Relation: "email_address",
+		Source:   "Hunter",
+	}
+	if !fs.Add(email) {
+		t.Errorf("The new finding was reported as already known")
+	}
+	if fs.Add(&Finding{Type: "EmailAddress", Value: "ADMIN@owasp.org", Domain: "owasp.org",
+		Source: "HIBP", Properties: map[string]string{"breaches": "2"}}) {
+		t.Errorf("The known finding was reported as new")
+	}
+	fs.Add(&Finding{Type: "Other", Value: "value", Source: "Example"})
+
+	if found := fs.Find(time.Time{}, "EmailAddress"); len(found) != 1 {
+		t.Fatalf("Expected 1 email address, but %d were returned", len(found))
+	} else if found[0].Properties["breaches"] != "2" {
+		t.Errorf("The properties were not merged into the known finding")
+	}
+	if f := fs.Get("EmailAddress", "Admin@OWASP.org", "owasp.org"); f == nil || f.Source != "Hunter" {
+		t.Errorf("The known finding was not returned: %v", f)
+	} else if f.Properties["breaches"] = "0"; fs.Get("EmailAddress", "admin@owasp.org", "owasp.org").Properties["breaches"] != "2" {
+		t.Errorf("The finding returned was not a copy")
+	}
+	if f := fs.Get("EmailAddress", "admin@owasp.org", "example.com"); f != nil {
+		t.Errorf("The finding of another domain name was returned: %v", f)
+	}
+	if found := fs.Find(time.Now().Add(time.Hour)); len(found) != 0 {
+		t.Errorf("Expected no findings seen after the provided time, but %d were returned", len(found))
+	}
+
+	if err := fs.Save(); err != nil {
+		t.Fatalf("Failed to save the findings: %v", err)
+	}
+	loaded, err := NewFindingStore(path)
+	if err != nil {
+		t.Fatalf("Failed to load the findings: %v", err)
+	}
+	if found := loaded.Find(time.Time{}); len(found) != 2 {
+		t.Errorf("Expected 2 findings to be loaded, but %d were returned", len(found))
+	}
+
+	var nilStore *FindingStore
+	if nilStore.Add(email) || nilStore.Find(time.Time{}) != nil || nilStore.Get("EmailAddress", email.Value, email.Domain) != nil || nilStore.Save() != nil {
+		t.Errorf("The nil finding store did not ignore the calls")
+	}
+}
+
+func TestFindingsOrder(t *testing.T) {
+	dir := t.TempDir()
+	findings := []*Finding{
+		{Type: "Service", Value: "www.owasp.org:443", Domain: "owasp.org", Source: "Example"},
+		{Type: "EmailAddress", Value: "admin@owasp.org", Domain: "owasp.org", Source: "Hunter"},
+		{Type: "EmailAddress", Value: "admin@owasp.org", Domain: "example.com", Source: "Hunter"},
+		{Type: "EmailAddress", Value: "abuse@owasp.org", Domain: "owasp.org", Source: "Hunter"},
+	}
+
+	var saved [][]byte
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(dir, strconv.Itoa(i), FindingsFile)
+
+		fs, err := NewFindingStore(path)
+		if err != nil {
+			t.Fatalf("Failed to create the finding store: %v", err)
+		}
+		// The findings are added in the opposite order the second time
+		for j := range findings {
+			f := findings[j]
+			if i == 1 {
+				f = findings[len(findings)-1-j]
+			}
+			fs.Add(f)
+		}
+
+		found := fs.Find(time.Time{})
+		expected := []string{"abuse@owasp.org|owasp.org", "admin@owasp.org|example.com",
+			"admin@owasp.org|owasp.org", "www.owasp.org:443|owasp.org"}
+		for j, f := range found {
+			if got := f.Value + "|" + f.Domain; got != expected[j] {
+				t.Errorf("Finding %d was %s, expected %s", j, got, expected[j])
+			}
+			if f.ID != FindingID(f) || len(f.ID) != 16 {
+				t.Errorf("The finding %s has the ID %s, expected %s", f.Value, f.ID, FindingID(f))
+			}
+		}
+
+		if err := fs.Save(); err != nil {
+			t.Fatalf("Failed to save the findings: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read the findings: %v", err)
+		}
+		// The times differ, so only the IDs are compared
+		var ids []byte
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+			var f Finding
+			if err := json.Unmarshal(line, &f); err != nil {
+				t.Fatalf("Failed to parse the saved finding: %v", err)
+			}
+			ids = append(ids, f.ID...)
+		}
+		saved = append(saved, ids)
+	}
+
+	if !bytes.Equal(saved[0], saved[1]) {
+		t.Errorf("The findings were not saved in the same order")
+	}
+	if a, b := FindingID(&Finding{Type: "EmailAddress", Value: "ADMIN@owasp.org"}),
+		FindingID(&Finding{Type: "EmailAddress", Value: "admin@owasp.org"}); a != b {
+		t.Errorf("The IDs of the same finding differ: %s and %s", a, b)
+	}
+}
@@ -0,0 +1,621 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// DefaultNameBatchSize is the number of names queried at once when the 'name_batch_size' option is not provided.
+const DefaultNameBatchSize = 500
+
+// NameBatchSize returns the number of names queried at once from the graph database in the 'name_batch_size' option.
+func NameBatchSize(cfg *config.Config) int {
+	if size := options.Int(cfg, "name_batch_size"); size > 0 {
+		return size
+	}
+	return DefaultNameBatchSize
+}
+
+// NamesToAddrsStream sends a NameAddrPair for each name and address discovered in the graph database on the
+// channel, querying the names in batches of the size provided, so the addresses of large enumerations are
+// not all held in memory at once. The batches without addresses are skipped, and the channel is closed
+// once the names have been queried. The context error is returned when the stream is interrupted.
+func NamesToAddrsStream(ctx context.Context, g *netmap.Graph, since time.Time,
+	size int, out chan<- *netmap.NameAddrPair, names ...string) error {
+	defer close(out)
+
+	if size <= 0 {
+		size = DefaultNameBatchSize
+	}
+
+	for start := 0; start < len(names); start += size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+
+		pairs, err := g.NamesToAddrs(ctx, since, names[start:end]...)
+		if err != nil {
+			// The batches with no names or addresses in the graph return errors
+			continue
+		}
+
+		for _, p := range pairs {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- p:
+			}
+		}
+	}
+	return nil
+}
+
+// NameAddrRecord is a NameAddrPair with the provenance of the relation resolving the name to the address,
+// so the reports do not query the graph database again for the records behind each pair.
+type NameAddrRecord struct {
+	netmap.NameAddrPair
+	// RecordType is the type of the record holding the address, 'A' or 'AAAA'
+	RecordType string
+	// Via is 'CNAME' or 'SRV' when the address was reached through the alias chain of the name
+	Via string
+	// Target is the name holding the address record when it was reached through an alias chain
+	Target    string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// NamesToAddrRecords returns a NameAddrRecord for each name and address discovered in the graph database,
+// following the alias chains of the names like the NamesToAddrs method of the graph. The first seen
+// time of the relations not kept by the database is the time the address was first stored.
+func NamesToAddrRecords(ctx context.Context, g *netmap.Graph, since time.Time, names ...string) ([]*NameAddrRecord, error) {
+	var results []*NameAddrRecord
+
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if _, found := seen[name]; found {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		assets, err := g.DB.FindByContent(&domain.FQDN{Name: name}, since)
+		if err != nil || len(assets) == 0 {
+			continue
+		}
+
+		var via string
+		cur := assets[0]
+		// Get to the end of the alias chains for service names and CNAMES
+		for i := 1; i <= 10; i++ {
+			reltypes := []string{"cname_record"}
+			if i == 1 {
+				reltypes = append(reltypes, "srv_record")
+			}
+
+			rels, err := g.DB.OutgoingRelations(cur, since, reltypes...)
+			if err != nil || len(rels) == 0 {
+				break
+			}
+
+			next := cur
+			for _, rel := range rels {
+				if found, err := g.DB.FindById(rel.ToAsset.ID, since); err == nil && found != nil {
+					if via == "" {
+						via = "CNAME"
+						if rel.Type == "srv_record" {
+							via = "SRV"
+						}
+					}
+					next = found
+					break
+				}
+			}
+			if next == cur {
+				break
+			}
+			cur = next
+		}
+
+		var target string
+		if via != "" {
+			if fqdn, ok := cur.Asset.(domain.FQDN); ok {
+				target = fqdn.Name
+			}
+		}
+
+		rels, err := g.DB.OutgoingRelations(cur, since, "a_record", "aaaa_record")
+		if err != nil {
+			continue
+		}
+
+		addrs := make(map[string]struct{})
+		for _, rel := range rels {
+			found, err := g.DB.FindById(rel.ToAsset.ID, since)
+			if err != nil || found == nil {
+				continue
+			}
+
+			ip, ok := found.Asset.(network.IPAddress)
+			if !ok {
+				continue
+			}
+			if _, dup := addrs[ip.Address.String()]; dup {
+				continue
+			}
+			addrs[ip.Address.String()] = struct{}{}
+
+			first := rel.CreatedAt
+			if first.IsZero() {
+				first = found.CreatedAt
+			}
+			results = append(results, &NameAddrRecord{
+				NameAddrPair: netmap.NameAddrPair{
+					FQDN: &domain.FQDN{Name: name},
+					Addr: &ip,
+				},
+				RecordType: strings.ToUpper(strings.TrimSuffix(rel.Type, "_record")),
+				Via:        via,
+				Target:     target,
+				FirstSeen:  first,
+				LastSeen:   rel.LastSeen,
+			})
+		}
+	}
+	return results, nil
+}
+
+// NamesToAddrRecordsStream sends a NameAddrRecord for each name and address discovered in the graph database
+// on the channel, querying the names in batches like NamesToAddrsStream. The channel is closed once the names
+// have been queried, and the context error is returned when the stream is interrupted.
+func NamesToAddrRecordsStream(ctx context.Context, g *netmap.Graph, since time.Time,
+	size int, out chan<- *NameAddrRecord, names ...string) error {
+	defer close(out)
+
+	if size <= 0 {
+		size = DefaultNameBatchSize
+	}
+
+	for start := 0; start < len(names); start += size {
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+
+		records, err := NamesToAddrRecords(ctx, g, since, names[start:end]...)
+		for _, r := range records {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- r:
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrInvalidPageToken is returned when the page token was not provided by a previous call of the same helper.
+var ErrInvalidPageToken = errors.New("the page token is not valid")
+
+// QueryOptions bounds the graph queries made on behalf of the API handlers, so a pathological query
+// against a huge database cannot hold the handler. The zero value applies no bounds.
+type QueryOptions struct {
+	// Limit is the number of results returned by each call, or zero for all the results
+	Limit int
+	// PageToken continues after the results of the previous call that returned the token
+	PageToken string
+	// Timeout is the time each call is allowed, in addition to the deadline of the context
+	Timeout time.Duration
+	// BatchSize is the number of names queried at once, or DefaultNameBatchSize when zero
+	BatchSize int
+}
+
+func (o *QueryOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// NamesToAddrsPage returns a page of the NameAddrPairs of the names provided, which must be the same names
+// for each call continuing with the token returned. The pages end between names, so a page only exceeds
+// the limit when the addresses of a single name do. The token is empty after the last page. When the call
+// is interrupted, the pairs found so far are returned with the token continuing after them and the context error.
+func NamesToAddrsPage(ctx context.Context, g *netmap.Graph, since time.Time,
+	opts QueryOptions, names ...string) ([]*netmap.NameAddrPair, string, error) {
+	start, err := decodeOffsetToken(opts.PageToken, "names")
+	if err != nil || start > len(names) {
+		return nil, "", ErrInvalidPageToken
+	}
+
+	ctx, cancel := opts.context(ctx)
+	defer cancel()
+
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultNameBatchSize
+	}
+
+	var results []*netmap.NameAddrPair
+	for i := start; i < len(names); i += size {
+		end := i + size
+		if end > len(names) {
+			end = len(names)
+		}
+
+		pairs, err := namesToAddrs(ctx, g, since, names[i:end])
+		if ctx.Err() != nil {
+			return results, encodePageToken("names", strconv.Itoa(i)), ctx.Err()
+		}
+		if err != nil {
+			// The batches with no names or addresses in the graph return errors
+			continue
+		}
+
+		byName := make(map[string][]*netmap.NameAddrPair)
+		for _, p := range pairs {
+			byName[p.FQDN.Name] = append(byName[p.FQDN.Name], p)
+		}
+		for j := i; j < end; j++ {
+			list, found := byName[names[j]]
+			if !found {
+				continue
+			}
+			if opts.Limit > 0 && len(results) > 0 && len(results)+len(list) > opts.Limit {
+				return results, encodePageToken("names", strconv.Itoa(j)), nil
+			}
+
+			results = append(results, list...)
+			delete(byName, names[j])
+		}
+		if opts.Limit > 0 && len(results) >= opts.Limit && end < len(names) {
+			return results, encodePageToken("names", strconv.Itoa(end)), nil
+		}
+	}
+	return results, "", nil
+}
+
+// namesToAddrs returns once the context is done, leaving the query of the batch to finish in the background.
+func namesToAddrs(ctx context.Context, g *netmap.Graph, since time.Time, names []string) ([]*netmap.NameAddrPair, error) {
+	type result struct {
+		pairs []*netmap.NameAddrPair
+		err   error
+	}
+
+	ch := make(chan *result, 1)
+	go func() {
+		pairs, err := g.NamesToAddrs(ctx, since, names...)
+		ch <- &result{pairs: pairs, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.pairs, r.err
+	}
+}
+
+// ReadASPrefixesPage returns a page of the prefixes announced by the ASN in the graph database since the time
+// provided, ordered by the netblocks in the database so the pages remain stable while announcements are added.
+// The token is empty after the last page. When the call is interrupted, the prefixes found so far are returned
+// with the token continuing after them and the context error.
+func ReadASPrefixesPage(ctx context.Context, g *netmap.Graph, asn int, since time.Time, opts QueryOptions) ([]string, string, error) {
+	after, err := decodePageToken(opts.PageToken, "prefixes")
+	if err != nil {
+		return nil, "", ErrInvalidPageToken
+	}
+
+	ctx, cancel := opts.context(ctx)
+	defer cancel()
+
+	var rels []*types.Relation
+	if err := queryWithContext(ctx, func() error {
+		assets, err := g.DB.FindByContent(&network.AutonomousSystem{Number: asn}, since)
+		if err != nil || len(assets) == 0 {
+			return err
+		}
+
+		rels, err = g.DB.OutgoingRelations(assets[0], since, "announces")
+		return err
+	}); err != nil {
+		if ctx.Err() != nil {
+			return nil, opts.PageToken, err
+		}
+		// The ASNs without announcements return errors
+		return nil, "", nil
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].ToAsset.ID < rels[j].ToAsset.ID })
+
+	var last string
+	var prefixes []string
+	for _, rel := range rels {
+		id := rel.ToAsset.ID
+		if after != "" && id <= after {
+			continue
+		}
+		if opts.Limit > 0 && len(prefixes) >= opts.Limit {
+			return prefixes, encodePageToken("prefixes", last), nil
+		}
+		if ctx.Err() != nil {
+			return prefixes, encodePageToken("prefixes", last), ctx.Err()
+		}
+
+		if a, err := g.DB.FindById(id, since); err == nil && a != nil {
+			if netblock, ok := a.Asset.(network.Netblock); ok {
+				prefixes = append(prefixes, netblock.Cidr.String())
+			}
+		}
+		last = id
+	}
+	return prefixes, "", nil
+}
+
+// queryWithContext returns the error of the query, or the context error once the context is done
+// while the query finishes in the background.
+func queryWithContext(ctx context.Context, query func() error) error {
+	ch := make(chan error, 1)
+	go func() { ch <- query() }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-ch:
+		return err
+	}
+}
+
+func encodePageToken(kind, value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + value))
+}
+
+// decodePageToken returns the value of the token provided by the helper of the kind, or the empty string for no token.
+func decodePageToken(token, kind string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+
+	k, value, found := strings.Cut(string(data), ":")
+	if !found || k != kind {
+		return "", ErrInvalidPageToken
+	}
+	return value, nil
+}
+
+func decodeOffsetToken(token, kind string) (int, error) {
+	value, err := decodePageToken(token, kind)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidPageToken
+	}
+	return offset, nil
+}
+
+// TimeWindow is the period of one or more collection runs. The zero End leaves the window open.
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// PrefixChanges holds the prefixes of an autonomous system announced during the later window
+// only, during the earlier window only, and during both windows.
+type PrefixChanges struct {
+	Announced []string
+	Withdrawn []string
+	Unchanged []string
+}
+
+// ReadASPrefixChanges compares the prefixes announced by the ASN in the graph database during the two windows,
+// so changes to the BGP footprint can be detected between collection runs. The graph only keeps the first
+// and last time each announcement was seen, so a prefix is considered announced during a window when
+// the period between those times overlaps it.
+func ReadASPrefixChanges(ctx context.Context, g *netmap.Graph, asn int, earlier, later TimeWindow) (*PrefixChanges, error) {
+	assets, err := g.DB.FindByContent(&network.AutonomousSystem{Number: asn}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) == 0 {
+		return &PrefixChanges{}, nil
+	}
+
+	rels, err := g.DB.OutgoingRelations(assets[0], time.Time{}, "announces")
+	if err != nil {
+		return nil, err
+	}
+
+	var seen []*types.Relation
+	for _, rel := range rels {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		a, err := g.DB.FindById(rel.ToAsset.ID, time.Time{})
+		if err != nil || a == nil {
+			continue
+		}
+		if _, ok := a.Asset.(network.Netblock); ok {
+			// The repository does not return the creation time of the relations, while the
+			// netblocks are created when the first announcement is stored
+			if rel.CreatedAt.IsZero() {
+				rel.CreatedAt = a.CreatedAt
+			}
+			rel.ToAsset = a
+			seen = append(seen, rel)
+		}
+	}
+	return prefixChanges(seen, earlier, later), nil
+}
+
+func prefixChanges(rels []*types.Relation, earlier, later TimeWindow) *PrefixChanges {
+	before := make(map[string]struct{})
+	after := make(map[string]struct{})
+
+	for _, rel := range rels {
+		netblock, ok := rel.ToAsset.Asset.(network.Netblock)
+		if !ok {
+			continue
+		}
+
+		prefix := netblock.Cidr.String()
+		if earlier.overlaps(rel.CreatedAt, rel.LastSeen) {
+			before[prefix] = struct{}{}
+		}
+		if later.overlaps(rel.CreatedAt, rel.LastSeen) {
+			after[prefix] = struct{}{}
+		}
+	}
+
+	changes := &PrefixChanges{}
+	for prefix := range after {
+		if _, found := before[prefix]; found {
+			changes.Unchanged = append(changes.Unchanged, prefix)
+		} else {
+			changes.Announced = append(changes.Announced, prefix)
+		}
+	}
+	for prefix := range before {
+		if _, found := after[prefix]; !found {
+			changes.Withdrawn = append(changes.Withdrawn, prefix)
+		}
+	}
+
+	sort.Strings(changes.Announced)
+	sort.Strings(changes.Withdrawn)
+	sort.Strings(changes.Unchanged)
+	return changes
+}
+
+func (w TimeWindow) overlaps(first, last time.Time) bool {
+	if last.Before(first) {
+		last = first
+	}
+	return !last.Before(w.Start) && (w.End.IsZero() || !first.After(w.End))
+}
+
+// ReadAddrNames returns the names having A or AAAA records that resolve to the address in the graph database.
+func ReadAddrNames(ctx context.Context, g *netmap.Graph, addr string, since time.Time) ([]string, error) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := "IPv4"
+	if ip.Is6() {
+		t = "IPv6"
+	}
+
+	assets, err := g.DB.FindByContent(&network.IPAddress{Address: ip.Unmap(), Type: t}, since)
+	if err != nil || len(assets) == 0 {
+		return nil, err
+	}
+
+	rels, err := g.DB.IncomingRelations(assets[0], since, "a_record", "aaaa_record")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]struct{})
+	for _, rel := range rels {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		a, err := g.DB.FindById(rel.FromAsset.ID, since)
+		if err != nil || a == nil {
+			continue
+		}
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			if _, found := seen[fqdn.Name]; !found {
+				seen[fqdn.Name] = struct{}{}
+				names = append(names, fqdn.Name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadOrgASNs returns the autonomous systems managed by the organizations whose descriptions contain the
+// provided organization name, regardless of case, such as 'OWASP' matching 'OWASP-FOUNDATION - OWASP, US'.
+func ReadOrgASNs(ctx context.Context, g *netmap.Graph, org string, since time.Time) ([]int, error) {
+	org = strings.ToLower(strings.TrimSpace(org))
+	if org == "" {
+		return nil, errors.New("the organization name is empty")
+	}
+
+	orgs, err := g.DB.FindByType(oam.RIROrg, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var asns []int
+	seen := make(map[int]struct{})
+	for _, o := range orgs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		rir, ok := o.Asset.(network.RIROrganization)
+		if !ok || !strings.Contains(strings.ToLower(rir.Name), org) {
+			continue
+		}
+
+		rels, err := g.DB.IncomingRelations(o, since, "managed_by")
+		if err != nil {
+			continue
+		}
+		for _, rel := range rels {
+			a, err := g.DB.FindById(rel.FromAsset.ID, since)
+			if err != nil || a == nil {
+				continue
+			}
+			if as, ok := a.Asset.(network.AutonomousSystem); ok {
+				if _, found := seen[as.Number]; !found {
+					seen[as.Number] = struct{}{}
+					asns = append(asns, as.Number)
+				}
+			}
+		}
+	}
+
+	sort.Ints(asns)
+	return asns, nil
+}
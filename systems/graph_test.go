@@ -0,0 +1,368 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestNamesToAddrsStream(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	var names []string
+	expected := make(map[string]string)
+	for i := 1; i <= 25; i++ {
+		name := fmt.Sprintf("www%d.owasp.org", i)
+		addr := fmt.Sprintf("192.0.2.%d", i)
+
+		names = append(names, name)
+		// Every fifth name has no address, so a few batches are partial
+		if i%5 == 0 {
+			continue
+		}
+		if err := g.UpsertA(ctx, name, addr); err != nil {
+			t.Fatalf("Failed to insert the A record for %s: %v", name, err)
+		}
+		expected[name] = addr
+	}
+
+	for _, size := range []int{0, 1, 4, 100} {
+		ch := make(chan *netmap.NameAddrPair)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- NamesToAddrsStream(ctx, g, time.Time{}, size, ch, append(names, "unknown.owasp.org")...)
+		}()
+
+		got := make(map[string]string)
+		for p := range ch {
+			got[p.FQDN.Name] = p.Addr.Address.String()
+		}
+		if err := <-errs; err != nil {
+			t.Errorf("NamesToAddrsStream with batches of %d returned an error: %v", size, err)
+		}
+
+		if len(got) != len(expected) {
+			t.Errorf("NamesToAddrsStream with batches of %d returned %d pairs, expected %d", size, len(got), len(expected))
+		}
+		for name, addr := range expected {
+			if got[name] != addr {
+				t.Errorf("NamesToAddrsStream with batches of %d returned %s for %s, expected %s", size, got[name], name, addr)
+			}
+		}
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	ch := make(chan *netmap.NameAddrPair)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- NamesToAddrsStream(cctx, g, time.Time{}, 2, ch, names...)
+	}()
+	<-ch
+	cancel()
+	for range ch {
+	}
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("NamesToAddrsStream did not return the context error: %v", err)
+	}
+}
+
+func TestNamesToAddrRecords(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	if err := g.UpsertA(ctx, "www.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("Failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertAAAA(ctx, "www.owasp.org", "2001:db8::1"); err != nil {
+		t.Fatalf("Failed to insert the AAAA record: %v", err)
+	}
+	if err := g.UpsertCNAME(ctx, "docs.owasp.org", "www.owasp.org"); err != nil {
+		t.Fatalf("Failed to insert the CNAME record: %v", err)
+	}
+	if err := g.UpsertSRV(ctx, "_sip._tcp.owasp.org", "sip.owasp.org"); err != nil {
+		t.Fatalf("Failed to insert the SRV record: %v", err)
+	}
+	if err := g.UpsertA(ctx, "sip.owasp.org", "192.0.2.5"); err != nil {
+		t.Fatalf("Failed to insert the A record of the SRV target: %v", err)
+	}
+
+	records, err := NamesToAddrRecords(ctx, g, time.Time{}, "www.owasp.org", "docs.owasp.org", "_sip._tcp.owasp.org", "unknown.owasp.org")
+	if err != nil {
+		t.Fatalf("NamesToAddrRecords returned an error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, r := range records {
+		if r.FirstSeen.IsZero() || r.LastSeen.IsZero() {
+			t.Errorf("The record of %s and %s has no first or last seen time", r.FQDN.Name, r.Addr.Address)
+		}
+		got[r.FQDN.Name+" "+r.Addr.Address.String()] = strings.TrimSpace(r.RecordType + " " + r.Via + " " + r.Target)
+	}
+
+	expected := map[string]string{
+		"www.owasp.org 192.0.2.1":       "A",
+		"www.owasp.org 2001:db8::1":     "AAAA",
+		"docs.owasp.org 192.0.2.1":      "A CNAME www.owasp.org",
+		"docs.owasp.org 2001:db8::1":    "AAAA CNAME www.owasp.org",
+		"_sip._tcp.owasp.org 192.0.2.5": "A SRV sip.owasp.org",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("NamesToAddrRecords returned %v, expected %v", got, expected)
+	}
+
+	ch := make(chan *NameAddrRecord, 10)
+	if err := NamesToAddrRecordsStream(ctx, g, time.Time{}, 1, ch, "www.owasp.org", "docs.owasp.org"); err != nil {
+		t.Errorf("NamesToAddrRecordsStream returned an error: %v", err)
+	}
+	var count int
+	for range ch {
+		count++
+	}
+	if count != 4 {
+		t.Errorf("NamesToAddrRecordsStream sent %d records, expected 4", count)
+	}
+}
+
+func TestNamesToAddrsPage(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	var names []string
+	for i := 1; i <= 10; i++ {
+		name := fmt.Sprintf("www%d.owasp.org", i)
+
+		names = append(names, name)
+		if i%4 == 0 {
+			continue
+		}
+		if err := g.UpsertA(ctx, name, fmt.Sprintf("192.0.2.%d", i)); err != nil {
+			t.Fatalf("Failed to insert the A record for %s: %v", name, err)
+		}
+	}
+	// The name with two addresses is not split between the pages
+	if err := g.UpsertA(ctx, "www3.owasp.org", "192.0.2.103"); err != nil {
+		t.Fatalf("Failed to insert the second A record: %v", err)
+	}
+
+	var pages int
+	var token string
+	seen := make(map[string]int)
+	for {
+		pairs, next, err := NamesToAddrsPage(ctx, g, time.Time{}, QueryOptions{Limit: 3, PageToken: token, BatchSize: 4}, names...)
+		if err != nil {
+			t.Fatalf("NamesToAddrsPage returned an error: %v", err)
+		}
+		if len(pairs) > 3 {
+			t.Errorf("NamesToAddrsPage returned %d pairs, expected at most 3", len(pairs))
+		}
+		for _, p := range pairs {
+			seen[p.FQDN.Name]++
+		}
+
+		pages++
+		if token = next; token == "" || pages > 10 {
+			break
+		}
+	}
+	if len(seen) != 8 || seen["www3.owasp.org"] != 2 {
+		t.Errorf("NamesToAddrsPage returned the pairs of %d names, expected 8: %v", len(seen), seen)
+	}
+	if pages < 3 {
+		t.Errorf("NamesToAddrsPage returned %d pages, expected at least 3", pages)
+	}
+
+	if _, _, err := NamesToAddrsPage(ctx, g, time.Time{}, QueryOptions{PageToken: "bogus"}, names...); err != ErrInvalidPageToken {
+		t.Errorf("NamesToAddrsPage returned %v for a bogus token", err)
+	}
+	if _, _, err := NamesToAddrsPage(ctx, g, time.Time{}, QueryOptions{PageToken: encodePageToken("prefixes", "1")}, names...); err != ErrInvalidPageToken {
+		t.Errorf("NamesToAddrsPage returned %v for the token of another helper", err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, next, err := NamesToAddrsPage(cctx, g, time.Time{}, QueryOptions{Timeout: time.Minute}, names...); err != context.Canceled || next == "" {
+		t.Errorf("NamesToAddrsPage returned %v and the token %q for the interrupted call", err, next)
+	}
+}
+
+func TestReadASPrefixesPage(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	expected := make(map[string]struct{})
+	for i := 0; i < 5; i++ {
+		cidr := fmt.Sprintf("198.51.%d.0/24", i)
+
+		expected[cidr] = struct{}{}
+		if err := g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-AS", fmt.Sprintf("198.51.%d.1", i), cidr); err != nil {
+			t.Fatalf("Failed to insert the infrastructure: %v", err)
+		}
+	}
+
+	var pages int
+	var token string
+	seen := make(map[string]struct{})
+	for {
+		prefixes, next, err := ReadASPrefixesPage(ctx, g, 64496, time.Time{}, QueryOptions{Limit: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ReadASPrefixesPage returned an error: %v", err)
+		}
+		if len(prefixes) > 2 {
+			t.Errorf("ReadASPrefixesPage returned %d prefixes, expected at most 2", len(prefixes))
+		}
+		for _, p := range prefixes {
+			seen[p] = struct{}{}
+		}
+
+		pages++
+		if token = next; token == "" || pages > 5 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(seen, expected) || pages != 3 {
+		t.Errorf("ReadASPrefixesPage returned %v in %d pages, expected %v in 3", seen, pages, expected)
+	}
+
+	if prefixes, next, err := ReadASPrefixesPage(ctx, g, 64497, time.Time{}, QueryOptions{}); err != nil || len(prefixes) != 0 || next != "" {
+		t.Errorf("ReadASPrefixesPage returned %v, %q and %v for an unknown ASN", prefixes, next, err)
+	}
+}
+
+func TestNameBatchSize(t *testing.T) {
+	cfg := config.NewConfig()
+
+	if size := NameBatchSize(cfg); size != DefaultNameBatchSize {
+		t.Errorf("NameBatchSize returned %d, expected the default %d", size, DefaultNameBatchSize)
+	}
+	cfg.Options["name_batch_size"] = 1000
+	if size := NameBatchSize(cfg); size != 1000 {
+		t.Errorf("NameBatchSize returned %d, expected 1000", size)
+	}
+}
+
+func TestPrefixChanges(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2023, time.June, d, 0, 0, 0, 0, time.UTC)
+	}
+	rel := func(cidr string, first, last int) *types.Relation {
+		return &types.Relation{
+			Type:      "announces",
+			CreatedAt: day(first),
+			LastSeen:  day(last),
+			ToAsset:   &types.Asset{Asset: network.Netblock{Cidr: netip.MustParsePrefix(cidr), Type: "IPv4"}},
+		}
+	}
+
+	rels := []*types.Relation{
+		rel("192.0.2.0/24", 1, 20),
+		rel("198.51.100.0/24", 1, 5),
+		rel("203.0.113.0/24", 12, 20),
+		rel("203.0.113.0/25", 2, 3),
+	}
+	got := prefixChanges(rels, TimeWindow{Start: day(4), End: day(9)}, TimeWindow{Start: day(10)})
+	expected := &PrefixChanges{
+		Announced: []string{"203.0.113.0/24"},
+		Withdrawn: []string{"198.51.100.0/24"},
+		Unchanged: []string{"192.0.2.0/24"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("prefixChanges returned %+v, expected %+v", got, expected)
+	}
+}
+
+func TestReadASPrefixChanges(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	if err := g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-AS", "192.0.2.1", "192.0.2.0/24"); err != nil {
+		t.Fatalf("Failed to insert the infrastructure: %v", err)
+	}
+
+	now := time.Now()
+	got, err := ReadASPrefixChanges(ctx, g, 64496,
+		TimeWindow{End: now.Add(-24 * time.Hour)}, TimeWindow{Start: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("ReadASPrefixChanges returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Announced, []string{"192.0.2.0/24"}) || len(got.Withdrawn) != 0 || len(got.Unchanged) != 0 {
+		t.Errorf("ReadASPrefixChanges returned %+v, expected the prefix to be announced", got)
+	}
+
+	if got, err := ReadASPrefixChanges(ctx, g, 64497, TimeWindow{}, TimeWindow{}); err != nil || len(got.Unchanged) != 0 {
+		t.Errorf("ReadASPrefixChanges returned %+v and %v for an unknown ASN", got, err)
+	}
+}
+
+func TestReadAddrNames(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	for _, name := range []string{"www.owasp.org", "owasp.org"} {
+		if err := g.UpsertA(ctx, name, "192.0.2.1"); err != nil {
+			t.Fatalf("Failed to insert the A record for %s: %v", name, err)
+		}
+	}
+	if err := g.UpsertAAAA(ctx, "v6.owasp.org", "2001:db8::1"); err != nil {
+		t.Fatalf("Failed to insert the AAAA record: %v", err)
+	}
+
+	if names, err := ReadAddrNames(ctx, g, "192.0.2.1", time.Time{}); err != nil ||
+		!reflect.DeepEqual(names, []string{"owasp.org", "www.owasp.org"}) {
+		t.Errorf("ReadAddrNames returned %v and %v, expected owasp.org and www.owasp.org", names, err)
+	}
+	if names, err := ReadAddrNames(ctx, g, "2001:db8::1", time.Time{}); err != nil || !reflect.DeepEqual(names, []string{"v6.owasp.org"}) {
+		t.Errorf("ReadAddrNames returned %v and %v, expected v6.owasp.org", names, err)
+	}
+	if names, err := ReadAddrNames(ctx, g, "192.0.2.2", time.Time{}); err != nil || len(names) != 0 {
+		t.Errorf("ReadAddrNames returned %v and %v for an unknown address", names, err)
+	}
+	if _, err := ReadAddrNames(ctx, g, "owasp.org", time.Time{}); err == nil {
+		t.Errorf("ReadAddrNames returned no error for a value that is not an address")
+	}
+}
+
+func TestReadOrgASNs(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	for asn, desc := range map[int]string{
+		64496: "EXAMPLE-AS - Example Networks, US",
+		64497: "EXAMPLE-EU - Example Networks, NL",
+		64498: "OTHER-AS - Other Company, US",
+	} {
+		if _, err := g.UpsertAS(ctx, asn, desc); err != nil {
+			t.Fatalf("Failed to insert AS%d: %v", asn, err)
+		}
+	}
+
+	if asns, err := ReadOrgASNs(ctx, g, "example networks", time.Time{}); err != nil || !reflect.DeepEqual(asns, []int{64496, 64497}) {
+		t.Errorf("ReadOrgASNs returned %v and %v, expected AS64496 and AS64497", asns, err)
+	}
+	if asns, err := ReadOrgASNs(ctx, g, "Unknown", time.Time{}); err != nil || len(asns) != 0 {
+		t.Errorf("ReadOrgASNs returned %v and %v for an unknown organization", asns, err)
+	}
+	if _, err := ReadOrgASNs(ctx, g, " ", time.Time{}); err == nil {
+		t.Errorf("ReadOrgASNs returned no error for an empty organization name")
+	}
+}
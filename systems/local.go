@@ -18,8 +18,10 @@ import (
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
 	amassnet "github.com/owasp-amass/amass/v4/net"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
 	"github.com/owasp-amass/amass/v4/requests"
-	"github.com/owasp-amass/amass/v4/resources"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -29,8 +31,11 @@ type LocalSystem struct {
 	Cfg               *config.Config
 	pool              *resolve.Resolvers
 	trusted           *resolve.Resolvers
+	forwarders        tunnelForwarders
 	graphs            []*netmap.Graph
 	cache             *requests.ASNCache
+	deadLetters       *DeadLetterQueue
+	findings          *FindingStore
 	done              chan struct{}
 	doneAlreadyClosed bool
 	addSource         chan service.Service
@@ -42,30 +47,38 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 	if err := cfg.CheckSettings(); err != nil {
 		return nil, err
 	}
-
-	trusted, num := trustedResolvers(cfg)
-	if trusted == nil || num == 0 {
-		return nil, errors.New("the system was unable to build the pool of trusted resolvers")
+	if _, err := ScopeURLs(cfg); err != nil {
+		return nil, err
+	}
+	if Offline(cfg) {
+		// Nothing may leave the host, so the network settings and active techniques are not applied
+		if err := disableEgress(); err != nil {
+			return nil, err
+		}
+		cfg.Active = false
+		cfg.Passive = true
+	} else {
+		// Use the platform network settings when requested in the configuration
+		applySystemSettings(cfg)
+		if err := setupSourceAddresses(cfg); err != nil {
+			return nil, err
+		}
 	}
 
-	pool, num := untrustedResolvers(cfg)
-	if pool == nil || num == 0 {
-		return nil, errors.New("the system was unable to build the pool of untrusted resolvers")
+	trusted, pool, forwarders, err := resolverPools(cfg)
+	if err != nil {
+		return nil, err
 	}
-	if cfg.MaxDNSQueries == 0 {
-		cfg.MaxDNSQueries += num * cfg.ResolversQPS
-	} else {
-		pool.SetMaxQPS(cfg.MaxDNSQueries)
+	if err := setupResponseCache(cfg); err != nil {
+		forwarders.Close()
+		return nil, err
 	}
-	// set a single name server rate limiter for both resolver pools
-	rate := resolve.NewRateTracker()
-	trusted.SetRateTracker(rate)
-	pool.SetRateTracker(rate)
 
 	sys := &LocalSystem{
 		Cfg:        cfg,
 		pool:       pool,
 		trusted:    trusted,
+		forwarders: forwarders,
 		cache:      requests.NewASNCache(),
 		done:       make(chan struct{}, 2),
 		addSource:  make(chan service.Service),
@@ -82,6 +95,14 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		_ = sys.Shutdown()
 		return nil, err
 	}
+	sys.deadLetters = NewDeadLetterQueue(DeadLetterPath(cfg))
+	// Load the findings of previous enumerations
+	findings, err := NewFindingStore(FindingsPath(cfg))
+	if err != nil {
+		_ = sys.Shutdown()
+		return nil, err
+	}
+	sys.findings = findings
 	// Setup the correct graph database handler
 	if err := sys.setupGraphDBs(cfg); err != nil {
 		_ = sys.Shutdown()
@@ -112,6 +133,16 @@ func (l *LocalSystem) Cache() *requests.ASNCache {
 	return l.cache
 }
 
+// DeadLetters implements the System interface.
+func (l *LocalSystem) DeadLetters() *DeadLetterQueue {
+	return l.deadLetters
+}
+
+// Findings implements the System interface.
+func (l *LocalSystem) Findings() *FindingStore {
+	return l.findings
+}
+
 // AddSource implements the System interface.
 func (l *LocalSystem) AddSource(src service.Service) error {
 	l.addSource <- src
@@ -189,9 +220,16 @@ func (l *LocalSystem) Shutdown() error {
 	for range l.GraphDatabases() {
 		//g.Close()
 	}
+	if err := l.findings.Save(); err != nil {
+		l.Cfg.Log.Printf("Failed to save the findings: %v", err)
+	}
 
+	http.UseResolvers(nil)
+	_ = http.UseResponseCache("", false)
+	_ = amassnet.UseSourceAddresses(nil, "")
 	l.pool.Stop()
 	l.trusted.Stop()
+	l.forwarders.Close()
 	l.cache = nil
 	return nil
 }
@@ -217,7 +255,8 @@ func (l *LocalSystem) setupGraphDBs(cfg *config.Config) error {
 	cfg.GraphDBs = append(cfg.GraphDBs, cfg.LocalDatabaseSettings(cfg.GraphDBs))
 
 	for _, db := range cfg.GraphDBs {
-		if db.Primary {
+		// The remote databases cannot be reached in offline mode
+		if (!Offline(cfg) && db.Primary) || (Offline(cfg) && db.System == "local") {
 			var g *netmap.Graph
 
 			if db.System == "local" {
@@ -269,29 +308,97 @@ func (l *LocalSystem) manageDataSources() {
 }
 
 func (l *LocalSystem) loadCacheData() error {
-	ranges, err := resources.GetIP2ASNData()
-	if err != nil {
-		return err
+	return LoadCacheData(l.cache)
+}
+
+func applySystemSettings(cfg *config.Config) {
+	if options.Bool(cfg, "system_resolvers") {
+		if addrs, err := amassdns.SystemResolvers(); err == nil {
+			if len(cfg.Resolvers) == 0 {
+				cfg.Resolvers = addrs
+			}
+			if len(cfg.TrustedResolvers) == 0 {
+				cfg.TrustedResolvers = addrs
+			}
+		} else {
+			cfg.Log.Printf("Failed to obtain the system DNS resolvers: %v", err)
+		}
 	}
 
-	for _, r := range ranges {
-		cidr := amassnet.Range2CIDR(r.FirstIP, r.LastIP)
-		if cidr == nil {
-			continue
+	if options.Bool(cfg, "system_proxy") {
+		if err := http.UseSystemProxy(); err != nil {
+			cfg.Log.Printf("Failed to use the system proxy settings: %v", err)
 		}
-		if ones, _ := cidr.Mask.Size(); ones == 0 {
-			continue
+	}
+
+	if bps := options.Int(cfg, "bandwidth_limit"); bps > 0 {
+		amassnet.SetBandwidthLimit(bps)
+	}
+
+	// The option can also be a table of data source names and sizes, where the 'default' entry applies to the others
+	http.SetMaxBodySize(options.SizeValue(options.Source(cfg, "http_max_body_size", "default")))
+
+	if patterns := options.Strings(cfg, "egress_allowlist"); len(patterns) > 0 {
+		restrictEgress(cfg, patterns)
+	}
+
+	// The crawler and the certificate prober share the politeness policy toward each host
+	if p, err := http.ParsePoliteness(cfg.Options["politeness"]); err == nil {
+		http.SetPoliteness(p)
+	} else {
+		cfg.Log.Printf("Failed to use the politeness policy: %v", err)
+		http.SetPoliteness(nil)
+	}
+}
+
+// resolverPools returns the pools of trusted and untrusted resolvers. In offline mode, the pools
+// are empty, since the records of the local datasets answer the queries of the enumeration. When
+// a tunnel is selected or domain resolvers are configured, the pools send the queries through the
+// forwarders that are returned.
+func resolverPools(cfg *config.Config) (*resolve.Resolvers, *resolve.Resolvers, tunnelForwarders, error) {
+	if Offline(cfg) {
+		return resolve.NewResolvers(), resolve.NewResolvers(), nil, nil
+	}
+
+	var forwarders tunnelForwarders
+	tunneled, err := setupTunnel(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	routes, err := domainResolvers(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if tunneled || len(routes) > 0 {
+		if err := forwarders.route(cfg, tunneled, routes); err != nil {
+			forwarders.Close()
+			return nil, nil, nil, fmt.Errorf("failed to route the resolvers through the forwarders: %v", err)
 		}
+	}
 
-		l.cache.Update(&requests.ASNRequest{
-			Address:     r.FirstIP.String(),
-			ASN:         r.ASN,
-			CC:          r.CC,
-			Prefix:      cidr.String(),
-			Description: r.Description,
-		})
+	trusted, num := trustedResolvers(cfg)
+	if trusted == nil || num == 0 {
+		forwarders.Close()
+		return nil, nil, nil, errors.New("the system was unable to build the pool of trusted resolvers")
 	}
-	return nil
+
+	pool, num := untrustedResolvers(cfg, tunneled || len(routes) > 0)
+	if pool == nil || num == 0 {
+		forwarders.Close()
+		return nil, nil, nil, errors.New("the system was unable to build the pool of untrusted resolvers")
+	}
+	if cfg.MaxDNSQueries == 0 {
+		cfg.MaxDNSQueries += num * cfg.ResolversQPS
+	} else {
+		pool.SetMaxQPS(cfg.MaxDNSQueries)
+	}
+	// set a single name server rate limiter for both resolver pools
+	rate := resolve.NewRateTracker()
+	trusted.SetRateTracker(rate)
+	pool.SetRateTracker(rate)
+	// Keep the HTTP clients from sending queries to the system resolvers
+	http.UseResolvers(trusted)
+	return trusted, pool, forwarders, nil
 }
 
 func trustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
@@ -302,14 +409,19 @@ func trustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 	}
 
 	_ = pool.AddResolvers(cfg.TrustedQPS, trusted...)
-	pool.SetDetectionResolver(cfg.TrustedQPS, "8.8.8.8")
+	// The 'detection_resolver' option allows wildcard detection without access to public DNS
+	detection := "8.8.8.8"
+	if addr := options.String(cfg, "detection_resolver"); addr != "" {
+		detection = addr
+	}
+	pool.SetDetectionResolver(cfg.TrustedQPS, detection)
 
 	pool.SetLogger(cfg.Log)
 	pool.SetTimeout(2 * time.Second)
 	return pool, pool.Len()
 }
 
-func untrustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
+func untrustedResolvers(cfg *config.Config, forwarded bool) (*resolve.Resolvers, int) {
 	if len(cfg.Resolvers) == 0 {
 		cfg.Resolvers = publicResolverAddrs(cfg)
 		if len(cfg.Resolvers) == 0 {
@@ -326,14 +438,17 @@ func untrustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 	}
 	_ = pool.AddResolvers(cfg.ResolversQPS, cfg.Resolvers...)
 	pool.SetTimeout(3 * time.Second)
-	pool.SetThresholdOptions(&resolve.ThresholdOptions{
-		ThresholdValue:      20,
-		CountTimeouts:       true,
-		CountFormatErrors:   true,
-		CountServerFailures: true,
-		CountNotImplemented: true,
-		CountQueryRefusals:  true,
-	})
+	// The forwarder of a tunnel or the routes must not be removed from the pool, since it is the only resolver
+	if !forwarded {
+		pool.SetThresholdOptions(&resolve.ThresholdOptions{
+			ThresholdValue:      20,
+			CountTimeouts:       true,
+			CountFormatErrors:   true,
+			CountServerFailures: true,
+			CountNotImplemented: true,
+			CountQueryRefusals:  true,
+		})
+	}
 	pool.ClientSubnetCheck()
 	return pool, pool.Len()
 }
@@ -7,6 +7,8 @@ package systems
 import (
 	"reflect"
 	"testing"
+
+	"github.com/owasp-amass/config/config"
 )
 
 func TestCheckAddresses(t *testing.T) {
@@ -56,3 +58,48 @@ func TestCheckAddresses(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainResolvers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["domain_resolvers"] = map[string]interface{}{
+		"Corp.OWASP.org.": []interface{}{"10.0.0.53", "10.0.1.53:5353"},
+		"lab.owasp.org":   "10.1.0.53, 10.1.1.53",
+	}
+
+	routes, err := domainResolvers(cfg)
+	if err != nil {
+		t.Fatalf("domainResolvers failed: %v", err)
+	}
+	expected := map[string][]string{
+		"corp.owasp.org": {"10.0.0.53:53", "10.0.1.53:5353"},
+		"lab.owasp.org":  {"10.1.0.53:53", "10.1.1.53:53"},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("domainResolvers returned %v, expected %v", routes, expected)
+	}
+
+	var forwarders tunnelForwarders
+	defer forwarders.Close()
+	cfg.Resolvers = []string{"192.0.2.53"}
+	if err := forwarders.route(cfg, false, routes); err != nil {
+		t.Fatalf("route failed: %v", err)
+	}
+	if len(forwarders) != 3 {
+		t.Errorf("route started %d forwarders, expected the trusted, detection and untrusted forwarders", len(forwarders))
+	}
+	if cfg.Resolvers[0] != forwarders[2].Addr() || cfg.TrustedResolvers[0] != forwarders[0].Addr() ||
+		cfg.Options["detection_resolver"] != forwarders[1].Addr() {
+		t.Errorf("route did not replace the resolvers with the forwarders")
+	}
+
+	for _, value := range []interface{}{
+		map[string]interface{}{"corp.owasp.org": "NotAnIP"},
+		map[string]interface{}{"corp.owasp.org": []interface{}{}},
+		map[string]interface{}{".": "10.0.0.53"},
+	} {
+		cfg.Options["domain_resolvers"] = value
+		if _, err := domainResolvers(cfg); err == nil {
+			t.Errorf("domainResolvers accepted %v", value)
+		}
+	}
+}
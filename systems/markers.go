@@ -0,0 +1,126 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// MarkersDir is the name of the directory in the default output directory that holds the markers shared by the sessions.
+const MarkersDir = "markers"
+
+// markerNameRE matches the names of the markers and of the expired markers moved aside.
+var markerNameRE = regexp.MustCompile(`^[0-9a-f]{32}(\.[0-9]+\.[0-9]+)?$`)
+
+// markerTakeovers makes the names of the expired markers being taken over unique within the process.
+var markerTakeovers uint64
+
+// MarkerStore keeps the time each key, such as an asset requested from the data sources, was last marked.
+// The markers are files in a directory shared by the sessions and replicas, and the time of a marker is the
+// time the file was created. A marker is created exclusively, and an expired marker is moved aside before
+// being created again, so a single session marks a key within the TTL without holding a lock.
+type MarkerStore struct {
+	dir string
+}
+
+// NewMarkerStore returns a MarkerStore keeping the markers in the provided directory.
+func NewMarkerStore(dir string) (*MarkerStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &MarkerStore{dir: dir}, nil
+}
+
+// MarkersPath returns the path of the directory holding the markers shared by the sessions. The 'markers_directory'
+// option provides a directory on storage shared by the replicas, and the default is within the default output
+// directory, so the sessions writing to separate output directories still share the markers.
+func MarkersPath(cfg *config.Config) string {
+	if dir := options.String(cfg, "markers_directory"); dir != "" {
+		return dir
+	}
+	return filepath.Join(config.OutputDirectory(), MarkersDir)
+}
+
+// Mark sets the marker of the key and returns true, unless the key was already marked within the TTL.
+func (m *MarkerStore) Mark(key string, ttl time.Duration) (bool, error) {
+	path := m.path(key)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			return true, f.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if time.Since(info.ModTime()) < ttl {
+			return false, nil
+		}
+		if !m.takeover(path, ttl) {
+			return false, nil
+		}
+	}
+}
+
+// takeover moves the expired marker aside, so the key can be marked again, and returns false when
+// another session marked the key meanwhile. Only one of the sessions moving the marker succeeds.
+func (m *MarkerStore) takeover(path string, ttl time.Duration) bool {
+	expired := fmt.Sprintf("%s.%d.%d", path, os.Getpid(), atomic.AddUint64(&markerTakeovers, 1))
+
+	if err := os.Rename(path, expired); err != nil {
+		// The marker was already moved by another session
+		return errors.Is(err, os.ErrNotExist)
+	}
+	defer os.Remove(expired)
+
+	// The marker moved could have been set by another session since it was found expired
+	if info, err := os.Stat(expired); err == nil && time.Since(info.ModTime()) < ttl {
+		_ = os.Link(expired, path)
+		return false
+	}
+	return true
+}
+
+// Prune removes the markers set before the TTL, along with the expired markers left behind by the sessions.
+func (m *MarkerStore) Prune(ttl time.Duration) error {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !markerNameRE.MatchString(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) >= ttl {
+			_ = os.Remove(filepath.Join(m.dir, entry.Name()))
+		}
+	}
+	return nil
+}
+
+func (m *MarkerStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(m.dir, hex.EncodeToString(sum[:16]))
+}
@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMarkerStoreConcurrentSessions(t *testing.T) {
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	var marked int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each session opens its own store on the shared directory
+			m, err := NewMarkerStore(dir)
+			if err != nil {
+				t.Errorf("Failed to open the marker store: %v", err)
+				return
+			}
+			if ok, err := m.Mark("address|72.237.4.113", time.Hour); err != nil {
+				t.Errorf("Failed to mark the key: %v", err)
+			} else if ok {
+				atomic.AddInt32(&marked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if marked != 1 {
+		t.Errorf("The key was marked by %d sessions, expected 1", marked)
+	}
+}
+
+func TestMarkerStoreTTL(t *testing.T) {
+	m, err := NewMarkerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open the marker store: %v", err)
+	}
+
+	if ok, err := m.Mark("whois|owasp.org", 50*time.Millisecond); err != nil || !ok {
+		t.Fatalf("Failed to mark the key: %v", err)
+	}
+	if ok, _ := m.Mark("whois|owasp.org", 50*time.Millisecond); ok {
+		t.Errorf("The key was marked again within the TTL")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	var wg sync.WaitGroup
+	var marked int32
+	// The sessions finding the marker expired at the same time mark the key once
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if ok, err := m.Mark("whois|owasp.org", 50*time.Millisecond); err != nil {
+				t.Errorf("Failed to mark the key: %v", err)
+			} else if ok {
+				atomic.AddInt32(&marked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if marked != 1 {
+		t.Errorf("The expired key was marked again by %d sessions, expected 1", marked)
+	}
+}
+
+func TestMarkerStorePrune(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewMarkerStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to open the marker store: %v", err)
+	}
+
+	for _, key := range []string{"dns|www.owasp.org", "address|192.0.2.1"} {
+		if ok, err := m.Mark(key, time.Hour); err != nil || !ok {
+			t.Fatalf("Failed to mark the key %s: %v", key, err)
+		}
+	}
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("kept"), 0644); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	for _, path := range []string{m.path("dns|www.owasp.org"), other} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("Failed to age the file: %v", err)
+		}
+	}
+
+	if err := m.Prune(time.Hour); err != nil {
+		t.Fatalf("Failed to prune the markers: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("Expected the marker within the TTL and the other file to remain, %d remain", len(entries))
+	}
+	if ok, _ := m.Mark("address|192.0.2.1", time.Hour); ok {
+		t.Errorf("The marker within the TTL was removed by the pruning")
+	}
+}
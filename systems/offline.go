@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"path/filepath"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// HTTPCacheDir is the name of the directory in the output directory that holds the cached HTTP responses.
+	HTTPCacheDir = "http_cache"
+	// DNSCacheFile is the name of the file in the output directory that holds the records of the recorded DNS responses.
+	DNSCacheFile = "dns_cache.json"
+)
+
+// Offline returns true when the 'offline' option is enabled, which causes the system to operate only
+// on local datasets, such as imported zone files, passive DNS dumps and cached HTTP responses.
+func Offline(cfg *config.Config) bool {
+	return options.Bool(cfg, "offline")
+}
+
+// HTTPCachePath returns the path of the directory holding the HTTP responses cached in the output directory.
+func HTTPCachePath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), HTTPCacheDir)
+}
+
+// DNSCachePath returns the path of the file holding the records of the DNS responses recorded in the output directory.
+func DNSCachePath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), DNSCacheFile)
+}
+
+// disableEgress refuses all the connections of the process, and verifies they are refused before continuing.
+func disableEgress() error {
+	amassnet.DisableEgress()
+	return amassnet.VerifyEgressDisabled(context.Background())
+}
+
+// setupResponseCache replays the cached HTTP responses in offline mode, and
+// records the responses obtained when the 'http_cache' option is enabled.
+func setupResponseCache(cfg *config.Config) error {
+	if Offline(cfg) {
+		return http.UseResponseCache(HTTPCachePath(cfg), true)
+	}
+	if options.Bool(cfg, "http_cache") {
+		return http.UseResponseCache(HTTPCachePath(cfg), false)
+	}
+	return nil
+}
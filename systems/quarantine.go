@@ -0,0 +1,178 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// QuarantineFile is the name of the file in the output directory that holds the quarantined names.
+const QuarantineFile = "quarantine.json"
+
+// QuarantinedName is a name scraped by a data source that was recognized as junk and set aside for review.
+type QuarantinedName struct {
+	Name        string    `json:"name"`
+	Domain      string    `json:"domain"`
+	Source      string    `json:"source"`
+	Reasons     []string  `json:"reasons"`
+	Quarantined time.Time `json:"quarantined"`
+}
+
+// Quarantine persists the quarantined names in a file, one JSON object per line.
+// The methods of a nil Quarantine do nothing.
+type Quarantine struct {
+	sync.Mutex
+	path string
+}
+
+// NewQuarantine returns a Quarantine that stores the names in the provided file.
+func NewQuarantine(path string) *Quarantine {
+	return &Quarantine{path: path}
+}
+
+// QuarantinePath returns the path of the quarantine in the output directory of the configuration.
+func QuarantinePath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), QuarantineFile)
+}
+
+// Add appends the QuarantinedName to the file. Since junk names can arrive in large
+// volumes, the file is not rewritten and List returns the latest entry for each name.
+func (q *Quarantine) Add(n *QuarantinedName) error {
+	if q == nil || n == nil {
+		return nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(q.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(f).Encode(n); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns the names currently in the quarantine, in the order they were first quarantined.
+func (q *Quarantine) List() ([]*QuarantinedName, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	return q.read()
+}
+
+// Remove deletes the provided names from the quarantine.
+// When no names are provided, the quarantine is emptied.
+func (q *Quarantine) Remove(names ...string) error {
+	if q == nil {
+		return nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if len(names) == 0 {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	entries, err := q.read()
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		remove[name] = struct{}{}
+	}
+
+	var keep []*QuarantinedName
+	for _, n := range entries {
+		if _, found := remove[n.Name]; !found {
+			keep = append(keep, n)
+		}
+	}
+	return q.write(keep)
+}
+
+func (q *Quarantine) read() ([]*QuarantinedName, error) {
+	f, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*QuarantinedName
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var n QuarantinedName
+		if err := json.Unmarshal(scanner.Bytes(), &n); err != nil {
+			return nil, fmt.Errorf("failed to parse the quarantine %s: %v", q.path, err)
+		}
+		if i, found := index[n.Name]; found {
+			entries[i] = &n
+			continue
+		}
+		index[n.Name] = len(entries)
+		entries = append(entries, &n)
+	}
+	return entries, scanner.Err()
+}
+
+// write replaces the contents of the quarantine file without leaving a partially written file behind.
+func (q *Quarantine) write(entries []*QuarantinedName) error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), QuarantineFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := json.NewEncoder(tmp)
+	for _, n := range entries {
+		if err := enc.Encode(n); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
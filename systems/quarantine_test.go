@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	q := NewQuarantine(filepath.Join(t.TempDir(), QuarantineFile))
+
+	for _, n := range []*QuarantinedName{
+		{Name: "3f2a9c1b7d04e5f6.owasp.org", Domain: "owasp.org", Source: "Example", Reasons: []string{"hex_blob"}},
+		{Name: "-www.owasp.org", Domain: "owasp.org", Source: "Example", Reasons: []string{"invalid_characters"}},
+		{Name: "3f2a9c1b7d04e5f6.owasp.org", Domain: "owasp.org", Source: "Other", Reasons: []string{"hex_blob"}},
+	} {
+		n.Quarantined = time.Now()
+		if err := q.Add(n); err != nil {
+			t.Fatalf("Failed to quarantine the name: %v", err)
+		}
+	}
+
+	entries, err := q.List()
+	if err != nil {
+		t.Fatalf("Failed to list the quarantined names: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 quarantined names, but %d were returned", len(entries))
+	}
+	if entries[0].Source != "Other" {
+		t.Errorf("Expected the latest entry for the name, but got the source %s", entries[0].Source)
+	}
+
+	if err := q.Remove("3f2a9c1b7d04e5f6.owasp.org"); err != nil {
+		t.Fatalf("Failed to remove the name: %v", err)
+	}
+	if entries, _ := q.List(); len(entries) != 1 || entries[0].Name != "-www.owasp.org" {
+		t.Errorf("The name was not removed from the quarantine")
+	}
+
+	if err := q.Remove(); err != nil {
+		t.Fatalf("Failed to empty the quarantine: %v", err)
+	}
+	if entries, _ := q.List(); len(entries) != 0 {
+		t.Errorf("The quarantine was not emptied")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// RollupFile is the name of the file in the output directory that holds the relation rollups.
+	RollupFile = "rollups.json"
+	// DefaultRollupThreshold is the number of relations of a type beyond which they are rolled up,
+	// when the 'rollup_threshold' option is not provided.
+	DefaultRollupThreshold = 1000
+	// DefaultRollupSample is the number of relations kept by each rollup when the 'rollup_sample' option is not provided.
+	DefaultRollupSample = 100
+	// RollupMaxAge is the time after which the rollups are computed again from the graph database.
+	RollupMaxAge = 24 * time.Hour
+)
+
+// RollupEdge is a relation sampled by a rollup, identified by the asset at its other end.
+type RollupEdge struct {
+	Asset    string    `json:"asset"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// RelationRollup summarizes the relations of one type and direction of a high-degree asset,
+// such as the names served by a popular name server, with their count and the relations last seen.
+type RelationRollup struct {
+	Relation string       `json:"relation"`
+	Count    int          `json:"count"`
+	Sample   []RollupEdge `json:"sample"`
+}
+
+// assetRollups holds the rollups of the incoming or outgoing relations of an asset, along with
+// every type of relation found, so the types not rolled up can still be read from the database.
+type assetRollups struct {
+	Asset    string            `json:"asset"`
+	Incoming bool              `json:"incoming"`
+	Types    []string          `json:"types"`
+	Rollups  []*RelationRollup `json:"rollups"`
+	Updated  time.Time         `json:"updated"`
+}
+
+func (ar *assetRollups) fresh(since time.Time) bool {
+	return !ar.Updated.Before(since) && time.Since(ar.Updated) < RollupMaxAge
+}
+
+func (ar *assetRollups) rollup(relation string) *RelationRollup {
+	for _, r := range ar.Rollups {
+		if r.Relation == relation {
+			return r
+		}
+	}
+	return nil
+}
+
+// RollupStore keeps the rollups of the high-degree assets in a file, so the graph queries following
+// the relations of these assets read a sample instead of millions of relations. The methods of a nil
+// RollupStore read every relation from the database, which provides the full detail on demand.
+type RollupStore struct {
+	sync.Mutex
+	path      string
+	threshold int
+	sample    int
+	assets    map[string]*assetRollups
+	dirty     bool
+}
+
+// RollupPath returns the path of the rollups in the output directory of the configuration.
+func RollupPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), RollupFile)
+}
+
+// RollupSettings returns the 'rollup_threshold' and 'rollup_sample' options, or their defaults.
+func RollupSettings(cfg *config.Config) (threshold, sample int) {
+	threshold, sample = DefaultRollupThreshold, DefaultRollupSample
+	if n := options.Int(cfg, "rollup_threshold"); n > 0 {
+		threshold = n
+	}
+	if n := options.Int(cfg, "rollup_sample"); n > 0 {
+		sample = n
+	}
+	return threshold, sample
+}
+
+// NewRollupStore returns a RollupStore holding the rollups kept in the file at the provided path,
+// which rolls up the relation types of an asset with more relations than the threshold.
+func NewRollupStore(path string, threshold, sample int) (*RollupStore, error) {
+	rs := &RollupStore{
+		path:      path,
+		threshold: threshold,
+		sample:    sample,
+		assets:    make(map[string]*assetRollups),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var list []*assetRollups
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, ar := range list {
+		rs.assets[rollupKey(ar.Asset, ar.Incoming)] = ar
+	}
+	return rs, nil
+}
+
+// Relations returns the incoming or outgoing relations of the asset with the types provided, or all the
+// relations when no types are provided. The relation types rolled up for the asset are represented by
+// their sampled relations, which only identify the asset at the other end, and by the rollups returned.
+// The rollups are computed once the relations of the asset are read and stay in use until they age out.
+func (rs *RollupStore) Relations(g *netmap.Graph, a *types.Asset, since time.Time,
+	incoming bool, relTypes ...string) ([]*types.Relation, []*RelationRollup, error) {
+	if rs == nil {
+		rels, err := readRelations(g, a, since, incoming, relTypes...)
+		return rels, nil, err
+	}
+
+	key := rollupKey(a.ID, incoming)
+	rs.Lock()
+	ar, found := rs.assets[key]
+	rs.Unlock()
+
+	if !found || !ar.fresh(since) {
+		rels, err := readRelations(g, a, since, incoming)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ar = rs.summarize(a.ID, incoming, rels)
+		rs.Lock()
+		if len(ar.Rollups) > 0 {
+			rs.assets[key] = ar
+			rs.dirty = true
+		} else if found {
+			delete(rs.assets, key)
+			rs.dirty = true
+		}
+		rs.Unlock()
+
+		if len(ar.Rollups) == 0 {
+			return filterRelations(rels, relTypes), nil, nil
+		}
+	}
+
+	wanted := relTypes
+	if len(wanted) == 0 {
+		wanted = ar.Types
+	}
+
+	var unrolled []string
+	var rels []*types.Relation
+	var rollups []*RelationRollup
+	for _, rtype := range wanted {
+		r := ar.rollup(rtype)
+		if r == nil {
+			unrolled = append(unrolled, rtype)
+			continue
+		}
+
+		rollups = append(rollups, r)
+		for _, edge := range r.Sample {
+			rels = append(rels, sampledRelation(a, rtype, edge, incoming))
+		}
+	}
+
+	if len(unrolled) > 0 {
+		more, err := readRelations(g, a, since, incoming, unrolled...)
+		if err != nil {
+			return nil, nil, err
+		}
+		rels = append(rels, more...)
+	}
+	return rels, rollups, nil
+}
+
+// Rollups returns the rollups of the incoming or outgoing relations of the asset.
+func (rs *RollupStore) Rollups(id string, incoming bool) []*RelationRollup {
+	if rs == nil {
+		return nil
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	if ar, found := rs.assets[rollupKey(id, incoming)]; found {
+		return ar.Rollups
+	}
+	return nil
+}
+
+// Save writes the rollups to the file when they have changed since the store was created.
+func (rs *RollupStore) Save() error {
+	if rs == nil {
+		return nil
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	if !rs.dirty {
+		return nil
+	}
+
+	list := make([]*assetRollups, 0, len(rs.assets))
+	for _, ar := range rs.assets {
+		list = append(list, ar)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Asset != list[j].Asset {
+			return list[i].Asset < list[j].Asset
+		}
+		return !list[i].Incoming && list[j].Incoming
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rs.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(rs.path, data, 0644); err != nil {
+		return err
+	}
+
+	rs.dirty = false
+	return nil
+}
+
+// summarize rolls up the relation types with more relations than the threshold, keeping those last seen.
+func (rs *RollupStore) summarize(id string, incoming bool, rels []*types.Relation) *assetRollups {
+	byType := make(map[string][]*types.Relation)
+	for _, rel := range rels {
+		byType[rel.Type] = append(byType[rel.Type], rel)
+	}
+
+	ar := &assetRollups{
+		Asset:    id,
+		Incoming: incoming,
+		Updated:  time.Now(),
+	}
+	for rtype, list := range byType {
+		ar.Types = append(ar.Types, rtype)
+		if len(list) <= rs.threshold {
+			continue
+		}
+
+		sort.Slice(list, func(i, j int) bool {
+			if !list[i].LastSeen.Equal(list[j].LastSeen) {
+				return list[i].LastSeen.After(list[j].LastSeen)
+			}
+			return list[i].ID < list[j].ID
+		})
+
+		r := &RelationRollup{
+			Relation: rtype,
+			Count:    len(list),
+		}
+		for _, rel := range list {
+			if len(r.Sample) >= rs.sample {
+				break
+			}
+
+			other := rel.ToAsset
+			if incoming {
+				other = rel.FromAsset
+			}
+			r.Sample = append(r.Sample, RollupEdge{Asset: other.ID, LastSeen: rel.LastSeen})
+		}
+		ar.Rollups = append(ar.Rollups, r)
+	}
+
+	sort.Strings(ar.Types)
+	sort.Slice(ar.Rollups, func(i, j int) bool { return ar.Rollups[i].Relation < ar.Rollups[j].Relation })
+	return ar
+}
+
+func readRelations(g *netmap.Graph, a *types.Asset, since time.Time, incoming bool, relTypes ...string) ([]*types.Relation, error) {
+	if incoming {
+		return g.DB.IncomingRelations(a, since, relTypes...)
+	}
+	return g.DB.OutgoingRelations(a, since, relTypes...)
+}
+
+func filterRelations(rels []*types.Relation, relTypes []string) []*types.Relation {
+	if len(relTypes) == 0 {
+		return rels
+	}
+
+	var results []*types.Relation
+	for _, rel := range rels {
+		for _, rtype := range relTypes {
+			if rel.Type == rtype {
+				results = append(results, rel)
+				break
+			}
+		}
+	}
+	return results
+}
+
+func sampledRelation(a *types.Asset, rtype string, edge RollupEdge, incoming bool) *types.Relation {
+	rel := &types.Relation{
+		Type:      rtype,
+		LastSeen:  edge.LastSeen,
+		FromAsset: a,
+		ToAsset:   &types.Asset{ID: edge.Asset},
+	}
+	if incoming {
+		rel.FromAsset, rel.ToAsset = rel.ToAsset, a
+	}
+	return rel
+}
+
+func rollupKey(id string, incoming bool) string {
+	if incoming {
+		return id + "|in"
+	}
+	return id + "|out"
+}
@@ -0,0 +1,88 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestRollupStore(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	for i := 1; i <= 12; i++ {
+		name := fmt.Sprintf("www%d.owasp.org", i)
+		if err := g.UpsertNS(ctx, name, "ns1.provider.net"); err != nil {
+			t.Fatalf("Failed to insert the NS record for %s: %v", name, err)
+		}
+	}
+	if err := g.UpsertMX(ctx, "www1.owasp.org", "ns1.provider.net"); err != nil {
+		t.Fatalf("Failed to insert the MX record: %v", err)
+	}
+
+	assets, err := g.DB.FindByContent(domain.FQDN{Name: "ns1.provider.net"}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		t.Fatalf("Failed to find the name server: %v", err)
+	}
+	ns := assets[0]
+
+	path := filepath.Join(t.TempDir(), RollupFile)
+	rs, err := NewRollupStore(path, 10, 3)
+	if err != nil {
+		t.Fatalf("Failed to create the rollup store: %v", err)
+	}
+
+	rels, rollups, err := rs.Relations(g, ns, time.Time{}, true)
+	if err != nil {
+		t.Fatalf("Relations returned an error: %v", err)
+	}
+	if len(rollups) != 1 || rollups[0].Relation != "ns_record" || rollups[0].Count != 12 {
+		t.Fatalf("The ns_record relations were not rolled up as expected: %+v", rollups)
+	}
+	// The sample of the NS records and the MX record that was not rolled up
+	if len(rels) != 4 {
+		t.Errorf("Relations returned %d relations, expected 4", len(rels))
+	}
+	for _, rel := range rels {
+		if rel.ToAsset.ID != ns.ID || rel.FromAsset.ID == "" {
+			t.Errorf("The relation %s does not lead to the name server", rel.Type)
+		}
+	}
+
+	if rels, _, _ := rs.Relations(g, ns, time.Time{}, true, "mx_record"); len(rels) != 1 {
+		t.Errorf("Relations returned %d mx_record relations, expected 1", len(rels))
+	}
+	if rels, _, _ := rs.Relations(g, ns, time.Time{}, false); len(rels) != 0 {
+		t.Errorf("Relations returned %d outgoing relations, expected none", len(rels))
+	}
+	if err := rs.Save(); err != nil {
+		t.Fatalf("Failed to save the rollups: %v", err)
+	}
+
+	loaded, err := NewRollupStore(path, 10, 3)
+	if err != nil {
+		t.Fatalf("Failed to load the rollups: %v", err)
+	}
+	if r := loaded.Rollups(ns.ID, true); len(r) != 1 || len(r[0].Sample) != 3 {
+		t.Errorf("The rollups were not loaded from the file: %+v", r)
+	}
+	// The rollups computed before the time provided are not used
+	if _, rollups, _ := loaded.Relations(g, ns, time.Now().Add(time.Minute), true); len(rollups) != 1 {
+		t.Errorf("The rollups were not computed again: %+v", rollups)
+	}
+
+	var full *RollupStore
+	if rels, rollups, err := full.Relations(g, ns, time.Time{}, true); err != nil || len(rels) != 13 || rollups != nil {
+		t.Errorf("The nil store returned %d relations and %d rollups, expected 13 and none", len(rels), len(rollups))
+	}
+}
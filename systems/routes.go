@@ -0,0 +1,45 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// domainResolvers returns the resolvers of the 'domain_resolvers' option, keyed by the domain name they answer for,
+// such as the internal resolvers of a split-horizon domain. The option is a table of domain names and lists of
+// resolver addresses, or strings of addresses separated by commas:
+//
+//	domain_resolvers:
+//	  corp.example.com: ["10.0.0.53", "10.0.1.53:5353"]
+func domainResolvers(cfg *config.Config) (map[string][]string, error) {
+	m := options.Table(cfg, "domain_resolvers")
+	if m == nil {
+		return nil, nil
+	}
+
+	routes := make(map[string][]string, len(m))
+	for domain, v := range m {
+		var addrs []string
+		for _, value := range options.StringsValue(v) {
+			if a := checkAddresses([]string{value}); len(a) > 0 {
+				addrs = append(addrs, a...)
+				continue
+			}
+			return nil, fmt.Errorf("the resolver %s of the domain %s is not an IP address", value, domain)
+		}
+
+		name := strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		if name == "" || len(addrs) == 0 {
+			return nil, fmt.Errorf("the domain resolvers require a domain name and resolver addresses, got %q", domain)
+		}
+		routes[name] = addrs
+	}
+	return routes, nil
+}
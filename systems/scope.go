@@ -0,0 +1,150 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// AssetInScope returns true when the asset of the type, named as in the findings and the graph database,
+// matches the scope of the configuration. The Service and Port assets, such as 'www.example.com:443' and
+// '192.0.2.1:8443/tcp', are in scope when the name or address of the host providing them is in scope,
+// and the URLs are also required to match the path prefixes of their host in the 'scope_urls' option.
+func AssetInScope(cfg *config.Config, atype, value string) bool {
+	switch atype {
+	case "FQDN":
+		name := strings.ToLower(strings.Trim(value, "."))
+		return cfg.IsDomainInScope(name) && !cfg.Blacklisted(name)
+	case "IPAddress":
+		return addressInScope(cfg, net.ParseIP(value))
+	case "Netblock":
+		_, ipnet, err := net.ParseCIDR(value)
+		if err != nil {
+			return false
+		}
+
+		ones, _ := ipnet.Mask.Size()
+		for _, cidr := range cfg.Scope.CIDRs {
+			if size, _ := cidr.Mask.Size(); size <= ones && cidr.Contains(ipnet.IP) {
+				return true
+			}
+		}
+	case "ASN":
+		asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(value), "AS"))
+		if err != nil {
+			return false
+		}
+
+		for _, a := range cfg.Scope.ASNs {
+			if a == asn {
+				return true
+			}
+		}
+	case "URL":
+		u, err := url.Parse(value)
+		if err != nil || u.Hostname() == "" {
+			return false
+		}
+
+		prefixes, err := ScopeURLs(cfg)
+		if err != nil || !prefixes.Allowed(u) {
+			return false
+		}
+		if ip := net.ParseIP(u.Hostname()); ip != nil {
+			return addressInScope(cfg, ip)
+		}
+		return AssetInScope(cfg, "FQDN", u.Hostname())
+	case "Service", "Port":
+		host, _, _, err := ParseService(value)
+		if err != nil {
+			return false
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return addressInScope(cfg, ip)
+		}
+		return AssetInScope(cfg, "FQDN", host)
+	}
+	return false
+}
+
+// ScopeURLs returns the path prefixes in the 'scope_urls' option, such as 'https://example.com/app/*',
+// which limit the URLs crawled and requested from the hosts they name.
+func ScopeURLs(cfg *config.Config) (*http.URLPrefixes, error) {
+	return http.NewURLPrefixes(options.Strings(cfg, "scope_urls")...)
+}
+
+// ParseService returns the host, port and transport protocol of the Service or Port asset, such as
+// 'www.example.com:443', '[2001:db8::1]:53/udp' or 'https://www.example.com'. The protocol is 'tcp'
+// unless provided, and the URLs without a port use the default port of the scheme.
+func ParseService(value string) (string, int, string, error) {
+	s := strings.TrimSpace(value)
+
+	var port int
+	if scheme, rest, found := strings.Cut(s, "://"); found {
+		switch strings.ToLower(scheme) {
+		case "http":
+			port = 80
+		case "https":
+			port = 443
+		default:
+			return "", 0, "", fmt.Errorf("the service %s has an unknown scheme", value)
+		}
+
+		s, _, _ = strings.Cut(rest, "/")
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(strings.Trim(s, "[]"), strconv.Itoa(port))
+		}
+	}
+
+	proto := "tcp"
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		proto = strings.ToLower(s[i+1:])
+		s = s[:i]
+	}
+	if proto != "tcp" && proto != "udp" {
+		return "", 0, "", fmt.Errorf("the service %s has an unknown protocol", value)
+	}
+
+	host, p, err := net.SplitHostPort(s)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	port, err = strconv.Atoi(p)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, "", fmt.Errorf("the service %s has an invalid port", value)
+	}
+	if host == "" {
+		return "", 0, "", fmt.Errorf("the service %s has no host", value)
+	}
+	return strings.ToLower(strings.TrimSuffix(host, ".")), port, proto, nil
+}
+
+// addressInScope only matches the addresses and netblocks explicitly provided by the scope.
+func addressInScope(cfg *config.Config, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, addr := range cfg.Scope.Addresses {
+		if addr.Equal(ip) {
+			return true
+		}
+	}
+	for _, cidr := range cfg.Scope.CIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"net"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestParseService(t *testing.T) {
+	tests := []struct {
+		value string
+		host  string
+		port  int
+		proto string
+		err   bool
+	}{
+		{value: "www.owasp.org:443", host: "www.owasp.org", port: 443, proto: "tcp"},
+		{value: "WWW.OWASP.ORG.:8443/tcp", host: "www.owasp.org", port: 8443, proto: "tcp"},
+		{value: "[2001:db8::1]:53/udp", host: "2001:db8::1", port: 53, proto: "udp"},
+		{value: "https://www.owasp.org/index.html", host: "www.owasp.org", port: 443, proto: "tcp"},
+		{value: "http://[2001:db8::1]:8080", host: "2001:db8::1", port: 8080, proto: "tcp"},
+		{value: "www.owasp.org", err: true},
+		{value: "192.0.2.0/24", err: true},
+		{value: "2001:db8::1", err: true},
+		{value: "www.owasp.org:70000", err: true},
+		{value: "www.owasp.org:53/sctp", err: true},
+		{value: "ftp://www.owasp.org", err: true},
+	}
+
+	for _, test := range tests {
+		host, port, proto, err := ParseService(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseService accepted %s", test.value)
+			}
+			continue
+		}
+		if err != nil || host != test.host || port != test.port || proto != test.proto {
+			t.Errorf("ParseService(%s) returned %s, %d, %s, %v", test.value, host, port, proto, err)
+		}
+	}
+}
+
+func TestAssetInScope(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org")
+	cfg.BlacklistSubdomain("internal.owasp.org")
+	cfg.Scope.Addresses = []net.IP{net.ParseIP("198.51.100.7")}
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/24")
+	cfg.Scope.CIDRs = []*net.IPNet{cidr}
+	cfg.Scope.ASNs = []int{64500}
+	cfg.Options["scope_urls"] = []interface{}{"https://apps.owasp.org/app/*"}
+
+	tests := []struct {
+		atype    string
+		value    string
+		expected bool
+	}{
+		{"FQDN", "www.owasp.org", true},
+		{"FQDN", "host.internal.owasp.org", false},
+		{"FQDN", "www.example.com", false},
+		{"IPAddress", "192.0.2.10", true},
+		{"IPAddress", "198.51.100.7", true},
+		{"IPAddress", "203.0.113.1", false},
+		{"Netblock", "192.0.2.128/25", true},
+		{"Netblock", "192.0.0.0/16", false},
+		{"ASN", "AS64500", true},
+		{"ASN", "64501", false},
+		{"Service", "www.owasp.org:443", true},
+		{"Service", "https://www.owasp.org", true},
+		{"Service", "host.internal.owasp.org:443", false},
+		{"Service", "www.example.com:443", false},
+		{"Port", "192.0.2.10:8443/tcp", true},
+		{"Port", "[2001:db8::1]:53/udp", false},
+		{"Port", "www.owasp.org", false},
+		{"URL", "https://www.owasp.org/index.html", true},
+		{"URL", "https://apps.owasp.org/app/login", true},
+		{"URL", "https://apps.owasp.org/admin", false},
+		{"URL", "https://www.example.com/app/login", false},
+		{"EmailAddress", "admin@owasp.org", false},
+	}
+
+	for _, test := range tests {
+		if got := AssetInScope(cfg, test.atype, test.value); got != test.expected {
+			t.Errorf("AssetInScope(%s, %s) returned %v, expected %v", test.atype, test.value, got, test.expected)
+		}
+	}
+}
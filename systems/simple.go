@@ -20,6 +20,8 @@ type SimpleSystem struct {
 	Trusted  *resolve.Resolvers
 	Graph    *netmap.Graph
 	ASNCache *requests.ASNCache
+	DLQ      *DeadLetterQueue
+	Finds    *FindingStore
 	Service  service.Service
 }
 
@@ -35,6 +37,12 @@ func (ss *SimpleSystem) TrustedResolvers() *resolve.Resolvers { return ss.Truste
 // Cache implements the System interface.
 func (ss *SimpleSystem) Cache() *requests.ASNCache { return ss.ASNCache }
 
+// DeadLetters implements the System interface.
+func (ss *SimpleSystem) DeadLetters() *DeadLetterQueue { return ss.DLQ }
+
+// Findings implements the System interface.
+func (ss *SimpleSystem) Findings() *FindingStore { return ss.Finds }
+
 // AddSource implements the System interface.
 func (ss *SimpleSystem) AddSource(src service.Service) error { ss.Service = src; return nil }
 
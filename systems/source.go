@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"fmt"
+	"net"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// setupSourceAddresses spreads the outbound connections across the local addresses and network interfaces in
+// the 'source_addresses' option, using the rotation in the 'source_rotation' option. The interfaces contribute
+// all their global unicast addresses, and the addresses are validated by binding to them before being used.
+func setupSourceAddresses(cfg *config.Config) error {
+	var addrs []net.IP
+
+	for _, entry := range options.Strings(cfg, "source_addresses") {
+		if ip := net.ParseIP(entry); ip != nil {
+			addrs = append(addrs, ip)
+			continue
+		}
+
+		ips, err := amassnet.InterfaceAddresses(entry)
+		if err != nil {
+			return fmt.Errorf("the source address %s is not an IP address or network interface: %v", entry, err)
+		}
+		addrs = append(addrs, ips...)
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	for _, ip := range addrs {
+		l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: ip})
+		if err != nil {
+			return fmt.Errorf("the source address %s is not assigned to this host: %v", ip, err)
+		}
+		l.Close()
+	}
+
+	if err := amassnet.UseSourceAddresses(addrs, options.String(cfg, "source_rotation")); err != nil {
+		return err
+	}
+
+	cfg.Log.Printf("Spreading the outbound connections across %d source addresses", len(addrs))
+	return nil
+}
@@ -10,7 +10,9 @@ import (
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/resources"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -44,6 +46,12 @@ type System interface {
 	// GraphDatabases return the Graphs used by the System
 	GraphDatabases() []*netmap.Graph
 
+	// DeadLetters returns the queue holding the data source requests that failed repeatedly
+	DeadLetters() *DeadLetterQueue
+
+	// Findings returns the store holding the assets that are not kept in the graph databases
+	Findings() *FindingStore
+
 	// GetMemoryUsage() returns the number bytes allocated to heap objects on this system
 	GetMemoryUsage() uint64
 
@@ -67,3 +75,30 @@ func PopulateCache(ctx context.Context, asn int, sys System) {
 		}
 	}
 }
+
+// LoadCacheData populates the provided cache with the ASN information embedded in the resources package.
+func LoadCacheData(cache *requests.ASNCache) error {
+	ranges, err := resources.GetIP2ASNData()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ranges {
+		cidr := amassnet.Range2CIDR(r.FirstIP, r.LastIP)
+		if cidr == nil {
+			continue
+		}
+		if ones, _ := cidr.Mask.Size(); ones == 0 {
+			continue
+		}
+
+		cache.Update(&requests.ASNRequest{
+			Address:     r.FirstIP.String(),
+			ASN:         r.ASN,
+			CC:          r.CC,
+			Prefix:      cidr.String(),
+			Description: r.Description,
+		})
+	}
+	return nil
+}
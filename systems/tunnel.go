@@ -0,0 +1,113 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"fmt"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/options"
+	"github.com/owasp-amass/config/config"
+)
+
+// setupTunnel selects the SOCKS proxy in the 'socks_proxy' option, such as the dynamic forward of an SSH
+// session, or the interface in the 'tunnel_interface' option, such as a WireGuard interface, to carry
+// the DNS queries and HTTP requests of the enumeration. It returns true when a tunnel was selected.
+func setupTunnel(cfg *config.Config) (bool, error) {
+	if addr := options.String(cfg, "socks_proxy"); addr != "" {
+		if err := amassnet.UseSOCKSProxy(addr); err != nil {
+			return false, fmt.Errorf("failed to use the SOCKS proxy: %v", err)
+		}
+		return true, nil
+	}
+	if name := options.String(cfg, "tunnel_interface"); name != "" {
+		if err := amassnet.UseInterface(name); err != nil {
+			return false, fmt.Errorf("failed to use the tunnel interface: %v", err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// tunnelForwarders holds the forwarders started for the resolver pools, so they can be closed on shutdown.
+type tunnelForwarders []*amassdns.Forwarder
+
+// route replaces the trusted and untrusted resolvers in the configuration with forwarders sending the
+// queries through the selected tunnel, or through the network of the host when only the routes of the
+// 'domain_resolvers' option are provided. The resolver pools accept a single resolver for each address,
+// so each forwarder spreads the queries across all the upstream resolvers of the pool, and sends the
+// queries for the routed domain names to their own resolvers. Without resolvers in the configuration,
+// the baseline resolvers are used in place of the public resolvers, which are too many for a forwarder.
+func (t *tunnelForwarders) route(cfg *config.Config, tunneled bool, routes map[string][]string) error {
+	network := "udp"
+	if tunneled {
+		network = amassnet.TunnelNetwork()
+	}
+
+	trusted := config.DefaultBaselineResolvers
+	if len(cfg.TrustedResolvers) > 0 {
+		trusted = cfg.TrustedResolvers
+	}
+
+	addr, qps, err := t.add(network, cfg.TrustedQPS, trusted, routes)
+	if err != nil {
+		return err
+	}
+	cfg.TrustedResolvers = []string{addr}
+	cfg.TrustedQPS = qps
+
+	// The wildcard detection uses the trusted forwarder through a tunnel, and otherwise keeps its resolver
+	detection := addr
+	if !tunneled {
+		detection = "8.8.8.8"
+		if v := options.String(cfg, "detection_resolver"); v != "" {
+			detection = v
+		}
+		if detection, _, err = t.add(network, cfg.TrustedQPS, []string{detection}, routes); err != nil {
+			return err
+		}
+	}
+	cfg.Options["detection_resolver"] = detection
+
+	untrusted := config.DefaultBaselineResolvers
+	if len(cfg.Resolvers) > 0 {
+		untrusted = cfg.Resolvers
+	}
+
+	addr, qps, err = t.add(network, cfg.ResolversQPS, checkAddresses(untrusted), routes)
+	if err != nil {
+		return err
+	}
+	cfg.Resolvers = []string{addr}
+	cfg.ResolversQPS = qps
+	return nil
+}
+
+// add starts a forwarder for the upstream resolvers, and returns the address of
+// the forwarder with the rate limit covering all the upstream resolvers.
+func (t *tunnelForwarders) add(network string, qps int, upstreams []string, routes map[string][]string) (string, int, error) {
+	f, err := amassdns.NewForwarder(network, amassnet.DialContext, upstreams...)
+	if err != nil {
+		return "", 0, err
+	}
+
+	*t = append(*t, f)
+	// The forwarders dial the upstream resolvers, which are allowed like the resolvers of the pools
+	amassnet.AllowEgress(upstreams...)
+	for domain, addrs := range routes {
+		if err := f.AddRoute(domain, addrs...); err != nil {
+			return "", 0, err
+		}
+		amassnet.AllowEgress(addrs...)
+	}
+	return f.Addr(), qps * len(upstreams), nil
+}
+
+func (t tunnelForwarders) Close() {
+	for _, f := range t {
+		_ = f.Close()
+	}
+}
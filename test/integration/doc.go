@@ -0,0 +1,8 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package integration runs complete enumerations against the PostgreSQL database and the
+// authoritative DNS server for the amass.test zone started by 'make integration-up'.
+// The tests are built with the 'integration' tag and executed by 'make integration-test'.
+package integration
@@ -0,0 +1,162 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+const testDomain = "amass.test"
+
+func TestEnumeration(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BruteForcing = true
+	cfg.Recursive = false
+	cfg.Wordlist = []string{"www", "mail", "api", "dev", "ns1", "vpn", "staging"}
+	cfg.ProvidedNames = []string{"foo.wild." + testDomain}
+	cfg.SourceFilter.Include = true
+	cfg.SourceFilter.Sources = []string{"Brute Forcing"}
+
+	g := runEnumeration(t, cfg)
+
+	names := discoveredNames(t, g, cfg)
+	for _, name := range []string{"www", "mail", "api", "dev", "ns1"} {
+		if _, found := names[name+"."+testDomain]; !found {
+			t.Errorf("The enumeration did not discover %s.%s", name, testDomain)
+		}
+	}
+	for _, name := range []string{"vpn", "staging", "foo.wild"} {
+		if _, found := names[name+"."+testDomain]; found {
+			t.Errorf("The enumeration stored %s.%s, which is not in the zone", name, testDomain)
+		}
+	}
+
+	var fqdns []string
+	for name := range names {
+		fqdns = append(fqdns, name)
+	}
+
+	pairs, err := g.NamesToAddrs(context.Background(), cfg.CollectionStartTime, fqdns...)
+	if err != nil {
+		t.Fatalf("Failed to obtain the addresses of the discovered names: %v", err)
+	}
+
+	expected := map[string][]string{
+		"www." + testDomain:  {"192.0.2.10", "2001:db8::10"},
+		"dev." + testDomain:  {"192.0.2.10", "2001:db8::10"},
+		"mail." + testDomain: {"192.0.2.20"},
+		"api." + testDomain:  {"192.0.2.30"},
+	}
+	for name, addrs := range expected {
+		for _, addr := range addrs {
+			var found bool
+
+			for _, p := range pairs {
+				if p.FQDN.Name == name && p.Addr.Address.String() == addr {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("The graph does not associate %s with the address %s", name, addr)
+			}
+		}
+	}
+}
+
+// testConfig returns a configuration using the services of the integration environment.
+// The addresses can be changed with the AMASS_TEST_DNS and AMASS_TEST_POSTGRES_* variables.
+func testConfig(t *testing.T) *config.Config {
+	dns := getenv("AMASS_TEST_DNS", "127.0.0.1:55353")
+
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain(testDomain)
+	cfg.Resolvers = []string{dns}
+	cfg.TrustedResolvers = []string{dns}
+	cfg.Options["detection_resolver"] = dns
+	cfg.GraphDBs = []*config.Database{{
+		System:   "postgres",
+		Primary:  true,
+		Host:     getenv("AMASS_TEST_POSTGRES_HOST", "127.0.0.1"),
+		Port:     getenv("AMASS_TEST_POSTGRES_PORT", "55432"),
+		Username: getenv("AMASS_TEST_POSTGRES_USER", "amass"),
+		Password: getenv("AMASS_TEST_POSTGRES_PASSWORD", "amass"),
+		DBName:   getenv("AMASS_TEST_POSTGRES_DB", "assetdb"),
+	}}
+	if testing.Verbose() {
+		cfg.Log = log.New(os.Stderr, "", log.Lmicroseconds)
+	}
+	return cfg
+}
+
+// runEnumeration executes the enumeration and returns the graph holding the results.
+// The database tables are removed once the test completes, so each test starts empty.
+func runEnumeration(t *testing.T, cfg *config.Config) *netmap.Graph {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create the system: %v", err)
+	}
+	t.Cleanup(func() { _ = sys.Shutdown() })
+
+	if err := sys.SetDataSources(datasrcs.GetAllSources(sys)); err != nil {
+		t.Fatalf("Failed to set the data sources: %v", err)
+	}
+
+	g := sys.GraphDatabases()[0]
+	t.Cleanup(g.Remove)
+
+	e := enum.NewEnumeration(cfg, sys, g)
+	if e == nil {
+		t.Fatal("Failed to setup the enumeration")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("The enumeration did not complete before the timeout")
+	}
+	return g
+}
+
+func discoveredNames(t *testing.T, g *netmap.Graph, cfg *config.Config) map[string]struct{} {
+	assets, err := g.DB.FindByScope([]oam.Asset{domain.FQDN{Name: testDomain}}, cfg.CollectionStartTime)
+	if err != nil {
+		t.Fatalf("Failed to obtain the names discovered for %s: %v", testDomain, err)
+	}
+
+	names := make(map[string]struct{})
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			names[fqdn.Name] = struct{}{}
+		}
+	}
+	return names
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,328 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wordlist manages the named wordlists declared by the configuration. The lists are obtained
+// from URLs or paths, pinned by their SHA-256 hashes and cached, so every enumeration uses the same words,
+// and the words that found names are measured to compare the lists across engagements.
+package wordlist
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// CacheDir is the name of the directory in the output directory that holds the downloaded wordlists.
+const CacheDir = "wordlists"
+
+// The uses of the words, which are the brute forcing and the name alterations.
+const (
+	UseBrute       = "brute"
+	UseAlterations = "alterations"
+)
+
+// ErrHashMismatch is returned when the content of a wordlist does not match its pinned hash.
+var ErrHashMismatch = errors.New("the wordlist does not match the pinned SHA-256 hash")
+
+var nameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// List is a named wordlist declared by the configuration.
+type List struct {
+	Name string
+	// Source is the URL or path the wordlist is obtained from
+	Source string
+	// SHA256 is the pinned hash of the content, and the list is rejected when it does not match
+	SHA256 string
+	Uses   []string
+	// Words and Hash are set once the wordlist has been loaded
+	Words []string
+	Hash  string
+}
+
+// Stats holds the words of a wordlist found in the names discovered by an enumeration.
+type Stats struct {
+	Name    string
+	Words   int
+	Hits    int
+	HitRate float64
+	// Top holds the words found in the most names, up to ten of them
+	Top []string
+}
+
+// Parse reads the 'managed_wordlists' option, a list of tables such as the following:
+//
+//	managed_wordlists:
+//	  - name: common
+//	    url: "https://example.com/common.txt"
+//	    sha256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+//	    use: [brute, alterations]
+//	  - name: internal
+//	    path: "./wordlists/internal.txt"
+//
+// The lists are used for brute forcing when the use is not provided.
+func Parse(v interface{}) ([]*List, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var lists []*List
+	seen := make(map[string]struct{})
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return lists, fmt.Errorf("wordlist %d is not a table", i+1)
+		}
+
+		l := &List{Name: stringValue(m["name"]), SHA256: strings.ToLower(stringValue(m["sha256"]))}
+		if !nameRE.MatchString(l.Name) {
+			return lists, fmt.Errorf("wordlist %d has an invalid name: %q", i+1, l.Name)
+		}
+		if _, found := seen[l.Name]; found {
+			return lists, fmt.Errorf("wordlist %s is declared more than once", l.Name)
+		}
+		seen[l.Name] = struct{}{}
+
+		u, p := stringValue(m["url"]), stringValue(m["path"])
+		switch {
+		case u != "" && p != "":
+			return lists, fmt.Errorf("wordlist %s has both a URL and a path", l.Name)
+		case u != "":
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				return lists, fmt.Errorf("wordlist %s has an invalid URL: %s", l.Name, u)
+			}
+			l.Source = u
+		case p != "":
+			l.Source = p
+		default:
+			return lists, fmt.Errorf("wordlist %s requires a URL or path", l.Name)
+		}
+		if l.SHA256 != "" {
+			if b, err := hex.DecodeString(l.SHA256); err != nil || len(b) != sha256.Size {
+				return lists, fmt.Errorf("wordlist %s has an invalid SHA-256 hash", l.Name)
+			}
+		}
+
+		switch use := m["use"].(type) {
+		case string:
+			l.Uses = []string{use}
+		case []interface{}:
+			for _, u := range use {
+				l.Uses = append(l.Uses, fmt.Sprint(u))
+			}
+		}
+		for _, use := range l.Uses {
+			if use != UseBrute && use != UseAlterations {
+				return lists, fmt.Errorf("wordlist %s has an unknown use: %s", l.Name, use)
+			}
+		}
+		if len(l.Uses) == 0 {
+			l.Uses = []string{UseBrute}
+		}
+		lists = append(lists, l)
+	}
+	return lists, nil
+}
+
+func stringValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
+
+// UsedFor returns true when the words of the list are used for the provided purpose.
+func (l *List) UsedFor(use string) bool {
+	for _, u := range l.Uses {
+		if u == use {
+			return true
+		}
+	}
+	return false
+}
+
+// Remote returns true when the wordlist is downloaded from a URL.
+func (l *List) Remote() bool {
+	return strings.HasPrefix(l.Source, "http://") || strings.HasPrefix(l.Source, "https://")
+}
+
+// Load obtains the words of the list. The wordlists downloaded are kept in the cache directory,
+// and the cached copy is used without a request when it matches the pinned hash, or when the
+// download fails and no hash was pinned.
+func (l *List) Load(ctx context.Context, cacheDir string) error {
+	data, err := l.read(ctx, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	l.Hash = hex.EncodeToString(sum[:])
+	l.Words = Words(data)
+	return nil
+}
+
+func (l *List) read(ctx context.Context, cacheDir string) ([]byte, error) {
+	if !l.Remote() {
+		data, err := os.ReadFile(l.Source)
+		if err != nil {
+			return nil, err
+		}
+		if !l.matches(data) {
+			return nil, ErrHashMismatch
+		}
+		return data, nil
+	}
+
+	path := filepath.Join(cacheDir, l.Name+".txt")
+	cached, cerr := os.ReadFile(path)
+	if cerr == nil && l.SHA256 != "" && l.matches(cached) {
+		return cached, nil
+	}
+
+	data, err := download(ctx, l.Source)
+	if err != nil {
+		if cerr == nil && l.SHA256 == "" {
+			return cached, nil
+		}
+		return nil, err
+	}
+	if !l.matches(data) {
+		return nil, ErrHashMismatch
+	}
+
+	if err := writeCache(path, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (l *List) matches(data []byte) bool {
+	if l.SHA256 == "" {
+		return true
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) == l.SHA256
+}
+
+func download(ctx context.Context, u string) ([]byte, error) {
+	resp, err := amasshttp.RequestWebPage(ctx, &amasshttp.Request{URL: u})
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.New(resp.Status)
+	}
+	return []byte(resp.Body), nil
+}
+
+// writeCache replaces the cached wordlist without leaving a partially written file behind.
+func writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Words returns the unique words of the content, one per line, in lower case and without the comments.
+func Words(data []byte) []string {
+	var words []string
+	seen := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		w := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		if _, found := seen[w]; !found {
+			seen[w] = struct{}{}
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Measure returns the words of the list found in the labels of the names below the domain names,
+// including the parts of the labels separated by hyphens, as the alterations combine the words.
+func Measure(l *List, names, domains []string) *Stats {
+	counts := make(map[string]int)
+	for _, name := range names {
+		for _, token := range nameTokens(strings.ToLower(name), domains) {
+			counts[token]++
+		}
+	}
+
+	s := &Stats{Name: l.Name, Words: len(l.Words)}
+	var found []string
+	for _, w := range l.Words {
+		if counts[w] > 0 {
+			found = append(found, w)
+		}
+	}
+	s.Hits = len(found)
+	if s.Words > 0 {
+		s.HitRate = float64(s.Hits) / float64(s.Words)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if counts[found[i]] != counts[found[j]] {
+			return counts[found[i]] > counts[found[j]]
+		}
+		return found[i] < found[j]
+	})
+	if len(found) > 10 {
+		found = found[:10]
+	}
+	s.Top = found
+	return s
+}
+
+// nameTokens returns the labels of the name below the longest domain name containing it, and their parts.
+func nameTokens(name string, domains []string) []string {
+	var domain string
+	for _, d := range domains {
+		if d = strings.ToLower(d); strings.HasSuffix(name, "."+d) && len(d) > len(domain) {
+			domain = d
+		}
+	}
+	if domain == "" {
+		return nil
+	}
+	sub := strings.TrimSuffix(name, "."+domain)
+
+	seen := make(map[string]struct{})
+	var tokens []string
+	add := func(t string) {
+		if _, found := seen[t]; t != "" && !found {
+			seen[t] = struct{}{}
+			tokens = append(tokens, t)
+		}
+	}
+
+	for _, label := range strings.Split(sub, ".") {
+		add(label)
+		if strings.Contains(label, "-") {
+			for _, part := range strings.Split(label, "-") {
+				add(part)
+			}
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package wordlist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	lists, err := Parse([]interface{}{
+		map[string]interface{}{"name": "common", "url": "https://example.com/common.txt", "use": []interface{}{"brute", "alterations"}},
+		map[string]interface{}{"name": "internal", "path": "./internal.txt", "sha256": "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"},
+	})
+	if err != nil || len(lists) != 2 {
+		t.Fatalf("Parse returned %d lists: %v", len(lists), err)
+	}
+	if !lists[0].Remote() || !lists[0].UsedFor(UseAlterations) {
+		t.Errorf("The first wordlist was not parsed as expected: %+v", lists[0])
+	}
+	if lists[1].Remote() || !lists[1].UsedFor(UseBrute) || lists[1].UsedFor(UseAlterations) ||
+		lists[1].SHA256 != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("The second wordlist was not parsed as expected: %+v", lists[1])
+	}
+
+	for _, m := range []map[string]interface{}{
+		{"name": "../common", "path": "common.txt"},
+		{"name": "common"},
+		{"name": "common", "url": "https://example.com/common.txt", "path": "common.txt"},
+		{"name": "common", "url": "ftp://example.com/common.txt"},
+		{"name": "common", "path": "common.txt", "sha256": "abc"},
+		{"name": "common", "path": "common.txt", "use": "ports"},
+	} {
+		if _, err := Parse([]interface{}{m}); err == nil {
+			t.Errorf("The invalid wordlist %v was accepted", m)
+		}
+	}
+	if lists, err := Parse(nil); err != nil || lists != nil {
+		t.Errorf("Parse returned %v, %v without the option", lists, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	content := "# Common words\nwww\nMail\n\nwww\ndev\n"
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		fmt.Fprint(w, content)
+	}))
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	l := &List{Name: "common", Source: srv.URL + "/common.txt", SHA256: hash}
+	if err := l.Load(ctx, dir); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if expected := []string{"www", "mail", "dev"}; !reflect.DeepEqual(l.Words, expected) || l.Hash != hash {
+		t.Errorf("Load provided the words %v with the hash %s", l.Words, l.Hash)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "common.txt")); err != nil {
+		t.Errorf("The wordlist was not cached: %v", err)
+	}
+
+	// The cached copy matching the pinned hash is used without a request
+	srv.Close()
+	l = &List{Name: "common", Source: srv.URL + "/common.txt", SHA256: hash}
+	if err := l.Load(ctx, dir); err != nil || len(l.Words) != 3 || requests != 1 {
+		t.Errorf("The cached wordlist was not used: %v after %d requests", err, requests)
+	}
+
+	path := filepath.Join(dir, "internal.txt")
+	if err := os.WriteFile(path, []byte("admin\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the wordlist: %v", err)
+	}
+	l = &List{Name: "internal", Source: path, SHA256: hash}
+	if err := l.Load(ctx, dir); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("The wordlist not matching the pinned hash returned %v", err)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	l := &List{Name: "common", Words: []string{"www", "mail", "dev", "vpn", "owasp"}}
+	names := []string{
+		"www.owasp.org",
+		"dev-www.owasp.org",
+		"mail.dev.owasp.org",
+		"owasp.org",
+		"www.example.com",
+	}
+
+	s := Measure(l, names, []string{"owasp.org", "dev.owasp.org"})
+	if s.Name != "common" || s.Words != 5 || s.Hits != 3 || s.HitRate != 0.6 {
+		t.Errorf("Measure returned %+v", s)
+	}
+	if expected := []string{"www", "dev", "mail"}; !reflect.DeepEqual(s.Top, expected) {
+		t.Errorf("The top words were %v, expected %v", s.Top, expected)
+	}
+}